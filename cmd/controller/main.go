@@ -1,14 +1,20 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
+	"os"
 	"strings"
 
+	"github.com/tektoncd/pruner/pkg/config"
+	"github.com/tektoncd/pruner/pkg/logformat"
+	"github.com/tektoncd/pruner/pkg/metrics"
 	"github.com/tektoncd/pruner/pkg/reconciler/namespaceprunerconfig"
 	"github.com/tektoncd/pruner/pkg/reconciler/pipelinerun"
 	"github.com/tektoncd/pruner/pkg/reconciler/taskrun"
 	"github.com/tektoncd/pruner/pkg/reconciler/tektonpruner"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/injection"
@@ -21,8 +27,20 @@ import (
 func main() {
 	// Define command-line flags
 	flag.IntVar(&controller.DefaultThreadsPerController, "threads-per-controller", controller.DefaultThreadsPerController, "Threads (goroutines) to create per controller")
+	configThreads := flag.Int("config-threads-per-controller", 0, "Threads (goroutines) reconciling the namespace pruner configs. Optional, defaults to --threads-per-controller.")
 	namespace := flag.String("namespace", corev1.NamespaceAll, "Namespace to restrict informer to. Optional, defaults to all namespaces.")
 	disableHighAvailability := flag.Bool("disable-ha", true, "Whether to disable high-availability functionality for this component.")
+	remoteKubeconfigs := flag.String("remote-kubeconfigs", "", "Comma separated list of kubeconfig files, each optionally followed by @context, of remote clusters whose runs are garbage collected too. Optional, defaults to none.")
+	triggerGCAddress := flag.String("trigger-gc-address", "", "Address, e.g. :8090, of the endpoint triggering a garbage collection cycle on demand. Optional, defaults to disabled.")
+	triggerGCTokenFile := flag.String("trigger-gc-token-file", "", "File holding the bearer token the requests to the garbage collection trigger endpoint must carry. Required with --trigger-gc-address.")
+	metricsDumpFile := flag.String("metrics-dump-file", "", "File the current metrics snapshot is written to as JSON after each garbage collection cycle, for clusters which cannot scrape the metrics. Optional, defaults to disabled.")
+	rbacSelfCheck := flag.String("rbac-self-check", string(tektonpruner.RBACSelfCheckWarn), "Whether to verify at startup that the controller has the RBAC permissions it needs: off, warn to log the missing permissions, or block to stop the controller.")
+	cloudEventsSink := flag.String("cloudevents-sink", "", "URL of the sink a dev.tekton.pruner.pruned.v1 CloudEvent is sent to whenever a run is pruned. Optional, defaults to disabled.")
+	metricsUIDCacheSize := flag.Int("metrics-uid-cache-size", metrics.DefaultSeenResourcesLimit, "Number of run UIDs remembered to count the unique runs processed, the oldest ones are forgotten first. 0 disables the tracking, every processed run is then counted.")
+	metricsHighCardinality := flag.Bool("metrics-high-cardinality", false, "Whether to record the metrics adding series for every namespace on top of the default ones, e.g. the age distribution of the runs surviving each garbage collection cycle.")
+	logConfigOnLoad := flag.Bool("log-config-on-load", false, "Whether to log the fully parsed global config, including the namespace overrides and selectors, every time it is loaded.")
+	logFormat := flag.String("log-format", "", "Encoding of the logs: json or console. Optional, defaults to the encoding of the config-logging ConfigMap.")
+	oneShot := flag.Bool("one-shot", false, "Whether to run a single garbage collection cycle, print its summary and exit, e.g. from a CronJob. The exit status is 1 if the cycle met any failure.")
 	flag.Parse()
 
 	// Parse and get REST config
@@ -49,6 +67,91 @@ func main() {
 	if *namespace != "" {
 		namespaces = strings.Split(strings.ReplaceAll(*namespace, " ", ""), ",")
 		logger.Infof("controller is scoped to the following namespaces: %s\n", namespaces)
+		ctx = tektonpruner.WithNamespaceScope(ctx, namespaces)
+	}
+
+	// Namespace configs may change by the hundreds at once, e.g. on a mass rollout
+	if *configThreads < 0 {
+		logger.Fatalf("invalid --config-threads-per-controller: must not be negative, got %d", *configThreads)
+	}
+	ctx = namespaceprunerconfig.WithThreads(ctx, *configThreads)
+
+	// Verify the permissions of the controller before the first GC cycle
+	rbacSelfCheckMode, err := tektonpruner.ParseRBACSelfCheckMode(*rbacSelfCheck)
+	if err != nil {
+		logger.Fatalf("invalid --rbac-self-check: %v", err)
+	}
+	ctx = tektonpruner.WithRBACSelfCheck(ctx, rbacSelfCheckMode)
+
+	// Look up the resources protecting runs from being pruned
+	dynamicClient := dynamic.NewForConfigOrDie(cfg)
+	ctx = config.WithResourceExistsFunc(ctx, config.NewDynamicResourceExistsFunc(dynamicClient))
+	ctx = config.WithResourceGetFunc(ctx, config.NewDynamicResourceGetFunc(dynamicClient))
+
+	// Garbage collect the remote clusters too, the reconcilers stay local
+	if *remoteKubeconfigs != "" {
+		clusters, err := tektonpruner.LoadRemoteClusters(*remoteKubeconfigs)
+		if err != nil {
+			logger.Fatalf("failed to load the remote clusters: %v", err)
+		}
+		for _, cluster := range clusters {
+			logger.Infof("garbage collection also targets the remote cluster: %s", cluster.Name)
+		}
+		ctx = tektonpruner.WithRemoteClusters(ctx, clusters)
+	}
+
+	// Event-driven pipelines and dashboards may follow the pruned runs, a failed delivery never fails pruning
+	if *cloudEventsSink != "" {
+		sink, err := config.NewCloudEventsSink(*cloudEventsSink)
+		if err != nil {
+			logger.Fatalf("invalid --cloudevents-sink: %v", err)
+		}
+		go sink.Run(ctx)
+		ctx = config.WithPrunedFunc(ctx, sink.Notify)
+		logger.Infof("pruned runs are sent as CloudEvents to: %s", *cloudEventsSink)
+	}
+
+	// Give operators the config as the controller parsed it
+	if *logConfigOnLoad {
+		ctx = config.WithConfigLogging(ctx)
+	}
+
+	// Air-gapped clusters may pick up the metrics from a file instead of scraping them
+	if *metricsDumpFile != "" {
+		ctx = tektonpruner.WithMetricsDumpFile(ctx, *metricsDumpFile)
+	}
+
+	// The unique runs processed are counted within a bounded window, so that the memory of the controller stays bounded
+	if *metricsUIDCacheSize < 0 {
+		logger.Fatalf("invalid --metrics-uid-cache-size: must not be negative, got %d", *metricsUIDCacheSize)
+	}
+	metrics.GetRecorder().SetSeenResourcesLimit(*metricsUIDCacheSize)
+	metrics.GetRecorder().SetHighCardinality(*metricsHighCardinality)
+
+	// Garbage collection can be triggered on demand, only by the holders of the token
+	if *triggerGCAddress != "" {
+		if *triggerGCTokenFile == "" {
+			logger.Fatal("--trigger-gc-token-file is required with --trigger-gc-address")
+		}
+		token, err := tektonpruner.LoadTriggerGCToken(*triggerGCTokenFile)
+		if err != nil {
+			logger.Fatalf("failed to enable the garbage collection trigger endpoint: %v", err)
+		}
+		ctx = tektonpruner.WithTriggerGC(ctx, tektonpruner.TriggerGC{Address: *triggerGCAddress, Token: token})
+	}
+
+	// A CronJob runs a single garbage collection cycle, without the reconcilers, and exits with its outcome
+	if *oneShot {
+		ctx, _ = injection.EnableInjectionOrDie(ctx, cfg)
+		summary, err := tektonpruner.RunOnce(ctx)
+		if err := json.NewEncoder(os.Stdout).Encode(summary); err != nil {
+			logger.Errorf("failed to print the garbage collection summary: %v", err)
+		}
+		if err != nil {
+			logger.Errorf("one-shot garbage collection failed: %v", err)
+			os.Exit(1)
+		}
+		return
 	}
 
 	// Add High Availability flag
@@ -56,6 +159,12 @@ func main() {
 		ctx = sharedmain.WithHADisabled(ctx)
 	}
 
+	// sharedmain sets up the logger with the encoding of the log format
+	ctx, err = logformat.WithFormat(ctx, cfg, *logFormat)
+	if err != nil {
+		logger.Fatalf("invalid --log-format: %v", err)
+	}
+
 	// Use sharedmain to handle controller lifecycle
 	sharedmain.MainWithConfig(ctx, "tekton-pruner-controller", cfg,
 		tektonpruner.NewController,
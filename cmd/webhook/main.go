@@ -18,13 +18,16 @@ package main
 
 import (
 	"context"
+	"flag"
 
+	"github.com/tektoncd/pruner/pkg/logformat"
 	"github.com/tektoncd/pruner/pkg/webhook"
 	"k8s.io/client-go/tools/cache"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	secretinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/secret"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
 	"knative.dev/pkg/injection/sharedmain"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/signals"
@@ -33,7 +36,13 @@ import (
 	"knative.dev/pkg/webhook/certificates"
 )
 
+// globalConfigFetchAttempts bounds the attempts to fetch the global config validating a namespace config
+var globalConfigFetchAttempts = flag.Int("global-config-fetch-attempts", webhook.DefaultGlobalConfigFetchAttempts, "Attempts to fetch the global config when validating a namespace config, before falling back to the validation without the global limits.")
+
 func main() {
+	logFormat := flag.String("log-format", "", "Encoding of the logs: json or console. Optional, defaults to the encoding of the config-logging ConfigMap.")
+	cfg := injection.ParseAndGetRESTConfigOrDie()
+
 	// Create signal context
 	ctx := signals.NewContext()
 
@@ -46,9 +55,16 @@ func main() {
 		SecretName:  pkgwebhook.SecretNameFromEnv("tekton-pruner-webhook-certs"),
 	})
 
+	// sharedmain sets up the logger with the encoding of the log format
+	formatCtx, err := logformat.WithFormat(ctx, cfg, *logFormat)
+	if err != nil {
+		logging.FromContext(ctx).Fatalf("invalid --log-format: %v", err)
+	}
+	ctx = formatCtx
+
 	// Start webhook server with certificate controller
 	// The certificate controller ensures the webhook-certs secret exists and injects the CA bundle into webhook configurations
-	sharedmain.MainWithContext(ctx, "pruner-webhook",
+	sharedmain.MainWithConfig(ctx, "pruner-webhook", cfg,
 		certificates.NewController,
 		NewConfigMapValidationWebhook,
 	)
@@ -71,6 +87,8 @@ func NewConfigMapValidationWebhook(ctx context.Context, _ configmap.Watcher) *co
 		Client:      client,
 		SecretName:  opts.SecretName,
 		WebhookName: webhookName,
+		// an apiserver blip must not let a namespace config through without the global limits
+		GlobalConfigFetchAttempts: *globalConfigFetchAttempts,
 	}
 
 	// Create the controller
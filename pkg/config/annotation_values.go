@@ -0,0 +1,92 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// AnnotationValueSpec maps the values of an annotation of the runs to the config applying to them,
+// as a compact form of one selector entry per value
+type AnnotationValueSpec struct {
+	// Annotation is the key of the annotation whose value selects the config
+	Annotation string `yaml:"annotation,omitempty" json:"annotation,omitempty"`
+	// Values holds the config of the runs with each value of the annotation
+	Values map[string]PrunerConfig `yaml:"values,omitempty" json:"values,omitempty"`
+}
+
+// expandAnnotationValues returns the namespace config with every byAnnotationValue entry of its pipelineRuns and taskRuns
+// replaced by one selector entry per value, matching the annotation with that value. The fields set for a value override
+// the ones set on the entry itself
+func expandAnnotationValues(nsSpec NamespaceSpec) NamespaceSpec {
+	nsSpec.PipelineRuns = expandResourceAnnotationValues(nsSpec.PipelineRuns)
+	nsSpec.TaskRuns = expandResourceAnnotationValues(nsSpec.TaskRuns)
+	return nsSpec
+}
+
+// expandResourceAnnotationValues expands the byAnnotationValue entries of a list of resource configs
+func expandResourceAnnotationValues(resources []ResourceSpec) []ResourceSpec {
+	if !slices.ContainsFunc(resources, func(r ResourceSpec) bool { return r.ByAnnotationValue != nil }) {
+		return resources
+	}
+	expanded := make([]ResourceSpec, 0, len(resources))
+	for _, resource := range resources {
+		if resource.ByAnnotationValue == nil {
+			expanded = append(expanded, resource)
+			continue
+		}
+		// the values are expanded in a stable order, a run has a single value of the annotation anyway
+		for _, value := range slices.Sorted(maps.Keys(resource.ByAnnotationValue.Values)) {
+			expanded = append(expanded, ResourceSpec{
+				Selector:     []SelectorSpec{{MatchAnnotations: map[string]string{resource.ByAnnotationValue.Annotation: value}}},
+				PrunerConfig: mergePrunerConfig(resource.PrunerConfig, resource.ByAnnotationValue.Values[value]),
+				Priority:     resource.Priority,
+			})
+		}
+	}
+	return expanded
+}
+
+// validateAnnotationValues validates the structure of the byAnnotationValue entries of a namespace config
+func validateAnnotationValues(nsSpec *NamespaceSpec, path string) error {
+	for resourceType, resources := range map[string][]ResourceSpec{"pipelineRuns": nsSpec.PipelineRuns, "taskRuns": nsSpec.TaskRuns} {
+		for i, resource := range resources {
+			spec := resource.ByAnnotationValue
+			if spec == nil {
+				continue
+			}
+			specPath := fmt.Sprintf("%s.%s[%d].byAnnotationValue", path, resourceType, i)
+			if resource.Name != "" || len(resource.Selector) > 0 {
+				return fmt.Errorf("%s: cannot be combined with name or selector", specPath)
+			}
+			if spec.Annotation == "" {
+				return fmt.Errorf("%s: annotation cannot be empty", specPath)
+			}
+			if errs := validation.IsQualifiedName(spec.Annotation); len(errs) > 0 {
+				return fmt.Errorf("%s: invalid annotation '%s': %s", specPath, spec.Annotation, errs[0])
+			}
+			if len(spec.Values) == 0 {
+				return fmt.Errorf("%s: values cannot be empty", specPath)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/ptr"
+)
+
+// TestExpandAnnotationValues verifies that a byAnnotationValue entry expands to one selector entry per value
+func TestExpandAnnotationValues(t *testing.T) {
+	nsSpec := NamespaceSpec{
+		PipelineRuns: []ResourceSpec{
+			{Name: "release", PrunerConfig: PrunerConfig{HistoryLimit: ptr.Int32(20)}},
+			{
+				PrunerConfig: PrunerConfig{SuccessfulHistoryLimit: ptr.Int32(3)},
+				ByAnnotationValue: &AnnotationValueSpec{
+					Annotation: "cost-tier",
+					Values: map[string]PrunerConfig{
+						"low":  {TTLSecondsAfterFinished: ptr.Int32(600)},
+						"high": {TTLSecondsAfterFinished: ptr.Int32(86400), SuccessfulHistoryLimit: ptr.Int32(5)},
+					},
+				},
+			},
+		},
+	}
+
+	got := expandAnnotationValues(nsSpec)
+	assert.Equal(t, []ResourceSpec{
+		{Name: "release", PrunerConfig: PrunerConfig{HistoryLimit: ptr.Int32(20)}},
+		{
+			Selector:     []SelectorSpec{{MatchAnnotations: map[string]string{"cost-tier": "high"}}},
+			PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: ptr.Int32(86400), SuccessfulHistoryLimit: ptr.Int32(5)},
+		},
+		{
+			Selector:     []SelectorSpec{{MatchAnnotations: map[string]string{"cost-tier": "low"}}},
+			PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: ptr.Int32(600), SuccessfulHistoryLimit: ptr.Int32(3)},
+		},
+	}, got.PipelineRuns)
+	// the namespace config as written is left untouched
+	assert.NotNil(t, nsSpec.PipelineRuns[1].ByAnnotationValue)
+}
+
+// TestLoadNamespaceConfigAnnotationValues verifies that the store resolves the config of a run by the value of its annotation
+func TestLoadNamespaceConfigAnnotationValues(t *testing.T) {
+	ctx := context.Background()
+	ps := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+	assert.NoError(t, ps.LoadGlobalConfig(ctx, &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `
+enforcedConfigLevel: namespace
+ttlSecondsAfterFinished: 3600`}}))
+	assert.NoError(t, ps.LoadNamespaceConfig(ctx, "team-a", &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: PrunerNamespaceConfigMapName, Namespace: "team-a"},
+		Data: map[string]string{PrunerNamespaceConfigKey: `
+pipelineRuns:
+  - byAnnotationValue:
+      annotation: cost-tier
+      values:
+        low:
+          ttlSecondsAfterFinished: 600
+        high:
+          ttlSecondsAfterFinished: 86400
+          keep: 5`},
+	}))
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantTTL     *int32
+	}{
+		{name: "low value", annotations: map[string]string{"cost-tier": "low"}, wantTTL: ptr.Int32(600)},
+		{name: "high value", annotations: map[string]string{"cost-tier": "high"}, wantTTL: ptr.Int32(86400)},
+		{name: "unmapped value", annotations: map[string]string{"cost-tier": "medium"}, wantTTL: ptr.Int32(3600)},
+		{name: "no annotation", wantTTL: ptr.Int32(3600)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl, _ := ps.GetPipelineTTLSecondsAfterFinished("team-a", "build", SelectorSpec{MatchAnnotations: tt.annotations})
+			assert.Equal(t, tt.wantTTL, ttl)
+		})
+	}
+
+	// the deprecated fields apply within the values too
+	limit, identifiedBy := ps.GetPipelineSuccessHistoryLimitCount("team-a", "build", SelectorSpec{MatchAnnotations: map[string]string{"cost-tier": "high"}})
+	assert.Equal(t, ptr.Int32(5), limit)
+	assert.Equal(t, "identifiedBy_resource_selector", identifiedBy)
+	matching := ps.GetPipelineMatchingSelector("team-a", "build", SelectorSpec{MatchAnnotations: map[string]string{"cost-tier": "high"}})
+	if assert.NotNil(t, matching) {
+		assert.Equal(t, map[string]string{"cost-tier": "high"}, matching.MatchAnnotations)
+	}
+}
+
+// TestValidateConfigMapAnnotationValues verifies the validation of the byAnnotationValue entries
+func TestValidateConfigMapAnnotationValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		cm      *corev1.ConfigMap
+		wantErr string
+	}{
+		{
+			name: "valid",
+			cm: &corev1.ConfigMap{Data: map[string]string{PrunerNamespaceConfigKey: `
+taskRuns:
+  - byAnnotationValue:
+      annotation: example.com/cost-tier
+      values:
+        low:
+          ttlSecondsAfterFinished: 600`}},
+		},
+		{
+			name: "missing annotation",
+			cm: &corev1.ConfigMap{Data: map[string]string{PrunerNamespaceConfigKey: `
+pipelineRuns:
+  - byAnnotationValue:
+      values:
+        low:
+          ttlSecondsAfterFinished: 600`}},
+			wantErr: "ns-config.pipelineRuns[0].byAnnotationValue: annotation cannot be empty",
+		},
+		{
+			name: "invalid annotation",
+			cm: &corev1.ConfigMap{Data: map[string]string{PrunerNamespaceConfigKey: `
+pipelineRuns:
+  - byAnnotationValue:
+      annotation: "cost tier"
+      values:
+        low:
+          ttlSecondsAfterFinished: 600`}},
+			wantErr: "ns-config.pipelineRuns[0].byAnnotationValue: invalid annotation 'cost tier'",
+		},
+		{
+			name: "no values",
+			cm: &corev1.ConfigMap{Data: map[string]string{PrunerNamespaceConfigKey: `
+pipelineRuns:
+  - byAnnotationValue:
+      annotation: cost-tier`}},
+			wantErr: "ns-config.pipelineRuns[0].byAnnotationValue: values cannot be empty",
+		},
+		{
+			name: "combined with a selector",
+			cm: &corev1.ConfigMap{Data: map[string]string{PrunerNamespaceConfigKey: `
+pipelineRuns:
+  - selector:
+      - matchLabels:
+          app: build
+    byAnnotationValue:
+      annotation: cost-tier
+      values:
+        low:
+          ttlSecondsAfterFinished: 600`}},
+			wantErr: "ns-config.pipelineRuns[0].byAnnotationValue: cannot be combined with name or selector",
+		},
+		{
+			name: "negative value limit",
+			cm: &corev1.ConfigMap{Data: map[string]string{PrunerNamespaceConfigKey: `
+pipelineRuns:
+  - byAnnotationValue:
+      annotation: cost-tier
+      values:
+        low:
+          historyLimit: -1`}},
+			wantErr: "historyLimit cannot be negative",
+		},
+		{
+			name: "in the global config",
+			cm: &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `
+namespaces:
+  team-a:
+    pipelineRuns:
+      - byAnnotationValue:
+          annotation: cost-tier
+          values:
+            low:
+              ttlSecondsAfterFinished: 600`}},
+			wantErr: "global-config.namespaces.team-a.pipelineRuns[0]: byAnnotationValue is NOT supported in global ConfigMap",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfigMapWithGlobal(tt.cm, nil)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// jsonPatchOperation is an operation of a JSON Patch (RFC 6902)
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// ErrAnnotationNotAllowed reports a patch touching an annotation key the annotationAllowlist of the global config does not allow
+var ErrAnnotationNotAllowed = errors.New("annotation key not allowed by annotationAllowlist")
+
+// AnnotationPatch returns the JSON Patch which sets the given annotations of a resource and removes the given keys.
+// All the annotation mutations of the pruner go through it, so that keys holding slashes or tildes are escaped the same way.
+// The current annotations of the resource tell whether the annotations map has to be created first,
+// and which of the keys to remove are actually present, as removing a missing key fails the whole patch.
+// A patch touching a key the annotationAllowlist does not allow is not built, ErrAnnotationNotAllowed is returned instead
+func AnnotationPatch(resource metav1.Object, set map[string]string, remove ...string) ([]byte, error) {
+	current := resource.GetAnnotations()
+	for key := range set {
+		if !PrunerConfigStore.IsAnnotationAllowed(key) {
+			return nil, fmt.Errorf("%w: %s", ErrAnnotationNotAllowed, key)
+		}
+	}
+	for _, key := range remove {
+		if _, found := current[key]; found && !PrunerConfigStore.IsAnnotationAllowed(key) {
+			return nil, fmt.Errorf("%w: %s", ErrAnnotationNotAllowed, key)
+		}
+	}
+	operations := []jsonPatchOperation{}
+	if current == nil && len(set) > 0 {
+		operations = append(operations, jsonPatchOperation{Op: "add", Path: "/metadata/annotations", Value: map[string]string{}})
+	}
+
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		// add replaces the value of an existing key
+		operations = append(operations, jsonPatchOperation{Op: "add", Path: annotationPath(key), Value: set[key]})
+	}
+
+	for _, key := range remove {
+		if _, found := current[key]; !found {
+			continue
+		}
+		if _, found := set[key]; found {
+			continue
+		}
+		operations = append(operations, jsonPatchOperation{Op: "remove", Path: annotationPath(key)})
+	}
+	return json.Marshal(operations)
+}
+
+// annotationPath returns the JSON Pointer of an annotation
+func annotationPath(key string) string {
+	return "/metadata/annotations/" + EscapeJSONPointer(key)
+}
+
+// EscapeJSONPointer escapes a key to be used as a JSON Pointer token (RFC 6901)
+func EscapeJSONPointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
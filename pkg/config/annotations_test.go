@@ -0,0 +1,162 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestEscapeJSONPointer verifies the escaping of annotation keys used in JSON Patch paths.
+func TestEscapeJSONPointer(t *testing.T) {
+	tests := map[string]string{
+		"processed":                   "processed",
+		"pruner.tekton.dev/processed": "pruner.tekton.dev~1processed",
+		"a~b/c":                       "a~0b~1c",
+		"~1":                          "~01",
+	}
+	for key, want := range tests {
+		assert.Equal(t, want, EscapeJSONPointer(key), "key %q", key)
+	}
+}
+
+// TestAnnotationPatch verifies that the patch sets and removes exactly the given annotations once applied by the API server,
+// whatever the characters of the keys.
+func TestAnnotationPatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		set         map[string]string
+		remove      []string
+		want        map[string]string
+	}{
+		{
+			name: "set on a resource without annotations",
+			set:  map[string]string{"example.com/key": "value"},
+			want: map[string]string{"example.com/key": "value"},
+		},
+		{
+			name:        "set keys with slashes and tildes",
+			annotations: map[string]string{"kept": "yes"},
+			set:         map[string]string{"example.com/a~b": "1", "example.com/~1": "2"},
+			want:        map[string]string{"kept": "yes", "example.com/a~b": "1", "example.com/~1": "2"},
+		},
+		{
+			name:        "overwrite an existing key",
+			annotations: map[string]string{"example.com/key": "old"},
+			set:         map[string]string{"example.com/key": "new"},
+			want:        map[string]string{"example.com/key": "new"},
+		},
+		{
+			name:        "remove keys with slashes and tildes",
+			annotations: map[string]string{"kept": "yes", "example.com/a~b": "1", "example.com/~1": "2"},
+			remove:      []string{"example.com/a~b", "example.com/~1"},
+			want:        map[string]string{"kept": "yes"},
+		},
+		{
+			name:        "remove a missing key",
+			annotations: map[string]string{"kept": "yes"},
+			set:         map[string]string{"example.com/key": "value"},
+			remove:      []string{"example.com/missing"},
+			want:        map[string]string{"kept": "yes", "example.com/key": "value"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", Annotations: tt.annotations}}
+			client := fake.NewSimpleClientset(cm)
+
+			patchBytes, err := AnnotationPatch(cm, tt.set, tt.remove...)
+			assert.NoError(t, err)
+			patched, err := client.CoreV1().ConfigMaps("default").Patch(ctx, "test", types.JSONPatchType, patchBytes, metav1.PatchOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, patched.Annotations)
+		})
+	}
+}
+
+// TestAnnotationPatchAllowlist verifies that no patch is built when it touches a key the annotationAllowlist does not allow
+func TestAnnotationPatchAllowlist(t *testing.T) {
+	ctx := context.Background()
+	cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: "annotationAllowlist: [example.com/, other.io/processed]"}}
+	if err := PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+		t.Fatalf("LoadGlobalConfig() error = %v", err)
+	}
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		set         map[string]string
+		remove      []string
+		wantErr     bool
+	}{
+		{name: "set a key with an allowed prefix", set: map[string]string{"example.com/key": "value"}},
+		{name: "set an allowed key", set: map[string]string{"other.io/processed": "value"}},
+		{name: "set a key with another prefix", set: map[string]string{AnnotationPrunable: "true"}, wantErr: true},
+		{name: "set a key only sharing the start of an allowed key", set: map[string]string{"other.io/processed-at": "value"}, wantErr: true},
+		{name: "remove a disallowed key", annotations: map[string]string{AnnotationRetainDays: "7"}, remove: []string{AnnotationRetainDays}, wantErr: true},
+		{name: "remove a missing disallowed key", remove: []string{AnnotationRetainDays}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := &metav1.ObjectMeta{Annotations: tt.annotations}
+			_, err := AnnotationPatch(resource, tt.set, tt.remove...)
+			assert.Equal(t, tt.wantErr, errors.Is(err, ErrAnnotationNotAllowed), "error = %v", err)
+		})
+	}
+}
+
+// applyAnnotationPatch applies a patch created by AnnotationPatch to the given annotations, as the API server would
+func applyAnnotationPatch(annotations map[string]string, patchBytes []byte) (map[string]string, error) {
+	var operations []jsonPatchOperation
+	if err := json.Unmarshal(patchBytes, &operations); err != nil {
+		return nil, err
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	for _, op := range operations {
+		if op.Path == "/metadata/annotations" {
+			continue
+		}
+		key := unescape.Replace(strings.TrimPrefix(op.Path, "/metadata/annotations/"))
+		switch op.Op {
+		case "add":
+			annotations[key] = fmt.Sprint(op.Value)
+		case "remove":
+			delete(annotations, key)
+		}
+	}
+	return annotations, nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/logging"
+)
+
+// applyCanary decides whether the loaded global config applies to every namespace, or only to the canaryNamespace
+// until it is promoted, the other namespaces keeping the config last promoted. When no config was promoted yet,
+// e.g. on the first start with no state recorded, the other namespaces are pruned by no global config until the promotion.
+// It must be called with the lock held, before the loaded config replaces the current one
+func (ps *prunerConfigStore) applyCanary(ctx context.Context, configMap *corev1.ConfigMap, globalConfig *GlobalConfig) {
+	logger := logging.FromContext(ctx)
+
+	digest := ConfigDigest(configMap)
+	ps.canaryPending = false
+	switch {
+	case globalConfig.CanaryNamespace == "":
+	case ps.promotedConfig != nil && digest == digestOf(ps.promotedData):
+	case configMap.Annotations[AnnotationPromoteConfig] == digest:
+		logger.Infow("Global config tested in the canary namespace was promoted", "canaryNamespace", globalConfig.CanaryNamespace)
+	case ps.promotedConfig == nil:
+		ps.canaryPending = true
+		logger.Warnw("Global config applies to the canary namespace only, until it is promoted. No config promoted before is known, "+
+			"the other namespaces are pruned by no global config meanwhile", "canaryNamespace", globalConfig.CanaryNamespace,
+			"promoteAnnotation", fmt.Sprintf("%s=%s", AnnotationPromoteConfig, digest))
+	default:
+		// the namespaces other than the canary keep the config last promoted, not an intermediate pending one
+		ps.canaryPending = true
+		logger.Warnw("Global config applies to the canary namespace only, until it is promoted",
+			"canaryNamespace", globalConfig.CanaryNamespace,
+			"promoteAnnotation", fmt.Sprintf("%s=%s", AnnotationPromoteConfig, digest))
+	}
+
+	if !ps.canaryPending {
+		promoted := *globalConfig
+		ps.promotedConfig = &promoted
+		ps.promotedData = configMap.Data[PrunerGlobalConfigKey]
+	}
+}
+
+// globalConfigFor returns the global config the pruning decisions of a namespace are based on:
+// the config last promoted while a pending config is tested in the canary namespace, the loaded config otherwise.
+// It must be called with the lock held
+func (ps *prunerConfigStore) globalConfigFor(namespace string) GlobalConfig {
+	if !ps.canaryPending || namespace == ps.globalConfig.CanaryNamespace {
+		return ps.globalConfig
+	}
+	if ps.promotedConfig == nil {
+		return GlobalConfig{}
+	}
+	return *ps.promotedConfig
+}
+
+// IsCanaryPending reports whether a loaded global config applies to the canary namespace only, waiting to be promoted
+func (ps *prunerConfigStore) IsCanaryPending() bool {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.canaryPending
+}
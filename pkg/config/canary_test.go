@@ -0,0 +1,197 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLoadGlobalConfigCanary(t *testing.T) {
+	ctx := context.Background()
+	newConfigMap := func(data string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: PrunerConfigMapName, Namespace: "tekton-pipelines"},
+			Data:       map[string]string{PrunerGlobalConfigKey: data},
+		}
+	}
+	ttl := func(store *prunerConfigStore, namespace string) int32 {
+		value, _ := store.GetPipelineTTLSecondsAfterFinished(namespace, "", SelectorSpec{})
+		if value == nil {
+			return -1
+		}
+		return *value
+	}
+
+	newState := func(promoted string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: PrunerStateConfigMapName, Namespace: "tekton-pipelines"},
+			Data:       map[string]string{PrunerStatePromotedConfigKey: promoted},
+		}
+	}
+	const promoted = `
+ttlSecondsAfterFinished: 3600
+canaryNamespace: canary`
+
+	t.Run("changed config applies to the canary namespace until promoted", func(t *testing.T) {
+		store := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+		assert.NoError(t, store.LoadState(ctx, newState(promoted)))
+		assert.NoError(t, store.LoadGlobalConfig(ctx, newConfigMap(promoted)))
+		assert.False(t, store.IsCanaryPending())
+		assert.Equal(t, int32(3600), ttl(store, "canary"))
+		assert.Equal(t, int32(3600), ttl(store, "team-a"))
+
+		cm := newConfigMap(`
+ttlSecondsAfterFinished: 600
+canaryNamespace: canary`)
+		assert.NoError(t, store.LoadGlobalConfig(ctx, cm))
+		assert.True(t, store.IsCanaryPending())
+		assert.Equal(t, int32(600), ttl(store, "canary"))
+		assert.Equal(t, int32(3600), ttl(store, "team-a"))
+
+		// a further change keeps the config last promoted for the other namespaces
+		cm = newConfigMap(`
+ttlSecondsAfterFinished: 300
+canaryNamespace: canary`)
+		assert.NoError(t, store.LoadGlobalConfig(ctx, cm))
+		assert.Equal(t, int32(300), ttl(store, "canary"))
+		assert.Equal(t, int32(3600), ttl(store, "team-a"))
+
+		// a promotion of another config keeps the config pending
+		cm.Annotations = map[string]string{AnnotationPromoteConfig: "0123456789ab"}
+		assert.NoError(t, store.LoadGlobalConfig(ctx, cm))
+		assert.True(t, store.IsCanaryPending())
+
+		cm.Annotations[AnnotationPromoteConfig] = ConfigDigest(cm)
+		assert.NoError(t, store.LoadGlobalConfig(ctx, cm))
+		assert.False(t, store.IsCanaryPending())
+		assert.Equal(t, int32(300), ttl(store, "team-a"))
+
+		// the promoted config is the new reference
+		cm.Annotations = nil
+		assert.NoError(t, store.LoadGlobalConfig(ctx, cm))
+		assert.False(t, store.IsCanaryPending())
+	})
+
+	t.Run("first config applies to the canary namespace until promoted", func(t *testing.T) {
+		store := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+		assert.NoError(t, store.LoadState(ctx, nil))
+		cm := newConfigMap(promoted)
+		assert.NoError(t, store.LoadGlobalConfig(ctx, cm))
+		assert.True(t, store.IsCanaryPending())
+		assert.Equal(t, int32(3600), ttl(store, "canary"))
+		assert.Equal(t, int32(-1), ttl(store, "team-a"))
+		assert.NotContains(t, store.State(), PrunerStatePromotedConfigKey)
+
+		cm.Annotations = map[string]string{AnnotationPromoteConfig: ConfigDigest(cm)}
+		assert.NoError(t, store.LoadGlobalConfig(ctx, cm))
+		assert.False(t, store.IsCanaryPending())
+		assert.Equal(t, int32(3600), ttl(store, "team-a"))
+		assert.Equal(t, promoted, store.State()[PrunerStatePromotedConfigKey])
+	})
+
+	t.Run("restart keeps the config last promoted", func(t *testing.T) {
+		store := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+		assert.NoError(t, store.LoadState(ctx, newState(promoted)))
+		assert.NoError(t, store.LoadGlobalConfig(ctx, newConfigMap(`
+ttlSecondsAfterFinished: 600
+canaryNamespace: canary`)))
+
+		restarted := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+		assert.NoError(t, restarted.LoadState(ctx, &corev1.ConfigMap{Data: store.State()}))
+		assert.NoError(t, restarted.LoadGlobalConfig(ctx, newConfigMap(`
+ttlSecondsAfterFinished: 600
+canaryNamespace: canary`)))
+		assert.True(t, restarted.IsCanaryPending())
+		assert.Equal(t, int32(600), ttl(restarted, "canary"))
+		assert.Equal(t, int32(3600), ttl(restarted, "team-a"))
+	})
+
+	t.Run("namespace entries of the global config follow the canary", func(t *testing.T) {
+		store := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+		const first = `
+canaryNamespace: canary
+namespaces:
+  canary:
+    ttlSecondsAfterFinished: 3600
+  team-a:
+    ttlSecondsAfterFinished: 3600`
+		assert.NoError(t, store.LoadState(ctx, newState(first)))
+		assert.NoError(t, store.LoadGlobalConfig(ctx, newConfigMap(first)))
+		assert.NoError(t, store.LoadGlobalConfig(ctx, newConfigMap(`
+canaryNamespace: canary
+namespaces:
+  canary:
+    ttlSecondsAfterFinished: 60
+  team-a:
+    ttlSecondsAfterFinished: 60`)))
+		assert.Equal(t, int32(60), ttl(store, "canary"))
+		assert.Equal(t, int32(3600), ttl(store, "team-a"))
+	})
+
+	t.Run("pruning policies follow the canary, operational settings apply at once", func(t *testing.T) {
+		store := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+		const first = `
+canaryNamespace: canary
+gcIntervalSeconds: 600
+maxCompletedRunsPerNamespace: 100
+retainDaysTimeZone: Europe/Paris
+neverPrune:
+  pipelineRuns: [release]`
+		assert.NoError(t, store.LoadState(ctx, newState(first)))
+		assert.NoError(t, store.LoadGlobalConfig(ctx, newConfigMap(first)))
+		assert.NoError(t, store.LoadGlobalConfig(ctx, newConfigMap(`
+canaryNamespace: canary
+gcIntervalSeconds: 60
+maxCompletedRunsPerNamespace: 10
+deletionMode: annotate
+retainDaysTimeZone: Asia/Tokyo
+skipRunsWithFinalizers: [chains.tekton.dev]`)))
+		assert.True(t, store.IsCanaryPending())
+
+		assert.Equal(t, int32(10), *store.GetMaxCompletedRunsPerNamespace("canary"))
+		assert.Equal(t, DeletionModeAnnotate, store.GetDeletionMode("canary"))
+		assert.Equal(t, "Asia/Tokyo", store.GetRetainDaysLocation("canary").String())
+		assert.Equal(t, []string{"chains.tekton.dev"}, store.GetSkipRunsWithFinalizers("canary"))
+		assert.Empty(t, store.GetNeverPruneNames("canary", KindPipelineRun))
+
+		assert.Equal(t, int32(100), *store.GetMaxCompletedRunsPerNamespace("team-a"))
+		assert.Equal(t, DeletionModeDelete, store.GetDeletionMode("team-a"))
+		assert.Equal(t, "Europe/Paris", store.GetRetainDaysLocation("team-a").String())
+		assert.Empty(t, store.GetSkipRunsWithFinalizers("team-a"))
+		assert.Equal(t, []string{"release"}, store.GetNeverPruneNames("team-a", KindPipelineRun))
+
+		assert.Equal(t, 60*time.Second, store.GetGCInterval())
+	})
+
+	t.Run("without canary namespace a changed config applies everywhere", func(t *testing.T) {
+		store := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+		assert.NoError(t, store.LoadGlobalConfig(ctx, newConfigMap(`ttlSecondsAfterFinished: 3600`)))
+		assert.NoError(t, store.LoadGlobalConfig(ctx, newConfigMap(`ttlSecondsAfterFinished: 600`)))
+		assert.False(t, store.IsCanaryPending())
+		assert.Equal(t, int32(600), ttl(store, "team-a"))
+	})
+
+	t.Run("invalid canary namespace", func(t *testing.T) {
+		assert.Error(t, ValidateGlobalConfig(&GlobalConfig{CanaryNamespace: "Not_A_Namespace"}))
+	})
+}
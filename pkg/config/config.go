@@ -17,14 +17,29 @@ limitations under the License.
 package config
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
+	"time"
+	// the time zones of retainDaysTimeZone are embedded, the container images have no time zone database
+	_ "time/tzdata"
 
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pruner/pkg/metrics"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"knative.dev/pkg/logging"
+	"knative.dev/pkg/ptr"
 )
 
 // following types are for internal use
@@ -38,6 +53,24 @@ type PrunerFieldType string
 // EnforcedConfigLevel is a string type to manage the different override levels allowed for Pruner config
 type EnforcedConfigLevel string
 
+// MetricsNamespaceAggregation is a string type to manage how the namespace label is recorded on metrics
+type MetricsNamespaceAggregation string
+
+// DeletionMode is a string type to manage how the pruner removes the selected resources
+type DeletionMode string
+
+// TTLFrom is a string type to manage the time the TTL of a resource is counted from
+type TTLFrom string
+
+// HistoryLimitEvictionPriority is a string type to manage which runs are pruned first over a shared history limit
+type HistoryLimitEvictionPriority string
+
+// CancelledCountsAs is a string type to manage how the cancelled runs are counted by the history limits
+type CancelledCountsAs string
+
+// TTLHistoryPolicy is a string type to manage how the TTL and the history limits interact
+type TTLHistoryPolicy string
+
 const (
 	// PrunerResourceTypePipelineRun represents the resource type for a PipelineRun in the pruner.
 	PrunerResourceTypePipelineRun PrunerResourceType = "pipelineRun"
@@ -62,6 +95,54 @@ const (
 
 	// EnforcedConfigLevelResource represents the resource-level config for pruner.
 	EnforcedConfigLevelResource EnforcedConfigLevel = "resource"
+
+	// MetricsNamespaceAggregationNone records metrics with the namespace of the resource (default).
+	MetricsNamespaceAggregationNone MetricsNamespaceAggregation = "none"
+
+	// MetricsNamespaceAggregationAggregate records metrics of the namespaces matching
+	// metricsAggregatedNamespacePattern under a single aggregated namespace label.
+	MetricsNamespaceAggregationAggregate MetricsNamespaceAggregation = "aggregate"
+
+	// DeletionModeDelete deletes the resources selected for pruning.
+	DeletionModeDelete DeletionMode = "delete"
+
+	// DeletionModeAnnotate marks the resources selected for pruning with the prunable annotation
+	// instead of deleting them, leaving the actual removal to another process.
+	DeletionModeAnnotate DeletionMode = "annotate"
+
+	// TTLFromCompletion counts the TTL of a resource from its completion time (default).
+	TTLFromCompletion TTLFrom = "completion"
+
+	// TTLFromStart counts the TTL of a resource from its start time.
+	TTLFromStart TTLFrom = "start"
+
+	// HistoryLimitEvictionOldest applies the history limit to the successful and the failed runs separately,
+	// pruning the oldest runs of each status (default).
+	HistoryLimitEvictionOldest HistoryLimitEvictionPriority = "oldest"
+
+	// HistoryLimitEvictionFailed applies a shared history limit to the successful and failed runs together,
+	// pruning the failed runs before the successful ones.
+	HistoryLimitEvictionFailed HistoryLimitEvictionPriority = "failed"
+
+	// HistoryLimitEvictionSuccessful applies a shared history limit to the successful and failed runs together,
+	// pruning the successful runs before the failed ones.
+	HistoryLimitEvictionSuccessful HistoryLimitEvictionPriority = "successful"
+
+	// CancelledCountsAsFailed counts the cancelled runs toward the failed history limit (default).
+	CancelledCountsAsFailed CancelledCountsAs = "failed"
+
+	// CancelledCountsAsSuccessful counts the cancelled runs toward the successful history limit.
+	CancelledCountsAsSuccessful CancelledCountsAs = "successful"
+
+	// CancelledCountsAsIgnored leaves the cancelled runs out of the history limits, they are neither counted nor pruned by them.
+	CancelledCountsAsIgnored CancelledCountsAs = "ignored"
+
+	// TTLHistoryPolicyIndependent lets the TTL and the history limits prune the runs independently (default).
+	TTLHistoryPolicyIndependent TTLHistoryPolicy = "independent"
+
+	// TTLHistoryPolicyCombined prunes the runs once their TTL expires, except the newest runs within the history limits.
+	// The history limits then only keep runs, they never prune on their own.
+	TTLHistoryPolicyCombined TTLHistoryPolicy = "combined"
 )
 
 // ResourceSpec is used to hold the config of a specific resource
@@ -70,6 +151,24 @@ type ResourceSpec struct {
 	Name         string         `yaml:"name,omitempty" json:"name,omitempty"`         // Exact name of the parent Pipeline or Task
 	Selector     []SelectorSpec `yaml:"selector,omitempty" json:"selector,omitempty"` // Supports selection based on labels and annotations. If Name is given, Name takes precedence
 	PrunerConfig `yaml:",inline,omitempty" json:",inline,omitempty"`
+	// ByAnnotationValue selects the config by the value of an annotation of the runs, in place of Name and Selector
+	ByAnnotationValue *AnnotationValueSpec `yaml:"byAnnotationValue,omitempty" json:"byAnnotationValue,omitempty"`
+	// Priority orders the entries whose selectors match the same run, the highest priority wins (default: 0).
+	// Entries of the same priority apply in the order they are declared
+	Priority *int32 `yaml:"priority,omitempty" json:"priority,omitempty"`
+}
+
+// byPriority returns the resource specs in the order their selectors are matched: highest priority first,
+// then in declaration order. The specs are returned as is when none of them has a priority
+func byPriority(resourceSpecs []ResourceSpec) []ResourceSpec {
+	if !slices.ContainsFunc(resourceSpecs, func(r ResourceSpec) bool { return r.Priority != nil }) {
+		return resourceSpecs
+	}
+	sorted := slices.Clone(resourceSpecs)
+	slices.SortStableFunc(sorted, func(a, b ResourceSpec) int {
+		return cmp.Compare(ptr.Int32Value(b.Priority), ptr.Int32Value(a.Priority))
+	})
+	return sorted
 }
 
 // SelectorSpec allows specifying selectors for matching resources like PipelineRun or TaskRun
@@ -87,6 +186,19 @@ type NamespaceSpec struct {
 	PrunerConfig `yaml:",inline,omitempty" json:",inline,omitempty"` // Root-level defaults
 	PipelineRuns []ResourceSpec                                      `yaml:"pipelineRuns,omitempty" json:"pipelineRuns,omitempty"` // Selector-based configs (namespace ConfigMap only)
 	TaskRuns     []ResourceSpec                                      `yaml:"taskRuns,omitempty" json:"taskRuns,omitempty"`         // Selector-based configs (namespace ConfigMap only)
+	// ProfileRef names a profile of the global config, whose fields apply unless set here (namespace ConfigMap only)
+	ProfileRef string `yaml:"profileRef,omitempty" json:"profileRef,omitempty"`
+	// PerLabelValueHistoryLimit applies a successful history limit to each distinct value of a label, in place of
+	// the namespace limit, to the runs carrying the label. A name or selector entry matching a run still takes precedence
+	PerLabelValueHistoryLimit *PerLabelValueHistoryLimit `yaml:"perLabelValueHistoryLimit,omitempty" json:"perLabelValueHistoryLimit,omitempty"`
+}
+
+// PerLabelValueHistoryLimit groups the runs of a namespace by the value of a label, e.g. one group per team
+type PerLabelValueHistoryLimit struct {
+	// Label is the key of the label whose values group the runs
+	Label string `yaml:"label" json:"label"`
+	// Limit is the number of successful runs kept for each value of the label
+	Limit *int32 `yaml:"limit" json:"limit"`
 }
 
 // GlobalConfig represents the global ConfigMap (tekton-pruner-default-spec)
@@ -95,6 +207,204 @@ type NamespaceSpec struct {
 type GlobalConfig struct {
 	PrunerConfig `yaml:",inline,omitempty" json:",inline,omitempty"` // Global root-level defaults
 	Namespaces   map[string]NamespaceSpec                            `yaml:"namespaces,omitempty" json:"namespaces,omitempty"` // Per-namespace defaults (selectors ignored)
+	// Profiles are named namespace configs, inherited by the namespace ConfigMaps referencing them with profileRef
+	Profiles map[string]NamespaceSpec `yaml:"profiles,omitempty" json:"profiles,omitempty"`
+
+	// MetricsNamespaceAggregation allowed values: none, aggregate (default: none)
+	MetricsNamespaceAggregation *MetricsNamespaceAggregation `yaml:"metricsNamespaceAggregation,omitempty" json:"metricsNamespaceAggregation,omitempty"`
+	// MetricsAggregatedNamespacePattern is a regular expression of the namespaces recorded
+	// under a single namespace label, used only when metricsNamespaceAggregation is aggregate
+	MetricsAggregatedNamespacePattern string `yaml:"metricsAggregatedNamespacePattern,omitempty" json:"metricsAggregatedNamespacePattern,omitempty"`
+	// SuccessfulReasons lists, for each resource type, the condition reasons of a completed run treated as successful,
+	// any other reason is treated as failed. A resource type not listed uses the Tekton defaults
+	SuccessfulReasons *SuccessfulReasonsSpec `yaml:"successfulReasons,omitempty" json:"successfulReasons,omitempty"`
+	// MaxCompletedRunsPerNamespace caps the completed PipelineRuns and standalone TaskRuns kept in a namespace,
+	// the oldest runs beyond the cap are removed after the per-resource limits are applied. If not set, there is no cap
+	MaxCompletedRunsPerNamespace *int32 `yaml:"maxCompletedRunsPerNamespace,omitempty" json:"maxCompletedRunsPerNamespace,omitempty"`
+	// NamespaceObjectBudget caps the PipelineRuns and TaskRuns of any kind and status held by a namespace. Once all the other
+	// rules are applied, the oldest completed runs are removed until the namespace is within budget. If not set, there is no budget
+	NamespaceObjectBudget *int32 `yaml:"namespaceObjectBudget,omitempty" json:"namespaceObjectBudget,omitempty"`
+	// PipelineRunLabelKeys and TaskRunLabelKeys list, in priority order, the label keys used to group runs
+	// for history limits, the first key present on a run is used. The first key also replaces the Tekton default
+	// label key the TTL of a run is looked up by. If not set, the Tekton defaults are used
+	PipelineRunLabelKeys []string `yaml:"pipelineRunLabelKeys,omitempty" json:"pipelineRunLabelKeys,omitempty"`
+	TaskRunLabelKeys     []string `yaml:"taskRunLabelKeys,omitempty" json:"taskRunLabelKeys,omitempty"`
+	// DeletionMode allowed values: delete, annotate (default: delete)
+	DeletionMode *DeletionMode `yaml:"deletionMode,omitempty" json:"deletionMode,omitempty"`
+	// HistoryLimitEvictionPriority allowed values: oldest, failed, successful (default: oldest).
+	// With failed or successful, a history limit shared by the successful and failed runs of a group, as with historyLimit,
+	// caps them together and the runs of that status are pruned first
+	HistoryLimitEvictionPriority *HistoryLimitEvictionPriority `yaml:"historyLimitEvictionPriority,omitempty" json:"historyLimitEvictionPriority,omitempty"`
+	// CancelledCountsAs allowed values: failed, successful, ignored (default: failed).
+	// It sets the history limit the cancelled runs count toward, with ignored they are left out of the history limits
+	CancelledCountsAs *CancelledCountsAs `yaml:"cancelledCountsAs,omitempty" json:"cancelledCountsAs,omitempty"`
+	// TTLHistoryPolicy allowed values: independent, combined (default: independent).
+	// With combined, a run whose TTL expired is kept while it is among the newest runs within its history limit,
+	// e.g. "delete after 7 days, but always keep the last 10"
+	TTLHistoryPolicy *TTLHistoryPolicy `yaml:"ttlHistoryPolicy,omitempty" json:"ttlHistoryPolicy,omitempty"`
+	// MinSuccessfulToKeep is the number of successful runs of each Pipeline and Task the history limiter keeps across
+	// all the namespaces, even when the history limits of their namespaces would prune them. If not set, there is no floor
+	MinSuccessfulToKeep *int32 `yaml:"minSuccessfulToKeep,omitempty" json:"minSuccessfulToKeep,omitempty"`
+	// ExcludePrunableFromHistory excludes the resources already marked prunable from the history limit count
+	ExcludePrunableFromHistory bool `yaml:"excludePrunableFromHistory,omitempty" json:"excludePrunableFromHistory,omitempty"`
+	// ProcessedAnnotationKey overrides the annotation key which marks a resource as processed by the history limiter
+	// (default: pruner.tekton.dev/historyLimitCheckProcessed)
+	ProcessedAnnotationKey string `yaml:"processedAnnotationKey,omitempty" json:"processedAnnotationKey,omitempty"`
+	// AnnotationAllowlist restricts the annotation keys the pruner adds or removes, e.g. under an admission policy
+	// protecting some prefixes. An entry ending with a slash allows the keys with that prefix, any other entry a single key.
+	// The writes touching another key are skipped. If not set, every key is allowed
+	AnnotationAllowlist []string `yaml:"annotationAllowlist,omitempty" json:"annotationAllowlist,omitempty"`
+	// PruneLargeStatusBytes prunes the completed PipelineRuns and standalone TaskRuns whose serialized status
+	// is larger than the given number of bytes, after the per-resource limits are applied. If not set, no run is pruned by size
+	PruneLargeStatusBytes *int64 `yaml:"pruneLargeStatusBytes,omitempty" json:"pruneLargeStatusBytes,omitempty"`
+	// HistoryLimitBytes caps the cumulative serialized size of the runs counted toward the same history limit. The newest runs
+	// are kept until the budget is exceeded, whichever of the history limit and the budget is stricter applies. If not set, there is no budget
+	HistoryLimitBytes *int64 `yaml:"historyLimitBytes,omitempty" json:"historyLimitBytes,omitempty"`
+	// ProtectIfReferencedBy lists the resources which protect the runs referencing them from being pruned, as long as they exist
+	ProtectIfReferencedBy []ProtectionRule `yaml:"protectIfReferencedBy,omitempty" json:"protectIfReferencedBy,omitempty"`
+	// MaxConcurrentDeletions caps the number of Delete calls issued concurrently by all the garbage collection workers.
+	// If not set, deletions are not limited
+	MaxConcurrentDeletions *int32 `yaml:"maxConcurrentDeletions,omitempty" json:"maxConcurrentDeletions,omitempty"`
+	// AutoWorkerCount sizes the garbage collection worker pool after the number of namespaces to collect,
+	// one worker per NamespacesPerAutoWorker namespaces, instead of using a fixed number of workers
+	AutoWorkerCount bool `yaml:"autoWorkerCount,omitempty" json:"autoWorkerCount,omitempty"`
+	// MaxWorkerCount caps the number of workers sized by AutoWorkerCount (default: 20)
+	MaxWorkerCount *int32 `yaml:"maxWorkerCount,omitempty" json:"maxWorkerCount,omitempty"`
+	// GCIntervalSeconds runs garbage collection periodically, on top of the ConfigMap updates,
+	// between 30 seconds and 24 hours. If not set, garbage collection does not run periodically
+	GCIntervalSeconds *int32 `yaml:"gcIntervalSeconds,omitempty" json:"gcIntervalSeconds,omitempty"`
+	// InterNamespaceDelayMillis is the delay each garbage collection worker waits after collecting a namespace,
+	// spreading the API calls of a cycle over time, up to 60000. If not set, the namespaces are collected back to back
+	InterNamespaceDelayMillis *int32 `yaml:"interNamespaceDelayMillis,omitempty" json:"interNamespaceDelayMillis,omitempty"`
+	// HistoryDeletionBatchSize caps the number of runs pruned by the history limiter per namespace in a garbage collection cycle,
+	// and per history limit check of a completed run. Oldest runs are pruned first, the rest are left for later cycles.
+	// If not set, all the runs over the history limit are pruned at once
+	HistoryDeletionBatchSize *int32 `yaml:"historyDeletionBatchSize,omitempty" json:"historyDeletionBatchSize,omitempty"`
+	// NamespaceExcludeRegexes lists regular expressions of the namespaces skipped by garbage collection,
+	// on top of the system namespaces which are always skipped
+	NamespaceExcludeRegexes []string `yaml:"namespaceExcludeRegexes,omitempty" json:"namespaceExcludeRegexes,omitempty"`
+	// NamespacePriority lists the namespaces garbage collection processes first, in this order.
+	// The other namespaces follow in alphabetical order
+	NamespacePriority []string `yaml:"namespacePriority,omitempty" json:"namespacePriority,omitempty"`
+	// TTLRequeueCeilingSeconds caps how far out a run with an unexpired TTL is scheduled to be reconciled again.
+	// A run expiring later is not scheduled, it is evaluated again on the next resync or garbage collection.
+	// If not set, such runs are scheduled up to 24 hours out
+	TTLRequeueCeilingSeconds *int32 `yaml:"ttlRequeueCeilingSeconds,omitempty" json:"ttlRequeueCeilingSeconds,omitempty"`
+	// DeleteLeftoverPods deletes the pods labeled with the name of a deleted run which were not garbage collected along with it
+	DeleteLeftoverPods bool `yaml:"deleteLeftoverPods,omitempty" json:"deleteLeftoverPods,omitempty"`
+	// AggressivePruneTerminatingNamespaces prunes all the completed runs of a namespace being deleted on the next
+	// garbage collection, whatever their TTL and history limits, to speed up its teardown
+	AggressivePruneTerminatingNamespaces bool `yaml:"aggressivePruneTerminatingNamespaces,omitempty" json:"aggressivePruneTerminatingNamespaces,omitempty"`
+	// FallbackTTLSecondsAfterFinished is the TTL of the runs no TTL is configured for at any level,
+	// not even the global one. If not set, such runs are never removed by TTL
+	FallbackTTLSecondsAfterFinished *int32 `yaml:"fallbackTTLSecondsAfterFinished,omitempty" json:"fallbackTTLSecondsAfterFinished,omitempty"`
+	// TTLNoResultsSeconds shortens the TTL of the completed runs which emitted no results to that many seconds.
+	// It never extends a TTL, nor gives a TTL to the runs which have none. If not set, the results are not looked at
+	TTLNoResultsSeconds *int32 `yaml:"ttlNoResultsSeconds,omitempty" json:"ttlNoResultsSeconds,omitempty"`
+	// RetainDays keeps a run until midnight, in RetainDaysTimeZone, that many calendar days after the day it finished.
+	// It takes the place of the global ttlSecondsAfterFinished, a TTL configured for a namespace or a resource takes precedence
+	RetainDays *int32 `yaml:"retainDays,omitempty" json:"retainDays,omitempty"`
+	// RetainDaysTimeZone is the IANA name of the time zone the calendar days of RetainDays are counted in (default: UTC)
+	RetainDaysTimeZone string `yaml:"retainDaysTimeZone,omitempty" json:"retainDaysTimeZone,omitempty"`
+	// TTLFrom allowed values: completion, start (default: completion)
+	TTLFrom *TTLFrom `yaml:"ttlFrom,omitempty" json:"ttlFrom,omitempty"`
+	// TTLAnchorAnnotation is the annotation holding an RFC3339 timestamp the TTL of a run is counted from instead,
+	// e.g. the time its results were published. Runs without a valid timestamp fall back to ttlFrom
+	TTLAnchorAnnotation string `yaml:"ttlAnchorAnnotation,omitempty" json:"ttlAnchorAnnotation,omitempty"`
+	// FreezeUntil is an RFC3339 timestamp until which pruning is suspended cluster-wide, e.g. during a release freeze.
+	// Pruning resumes on its own once the timestamp passes
+	FreezeUntil string `yaml:"freezeUntil,omitempty" json:"freezeUntil,omitempty"`
+	// AbandonedAfterSeconds lets the TTL remove a run which is still not completed that many seconds after it started,
+	// used only when ttlFrom is start. If not set, only completed runs are removed
+	AbandonedAfterSeconds *int32 `yaml:"abandonedAfterSeconds,omitempty" json:"abandonedAfterSeconds,omitempty"`
+	// PruneStuckAfterSeconds prunes the PipelineRuns and standalone TaskRuns which are still not completed that many seconds
+	// after they started, and past their own timeout, e.g. runs orphaned by a crashed controller. If not set, such runs are kept
+	PruneStuckAfterSeconds *int32 `yaml:"pruneStuckAfterSeconds,omitempty" json:"pruneStuckAfterSeconds,omitempty"`
+	// AbsoluteMaxAgeSeconds prunes any completed run created more than that many seconds ago, whatever its TTL,
+	// history limits or retainDays. neverPrune, skipRunsWithFinalizers and protecting resources still keep a run
+	AbsoluteMaxAgeSeconds *int32 `yaml:"absoluteMaxAgeSeconds,omitempty" json:"absoluteMaxAgeSeconds,omitempty"`
+	// EphemeralNamespacePolicy prunes the runs of short-lived namespaces more aggressively
+	EphemeralNamespacePolicy *EphemeralNamespacePolicy `yaml:"ephemeralNamespacePolicy,omitempty" json:"ephemeralNamespacePolicy,omitempty"`
+	// NeverPrune lists the Pipelines and Tasks whose runs are never pruned, in any namespace
+	NeverPrune *NeverPruneSpec `yaml:"neverPrune,omitempty" json:"neverPrune,omitempty"`
+	// SkipRunsWithFinalizers lists the finalizers of other controllers whose runs are not pruned by TTL nor history limits,
+	// "*" matching any finalizer. If not set, runs are pruned whatever their finalizers
+	SkipRunsWithFinalizers []string `yaml:"skipRunsWithFinalizers,omitempty" json:"skipRunsWithFinalizers,omitempty"`
+	// TaskRunParentKinds lists the owner kinds, besides PipelineRun, which make a TaskRun part of its owner rather than
+	// a standalone TaskRun, e.g. the kinds of newer Tekton resources creating TaskRuns. Such TaskRuns are not pruned on their own
+	TaskRunParentKinds []string `yaml:"taskRunParentKinds,omitempty" json:"taskRunParentKinds,omitempty"`
+	// ListRetryAttempts is the number of times a List call throttled by the API server with 429 Too Many Requests
+	// is retried before the garbage collection of the namespace gives up (default: 3, 0 disables the retries)
+	ListRetryAttempts *int32 `yaml:"listRetryAttempts,omitempty" json:"listRetryAttempts,omitempty"`
+	// StrictNamespaceListing fails the cluster-wide lookups of the history limiter, as for minSuccessfulToKeep,
+	// when the runs of any namespace cannot be listed, instead of acting on the namespaces listed (default: false)
+	StrictNamespaceListing bool `yaml:"strictNamespaceListing,omitempty" json:"strictNamespaceListing,omitempty"`
+	// SafeMode pauses the pruning by the root-level TTL or history limits when a config update tightens them drastically,
+	// until the config is acknowledged. If not set, config updates apply as they are
+	SafeMode *SafeModeSpec `yaml:"safeMode,omitempty" json:"safeMode,omitempty"`
+	// ListRetryBackoffMilliseconds is the delay before the first retry of a throttled List call, doubled on every retry.
+	// A Retry-After delay suggested by the API server takes precedence (default: 500)
+	ListRetryBackoffMilliseconds *int32 `yaml:"listRetryBackoffMilliseconds,omitempty" json:"listRetryBackoffMilliseconds,omitempty"`
+	// AnnotationPatchRetryAttempts is the number of times a failed patch removing the processed annotation of a run is retried
+	// by the garbage collection, before the run is left to be evaluated again by the next cycle (default: 3, 0 disables the retries).
+	// The retries wait for an exponential backoff starting at listRetryBackoffMilliseconds
+	AnnotationPatchRetryAttempts *int32 `yaml:"annotationPatchRetryAttempts,omitempty" json:"annotationPatchRetryAttempts,omitempty"`
+	// CanaryNamespace is the namespace a changed global config is tested in first: its TTL, history limits and enforced config levels
+	// apply to the runs of that namespace only, the other namespaces keep the config last promoted until the global ConfigMap
+	// is annotated with AnnotationPromoteConfig. If not set, a changed config applies to every namespace at once
+	CanaryNamespace string `yaml:"canaryNamespace,omitempty" json:"canaryNamespace,omitempty"`
+	// APICallTimeoutSeconds bounds each Get, Update, Patch and Delete call made to prune a run, so that a hung call
+	// does not stall a worker. A call which times out fails as any other API error (default: 60)
+	APICallTimeoutSeconds *int32 `yaml:"apiCallTimeoutSeconds,omitempty" json:"apiCallTimeoutSeconds,omitempty"`
+	// QuotaPressure tightens the history limits of the namespaces whose ResourceQuota on the number of runs is nearly used up.
+	// If not set, the ResourceQuotas are not read
+	QuotaPressure *QuotaPressureSpec `yaml:"quotaPressure,omitempty" json:"quotaPressure,omitempty"`
+}
+
+// NeverPruneSpec lists, by name, the Pipelines and Tasks whose runs are kept whatever the TTL and history limits
+type NeverPruneSpec struct {
+	// PipelineRuns lists the names of the Pipelines, matched against the tekton.dev/pipeline label of the PipelineRuns
+	PipelineRuns []string `yaml:"pipelineRuns,omitempty" json:"pipelineRuns,omitempty"`
+	// TaskRuns lists the names of the Tasks, matched against the tekton.dev/task label of the TaskRuns
+	TaskRuns []string `yaml:"taskRuns,omitempty" json:"taskRuns,omitempty"`
+}
+
+// SuccessfulReasonsSpec lists the condition reasons treated as successful, separately for PipelineRuns and TaskRuns,
+// as the two resource types report different reasons
+type SuccessfulReasonsSpec struct {
+	// PipelineRuns lists the successful reasons of the PipelineRuns
+	PipelineRuns []string `yaml:"pipelineRuns,omitempty" json:"pipelineRuns,omitempty"`
+	// TaskRuns lists the successful reasons of the TaskRuns
+	TaskRuns []string `yaml:"taskRuns,omitempty" json:"taskRuns,omitempty"`
+}
+
+// EphemeralNamespacePolicy defines how the runs of short-lived namespaces, e.g. pull request previews, are pruned
+type EphemeralNamespacePolicy struct {
+	// NamespaceSelector selects the ephemeral namespaces by their labels
+	NamespaceSelector metav1.LabelSelector `yaml:"namespaceSelector" json:"namespaceSelector"`
+	// TTLSecondsAfterFinished prunes the completed runs of the ephemeral namespaces once expired,
+	// on top of the TTL otherwise configured for them
+	TTLSecondsAfterFinished *int32 `yaml:"ttlSecondsAfterFinished,omitempty" json:"ttlSecondsAfterFinished,omitempty"`
+	// DeleteEmptyNamespace deletes an ephemeral namespace once it holds no PipelineRun nor TaskRun
+	DeleteEmptyNamespace bool `yaml:"deleteEmptyNamespace,omitempty" json:"deleteEmptyNamespace,omitempty"`
+}
+
+// recognizedPipelineRunReasons holds the condition reasons a completed PipelineRun can report
+var recognizedPipelineRunReasons = []string{
+	pipelinev1.PipelineRunReasonSuccessful.String(),
+	pipelinev1.PipelineRunReasonCompleted.String(),
+	pipelinev1.PipelineRunReasonFailed.String(),
+	pipelinev1.PipelineRunReasonCancelled.String(),
+	pipelinev1.PipelineRunReasonTimedOut.String(),
+}
+
+// recognizedTaskRunReasons holds the condition reasons a completed TaskRun can report
+var recognizedTaskRunReasons = []string{
+	pipelinev1.TaskRunReasonSuccessful.String(),
+	pipelinev1.TaskRunReasonFailed.String(),
+	pipelinev1.TaskRunReasonCancelled.String(),
+	pipelinev1.TaskRunReasonTimedOut.String(),
+	pipelinev1.TaskRunReasonFailureIgnored.String(),
 }
 
 // PrunerConfig used to hold the cluster-wide pruning config as well as namespace specific pruning config
@@ -105,6 +415,8 @@ type PrunerConfig struct {
 	SuccessfulHistoryLimit  *int32               `yaml:"successfulHistoryLimit,omitempty" json:"successfulHistoryLimit,omitempty"`
 	FailedHistoryLimit      *int32               `yaml:"failedHistoryLimit,omitempty" json:"failedHistoryLimit,omitempty"`
 	HistoryLimit            *int32               `yaml:"historyLimit,omitempty" json:"historyLimit,omitempty"`
+	// Keep is the deprecated alias of HistoryLimit, it applies only when HistoryLimit is not set
+	Keep *int32 `yaml:"keep,omitempty" json:"keep,omitempty"`
 }
 
 // prunerConfigStore defines the store structure to hold config from ConfigMap
@@ -112,6 +424,32 @@ type prunerConfigStore struct {
 	mutex           sync.RWMutex
 	globalConfig    GlobalConfig
 	namespaceConfig map[string]NamespaceSpec // namespace -> NamespaceSpec
+	// namespaceProfileSpecs holds the namespace configs referencing a profile as written, to resolve them again
+	// when the global config changes
+	namespaceProfileSpecs map[string]NamespaceSpec
+	// namespaceExcludePatterns holds the compiled namespaceExcludeRegexes of the global config
+	namespaceExcludePatterns []*regexp.Regexp
+	// retainDaysLocation holds the loaded retainDaysTimeZone of the global config
+	retainDaysLocation *time.Location
+	// acceptedConfig holds the root-level config last accepted by safe mode, the loaded configs are compared against it.
+	// It is nil while no config was accepted, neither by this process nor as recorded in the state ConfigMap
+	acceptedConfig *PrunerConfig
+	// acceptedData holds the global config last accepted by safe mode, as written in the global ConfigMap
+	acceptedData string
+	// pausedFields holds the fields whose pruning safe mode paused until the config is acknowledged
+	pausedFields []PrunerFieldType
+	// promotedConfig holds the global config last applied to every namespace, the other namespaces keep pruning with it
+	// while a pending config applies to the canary namespace only. It is nil while no config was promoted,
+	// neither by this process nor as recorded in the state ConfigMap
+	promotedConfig *GlobalConfig
+	// promotedData holds the global config last applied to every namespace, as written in the global ConfigMap
+	promotedData string
+	// canaryPending reports whether the loaded config applies to the canary namespace only, waiting to be promoted
+	canaryPending bool
+	// loadedConfig identifies the loaded global config, its content along with its acknowledgement and promotion
+	loadedConfig string
+	// configUpdateTime holds the time the loaded global config last changed, zero until a config is loaded
+	configUpdateTime time.Time
 }
 
 var (
@@ -123,57 +461,161 @@ var (
 )
 
 // loads config from configMap (global-config) should be called on startup and if there is a change detected on the ConfigMap
-func (ps *prunerConfigStore) LoadGlobalConfig(ctx context.Context, configMap *corev1.ConfigMap) error {
+func (ps *prunerConfigStore) LoadGlobalConfig(ctx context.Context, configMap *corev1.ConfigMap) (err error) {
 	logger := logging.FromContext(ctx)
 	ps.mutex.Lock()
 	defer ps.mutex.Unlock()
 
+	defer func() {
+		metrics.GetRecorder().RecordConfigLoad(ctx, metrics.ConfigTypeGlobal, "", err == nil)
+	}()
+
 	// Log the current state of globalConfig and namespacedConfig before updating
 	logger.Debugw("Loading global config", "oldGlobalConfig", ps.globalConfig)
 
 	globalConfig := &GlobalConfig{}
 	if configMap.Data != nil && configMap.Data[PrunerGlobalConfigKey] != "" {
-		err := yaml.Unmarshal([]byte(configMap.Data[PrunerGlobalConfigKey]), globalConfig)
+		var err error
+		globalConfig, err = parseGlobalConfig(configMap.Data[PrunerGlobalConfigKey])
 		if err != nil {
 			return err
 		}
 	}
 
+	aggregationPattern, err := globalConfig.metricsAggregationPattern()
+	if err != nil {
+		return err
+	}
+
+	excludePatterns, err := globalConfig.namespaceExcludePatterns()
+	if err != nil {
+		return err
+	}
+
+	retainDaysLocation, err := globalConfig.retainDaysLocation()
+	if err != nil {
+		return err
+	}
+
+	if fallbackTTL, previous := globalConfig.FallbackTTLSecondsAfterFinished, ps.globalConfig.FallbackTTLSecondsAfterFinished; fallbackTTL != nil &&
+		(previous == nil || *previous != *fallbackTTL) {
+		logger.Infow("Fallback TTL is active for the runs no TTL is configured for", "fallbackTTLSecondsAfterFinished", *fallbackTTL)
+	}
+
+	for _, warning := range ConfigMapWarnings(configMap) {
+		logger.Warnw("Global config loaded with a warning", "warning", warning)
+	}
+
+	ps.applySafeMode(ctx, configMap, globalConfig)
+	ps.applyCanary(ctx, configMap, globalConfig)
+
 	ps.globalConfig = *globalConfig
+	ps.namespaceExcludePatterns = excludePatterns
+	ps.retainDaysLocation = retainDaysLocation
 
 	if ps.globalConfig.Namespaces == nil {
 		ps.globalConfig.Namespaces = map[string]NamespaceSpec{}
 	}
 
+	// the namespace configs inherit the profiles as defined now
+	for namespace, nsSpec := range ps.namespaceProfileSpecs {
+		resolved, err := resolveProfile(nsSpec, ps.globalConfig.Profiles)
+		if err != nil {
+			logger.Warnw("Namespace config applies without its profile", "namespace", namespace, "error", err)
+		}
+		ps.namespaceConfig[namespace] = expandAnnotationValues(resolved)
+	}
+
+	metrics.SetNamespaceAggregation(aggregationPattern)
+
+	// reloading an unchanged config, e.g. on a periodic cycle, keeps the time of the last change
+	loadedConfig := strings.Join([]string{configMap.Data[PrunerGlobalConfigKey],
+		configMap.Annotations[AnnotationAcknowledgeConfig], configMap.Annotations[AnnotationPromoteConfig]}, "\x00")
+	if ps.configUpdateTime.IsZero() || loadedConfig != ps.loadedConfig {
+		ps.loadedConfig = loadedConfig
+		ps.configUpdateTime = time.Now()
+	}
+
 	// Log the updated state of globalConfig and namespacedConfig after the update
 	logger.Debugw("Updated global config", "newGlobalConfig", ps.globalConfig)
 
+	if isConfigLoggingEnabled(ctx) {
+		// the config holds no secrets, it is logged as parsed
+		resolved, err := json.MarshalIndent(ps.globalConfig, "", "  ")
+		if err != nil {
+			logger.Warnw("Failed to format the loaded global config", "error", err)
+		} else {
+			logger.Infof("Loaded global config:\n%s", resolved)
+		}
+	}
+
 	return nil
 }
 
+// configLoggingKey is used as the key for enabling the logging of the loaded config in the context
+type configLoggingKey struct{}
+
+// WithConfigLogging enables logging the fully parsed global config, including the namespace overrides
+// and the selectors, at info level every time it is loaded
+func WithConfigLogging(ctx context.Context) context.Context {
+	return context.WithValue(ctx, configLoggingKey{}, true)
+}
+
+// isConfigLoggingEnabled reports whether the loaded global config is logged
+func isConfigLoggingEnabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(configLoggingKey{}).(bool)
+	return enabled
+}
+
 // LoadNamespaceConfig loads config from namespace-level ConfigMap
-func (ps *prunerConfigStore) LoadNamespaceConfig(ctx context.Context, namespace string, configMap *corev1.ConfigMap) error {
+func (ps *prunerConfigStore) LoadNamespaceConfig(ctx context.Context, namespace string, configMap *corev1.ConfigMap) (err error) {
 	logger := logging.FromContext(ctx)
 	ps.mutex.Lock()
 	defer ps.mutex.Unlock()
 
+	// only the canonical ConfigMap holds the config of a namespace
+	if configMap.Name != PrunerNamespaceConfigMapName {
+		logger.Warnw("Skipping ConfigMap which is not named "+PrunerNamespaceConfigMapName, "namespace", namespace, "name", configMap.Name)
+		return nil
+	}
+
+	defer func() {
+		metrics.GetRecorder().RecordConfigLoad(ctx, metrics.ConfigTypeNamespace, namespace, err == nil)
+	}()
+
 	// Log the current state before updating
 	logger.Debugw("Loading namespace config", "namespace", namespace, "oldConfig", ps.namespaceConfig[namespace])
 
 	namespaceSpec := NamespaceSpec{}
 	if configMap.Data != nil && configMap.Data[PrunerNamespaceConfigKey] != "" {
-		err := yaml.Unmarshal([]byte(configMap.Data[PrunerNamespaceConfigKey]), &namespaceSpec)
+		parsed, err := parseNamespaceSpec(configMap.Data[PrunerNamespaceConfigKey])
 		if err != nil {
 			return err
 		}
+		namespaceSpec = *parsed
+	}
+
+	for _, warning := range ConfigMapWarnings(configMap) {
+		logger.Warnw("Namespace config loaded with a warning", "namespace", namespace, "warning", warning)
+	}
+
+	// A namespace config referencing a missing profile applies on its own, until the profile is defined
+	resolved, resolveErr := resolveProfile(namespaceSpec, ps.globalConfig.Profiles)
+	if namespaceSpec.ProfileRef != "" {
+		if ps.namespaceProfileSpecs == nil {
+			ps.namespaceProfileSpecs = make(map[string]NamespaceSpec)
+		}
+		ps.namespaceProfileSpecs[namespace] = namespaceSpec
+	} else {
+		delete(ps.namespaceProfileSpecs, namespace)
 	}
 
-	ps.namespaceConfig[namespace] = namespaceSpec
+	ps.namespaceConfig[namespace] = expandAnnotationValues(resolved)
 
 	// Log the updated state after the update
 	logger.Debugw("Updated namespace config", "namespace", namespace, "newConfig", ps.namespaceConfig[namespace])
 
-	return nil
+	return resolveErr
 }
 
 // DeleteNamespaceConfig removes namespace-level config from the store
@@ -184,6 +626,7 @@ func (ps *prunerConfigStore) DeleteNamespaceConfig(ctx context.Context, namespac
 
 	logger.Debugw("Deleting namespace config", "namespace", namespace)
 	delete(ps.namespaceConfig, namespace)
+	delete(ps.namespaceProfileSpecs, namespace)
 }
 
 // loads config from configMap (global-config) should be called on startup and if there is a change detected on the ConfigMap
@@ -245,10 +688,10 @@ func getFromPrunerConfigResourceLevelwithSelector(namespacesSpec map[string]Name
 		// Name was specified but no match found - continue to selector matching
 	}
 
-	// If name-based matching didn't succeed, proceed with selector matching
+	// If name-based matching didn't succeed, proceed with selector matching, the highest priority match wins
 	if len(selector.MatchAnnotations) > 0 || len(selector.MatchLabels) > 0 {
 
-		for _, resourceSpec := range resourceSpecs {
+		for _, resourceSpec := range byPriority(resourceSpecs) {
 			// Check if the resourceSpec matches the provided selector by annotations AND labels
 			for _, selectorSpec := range resourceSpec.Selector {
 				// Both annotations and labels must match when both are specified (AND logic)
@@ -336,38 +779,9 @@ func getMatchingSelectorFromConfig(namespacesSpec map[string]NamespaceSpec, name
 		return nil
 	}
 
-	for _, resourceSpec := range resourceSpecs {
+	for _, resourceSpec := range byPriority(resourceSpecs) {
 		for _, selectorSpec := range resourceSpec.Selector {
-			annotationsMatch := true
-			labelsMatch := true
-
-			if len(selectorSpec.MatchAnnotations) > 0 {
-				if len(selector.MatchAnnotations) == 0 {
-					annotationsMatch = false
-				} else {
-					for key, value := range selectorSpec.MatchAnnotations {
-						if resourceAnnotationValue, exists := selector.MatchAnnotations[key]; !exists || resourceAnnotationValue != value {
-							annotationsMatch = false
-							break
-						}
-					}
-				}
-			}
-
-			if len(selectorSpec.MatchLabels) > 0 {
-				if len(selector.MatchLabels) == 0 {
-					labelsMatch = false
-				} else {
-					for key, value := range selectorSpec.MatchLabels {
-						if resourceLabelValue, exists := selector.MatchLabels[key]; !exists || resourceLabelValue != value {
-							labelsMatch = false
-							break
-						}
-					}
-				}
-			}
-
-			if annotationsMatch && labelsMatch {
+			if selectorSpec.Matches(selector) {
 				return &selectorSpec
 			}
 		}
@@ -376,6 +790,22 @@ func getMatchingSelectorFromConfig(namespacesSpec map[string]NamespaceSpec, name
 	return nil
 }
 
+// Matches reports whether a resource, given by its labels and annotations, matches the ConfigMap's selector.
+// When both matchLabels AND matchAnnotations are specified, BOTH must match (AND logic)
+func (s SelectorSpec) Matches(resource SelectorSpec) bool {
+	for key, value := range s.MatchAnnotations {
+		if resourceAnnotationValue, exists := resource.MatchAnnotations[key]; !exists || resourceAnnotationValue != value {
+			return false
+		}
+	}
+	for key, value := range s.MatchLabels {
+		if resourceLabelValue, exists := resource.MatchLabels[key]; !exists || resourceLabelValue != value {
+			return false
+		}
+	}
+	return true
+}
+
 // getResourceFieldData retrieves configuration field values based on enforcedConfigLevel
 // Design principle: Selector support ONLY for namespace-level ConfigMaps, NOT global ConfigMaps
 //
@@ -559,7 +989,7 @@ func getResourceFieldData(globalSpec GlobalConfig, namespaceConfigMap map[string
 func (ps *prunerConfigStore) GetEnforcedConfigLevelFromNamespaceSpec(namespacesSpec map[string]NamespaceSpec, namespace, name string, selector SelectorSpec, resourceType PrunerResourceType) *EnforcedConfigLevel {
 	var enforcedConfigLevel *EnforcedConfigLevel
 
-	namespaceSpec, found := ps.globalConfig.Namespaces[namespace]
+	namespaceSpec, found := namespacesSpec[namespace]
 	if !found {
 		return nil
 	}
@@ -586,8 +1016,8 @@ func (ps *prunerConfigStore) GetEnforcedConfigLevelFromNamespaceSpec(namespacesS
 			}
 		}
 	} else if len(selector.MatchAnnotations) > 0 || len(selector.MatchLabels) > 0 {
-		// Search by selectors
-		for _, resourceSpec := range resourceSpecs {
+		// Search by selectors, the highest priority match wins
+		for _, resourceSpec := range byPriority(resourceSpecs) {
 			for _, selectorSpec := range resourceSpec.Selector {
 				annotationsMatch := true
 				labelsMatch := true
@@ -642,15 +1072,16 @@ func (ps *prunerConfigStore) GetEnforcedConfigLevelFromNamespaceSpec(namespacesS
 
 func (ps *prunerConfigStore) getEnforcedConfigLevel(namespace, name string, selector SelectorSpec, resourceType PrunerResourceType) EnforcedConfigLevel {
 	var enforcedConfigLevel *EnforcedConfigLevel
+	globalConfig := ps.globalConfigFor(namespace)
 
 	// get it from global spec (order: resource level, namespace root level)
-	enforcedConfigLevel = ps.GetEnforcedConfigLevelFromNamespaceSpec(ps.globalConfig.Namespaces, namespace, name, selector, resourceType)
+	enforcedConfigLevel = ps.GetEnforcedConfigLevelFromNamespaceSpec(globalConfig.Namespaces, namespace, name, selector, resourceType)
 	if enforcedConfigLevel != nil {
 		return *enforcedConfigLevel
 	}
 
 	// get it from global spec, root level
-	enforcedConfigLevel = ps.globalConfig.EnforcedConfigLevel
+	enforcedConfigLevel = globalConfig.EnforcedConfigLevel
 	if enforcedConfigLevel != nil {
 		return *enforcedConfigLevel
 	}
@@ -671,177 +1102,767 @@ func (ps *prunerConfigStore) GetPipelineTTLSecondsAfterFinished(namespace, name
 	ps.mutex.Lock()
 	defer ps.mutex.Unlock()
 	enforcedConfigLevel := ps.GetPipelineEnforcedConfigLevel(namespace, name, selector)
-	return getResourceFieldData(ps.globalConfig, ps.namespaceConfig, namespace, name, selector, PrunerResourceTypePipelineRun, PrunerFieldTypeTTLSecondsAfterFinished, enforcedConfigLevel)
+	return getResourceFieldData(ps.globalConfigFor(namespace), ps.namespaceConfig, namespace, name, selector, PrunerResourceTypePipelineRun, PrunerFieldTypeTTLSecondsAfterFinished, enforcedConfigLevel)
 }
 
 func (ps *prunerConfigStore) GetPipelineSuccessHistoryLimitCount(namespace, name string, selector SelectorSpec) (*int32, string) {
 	ps.mutex.Lock()
 	defer ps.mutex.Unlock()
 	enforcedConfigLevel := ps.GetPipelineEnforcedConfigLevel(namespace, name, selector)
-	return getResourceFieldData(ps.globalConfig, ps.namespaceConfig, namespace, name, selector, PrunerResourceTypePipelineRun, PrunerFieldTypeSuccessfulHistoryLimit, enforcedConfigLevel)
+	return getResourceFieldData(ps.globalConfigFor(namespace), ps.namespaceConfig, namespace, name, selector, PrunerResourceTypePipelineRun, PrunerFieldTypeSuccessfulHistoryLimit, enforcedConfigLevel)
 }
 
 func (ps *prunerConfigStore) GetPipelineFailedHistoryLimitCount(namespace, name string, selector SelectorSpec) (*int32, string) {
 	ps.mutex.Lock()
 	defer ps.mutex.Unlock()
 	enforcedConfigLevel := ps.GetPipelineEnforcedConfigLevel(namespace, name, selector)
-	return getResourceFieldData(ps.globalConfig, ps.namespaceConfig, namespace, name, selector, PrunerResourceTypePipelineRun, PrunerFieldTypeFailedHistoryLimit, enforcedConfigLevel)
+	return getResourceFieldData(ps.globalConfigFor(namespace), ps.namespaceConfig, namespace, name, selector, PrunerResourceTypePipelineRun, PrunerFieldTypeFailedHistoryLimit, enforcedConfigLevel)
 }
 
 func (ps *prunerConfigStore) GetTaskTTLSecondsAfterFinished(namespace, name string, selector SelectorSpec) (*int32, string) {
 	ps.mutex.Lock()
 	defer ps.mutex.Unlock()
 	enforcedConfigLevel := ps.GetTaskEnforcedConfigLevel(namespace, name, selector)
-	return getResourceFieldData(ps.globalConfig, ps.namespaceConfig, namespace, name, selector, PrunerResourceTypeTaskRun, PrunerFieldTypeTTLSecondsAfterFinished, enforcedConfigLevel)
+	return getResourceFieldData(ps.globalConfigFor(namespace), ps.namespaceConfig, namespace, name, selector, PrunerResourceTypeTaskRun, PrunerFieldTypeTTLSecondsAfterFinished, enforcedConfigLevel)
 }
 
 func (ps *prunerConfigStore) GetTaskSuccessHistoryLimitCount(namespace, name string, selector SelectorSpec) (*int32, string) {
 	ps.mutex.Lock()
 	defer ps.mutex.Unlock()
 	enforcedConfigLevel := ps.GetTaskEnforcedConfigLevel(namespace, name, selector)
-	return getResourceFieldData(ps.globalConfig, ps.namespaceConfig, namespace, name, selector, PrunerResourceTypeTaskRun, PrunerFieldTypeSuccessfulHistoryLimit, enforcedConfigLevel)
+	return getResourceFieldData(ps.globalConfigFor(namespace), ps.namespaceConfig, namespace, name, selector, PrunerResourceTypeTaskRun, PrunerFieldTypeSuccessfulHistoryLimit, enforcedConfigLevel)
 }
 
 func (ps *prunerConfigStore) GetTaskFailedHistoryLimitCount(namespace, name string, selector SelectorSpec) (*int32, string) {
 	ps.mutex.Lock()
 	defer ps.mutex.Unlock()
 	enforcedConfigLevel := ps.GetTaskEnforcedConfigLevel(namespace, name, selector)
-	return getResourceFieldData(ps.globalConfig, ps.namespaceConfig, namespace, name, selector, PrunerResourceTypeTaskRun, PrunerFieldTypeFailedHistoryLimit, enforcedConfigLevel)
+	return getResourceFieldData(ps.globalConfigFor(namespace), ps.namespaceConfig, namespace, name, selector, PrunerResourceTypeTaskRun, PrunerFieldTypeFailedHistoryLimit, enforcedConfigLevel)
 }
 
-// GetPipelineMatchingSelector returns the ConfigMap's selector that matches a PipelineRun.
-func (ps *prunerConfigStore) GetPipelineMatchingSelector(namespace, name string, selector SelectorSpec) *SelectorSpec {
+// GetSuccessfulReasons returns the condition reasons treated as successful for the runs of the given kind
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetSuccessfulReasons(namespace, kind string) []string {
 	ps.mutex.RLock()
 	defer ps.mutex.RUnlock()
-	return getMatchingSelectorFromConfig(ps.namespaceConfig, namespace, name, selector, PrunerResourceTypePipelineRun)
+	globalConfig := ps.globalConfigFor(namespace)
+	if globalConfig.SuccessfulReasons == nil {
+		return nil
+	}
+	switch kind {
+	case KindPipelineRun:
+		return globalConfig.SuccessfulReasons.PipelineRuns
+	case KindTaskRun:
+		return globalConfig.SuccessfulReasons.TaskRuns
+	}
+	return nil
 }
 
-// GetTaskMatchingSelector returns the ConfigMap's selector that matches a TaskRun.
-func (ps *prunerConfigStore) GetTaskMatchingSelector(namespace, name string, selector SelectorSpec) *SelectorSpec {
+// GetNamespaceObjectBudget returns the cap of run objects held by a namespace
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetNamespaceObjectBudget(namespace string) *int32 {
 	ps.mutex.RLock()
 	defer ps.mutex.RUnlock()
-	return getMatchingSelectorFromConfig(ps.namespaceConfig, namespace, name, selector, PrunerResourceTypeTaskRun)
+	return ps.globalConfigFor(namespace).NamespaceObjectBudget
 }
 
-// ValidateGlobalConfig validates a GlobalConfig struct directly without ConfigMap conversion
-// This is a convenience function for validating global config and all nested namespace configs
-// without the overhead of serialization/deserialization through ConfigMaps.
-//
-// Use this function when you have a GlobalConfig struct and want to validate it directly,
-// for example when validating configuration from operator CRDs or other non-ConfigMap sources.
-//
-// For ConfigMap-based validation, use ValidateConfigMap or ValidateConfigMapWithGlobal instead.
-func ValidateGlobalConfig(globalConfig *GlobalConfig) error {
-	if globalConfig == nil {
-		return nil
-	}
+// GetMaxCompletedRunsPerNamespace returns the cap of completed runs kept in a namespace
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetMaxCompletedRunsPerNamespace(namespace string) *int32 {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).MaxCompletedRunsPerNamespace
+}
 
-	// Validate root-level global config
-	if err := validatePrunerConfig(&globalConfig.PrunerConfig, "global-config", nil); err != nil {
-		return err
+// GetDeletionMode returns how the resources selected for pruning are removed
+// returns DeletionModeDelete, if not configured in the global config
+func (ps *prunerConfigStore) GetDeletionMode(namespace string) DeletionMode {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	globalConfig := ps.globalConfigFor(namespace)
+	if globalConfig.DeletionMode == nil {
+		return DeletionModeDelete
 	}
+	return *globalConfig.DeletionMode
+}
 
-	// Validate nested namespace configs
-	// These are validated against the global limits
-	for ns, nsSpec := range globalConfig.Namespaces {
-		path := fmt.Sprintf("global-config.namespaces.%s", ns)
-		if err := validatePrunerConfig(&nsSpec.PrunerConfig, path, &globalConfig.PrunerConfig); err != nil {
-			return err
-		}
+// GetExcludePrunableFromHistory returns whether the resources marked prunable are excluded from the history limit count
+func (ps *prunerConfigStore) GetExcludePrunableFromHistory(namespace string) bool {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).ExcludePrunableFromHistory
+}
 
-		// CRITICAL: Validate that global ConfigMap namespace sections do NOT contain selectors
-		// Selectors are ONLY supported in namespace-level ConfigMaps (tekton-pruner-namespace-spec)
-		for i, pr := range nsSpec.PipelineRuns {
-			if len(pr.Selector) > 0 {
-				return fmt.Errorf("%s.pipelineRuns[%d]: selectors are NOT supported in global ConfigMap. Use namespace-level ConfigMap (tekton-pruner-namespace-spec) instead", path, i)
-			}
-		}
-		for i, tr := range nsSpec.TaskRuns {
-			if len(tr.Selector) > 0 {
-				return fmt.Errorf("%s.taskRuns[%d]: selectors are NOT supported in global ConfigMap. Use namespace-level ConfigMap (tekton-pruner-namespace-spec) instead", path, i)
-			}
+// IsAnnotationAllowed checks whether the pruner may add or remove the given annotation key
+// returns true, if no annotationAllowlist is configured in the global config
+func (ps *prunerConfigStore) IsAnnotationAllowed(key string) bool {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	if len(ps.globalConfig.AnnotationAllowlist) == 0 {
+		return true
+	}
+	for _, entry := range ps.globalConfig.AnnotationAllowlist {
+		if key == entry || (strings.HasSuffix(entry, "/") && strings.HasPrefix(key, entry)) {
+			return true
 		}
 	}
+	return false
+}
 
-	return nil
+// GetProcessedAnnotationKey returns the annotation key which marks a resource as processed by the history limiter
+// returns AnnotationHistoryLimitCheckProcessed, if not configured in the global config
+func (ps *prunerConfigStore) GetProcessedAnnotationKey() string {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	if ps.globalConfig.ProcessedAnnotationKey == "" {
+		return AnnotationHistoryLimitCheckProcessed
+	}
+	return ps.globalConfig.ProcessedAnnotationKey
 }
 
-func ValidateConfigMap(cm *corev1.ConfigMap) error {
-	return ValidateConfigMapWithGlobal(cm, nil)
+// GetPruneLargeStatusBytes returns the status size in bytes beyond which completed runs are pruned
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetPruneLargeStatusBytes(namespace string) *int64 {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).PruneLargeStatusBytes
 }
 
-// ValidateConfigMapWithGlobal validates a ConfigMap with optional global config for limit enforcement
-// If globalConfigMap is provided and cm is a namespace-level config, it validates that namespace
-// limits do not exceed global limits
-func ValidateConfigMapWithGlobal(cm *corev1.ConfigMap, globalConfigMap *corev1.ConfigMap) error {
-	if cm.Data == nil {
-		return nil
+// GetHistoryLimitBytes returns the cumulative size in bytes of the runs kept by a history limit
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetHistoryLimitBytes(namespace string) *int64 {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).HistoryLimitBytes
+}
+
+// GetEphemeralNamespacePolicy returns the policy of the ephemeral namespaces
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetEphemeralNamespacePolicy(namespace string) *EphemeralNamespacePolicy {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).EphemeralNamespacePolicy
+}
+
+// GetMaxConcurrentDeletions returns the maximum number of concurrent Delete calls of garbage collection
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetMaxConcurrentDeletions() *int32 {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfig.MaxConcurrentDeletions
+}
+
+// GetAutoWorkerCount returns whether the garbage collection worker pool is sized after the number of namespaces,
+// and the maximum number of workers it can be sized to, DefaultMaxWorkerCount if not configured in the global config
+func (ps *prunerConfigStore) GetAutoWorkerCount() (bool, int) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	if ps.globalConfig.MaxWorkerCount == nil {
+		return ps.globalConfig.AutoWorkerCount, DefaultMaxWorkerCount
 	}
+	return ps.globalConfig.AutoWorkerCount, int(*ps.globalConfig.MaxWorkerCount)
+}
 
-	// Parse global config if validating a global ConfigMap
-	var globalLimits *PrunerConfig
-	if cm.Data[PrunerGlobalConfigKey] != "" {
-		globalConfig := &GlobalConfig{}
-		if err := yaml.Unmarshal([]byte(cm.Data[PrunerGlobalConfigKey]), globalConfig); err != nil {
-			return fmt.Errorf("failed to parse global-config: %w", err)
-		}
-		if err := validatePrunerConfig(&globalConfig.PrunerConfig, "global-config", nil); err != nil {
-			return err
-		}
-		// Validate nested namespace configs within global config
-		// These are validated against the global limits
-		for ns, nsSpec := range globalConfig.Namespaces {
-			if err := validatePrunerConfig(&nsSpec.PrunerConfig, "global-config.namespaces."+ns, &globalConfig.PrunerConfig); err != nil {
-				return err
-			}
+// GetConfigUpdateTime returns the time the loaded global config last changed, the runs processed by the history limiter
+// before it are evaluated again. Reloading an unchanged config leaves it as is
+func (ps *prunerConfigStore) GetConfigUpdateTime() time.Time {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.configUpdateTime
+}
 
-			// CRITICAL: Validate that global ConfigMap namespace sections do NOT contain selectors
-			// Selectors are ONLY supported in namespace-level ConfigMaps (tekton-pruner-namespace-spec)
-			for i, pr := range nsSpec.PipelineRuns {
-				if len(pr.Selector) > 0 {
-					return fmt.Errorf("global-config.namespaces.%s.pipelineRuns[%d]: selectors are NOT supported in global ConfigMap. Use namespace-level ConfigMap (tekton-pruner-namespace-spec) instead", ns, i)
-				}
-			}
-			for i, tr := range nsSpec.TaskRuns {
-				if len(tr.Selector) > 0 {
-					return fmt.Errorf("global-config.namespaces.%s.taskRuns[%d]: selectors are NOT supported in global ConfigMap. Use namespace-level ConfigMap (tekton-pruner-namespace-spec) instead", ns, i)
-				}
-			}
-		}
-		return nil
+// GetGCInterval returns the interval of the periodic garbage collection
+// returns 0, if not configured in the global config
+func (ps *prunerConfigStore) GetGCInterval() time.Duration {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	if ps.globalConfig.GCIntervalSeconds == nil {
+		return 0
 	}
+	return time.Duration(*ps.globalConfig.GCIntervalSeconds) * time.Second
+}
 
-	// Parse and validate namespace config against global limits
-	if cm.Data[PrunerNamespaceConfigKey] != "" {
-		namespaceConfig := &NamespaceSpec{}
-		if err := yaml.Unmarshal([]byte(cm.Data[PrunerNamespaceConfigKey]), namespaceConfig); err != nil {
-			return fmt.Errorf("failed to parse ns-config: %w", err)
-		}
+// GetInterNamespaceDelay returns the delay each garbage collection worker waits after collecting a namespace
+// returns 0, if not configured in the global config
+func (ps *prunerConfigStore) GetInterNamespaceDelay() time.Duration {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	if ps.globalConfig.InterNamespaceDelayMillis == nil {
+		return 0
+	}
+	return time.Duration(*ps.globalConfig.InterNamespaceDelayMillis) * time.Millisecond
+}
 
-		// Extract global limits if global config is provided
-		if globalConfigMap != nil && globalConfigMap.Data != nil && globalConfigMap.Data[PrunerGlobalConfigKey] != "" {
-			globalConfig := &GlobalConfig{}
-			if err := yaml.Unmarshal([]byte(globalConfigMap.Data[PrunerGlobalConfigKey]), globalConfig); err != nil {
-				// If we can't parse global config, just do basic validation
-				return validatePrunerConfig(&namespaceConfig.PrunerConfig, "ns-config", nil)
-			}
-			globalLimits = &globalConfig.PrunerConfig
-		}
+// GetListRetryAttempts returns the number of retries of a List call throttled by the API server
+// returns DefaultListRetryAttempts, if not configured in the global config
+func (ps *prunerConfigStore) GetListRetryAttempts() int {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	if ps.globalConfig.ListRetryAttempts == nil {
+		return DefaultListRetryAttempts
+	}
+	return int(*ps.globalConfig.ListRetryAttempts)
+}
 
-		// Validate namespace config, enforcing global limits if available
-		if err := validatePrunerConfig(&namespaceConfig.PrunerConfig, "ns-config", globalLimits); err != nil {
-			return err
-		}
+// GetListRetryBackoff returns the delay before the first retry of a List call throttled by the API server
+// returns DefaultListRetryBackoffMilliseconds, if not configured in the global config
+func (ps *prunerConfigStore) GetListRetryBackoff() time.Duration {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	if ps.globalConfig.ListRetryBackoffMilliseconds == nil {
+		return DefaultListRetryBackoffMilliseconds * time.Millisecond
+	}
+	return time.Duration(*ps.globalConfig.ListRetryBackoffMilliseconds) * time.Millisecond
+}
 
-		// Validate selector-based limits (sum of selectors must not exceed namespace/global limits)
-		// Extract namespace name from ConfigMap metadata
-		namespace := cm.Namespace
-		var globalNamespaceSpec *NamespaceSpec
-		if globalConfigMap != nil && globalConfigMap.Data != nil && globalConfigMap.Data[PrunerGlobalConfigKey] != "" {
-			globalConfig := &GlobalConfig{}
-			if err := yaml.Unmarshal([]byte(globalConfigMap.Data[PrunerGlobalConfigKey]), globalConfig); err == nil {
-				if nsSpec, exists := globalConfig.Namespaces[namespace]; exists {
-					globalNamespaceSpec = &nsSpec
+// GetAnnotationPatchRetryAttempts returns the number of retries of a failed patch removing the processed annotation of a run
+// returns DefaultAnnotationPatchRetryAttempts, if not configured in the global config
+func (ps *prunerConfigStore) GetAnnotationPatchRetryAttempts() int {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	if ps.globalConfig.AnnotationPatchRetryAttempts == nil {
+		return DefaultAnnotationPatchRetryAttempts
+	}
+	return int(*ps.globalConfig.AnnotationPatchRetryAttempts)
+}
+
+// GetAPICallTimeout returns the timeout of each API call made to prune a run
+// returns DefaultAPICallTimeoutSeconds, if not configured in the global config
+func (ps *prunerConfigStore) GetAPICallTimeout() time.Duration {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	if ps.globalConfig.APICallTimeoutSeconds == nil {
+		return DefaultAPICallTimeoutSeconds * time.Second
+	}
+	return time.Duration(*ps.globalConfig.APICallTimeoutSeconds) * time.Second
+}
+
+// GetQuotaPressure returns how the history limits of the namespaces under quota pressure are tightened
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetQuotaPressure() *QuotaPressureSpec {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfig.QuotaPressure
+}
+
+// IsNamespaceExcluded checks whether a namespace matches one of the namespaceExcludeRegexes of the global config
+func (ps *prunerConfigStore) IsNamespaceExcluded(namespace string) bool {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	for _, pattern := range ps.namespaceExcludePatterns {
+		if pattern.MatchString(namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFreezeUntil returns the time until which pruning is frozen, and whether it is still frozen at the given time
+// returns false, if not configured in the global config or already passed
+func (ps *prunerConfigStore) GetFreezeUntil(now time.Time) (time.Time, bool) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	if ps.globalConfig.FreezeUntil == "" {
+		return time.Time{}, false
+	}
+	until, err := time.Parse(time.RFC3339, ps.globalConfig.FreezeUntil)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return until, now.Before(until)
+}
+
+// GetNamespacePriority returns the namespaces garbage collection processes first, in this order
+func (ps *prunerConfigStore) GetNamespacePriority() []string {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return slices.Clone(ps.globalConfig.NamespacePriority)
+}
+
+// GetTTLNoResultsSeconds returns the TTL of the completed runs which emitted no results
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetTTLNoResultsSeconds(namespace string) *int32 {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).TTLNoResultsSeconds
+}
+
+// GetFallbackTTLSecondsAfterFinished returns the TTL of the runs no TTL is configured for
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetFallbackTTLSecondsAfterFinished(namespace string) *int32 {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).FallbackTTLSecondsAfterFinished
+}
+
+// GetRetainDays returns for how many calendar days after the day they finished the runs are kept
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetRetainDays(namespace string) *int32 {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).RetainDays
+}
+
+// GetRetainDaysLocation returns the time zone the calendar days of retainDays are counted in
+// returns UTC, if not configured in the global config
+func (ps *prunerConfigStore) GetRetainDaysLocation(namespace string) *time.Location {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	globalConfig := ps.globalConfigFor(namespace)
+	if globalConfig.RetainDaysTimeZone != ps.globalConfig.RetainDaysTimeZone {
+		// the time zone of the config last promoted was validated when it was loaded
+		if location, err := globalConfig.retainDaysLocation(); err == nil {
+			return location
+		}
+	}
+	if ps.retainDaysLocation == nil {
+		return time.UTC
+	}
+	return ps.retainDaysLocation
+}
+
+// GetHistoryLimitEvictionPriority returns which runs are pruned first over a shared history limit
+// returns HistoryLimitEvictionOldest, if not configured in the global config
+func (ps *prunerConfigStore) GetHistoryLimitEvictionPriority(namespace string) HistoryLimitEvictionPriority {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	globalConfig := ps.globalConfigFor(namespace)
+	if globalConfig.HistoryLimitEvictionPriority == nil {
+		return HistoryLimitEvictionOldest
+	}
+	return *globalConfig.HistoryLimitEvictionPriority
+}
+
+// GetCancelledCountsAs returns the history limit the cancelled runs count toward
+// returns CancelledCountsAsFailed, if not configured in the global config
+func (ps *prunerConfigStore) GetCancelledCountsAs(namespace string) CancelledCountsAs {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	globalConfig := ps.globalConfigFor(namespace)
+	if globalConfig.CancelledCountsAs == nil {
+		return CancelledCountsAsFailed
+	}
+	return *globalConfig.CancelledCountsAs
+}
+
+// GetTTLHistoryPolicy returns how the TTL and the history limits interact
+// returns TTLHistoryPolicyIndependent, if not configured in the global config
+func (ps *prunerConfigStore) GetTTLHistoryPolicy(namespace string) TTLHistoryPolicy {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	globalConfig := ps.globalConfigFor(namespace)
+	if globalConfig.TTLHistoryPolicy == nil {
+		return TTLHistoryPolicyIndependent
+	}
+	return *globalConfig.TTLHistoryPolicy
+}
+
+// GetMinSuccessfulToKeep returns the number of successful runs of each Pipeline and Task kept across all the namespaces
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetMinSuccessfulToKeep(namespace string) *int32 {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).MinSuccessfulToKeep
+}
+
+// GetTTLAnchorAnnotation returns the annotation holding the time the TTL of a resource is counted from
+// returns an empty string, if not configured in the global config
+func (ps *prunerConfigStore) GetTTLAnchorAnnotation(namespace string) string {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).TTLAnchorAnnotation
+}
+
+// GetTTLFrom returns the time the TTL of a resource is counted from
+// returns TTLFromCompletion, if not configured in the global config
+func (ps *prunerConfigStore) GetTTLFrom(namespace string) TTLFrom {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	globalConfig := ps.globalConfigFor(namespace)
+	if globalConfig.TTLFrom == nil {
+		return TTLFromCompletion
+	}
+	return *globalConfig.TTLFrom
+}
+
+// GetAbandonedAfterSeconds returns after how many seconds since its start a run which is not completed can be removed
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetAbandonedAfterSeconds(namespace string) *int32 {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).AbandonedAfterSeconds
+}
+
+// GetAbsoluteMaxAgeSeconds returns the age beyond which any completed run is pruned
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetAbsoluteMaxAgeSeconds(namespace string) *int32 {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).AbsoluteMaxAgeSeconds
+}
+
+// GetPruneStuckAfterSeconds returns after how many seconds since its start a run which is not completed is pruned as stuck
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetPruneStuckAfterSeconds(namespace string) *int32 {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).PruneStuckAfterSeconds
+}
+
+// GetDeleteLeftoverPods returns whether the leftover pods of the deleted runs are deleted too
+func (ps *prunerConfigStore) GetDeleteLeftoverPods(namespace string) bool {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).DeleteLeftoverPods
+}
+
+// GetAggressivePruneTerminatingNamespaces returns whether all the completed runs of the namespaces being deleted are pruned
+func (ps *prunerConfigStore) GetAggressivePruneTerminatingNamespaces(namespace string) bool {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).AggressivePruneTerminatingNamespaces
+}
+
+// GetTTLRequeueCeilingSeconds returns how far out, in seconds, a run with an unexpired TTL is scheduled to be reconciled again
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetTTLRequeueCeilingSeconds() *int32 {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfig.TTLRequeueCeilingSeconds
+}
+
+// GetHistoryDeletionBatchSize returns the maximum number of runs pruned at once by the history limiter
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetHistoryDeletionBatchSize() *int32 {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfig.HistoryDeletionBatchSize
+}
+
+// GetProtectionRules returns the rules protecting runs referenced by other resources from being pruned
+func (ps *prunerConfigStore) GetProtectionRules(namespace string) []ProtectionRule {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).ProtectIfReferencedBy
+}
+
+// GetNeverPruneNames returns the names of the Pipelines or Tasks, depending on the given kind, whose runs are never pruned
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetNeverPruneNames(namespace, kind string) []string {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	globalConfig := ps.globalConfigFor(namespace)
+	if globalConfig.NeverPrune == nil {
+		return nil
+	}
+	switch kind {
+	case KindPipelineRun:
+		return globalConfig.NeverPrune.PipelineRuns
+	case KindTaskRun:
+		return globalConfig.NeverPrune.TaskRuns
+	}
+	return nil
+}
+
+// GetSkipRunsWithFinalizers returns the finalizers whose runs are not pruned
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetSkipRunsWithFinalizers(namespace string) []string {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfigFor(namespace).SkipRunsWithFinalizers
+}
+
+// GetTaskRunParentKinds returns the owner kinds which make a TaskRun part of its owner, PipelineRun first
+func (ps *prunerConfigStore) GetTaskRunParentKinds() []string {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	kinds := []string{KindPipelineRun}
+	for _, kind := range ps.globalConfig.TaskRunParentKinds {
+		if !slices.Contains(kinds, kind) {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+// GetStrictNamespaceListing returns whether listing the runs across namespaces fails when any namespace cannot be listed
+func (ps *prunerConfigStore) GetStrictNamespaceListing() bool {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.globalConfig.StrictNamespaceListing
+}
+
+// GetLabelKeys returns the prioritized label keys used to group runs of the given kind
+// returns nil, if not configured in the global config
+func (ps *prunerConfigStore) GetLabelKeys(kind string) []string {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	switch kind {
+	case KindPipelineRun:
+		return ps.globalConfig.PipelineRunLabelKeys
+	case KindTaskRun:
+		return ps.globalConfig.TaskRunLabelKeys
+	}
+	return nil
+}
+
+// GetDefaultLabelKey returns the label key holding the name runs of the given kind are configured by,
+// the first of the configured label keys, or the Tekton default if none is configured
+func (ps *prunerConfigStore) GetDefaultLabelKey(kind string) string {
+	if labelKeys := ps.GetLabelKeys(kind); len(labelKeys) > 0 {
+		return labelKeys[0]
+	}
+	if kind == KindTaskRun {
+		return LabelTaskName
+	}
+	return LabelPipelineName
+}
+
+// GetNamespaceSelectors returns the selectors configured for a resource type in the namespace ConfigMap
+func (ps *prunerConfigStore) GetNamespaceSelectors(namespace string, resourceType PrunerResourceType) []SelectorSpec {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	nsSpec, found := ps.namespaceConfig[namespace]
+	if !found {
+		return nil
+	}
+	resourceSpecs := nsSpec.PipelineRuns
+	if resourceType == PrunerResourceTypeTaskRun {
+		resourceSpecs = nsSpec.TaskRuns
+	}
+
+	var selectors []SelectorSpec
+	for _, resourceSpec := range resourceSpecs {
+		selectors = append(selectors, resourceSpec.Selector...)
+	}
+	return selectors
+}
+
+// namespaceConfigsIgnoredKey is used as the key for ignoring the namespace ConfigMaps in the context.
+type namespaceConfigsIgnoredKey struct{}
+
+// WithNamespaceConfigsIgnored makes the pruning decisions taken with the context ignore the namespace ConfigMaps,
+// e.g. for the runs of a remote cluster, whose namespaces only share their names with the local ones
+func WithNamespaceConfigsIgnored(ctx context.Context) context.Context {
+	return context.WithValue(ctx, namespaceConfigsIgnoredKey{}, true)
+}
+
+// AreNamespaceConfigsIgnored reports whether the pruning decisions taken with the context ignore the namespace ConfigMaps
+func AreNamespaceConfigsIgnored(ctx context.Context) bool {
+	ignored, _ := ctx.Value(namespaceConfigsIgnoredKey{}).(bool)
+	return ignored
+}
+
+// GetGlobalConfigFieldData returns the TTL or a history limit of a run from the global config alone, ignoring the namespace ConfigMaps
+func (ps *prunerConfigStore) GetGlobalConfigFieldData(namespace, name string, selector SelectorSpec, resourceType PrunerResourceType, fieldType PrunerFieldType) (*int32, string) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	enforcedConfigLevel := ps.getEnforcedConfigLevel(namespace, name, selector, resourceType)
+	return getResourceFieldData(ps.globalConfigFor(namespace), nil, namespace, name, selector, resourceType, fieldType, enforcedConfigLevel)
+}
+
+// GetPerLabelValueHistoryLimit returns the per label value history limit of a namespace, under the given enforced config level.
+// The namespace ConfigMap takes precedence over the namespace entry of the global config
+// returns nil, if not configured for the namespace
+func (ps *prunerConfigStore) GetPerLabelValueHistoryLimit(namespace string, enforcedConfigLevel EnforcedConfigLevel) *PerLabelValueHistoryLimit {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	switch enforcedConfigLevel {
+	case EnforcedConfigLevelGlobal:
+		return nil
+	case EnforcedConfigLevelNamespace:
+		if limit := ps.namespaceConfig[namespace].PerLabelValueHistoryLimit; limit != nil {
+			return limit
+		}
+	}
+	return ps.globalConfigFor(namespace).Namespaces[namespace].PerLabelValueHistoryLimit
+}
+
+// GetPipelineMatchingSelector returns the ConfigMap's selector that matches a PipelineRun.
+func (ps *prunerConfigStore) GetPipelineMatchingSelector(namespace, name string, selector SelectorSpec) *SelectorSpec {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return getMatchingSelectorFromConfig(ps.namespaceConfig, namespace, name, selector, PrunerResourceTypePipelineRun)
+}
+
+// GetTaskMatchingSelector returns the ConfigMap's selector that matches a TaskRun.
+func (ps *prunerConfigStore) GetTaskMatchingSelector(namespace, name string, selector SelectorSpec) *SelectorSpec {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return getMatchingSelectorFromConfig(ps.namespaceConfig, namespace, name, selector, PrunerResourceTypeTaskRun)
+}
+
+// ValidateGlobalConfig validates a GlobalConfig struct directly without ConfigMap conversion
+// This is a convenience function for validating global config and all nested namespace configs
+// without the overhead of serialization/deserialization through ConfigMaps.
+//
+// Use this function when you have a GlobalConfig struct and want to validate it directly,
+// for example when validating configuration from operator CRDs or other non-ConfigMap sources.
+//
+// For ConfigMap-based validation, use ValidateConfigMap or ValidateConfigMapWithGlobal instead.
+func ValidateGlobalConfig(globalConfig *GlobalConfig) error {
+	if globalConfig == nil {
+		return nil
+	}
+
+	// Validate root-level global config
+	if err := validatePrunerConfig(&globalConfig.PrunerConfig, "global-config", nil); err != nil {
+		return err
+	}
+
+	// Validate cluster-wide settings available only on global config
+	if err := validateGlobalSettings(globalConfig, "global-config"); err != nil {
+		return err
+	}
+
+	// Validate nested namespace configs
+	// These are validated against the global limits
+	for ns, nsSpec := range globalConfig.Namespaces {
+		path := fmt.Sprintf("global-config.namespaces.%s", ns)
+		if err := validatePrunerConfig(&nsSpec.PrunerConfig, path, &globalConfig.PrunerConfig); err != nil {
+			return err
+		}
+		if err := validatePerLabelValueHistoryLimit(nsSpec.PerLabelValueHistoryLimit, path); err != nil {
+			return err
+		}
+
+		// CRITICAL: Validate that global ConfigMap namespace sections do NOT contain selectors
+		// Selectors are ONLY supported in namespace-level ConfigMaps (tekton-pruner-namespace-spec)
+		for i, pr := range nsSpec.PipelineRuns {
+			if len(pr.Selector) > 0 {
+				return fmt.Errorf("%s.pipelineRuns[%d]: selectors are NOT supported in global ConfigMap. Use namespace-level ConfigMap (tekton-pruner-namespace-spec) instead", path, i)
+			}
+		}
+		for i, tr := range nsSpec.TaskRuns {
+			if len(tr.Selector) > 0 {
+				return fmt.Errorf("%s.taskRuns[%d]: selectors are NOT supported in global ConfigMap. Use namespace-level ConfigMap (tekton-pruner-namespace-spec) instead", path, i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ErrNegativeValue reports a config value below zero where only zero or more is allowed
+var ErrNegativeValue = errors.New("cannot be negative")
+
+// ErrExceedsLimit reports a config value above the limit set by the global config or by the system
+var ErrExceedsLimit = errors.New("cannot exceed")
+
+func ValidateConfigMap(cm *corev1.ConfigMap) error {
+	return ValidateConfigMapWithGlobal(cm, nil)
+}
+
+// ValidateConfigMapWithGlobal validates a ConfigMap with optional global config for limit enforcement
+// If globalConfigMap is provided and cm is a namespace-level config, it validates that namespace
+// limits do not exceed global limits
+func ValidateConfigMapWithGlobal(cm *corev1.ConfigMap, globalConfigMap *corev1.ConfigMap) error {
+	if cm.Data == nil {
+		return nil
+	}
+
+	// Parse global config if validating a global ConfigMap
+	var globalLimits *PrunerConfig
+	if cm.Data[PrunerGlobalConfigKey] != "" {
+		globalConfig, err := parseGlobalConfig(cm.Data[PrunerGlobalConfigKey])
+		if err != nil {
+			return fmt.Errorf("failed to parse global-config: %w", err)
+		}
+		if err := validatePrunerConfig(&globalConfig.PrunerConfig, "global-config", nil); err != nil {
+			return err
+		}
+		if err := validateGlobalSettings(globalConfig, "global-config"); err != nil {
+			return err
+		}
+		// Validate nested namespace configs within global config
+		// These are validated against the global limits
+		for ns, nsSpec := range globalConfig.Namespaces {
+			if err := validatePrunerConfig(&nsSpec.PrunerConfig, "global-config.namespaces."+ns, &globalConfig.PrunerConfig); err != nil {
+				return err
+			}
+			if err := validatePerLabelValueHistoryLimit(nsSpec.PerLabelValueHistoryLimit, "global-config.namespaces."+ns); err != nil {
+				return err
+			}
+
+			// CRITICAL: Validate that global ConfigMap namespace sections do NOT contain selectors
+			// Selectors are ONLY supported in namespace-level ConfigMaps (tekton-pruner-namespace-spec)
+			for i, pr := range nsSpec.PipelineRuns {
+				if len(pr.Selector) > 0 {
+					return fmt.Errorf("global-config.namespaces.%s.pipelineRuns[%d]: selectors are NOT supported in global ConfigMap. Use namespace-level ConfigMap (tekton-pruner-namespace-spec) instead", ns, i)
+				}
+			}
+			for i, tr := range nsSpec.TaskRuns {
+				if len(tr.Selector) > 0 {
+					return fmt.Errorf("global-config.namespaces.%s.taskRuns[%d]: selectors are NOT supported in global ConfigMap. Use namespace-level ConfigMap (tekton-pruner-namespace-spec) instead", ns, i)
+				}
+			}
+			for i, pr := range nsSpec.PipelineRuns {
+				if pr.ByAnnotationValue != nil {
+					return fmt.Errorf("global-config.namespaces.%s.pipelineRuns[%d]: byAnnotationValue is NOT supported in global ConfigMap. Use namespace-level ConfigMap (tekton-pruner-namespace-spec) instead", ns, i)
+				}
+			}
+			for i, tr := range nsSpec.TaskRuns {
+				if tr.ByAnnotationValue != nil {
+					return fmt.Errorf("global-config.namespaces.%s.taskRuns[%d]: byAnnotationValue is NOT supported in global ConfigMap. Use namespace-level ConfigMap (tekton-pruner-namespace-spec) instead", ns, i)
+				}
+			}
+		}
+		return nil
+	}
+
+	// Parse and validate namespace config against global limits
+	if cm.Data[PrunerNamespaceConfigKey] != "" {
+		namespaceConfig, err := parseNamespaceSpec(cm.Data[PrunerNamespaceConfigKey])
+		if err != nil {
+			return fmt.Errorf("failed to parse ns-config: %w", err)
+		}
+
+		// The fields inherited from the profile are validated along with the ones of the namespace config
+		if namespaceConfig.ProfileRef != "" && globalConfigMap != nil && globalConfigMap.Data[PrunerGlobalConfigKey] != "" {
+			if globalConfig, err := parseGlobalConfig(globalConfigMap.Data[PrunerGlobalConfigKey]); err == nil {
+				resolved, err := resolveProfile(*namespaceConfig, globalConfig.Profiles)
+				if err != nil {
+					return fmt.Errorf("ns-config: %w", err)
+				}
+				namespaceConfig = &resolved
+			}
+		}
+
+		// The byAnnotationValue entries are validated as the selector entries they expand to
+		if err := validateAnnotationValues(namespaceConfig, "ns-config"); err != nil {
+			return err
+		}
+		expanded := expandAnnotationValues(*namespaceConfig)
+		namespaceConfig = &expanded
+
+		// Extract global limits if global config is provided
+		if globalConfigMap != nil && globalConfigMap.Data != nil && globalConfigMap.Data[PrunerGlobalConfigKey] != "" {
+			globalConfig, err := parseGlobalConfig(globalConfigMap.Data[PrunerGlobalConfigKey])
+			if err != nil {
+				// If we can't parse global config, just do basic validation
+				return validatePrunerConfig(&namespaceConfig.PrunerConfig, "ns-config", nil)
+			}
+			globalLimits = &globalConfig.PrunerConfig
+		}
+
+		// Validate namespace config, enforcing global limits if available
+		if err := validatePrunerConfig(&namespaceConfig.PrunerConfig, "ns-config", globalLimits); err != nil {
+			return err
+		}
+		if err := validatePerLabelValueHistoryLimit(namespaceConfig.PerLabelValueHistoryLimit, "ns-config"); err != nil {
+			return err
+		}
+
+		// Validate selector-based limits (sum of selectors must not exceed namespace/global limits)
+		// Extract namespace name from ConfigMap metadata
+		namespace := cm.Namespace
+		var globalNamespaceSpec *NamespaceSpec
+		if globalConfigMap != nil && globalConfigMap.Data != nil && globalConfigMap.Data[PrunerGlobalConfigKey] != "" {
+			if globalConfig, err := parseGlobalConfig(globalConfigMap.Data[PrunerGlobalConfigKey]); err == nil {
+				if nsSpec, exists := globalConfig.Namespaces[namespace]; exists {
+					globalNamespaceSpec = &nsSpec
 				}
 				// Pass both globalConfig and globalNamespaceSpec for 4-tier hierarchy
 				if err := validateSelectorLimits(namespaceConfig, &globalConfig.PrunerConfig, globalNamespaceSpec, namespace); err != nil {
@@ -881,6 +1902,12 @@ func ValidateNamespaceSpec(namespaceSpec *NamespaceSpec, namespace string, globa
 
 	// Extract global limits if provided
 	if globalConfig != nil {
+		// The fields inherited from the profile are validated along with the ones of the namespace config
+		resolved, err := resolveProfile(*namespaceSpec, globalConfig.Profiles)
+		if err != nil {
+			return fmt.Errorf("ns-config: %w", err)
+		}
+		namespaceSpec = &resolved
 		globalLimits = &globalConfig.PrunerConfig
 		// Check if there's a namespace-specific override in global config
 		if nsSpec, exists := globalConfig.Namespaces[namespace]; exists {
@@ -892,6 +1919,16 @@ func ValidateNamespaceSpec(namespaceSpec *NamespaceSpec, namespace string, globa
 	if err := validatePrunerConfig(&namespaceSpec.PrunerConfig, "ns-config", globalLimits); err != nil {
 		return err
 	}
+	if err := validatePerLabelValueHistoryLimit(namespaceSpec.PerLabelValueHistoryLimit, "ns-config"); err != nil {
+		return err
+	}
+
+	// The byAnnotationValue entries are validated as the selector entries they expand to
+	if err := validateAnnotationValues(namespaceSpec, "ns-config"); err != nil {
+		return err
+	}
+	expanded := expandAnnotationValues(*namespaceSpec)
+	namespaceSpec = &expanded
 
 	// Validate selector-based limits (sum of selectors must not exceed namespace/global limits)
 	if err := validateSelectorLimits(namespaceSpec, globalLimits, globalNamespaceSpec, namespace); err != nil {
@@ -901,6 +1938,344 @@ func ValidateNamespaceSpec(namespaceSpec *NamespaceSpec, namespace string, globa
 	return nil
 }
 
+// validatePerLabelValueHistoryLimit validates the label and the limit of a per label value history limit, if set
+func validatePerLabelValueHistoryLimit(perLabelValue *PerLabelValueHistoryLimit, path string) error {
+	if perLabelValue == nil {
+		return nil
+	}
+	if errs := validation.IsQualifiedName(perLabelValue.Label); len(errs) > 0 {
+		return fmt.Errorf("%s: perLabelValueHistoryLimit: invalid label '%s': %s", path, perLabelValue.Label, strings.Join(errs, "; "))
+	}
+	if perLabelValue.Limit == nil {
+		return fmt.Errorf("%s: perLabelValueHistoryLimit: limit is required", path)
+	}
+	if *perLabelValue.Limit < 0 {
+		return fmt.Errorf("%s: perLabelValueHistoryLimit: limit %w, got %d", path, ErrNegativeValue, *perLabelValue.Limit)
+	}
+	return nil
+}
+
+// validateGlobalSettings validates the cluster-wide settings which are available only on the global config
+func validateGlobalSettings(globalConfig *GlobalConfig, path string) error {
+	if globalConfig.MetricsNamespaceAggregation != nil {
+		mode := *globalConfig.MetricsNamespaceAggregation
+		if mode != MetricsNamespaceAggregationNone && mode != MetricsNamespaceAggregationAggregate {
+			return fmt.Errorf("%s: invalid metricsNamespaceAggregation '%s', must be one of: none, aggregate", path, mode)
+		}
+		if mode == MetricsNamespaceAggregationAggregate && globalConfig.MetricsAggregatedNamespacePattern == "" {
+			return fmt.Errorf("%s: metricsAggregatedNamespacePattern is required when metricsNamespaceAggregation is aggregate", path)
+		}
+	}
+	if _, err := regexp.Compile(globalConfig.MetricsAggregatedNamespacePattern); err != nil {
+		return fmt.Errorf("%s: invalid metricsAggregatedNamespacePattern '%s': %w", path, globalConfig.MetricsAggregatedNamespacePattern, err)
+	}
+
+	for i, expr := range globalConfig.NamespaceExcludeRegexes {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("%s: namespaceExcludeRegexes[%d]: invalid regular expression '%s': %w", path, i, expr, err)
+		}
+	}
+
+	for i, namespace := range globalConfig.NamespacePriority {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return fmt.Errorf("%s: namespacePriority[%d]: invalid namespace '%s': %s", path, i, namespace, strings.Join(errs, "; "))
+		}
+		if slices.Index(globalConfig.NamespacePriority, namespace) != i {
+			return fmt.Errorf("%s: namespacePriority[%d]: duplicate namespace '%s'", path, i, namespace)
+		}
+	}
+
+	if globalConfig.CanaryNamespace != "" {
+		if errs := validation.IsDNS1123Label(globalConfig.CanaryNamespace); len(errs) > 0 {
+			return fmt.Errorf("%s: invalid canaryNamespace '%s': %s", path, globalConfig.CanaryNamespace, strings.Join(errs, "; "))
+		}
+	}
+
+	if successfulReasons := globalConfig.SuccessfulReasons; successfulReasons != nil {
+		if err := validateSuccessfulReasons(successfulReasons.PipelineRuns, recognizedPipelineRunReasons, path+".successfulReasons.pipelineRuns"); err != nil {
+			return err
+		}
+		if err := validateSuccessfulReasons(successfulReasons.TaskRuns, recognizedTaskRunReasons, path+".successfulReasons.taskRuns"); err != nil {
+			return err
+		}
+	}
+
+	if globalConfig.MaxCompletedRunsPerNamespace != nil && *globalConfig.MaxCompletedRunsPerNamespace < 0 {
+		return fmt.Errorf("%s: maxCompletedRunsPerNamespace %w, got %d", path, ErrNegativeValue, *globalConfig.MaxCompletedRunsPerNamespace)
+	}
+	if globalConfig.NamespaceObjectBudget != nil && *globalConfig.NamespaceObjectBudget < 0 {
+		return fmt.Errorf("%s: namespaceObjectBudget %w, got %d", path, ErrNegativeValue, *globalConfig.NamespaceObjectBudget)
+	}
+
+	if globalConfig.PruneLargeStatusBytes != nil && *globalConfig.PruneLargeStatusBytes <= 0 {
+		return fmt.Errorf("%s: pruneLargeStatusBytes must be positive, got %d", path, *globalConfig.PruneLargeStatusBytes)
+	}
+
+	if globalConfig.HistoryLimitBytes != nil && *globalConfig.HistoryLimitBytes <= 0 {
+		return fmt.Errorf("%s: historyLimitBytes must be positive, got %d", path, *globalConfig.HistoryLimitBytes)
+	}
+
+	if globalConfig.DeletionMode != nil {
+		mode := *globalConfig.DeletionMode
+		if mode != DeletionModeDelete && mode != DeletionModeAnnotate {
+			return fmt.Errorf("%s: invalid deletionMode '%s', must be one of: delete, annotate", path, mode)
+		}
+	}
+
+	if globalConfig.ProcessedAnnotationKey != "" {
+		if errs := validation.IsQualifiedName(globalConfig.ProcessedAnnotationKey); len(errs) > 0 {
+			return fmt.Errorf("%s: invalid processedAnnotationKey '%s': %s", path, globalConfig.ProcessedAnnotationKey, strings.Join(errs, "; "))
+		}
+	}
+	for _, entry := range globalConfig.AnnotationAllowlist {
+		var errs []string
+		if prefix, isPrefix := strings.CutSuffix(entry, "/"); isPrefix {
+			errs = validation.IsDNS1123Subdomain(prefix)
+		} else {
+			errs = validation.IsQualifiedName(entry)
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("%s: invalid annotationAllowlist entry '%s': %s", path, entry, strings.Join(errs, "; "))
+		}
+	}
+
+	if policy := globalConfig.EphemeralNamespacePolicy; policy != nil {
+		// an empty selector matches every namespace, which is never intended for a policy deleting namespaces
+		if len(policy.NamespaceSelector.MatchLabels) == 0 && len(policy.NamespaceSelector.MatchExpressions) == 0 {
+			return fmt.Errorf("%s: ephemeralNamespacePolicy.namespaceSelector cannot be empty", path)
+		}
+		if _, err := metav1.LabelSelectorAsSelector(&policy.NamespaceSelector); err != nil {
+			return fmt.Errorf("%s: invalid ephemeralNamespacePolicy.namespaceSelector: %w", path, err)
+		}
+		if policy.TTLSecondsAfterFinished != nil && *policy.TTLSecondsAfterFinished < 0 {
+			return fmt.Errorf("%s: ephemeralNamespacePolicy.ttlSecondsAfterFinished %w, got %d", path, ErrNegativeValue, *policy.TTLSecondsAfterFinished)
+		}
+	}
+
+	if globalConfig.FallbackTTLSecondsAfterFinished != nil && *globalConfig.FallbackTTLSecondsAfterFinished < 0 {
+		return fmt.Errorf("%s: fallbackTTLSecondsAfterFinished %w, got %d", path, ErrNegativeValue, *globalConfig.FallbackTTLSecondsAfterFinished)
+	}
+	if globalConfig.TTLNoResultsSeconds != nil && *globalConfig.TTLNoResultsSeconds < 0 {
+		return fmt.Errorf("%s: ttlNoResultsSeconds %w, got %d", path, ErrNegativeValue, *globalConfig.TTLNoResultsSeconds)
+	}
+
+	if globalConfig.MaxConcurrentDeletions != nil && *globalConfig.MaxConcurrentDeletions <= 0 {
+		return fmt.Errorf("%s: maxConcurrentDeletions must be positive, got %d", path, *globalConfig.MaxConcurrentDeletions)
+	}
+
+	if globalConfig.MinSuccessfulToKeep != nil && *globalConfig.MinSuccessfulToKeep <= 0 {
+		return fmt.Errorf("%s: minSuccessfulToKeep must be positive, got %d", path, *globalConfig.MinSuccessfulToKeep)
+	}
+
+	if globalConfig.MaxWorkerCount != nil && *globalConfig.MaxWorkerCount <= 0 {
+		return fmt.Errorf("%s: maxWorkerCount must be positive, got %d", path, *globalConfig.MaxWorkerCount)
+	}
+	if interval := globalConfig.GCIntervalSeconds; interval != nil && (*interval < MinGCIntervalSeconds || *interval > MaxGCIntervalSeconds) {
+		return fmt.Errorf("%s: gcIntervalSeconds must be between %d and %d, got %d", path, MinGCIntervalSeconds, MaxGCIntervalSeconds, *interval)
+	}
+
+	if delay := globalConfig.InterNamespaceDelayMillis; delay != nil && (*delay < 0 || *delay > MaxInterNamespaceDelayMillis) {
+		return fmt.Errorf("%s: interNamespaceDelayMillis must be between 0 and %d, got %d", path, MaxInterNamespaceDelayMillis, *delay)
+	}
+
+	if globalConfig.RetainDays != nil {
+		if *globalConfig.RetainDays <= 0 {
+			return fmt.Errorf("%s: retainDays must be positive, got %d", path, *globalConfig.RetainDays)
+		}
+		if *globalConfig.RetainDays > MaxRetainDays {
+			return fmt.Errorf("%s: retainDays %w %d, got %d", path, ErrExceedsLimit, MaxRetainDays, *globalConfig.RetainDays)
+		}
+		if globalConfig.TTLSecondsAfterFinished != nil {
+			return fmt.Errorf("%s: retainDays and ttlSecondsAfterFinished are mutually exclusive", path)
+		}
+	}
+	if _, err := globalConfig.retainDaysLocation(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if priority := globalConfig.HistoryLimitEvictionPriority; priority != nil && *priority != HistoryLimitEvictionOldest &&
+		*priority != HistoryLimitEvictionFailed && *priority != HistoryLimitEvictionSuccessful {
+		return fmt.Errorf("%s: invalid historyLimitEvictionPriority '%s', must be one of: oldest, failed, successful", path, *priority)
+	}
+	if countsAs := globalConfig.CancelledCountsAs; countsAs != nil && *countsAs != CancelledCountsAsFailed &&
+		*countsAs != CancelledCountsAsSuccessful && *countsAs != CancelledCountsAsIgnored {
+		return fmt.Errorf("%s: invalid cancelledCountsAs '%s', must be one of: failed, successful, ignored", path, *countsAs)
+	}
+	if policy := globalConfig.TTLHistoryPolicy; policy != nil && *policy != TTLHistoryPolicyIndependent && *policy != TTLHistoryPolicyCombined {
+		return fmt.Errorf("%s: invalid ttlHistoryPolicy '%s', must be one of: independent, combined", path, *policy)
+	}
+
+	if globalConfig.TTLFrom != nil && *globalConfig.TTLFrom != TTLFromCompletion && *globalConfig.TTLFrom != TTLFromStart {
+		return fmt.Errorf("%s: invalid ttlFrom '%s', must be one of: completion, start", path, *globalConfig.TTLFrom)
+	}
+	if globalConfig.FreezeUntil != "" {
+		if _, err := time.Parse(time.RFC3339, globalConfig.FreezeUntil); err != nil {
+			return fmt.Errorf("%s: invalid freezeUntil '%s', must be an RFC3339 timestamp: %w", path, globalConfig.FreezeUntil, err)
+		}
+	}
+	if err := validateSafeMode(globalConfig.SafeMode, path); err != nil {
+		return err
+	}
+	if err := validateQuotaPressure(globalConfig.QuotaPressure, path); err != nil {
+		return err
+	}
+	if globalConfig.TTLAnchorAnnotation != "" {
+		if errs := validation.IsQualifiedName(globalConfig.TTLAnchorAnnotation); len(errs) > 0 {
+			return fmt.Errorf("%s: invalid ttlAnchorAnnotation '%s': %s", path, globalConfig.TTLAnchorAnnotation, strings.Join(errs, "; "))
+		}
+	}
+	if globalConfig.AbandonedAfterSeconds != nil {
+		if *globalConfig.AbandonedAfterSeconds <= 0 {
+			return fmt.Errorf("%s: abandonedAfterSeconds must be positive, got %d", path, *globalConfig.AbandonedAfterSeconds)
+		}
+		if globalConfig.TTLFrom == nil || *globalConfig.TTLFrom != TTLFromStart {
+			return fmt.Errorf("%s: abandonedAfterSeconds requires ttlFrom to be start", path)
+		}
+	}
+
+	if globalConfig.PruneStuckAfterSeconds != nil && *globalConfig.PruneStuckAfterSeconds < MinPruneStuckAfterSeconds {
+		return fmt.Errorf("%s: pruneStuckAfterSeconds must be at least %d, got %d", path, MinPruneStuckAfterSeconds, *globalConfig.PruneStuckAfterSeconds)
+	}
+	if globalConfig.AbsoluteMaxAgeSeconds != nil && *globalConfig.AbsoluteMaxAgeSeconds < MinAbsoluteMaxAgeSeconds {
+		return fmt.Errorf("%s: absoluteMaxAgeSeconds must be at least %d, got %d", path, MinAbsoluteMaxAgeSeconds, *globalConfig.AbsoluteMaxAgeSeconds)
+	}
+
+	if globalConfig.TTLRequeueCeilingSeconds != nil && *globalConfig.TTLRequeueCeilingSeconds <= 0 {
+		return fmt.Errorf("%s: ttlRequeueCeilingSeconds must be positive, got %d", path, *globalConfig.TTLRequeueCeilingSeconds)
+	}
+
+	if globalConfig.ListRetryAttempts != nil && *globalConfig.ListRetryAttempts < 0 {
+		return fmt.Errorf("%s: listRetryAttempts %w, got %d", path, ErrNegativeValue, *globalConfig.ListRetryAttempts)
+	}
+	if globalConfig.ListRetryBackoffMilliseconds != nil && *globalConfig.ListRetryBackoffMilliseconds <= 0 {
+		return fmt.Errorf("%s: listRetryBackoffMilliseconds must be positive, got %d", path, *globalConfig.ListRetryBackoffMilliseconds)
+	}
+	if globalConfig.APICallTimeoutSeconds != nil && *globalConfig.APICallTimeoutSeconds <= 0 {
+		return fmt.Errorf("%s: apiCallTimeoutSeconds must be positive, got %d", path, *globalConfig.APICallTimeoutSeconds)
+	}
+	if globalConfig.AnnotationPatchRetryAttempts != nil && *globalConfig.AnnotationPatchRetryAttempts < 0 {
+		return fmt.Errorf("%s: annotationPatchRetryAttempts %w, got %d", path, ErrNegativeValue, *globalConfig.AnnotationPatchRetryAttempts)
+	}
+
+	if globalConfig.HistoryDeletionBatchSize != nil && *globalConfig.HistoryDeletionBatchSize <= 0 {
+		return fmt.Errorf("%s: historyDeletionBatchSize must be positive, got %d", path, *globalConfig.HistoryDeletionBatchSize)
+	}
+
+	for i, rule := range globalConfig.ProtectIfReferencedBy {
+		if _, err := schema.ParseGroupVersion(rule.APIVersion); err != nil || rule.APIVersion == "" {
+			return fmt.Errorf("%s: protectIfReferencedBy[%d]: invalid apiVersion '%s'", path, i, rule.APIVersion)
+		}
+		if rule.Kind == "" || rule.Resource == "" {
+			return fmt.Errorf("%s: protectIfReferencedBy[%d]: kind and resource are required", path, i)
+		}
+		for _, referencePath := range rule.StatusReferencePaths {
+			if slices.Contains(strings.Split(referencePath, "."), "") {
+				return fmt.Errorf("%s: protectIfReferencedBy[%d]: invalid statusReferencePaths entry '%s'", path, i, referencePath)
+			}
+		}
+	}
+
+	if neverPrune := globalConfig.NeverPrune; neverPrune != nil {
+		if err := validateNeverPruneNames(neverPrune.PipelineRuns, path+".neverPrune.pipelineRuns"); err != nil {
+			return err
+		}
+		if err := validateNeverPruneNames(neverPrune.TaskRuns, path+".neverPrune.taskRuns"); err != nil {
+			return err
+		}
+	}
+
+	for i, finalizer := range globalConfig.SkipRunsWithFinalizers {
+		if finalizer == "*" {
+			continue
+		}
+		if errs := validation.IsQualifiedName(finalizer); len(errs) > 0 {
+			return fmt.Errorf("%s.skipRunsWithFinalizers[%d]: invalid finalizer '%s': %s", path, i, finalizer, errs[0])
+		}
+	}
+
+	for i, kind := range globalConfig.TaskRunParentKinds {
+		if strings.TrimSpace(kind) == "" {
+			return fmt.Errorf("%s.taskRunParentKinds[%d]: kind cannot be empty", path, i)
+		}
+	}
+
+	for i, key := range globalConfig.PipelineRunLabelKeys {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("%s.pipelineRunLabelKeys[%d]: label key cannot be empty", path, i)
+		}
+	}
+	for i, key := range globalConfig.TaskRunLabelKeys {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("%s.taskRunLabelKeys[%d]: label key cannot be empty", path, i)
+		}
+	}
+
+	return validateProfiles(globalConfig, path)
+}
+
+// validateSuccessfulReasons checks that every reason is one the resource type can report
+func validateSuccessfulReasons(reasons, recognized []string, path string) error {
+	for i, reason := range reasons {
+		if !slices.Contains(recognized, reason) {
+			return fmt.Errorf("%s[%d]: unrecognized reason '%s', must be one of: %s",
+				path, i, reason, strings.Join(recognized, ", "))
+		}
+	}
+	return nil
+}
+
+// validateNeverPruneNames validates the names of a neverPrune list, they are matched against label values
+func validateNeverPruneNames(names []string, path string) error {
+	for i, name := range names {
+		if name == "" {
+			return fmt.Errorf("%s[%d]: name cannot be empty", path, i)
+		}
+		if errs := validation.IsValidLabelValue(name); len(errs) > 0 {
+			return fmt.Errorf("%s[%d]: invalid name '%s': %s", path, i, name, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// metricsAggregationPattern returns the pattern of namespaces to be aggregated on metrics
+// returns nil, if the namespace aggregation is not enabled
+func (gc *GlobalConfig) metricsAggregationPattern() (*regexp.Regexp, error) {
+	if gc.MetricsNamespaceAggregation == nil || *gc.MetricsNamespaceAggregation != MetricsNamespaceAggregationAggregate ||
+		gc.MetricsAggregatedNamespacePattern == "" {
+		return nil, nil
+	}
+	pattern, err := regexp.Compile(gc.MetricsAggregatedNamespacePattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metricsAggregatedNamespacePattern '%s': %w", gc.MetricsAggregatedNamespacePattern, err)
+	}
+	return pattern, nil
+}
+
+// retainDaysLocation loads the time zone the calendar days of retainDays are counted in
+func (gc *GlobalConfig) retainDaysLocation() (*time.Location, error) {
+	if gc.RetainDaysTimeZone == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(gc.RetainDaysTimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retainDaysTimeZone '%s': %w", gc.RetainDaysTimeZone, err)
+	}
+	return location, nil
+}
+
+// namespaceExcludePatterns compiles the regular expressions of the namespaces excluded from garbage collection
+func (gc *GlobalConfig) namespaceExcludePatterns() ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for i, expr := range gc.NamespaceExcludeRegexes {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("namespaceExcludeRegexes[%d]: invalid regular expression '%s': %w", i, expr, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
 // validatePrunerConfig validates the fields of a PrunerConfig
 // If globalConfig is provided, namespace-level settings are validated to not exceed global limits
 // If globalConfig is nil and path indicates a namespace config, system maximums are enforced
@@ -928,19 +2303,19 @@ func validatePrunerConfig(config *PrunerConfig, path string, globalConfig *Prune
 	// Validate TTLSecondsAfterFinished
 	if config.TTLSecondsAfterFinished != nil {
 		if *config.TTLSecondsAfterFinished < 0 {
-			return fmt.Errorf("%s: ttlSecondsAfterFinished cannot be negative, got %d", path, *config.TTLSecondsAfterFinished)
+			return fmt.Errorf("%s: ttlSecondsAfterFinished %w, got %d", path, ErrNegativeValue, *config.TTLSecondsAfterFinished)
 		}
 		// Namespace config cannot have longer TTL than global config
 		if globalConfig != nil && globalConfig.TTLSecondsAfterFinished != nil {
 			if *config.TTLSecondsAfterFinished > *globalConfig.TTLSecondsAfterFinished {
-				return fmt.Errorf("%s: ttlSecondsAfterFinished (%d) cannot exceed global limit (%d)",
-					path, *config.TTLSecondsAfterFinished, *globalConfig.TTLSecondsAfterFinished)
+				return fmt.Errorf("%s: ttlSecondsAfterFinished (%d) %w global limit (%d)",
+					path, *config.TTLSecondsAfterFinished, ErrExceedsLimit, *globalConfig.TTLSecondsAfterFinished)
 			}
 		} else if isNamespaceConfig && (globalConfig == nil || globalConfig.TTLSecondsAfterFinished == nil) {
 			// If this is a namespace config and no global limit is set, enforce system maximum
 			if *config.TTLSecondsAfterFinished > MaxTTLSecondsAfterFinished {
-				return fmt.Errorf("%s: ttlSecondsAfterFinished (%d) cannot exceed system maximum (%d seconds / 30 days)",
-					path, *config.TTLSecondsAfterFinished, MaxTTLSecondsAfterFinished)
+				return fmt.Errorf("%s: ttlSecondsAfterFinished (%d) %w system maximum (%d seconds / 30 days)",
+					path, *config.TTLSecondsAfterFinished, ErrExceedsLimit, MaxTTLSecondsAfterFinished)
 			}
 		}
 	}
@@ -948,34 +2323,34 @@ func validatePrunerConfig(config *PrunerConfig, path string, globalConfig *Prune
 	// Validate SuccessfulHistoryLimit
 	if config.SuccessfulHistoryLimit != nil {
 		if *config.SuccessfulHistoryLimit < 0 {
-			return fmt.Errorf("%s: successfulHistoryLimit cannot be negative, got %d", path, *config.SuccessfulHistoryLimit)
+			return fmt.Errorf("%s: successfulHistoryLimit %w, got %d", path, ErrNegativeValue, *config.SuccessfulHistoryLimit)
 		}
 		// For namespace configs, determine the upper limit based on global config
 		if isNamespaceConfig && globalConfig != nil {
 			// Priority 1: Use global successfulHistoryLimit if set
 			if globalConfig.SuccessfulHistoryLimit != nil {
 				if *config.SuccessfulHistoryLimit > *globalConfig.SuccessfulHistoryLimit {
-					return fmt.Errorf("%s: successfulHistoryLimit (%d) cannot exceed global limit (%d)",
-						path, *config.SuccessfulHistoryLimit, *globalConfig.SuccessfulHistoryLimit)
+					return fmt.Errorf("%s: successfulHistoryLimit (%d) %w global limit (%d)",
+						path, *config.SuccessfulHistoryLimit, ErrExceedsLimit, *globalConfig.SuccessfulHistoryLimit)
 				}
 			} else if globalConfig.HistoryLimit != nil {
 				// Priority 2: Use global historyLimit as fallback if no granular limit
 				if *config.SuccessfulHistoryLimit > *globalConfig.HistoryLimit {
-					return fmt.Errorf("%s: successfulHistoryLimit (%d) cannot exceed global historyLimit (%d)",
-						path, *config.SuccessfulHistoryLimit, *globalConfig.HistoryLimit)
+					return fmt.Errorf("%s: successfulHistoryLimit (%d) %w global historyLimit (%d)",
+						path, *config.SuccessfulHistoryLimit, ErrExceedsLimit, *globalConfig.HistoryLimit)
 				}
 			} else {
 				// Priority 3: Use system maximum if global config exists but has no relevant limits
 				if *config.SuccessfulHistoryLimit > MaxHistoryLimit {
-					return fmt.Errorf("%s: successfulHistoryLimit (%d) cannot exceed system maximum (%d)",
-						path, *config.SuccessfulHistoryLimit, MaxHistoryLimit)
+					return fmt.Errorf("%s: successfulHistoryLimit (%d) %w system maximum (%d)",
+						path, *config.SuccessfulHistoryLimit, ErrExceedsLimit, MaxHistoryLimit)
 				}
 			}
 		} else if isNamespaceConfig && globalConfig == nil {
 			// Priority 3: Use system maximum if no global config at all
 			if *config.SuccessfulHistoryLimit > MaxHistoryLimit {
-				return fmt.Errorf("%s: successfulHistoryLimit (%d) cannot exceed system maximum (%d)",
-					path, *config.SuccessfulHistoryLimit, MaxHistoryLimit)
+				return fmt.Errorf("%s: successfulHistoryLimit (%d) %w system maximum (%d)",
+					path, *config.SuccessfulHistoryLimit, ErrExceedsLimit, MaxHistoryLimit)
 			}
 		}
 	}
@@ -983,34 +2358,34 @@ func validatePrunerConfig(config *PrunerConfig, path string, globalConfig *Prune
 	// Validate FailedHistoryLimit
 	if config.FailedHistoryLimit != nil {
 		if *config.FailedHistoryLimit < 0 {
-			return fmt.Errorf("%s: failedHistoryLimit cannot be negative, got %d", path, *config.FailedHistoryLimit)
+			return fmt.Errorf("%s: failedHistoryLimit %w, got %d", path, ErrNegativeValue, *config.FailedHistoryLimit)
 		}
 		// For namespace configs, determine the upper limit based on global config
 		if isNamespaceConfig && globalConfig != nil {
 			// Priority 1: Use global failedHistoryLimit if set
 			if globalConfig.FailedHistoryLimit != nil {
 				if *config.FailedHistoryLimit > *globalConfig.FailedHistoryLimit {
-					return fmt.Errorf("%s: failedHistoryLimit (%d) cannot exceed global limit (%d)",
-						path, *config.FailedHistoryLimit, *globalConfig.FailedHistoryLimit)
+					return fmt.Errorf("%s: failedHistoryLimit (%d) %w global limit (%d)",
+						path, *config.FailedHistoryLimit, ErrExceedsLimit, *globalConfig.FailedHistoryLimit)
 				}
 			} else if globalConfig.HistoryLimit != nil {
 				// Priority 2: Use global historyLimit as fallback if no granular limit
 				if *config.FailedHistoryLimit > *globalConfig.HistoryLimit {
-					return fmt.Errorf("%s: failedHistoryLimit (%d) cannot exceed global historyLimit (%d)",
-						path, *config.FailedHistoryLimit, *globalConfig.HistoryLimit)
+					return fmt.Errorf("%s: failedHistoryLimit (%d) %w global historyLimit (%d)",
+						path, *config.FailedHistoryLimit, ErrExceedsLimit, *globalConfig.HistoryLimit)
 				}
 			} else {
 				// Priority 3: Use system maximum if global config exists but has no relevant limits
 				if *config.FailedHistoryLimit > MaxHistoryLimit {
-					return fmt.Errorf("%s: failedHistoryLimit (%d) cannot exceed system maximum (%d)",
-						path, *config.FailedHistoryLimit, MaxHistoryLimit)
+					return fmt.Errorf("%s: failedHistoryLimit (%d) %w system maximum (%d)",
+						path, *config.FailedHistoryLimit, ErrExceedsLimit, MaxHistoryLimit)
 				}
 			}
 		} else if isNamespaceConfig && globalConfig == nil {
 			// Priority 3: Use system maximum if no global config at all
 			if *config.FailedHistoryLimit > MaxHistoryLimit {
-				return fmt.Errorf("%s: failedHistoryLimit (%d) cannot exceed system maximum (%d)",
-					path, *config.FailedHistoryLimit, MaxHistoryLimit)
+				return fmt.Errorf("%s: failedHistoryLimit (%d) %w system maximum (%d)",
+					path, *config.FailedHistoryLimit, ErrExceedsLimit, MaxHistoryLimit)
 			}
 		}
 	}
@@ -1018,19 +2393,19 @@ func validatePrunerConfig(config *PrunerConfig, path string, globalConfig *Prune
 	// Validate HistoryLimit
 	if config.HistoryLimit != nil {
 		if *config.HistoryLimit < 0 {
-			return fmt.Errorf("%s: historyLimit cannot be negative, got %d", path, *config.HistoryLimit)
+			return fmt.Errorf("%s: historyLimit %w, got %d", path, ErrNegativeValue, *config.HistoryLimit)
 		}
 		// For namespace configs, validate against global historyLimit
 		if isNamespaceConfig && globalConfig != nil && globalConfig.HistoryLimit != nil {
 			if *config.HistoryLimit > *globalConfig.HistoryLimit {
-				return fmt.Errorf("%s: historyLimit (%d) cannot exceed global limit (%d)",
-					path, *config.HistoryLimit, *globalConfig.HistoryLimit)
+				return fmt.Errorf("%s: historyLimit (%d) %w global limit (%d)",
+					path, *config.HistoryLimit, ErrExceedsLimit, *globalConfig.HistoryLimit)
 			}
 		} else if isNamespaceConfig && (globalConfig == nil || globalConfig.HistoryLimit == nil) {
 			// Use system maximum if no global historyLimit is set
 			if *config.HistoryLimit > MaxHistoryLimit {
-				return fmt.Errorf("%s: historyLimit (%d) cannot exceed system maximum (%d)",
-					path, *config.HistoryLimit, MaxHistoryLimit)
+				return fmt.Errorf("%s: historyLimit (%d) %w system maximum (%d)",
+					path, *config.HistoryLimit, ErrExceedsLimit, MaxHistoryLimit)
 			}
 		}
 	}
@@ -1062,6 +2437,90 @@ func validateSelectorLimits(nsConfig *NamespaceSpec, globalConfig *PrunerConfig,
 	return nil
 }
 
+// ConfigMapWarnings returns the warnings about a ConfigMap which is valid, but uses deprecated fields
+// or is likely not to do what is intended
+func ConfigMapWarnings(cm *corev1.ConfigMap) []string {
+	var warnings []string
+	collectDeprecationWarnings := func(path string, pc *PrunerConfig) {
+		warnings = append(warnings, deprecationWarnings(path, pc)...)
+		warnings = append(warnings, zeroHistoryLimitWarnings(path, pc)...)
+	}
+	// the configs are unmarshalled as written, before the deprecated fields apply to their replacements
+	if data := cm.Data[PrunerGlobalConfigKey]; data != "" {
+		globalConfig := &GlobalConfig{}
+		if err := yaml.Unmarshal([]byte(data), globalConfig); err == nil {
+			globalConfig.forEachPrunerConfig("global-config", collectDeprecationWarnings)
+		}
+	}
+	if data := cm.Data[PrunerNamespaceConfigKey]; data != "" {
+		namespaceConfig := &NamespaceSpec{}
+		if err := yaml.Unmarshal([]byte(data), namespaceConfig); err == nil {
+			namespaceConfig.forEachPrunerConfig("ns-config", collectDeprecationWarnings)
+			warnings = append(warnings, selectorOverlapWarnings(namespaceConfig, "ns-config")...)
+		}
+	}
+	return warnings
+}
+
+// zeroHistoryLimitWarnings returns a warning for every history limit of a PrunerConfig set to 0 while no TTL is set.
+// A limit of 0 prunes every completed run of its category right away, which is often mistaken for an unset limit
+func zeroHistoryLimitWarnings(path string, pc *PrunerConfig) []string {
+	if pc.TTLSecondsAfterFinished != nil {
+		return nil
+	}
+	// the deprecated fields count as their replacements, without changing the config
+	applied := *pc
+	applyDeprecatedFields(&applied)
+
+	var warnings []string
+	for _, limit := range []struct {
+		name  string
+		value *int32
+		runs  string
+	}{
+		{name: "successfulHistoryLimit", value: applied.SuccessfulHistoryLimit, runs: "successful runs"},
+		{name: "failedHistoryLimit", value: applied.FailedHistoryLimit, runs: "failed runs"},
+		{name: "historyLimit", value: applied.HistoryLimit, runs: "completed runs"},
+	} {
+		if limit.value != nil && *limit.value == 0 {
+			warnings = append(warnings, fmt.Sprintf("%s.%s is 0 and ttlSecondsAfterFinished is not set: a limit of 0 deletes all %s as soon as they complete, "+
+				"while leaving the limit unset disables history-based pruning of them", path, limit.name, limit.runs))
+		}
+	}
+	return warnings
+}
+
+// selectorOverlapWarnings returns a warning for every selector whose matchLabels are identical to the ones of an earlier selector
+// of the same resource type and priority. A run matching both always gets the config of the first matching entry, the later one never applies to it
+func selectorOverlapWarnings(nsConfig *NamespaceSpec, path string) []string {
+	var warnings []string
+	for resourceType, resources := range map[string][]ResourceSpec{"pipelineRuns": nsConfig.PipelineRuns, "taskRuns": nsConfig.TaskRuns} {
+		type selectorRef struct {
+			path     string
+			labels   map[string]string
+			priority int32
+		}
+		var seen []selectorRef
+		for i, resource := range resources {
+			for j, selector := range resource.Selector {
+				selectorPath := fmt.Sprintf("%s[%d].selector[%d]", resourceType, i, j)
+				if len(selector.MatchLabels) == 0 {
+					continue
+				}
+				for _, earlier := range seen {
+					if earlier.priority == ptr.Int32Value(resource.Priority) && maps.Equal(earlier.labels, selector.MatchLabels) {
+						warnings = append(warnings, fmt.Sprintf("%s.%s: matchLabels are identical to %s, the first matching entry wins", path, selectorPath, earlier.path))
+						break
+					}
+				}
+				seen = append(seen, selectorRef{path: selectorPath, labels: selector.MatchLabels, priority: ptr.Int32Value(resource.Priority)})
+			}
+		}
+	}
+	slices.Sort(warnings)
+	return warnings
+}
+
 // validateResourceSelectorLimits validates selector limits for a specific resource type (PipelineRuns or TaskRuns)
 func validateResourceSelectorLimits(resources []ResourceSpec, nsConfig *PrunerConfig, globalConfig *PrunerConfig, globalNsSpec *NamespaceSpec, namespace, resourceType string) error {
 	if len(resources) == 0 {
@@ -1087,13 +2546,19 @@ func validateResourceSelectorLimits(resources []ResourceSpec, nsConfig *PrunerCo
 
 		// Validate individual selector limits are non-negative
 		if resource.SuccessfulHistoryLimit != nil && *resource.SuccessfulHistoryLimit < 0 {
-			return fmt.Errorf("ns-config.%s[%d]: successfulHistoryLimit cannot be negative, got %d", resourceType, i, *resource.SuccessfulHistoryLimit)
+			return fmt.Errorf("ns-config.%s[%d]: successfulHistoryLimit %w, got %d", resourceType, i, ErrNegativeValue, *resource.SuccessfulHistoryLimit)
 		}
 		if resource.FailedHistoryLimit != nil && *resource.FailedHistoryLimit < 0 {
-			return fmt.Errorf("ns-config.%s[%d]: failedHistoryLimit cannot be negative, got %d", resourceType, i, *resource.FailedHistoryLimit)
+			return fmt.Errorf("ns-config.%s[%d]: failedHistoryLimit %w, got %d", resourceType, i, ErrNegativeValue, *resource.FailedHistoryLimit)
 		}
 		if resource.HistoryLimit != nil && *resource.HistoryLimit < 0 {
-			return fmt.Errorf("ns-config.%s[%d]: historyLimit cannot be negative, got %d", resourceType, i, *resource.HistoryLimit)
+			return fmt.Errorf("ns-config.%s[%d]: historyLimit %w, got %d", resourceType, i, ErrNegativeValue, *resource.HistoryLimit)
+		}
+		if resource.Priority != nil && *resource.Priority < 0 {
+			return fmt.Errorf("ns-config.%s[%d]: priority %w, got %d", resourceType, i, ErrNegativeValue, *resource.Priority)
+		}
+		if resource.Priority != nil && len(resource.Selector) == 0 {
+			return fmt.Errorf("ns-config.%s[%d]: priority only orders the entries with a selector", resourceType, i)
 		}
 	}
 
@@ -1102,8 +2567,8 @@ func validateResourceSelectorLimits(resources []ResourceSpec, nsConfig *PrunerCo
 		upperBound := determineUpperBound(nsConfig.SuccessfulHistoryLimit, nsConfig.HistoryLimit,
 			globalNsSpec, globalConfig, "successfulHistoryLimit")
 		if sumSuccessful > upperBound {
-			return fmt.Errorf("namespace '%s' ns-config.%s: sum of selector successfulHistoryLimit (%d) cannot exceed upper bound (%d)",
-				namespace, resourceType, sumSuccessful, upperBound)
+			return fmt.Errorf("namespace '%s' ns-config.%s: sum of selector successfulHistoryLimit (%d) %w upper bound (%d)",
+				namespace, resourceType, sumSuccessful, ErrExceedsLimit, upperBound)
 		}
 	}
 
@@ -1112,8 +2577,8 @@ func validateResourceSelectorLimits(resources []ResourceSpec, nsConfig *PrunerCo
 		upperBound := determineUpperBound(nsConfig.FailedHistoryLimit, nsConfig.HistoryLimit,
 			globalNsSpec, globalConfig, "failedHistoryLimit")
 		if sumFailed > upperBound {
-			return fmt.Errorf("namespace '%s' ns-config.%s: sum of selector failedHistoryLimit (%d) cannot exceed upper bound (%d)",
-				namespace, resourceType, sumFailed, upperBound)
+			return fmt.Errorf("namespace '%s' ns-config.%s: sum of selector failedHistoryLimit (%d) %w upper bound (%d)",
+				namespace, resourceType, sumFailed, ErrExceedsLimit, upperBound)
 		}
 	}
 
@@ -1122,8 +2587,8 @@ func validateResourceSelectorLimits(resources []ResourceSpec, nsConfig *PrunerCo
 		upperBound := determineUpperBound(nsConfig.HistoryLimit, nil,
 			globalNsSpec, globalConfig, "historyLimit")
 		if sumHistory > upperBound {
-			return fmt.Errorf("namespace '%s' ns-config.%s: sum of selector historyLimit (%d) cannot exceed upper bound (%d)",
-				namespace, resourceType, sumHistory, upperBound)
+			return fmt.Errorf("namespace '%s' ns-config.%s: sum of selector historyLimit (%d) %w upper bound (%d)",
+				namespace, resourceType, sumHistory, ErrExceedsLimit, upperBound)
 		}
 	}
 
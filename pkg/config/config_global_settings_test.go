@@ -0,0 +1,523 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestValidateGlobalSettings verifies validation of the settings available only on global config.
+func TestValidateGlobalSettings(t *testing.T) {
+	tests := []struct {
+		name       string
+		configData string
+		wantErrMsg string
+	}{
+		{
+			name: "metrics namespace aggregation with pattern",
+			configData: `
+metricsNamespaceAggregation: aggregate
+metricsAggregatedNamespacePattern: "^pr-[0-9]+$"`,
+		},
+		{
+			name:       "metrics namespace aggregation none",
+			configData: `metricsNamespaceAggregation: none`,
+		},
+		{
+			name:       "invalid metrics namespace aggregation",
+			configData: `metricsNamespaceAggregation: collapse`,
+			wantErrMsg: "invalid metricsNamespaceAggregation",
+		},
+		{
+			name:       "metrics namespace aggregation without pattern",
+			configData: `metricsNamespaceAggregation: aggregate`,
+			wantErrMsg: "metricsAggregatedNamespacePattern is required",
+		},
+		{
+			name: "invalid metrics aggregated namespace pattern",
+			configData: `
+metricsNamespaceAggregation: aggregate
+metricsAggregatedNamespacePattern: "pr-[0-9"`,
+			wantErrMsg: "invalid metricsAggregatedNamespacePattern",
+		},
+		{
+			name:       "recognized successful reasons",
+			configData: `successfulReasons: {pipelineRuns: [Succeeded, Completed], taskRuns: [Succeeded, FailureIgnored]}`,
+		},
+		{
+			name:       "unrecognized successful reason",
+			configData: `successfulReasons: {pipelineRuns: [Succeeded, Done]}`,
+			wantErrMsg: "successfulReasons.pipelineRuns[1]: unrecognized reason 'Done'",
+		},
+		{
+			name:       "TaskRun reason listed for PipelineRuns",
+			configData: `successfulReasons: {pipelineRuns: [FailureIgnored]}`,
+			wantErrMsg: "successfulReasons.pipelineRuns[0]: unrecognized reason 'FailureIgnored'",
+		},
+		{
+			name:       "max completed runs per namespace",
+			configData: `maxCompletedRunsPerNamespace: 100`,
+		},
+		{
+			name:       "negative max completed runs per namespace",
+			configData: `maxCompletedRunsPerNamespace: -1`,
+			wantErrMsg: "maxCompletedRunsPerNamespace cannot be negative",
+		},
+		{
+			name:       "namespace object budget",
+			configData: `namespaceObjectBudget: 500`,
+		},
+		{
+			name:       "negative namespace object budget",
+			configData: `namespaceObjectBudget: -1`,
+			wantErrMsg: "namespaceObjectBudget cannot be negative",
+		},
+		{
+			name: "retain days",
+			configData: `retainDays: 30
+retainDaysTimeZone: Europe/Paris`,
+		},
+		{
+			name:       "zero retain days",
+			configData: `retainDays: 0`,
+			wantErrMsg: "retainDays must be positive",
+		},
+		{
+			name:       "too many retain days",
+			configData: `retainDays: 30000`,
+			wantErrMsg: "retainDays cannot exceed 24855",
+		},
+		{
+			name: "retain days with a TTL",
+			configData: `retainDays: 30
+ttlSecondsAfterFinished: 3600`,
+			wantErrMsg: "retainDays and ttlSecondsAfterFinished are mutually exclusive",
+		},
+		{
+			name:       "invalid retain days time zone",
+			configData: `retainDaysTimeZone: Mars/Olympus_Mons`,
+			wantErrMsg: "invalid retainDaysTimeZone 'Mars/Olympus_Mons'",
+		},
+		{
+			name:       "fallback TTL",
+			configData: `fallbackTTLSecondsAfterFinished: 86400`,
+		},
+		{
+			name:       "negative fallback TTL",
+			configData: `fallbackTTLSecondsAfterFinished: -1`,
+			wantErrMsg: "fallbackTTLSecondsAfterFinished cannot be negative",
+		},
+		{
+			name:       "TTL of the runs without results",
+			configData: `ttlNoResultsSeconds: 600`,
+		},
+		{
+			name:       "negative TTL of the runs without results",
+			configData: `ttlNoResultsSeconds: -1`,
+			wantErrMsg: "ttlNoResultsSeconds cannot be negative",
+		},
+		{
+			name:       "run label keys",
+			configData: `pipelineRunLabelKeys: [tekton.dev/pipeline, tekton.dev/pipelineRun]`,
+		},
+		{
+			name:       "empty task run label key",
+			configData: `taskRunLabelKeys: [tekton.dev/task, ""]`,
+			wantErrMsg: "taskRunLabelKeys[1]: label key cannot be empty",
+		},
+		{
+			name: "annotate deletion mode",
+			configData: `
+deletionMode: annotate
+excludePrunableFromHistory: true`,
+		},
+		{
+			name:       "invalid deletion mode",
+			configData: `deletionMode: archive`,
+			wantErrMsg: "invalid deletionMode 'archive'",
+		},
+		{
+			name:       "processed annotation key",
+			configData: `processedAnnotationKey: example.com/history-processed`,
+		},
+		{
+			name:       "invalid processed annotation key",
+			configData: `processedAnnotationKey: "example.com/history processed"`,
+			wantErrMsg: "invalid processedAnnotationKey",
+		},
+		{
+			name:       "annotation allowlist",
+			configData: "annotationAllowlist: [pruner.tekton.dev/, example.com/history-processed]",
+		},
+		{
+			name:       "invalid annotation allowlist prefix",
+			configData: "annotationAllowlist: [Pruner_Tekton/]",
+			wantErrMsg: "invalid annotationAllowlist entry 'Pruner_Tekton/'",
+		},
+		{
+			name:       "invalid annotation allowlist key",
+			configData: `annotationAllowlist: ["example.com/history processed"]`,
+			wantErrMsg: "invalid annotationAllowlist entry",
+		},
+		{
+			name:       "prune large status bytes",
+			configData: `pruneLargeStatusBytes: 1048576`,
+		},
+		{
+			name:       "zero prune large status bytes",
+			configData: `pruneLargeStatusBytes: 0`,
+			wantErrMsg: "pruneLargeStatusBytes must be positive",
+		},
+		{
+			name: "protect if referenced by",
+			configData: `
+protectIfReferencedBy:
+  - apiVersion: triggers.tekton.dev/v1beta1
+    kind: EventListener
+    resource: eventlisteners`,
+		},
+		{
+			name: "protect if referenced by without resource",
+			configData: `
+protectIfReferencedBy:
+  - apiVersion: triggers.tekton.dev/v1beta1
+    kind: EventListener`,
+			wantErrMsg: "protectIfReferencedBy[0]: kind and resource are required",
+		},
+		{
+			name: "protect if referenced by the status",
+			configData: `
+protectIfReferencedBy:
+  - apiVersion: example.com/v1
+    kind: Workflow
+    resource: workflows
+    statusReferencePaths: [childReferences.name]`,
+		},
+		{
+			name: "protect if referenced by an empty status path",
+			configData: `
+protectIfReferencedBy:
+  - apiVersion: example.com/v1
+    kind: Workflow
+    resource: workflows
+    statusReferencePaths: [childReferences..name]`,
+			wantErrMsg: "protectIfReferencedBy[0]: invalid statusReferencePaths entry 'childReferences..name'",
+		},
+		{
+			name: "never prune",
+			configData: `
+neverPrune:
+  pipelineRuns: [prod-release]
+  taskRuns: [db-migrate]`,
+		},
+		{
+			name: "never prune with an empty name",
+			configData: `
+neverPrune:
+  pipelineRuns: [prod-release, ""]`,
+			wantErrMsg: "neverPrune.pipelineRuns[1]: name cannot be empty",
+		},
+		{
+			name: "never prune with an invalid name",
+			configData: `
+neverPrune:
+  taskRuns: ["db migrate"]`,
+			wantErrMsg: "neverPrune.taskRuns[0]: invalid name 'db migrate'",
+		},
+		{
+			name:       "skip runs with finalizers",
+			configData: `skipRunsWithFinalizers: ["chains.tekton.dev", "example.com/audit", "*"]`,
+		},
+		{
+			name:       "skip runs with an invalid finalizer",
+			configData: `skipRunsWithFinalizers: ["chains tekton"]`,
+			wantErrMsg: "skipRunsWithFinalizers[0]: invalid finalizer 'chains tekton'",
+		},
+		{
+			name:       "min successful to keep",
+			configData: `minSuccessfulToKeep: 3`,
+		},
+		{
+			name:       "zero min successful to keep",
+			configData: `minSuccessfulToKeep: 0`,
+			wantErrMsg: "minSuccessfulToKeep must be positive",
+		},
+		{
+			name:       "history limit eviction priority",
+			configData: `historyLimitEvictionPriority: failed`,
+		},
+		{
+			name:       "invalid history limit eviction priority",
+			configData: `historyLimitEvictionPriority: newest`,
+			wantErrMsg: "invalid historyLimitEvictionPriority 'newest'",
+		},
+		{
+			name:       "gc interval",
+			configData: `gcIntervalSeconds: 300`,
+		},
+		{
+			name:       "gc interval too short",
+			configData: `gcIntervalSeconds: 1`,
+			wantErrMsg: "gcIntervalSeconds must be between 30 and 86400, got 1",
+		},
+		{
+			name:       "gc interval too long",
+			configData: `gcIntervalSeconds: 86401`,
+			wantErrMsg: "gcIntervalSeconds must be between 30 and 86400, got 86401",
+		},
+		{
+			name:       "inter namespace delay",
+			configData: `interNamespaceDelayMillis: 200`,
+		},
+		{
+			name:       "negative inter namespace delay",
+			configData: `interNamespaceDelayMillis: -1`,
+			wantErrMsg: "interNamespaceDelayMillis must be between 0 and 60000, got -1",
+		},
+		{
+			name:       "inter namespace delay too long",
+			configData: `interNamespaceDelayMillis: 60001`,
+			wantErrMsg: "interNamespaceDelayMillis must be between 0 and 60000, got 60001",
+		},
+		{
+			name:       "freeze until",
+			configData: `freezeUntil: "2025-06-01T12:00:00Z"`,
+		},
+		{
+			name:       "freeze until with an invalid timestamp",
+			configData: `freezeUntil: "2025-06-01 12:00"`,
+			wantErrMsg: "invalid freezeUntil '2025-06-01 12:00', must be an RFC3339 timestamp",
+		},
+		{
+			name:       "namespace priority",
+			configData: `namespacePriority: [prod, staging]`,
+		},
+		{
+			name:       "namespace priority with an invalid namespace",
+			configData: `namespacePriority: [Prod]`,
+			wantErrMsg: "namespacePriority[0]: invalid namespace 'Prod'",
+		},
+		{
+			name:       "namespace priority with a duplicate namespace",
+			configData: `namespacePriority: [prod, staging, prod]`,
+			wantErrMsg: "namespacePriority[2]: duplicate namespace 'prod'",
+		},
+		{
+			name:       "ttl anchor annotation",
+			configData: `ttlAnchorAnnotation: example.com/results-published`,
+		},
+		{
+			name:       "invalid ttl anchor annotation",
+			configData: `ttlAnchorAnnotation: "results published"`,
+			wantErrMsg: "invalid ttlAnchorAnnotation 'results published'",
+		},
+		{
+			name:       "cancelled counts as ignored",
+			configData: `cancelledCountsAs: ignored`,
+		},
+		{
+			name:       "invalid cancelled counts as",
+			configData: `cancelledCountsAs: skipped`,
+			wantErrMsg: "invalid cancelledCountsAs 'skipped'",
+		},
+		{
+			name:       "combined ttl history policy",
+			configData: `ttlHistoryPolicy: combined`,
+		},
+		{
+			name:       "invalid ttl history policy",
+			configData: `ttlHistoryPolicy: both`,
+			wantErrMsg: "invalid ttlHistoryPolicy 'both'",
+		},
+		{
+			name:       "max concurrent deletions",
+			configData: `maxConcurrentDeletions: 10`,
+		},
+		{
+			name:       "zero max concurrent deletions",
+			configData: `maxConcurrentDeletions: 0`,
+			wantErrMsg: "maxConcurrentDeletions must be positive",
+		},
+		{
+			name: "auto worker count",
+			configData: `
+autoWorkerCount: true
+maxWorkerCount: 50`,
+		},
+		{
+			name:       "zero max worker count",
+			configData: `maxWorkerCount: 0`,
+			wantErrMsg: "maxWorkerCount must be positive",
+		},
+		{
+			name:       "history deletion batch size",
+			configData: `historyDeletionBatchSize: 20`,
+		},
+		{
+			name:       "zero history deletion batch size",
+			configData: `historyDeletionBatchSize: 0`,
+			wantErrMsg: "historyDeletionBatchSize must be positive",
+		},
+		{
+			name: "list retry",
+			configData: `listRetryAttempts: 5
+listRetryBackoffMilliseconds: 200`,
+		},
+		{
+			name:       "negative list retry attempts",
+			configData: `listRetryAttempts: -1`,
+			wantErrMsg: "listRetryAttempts cannot be negative",
+		},
+		{
+			name:       "zero list retry backoff",
+			configData: `listRetryBackoffMilliseconds: 0`,
+			wantErrMsg: "listRetryBackoffMilliseconds must be positive",
+		},
+		{
+			name:       "namespace exclude regexes",
+			configData: `namespaceExcludeRegexes: [".*-system$", "^monitoring-.*"]`,
+		},
+		{
+			name:       "invalid namespace exclude regex",
+			configData: `namespaceExcludeRegexes: [".*-system$", "monitoring-[0-9"]`,
+			wantErrMsg: "namespaceExcludeRegexes[1]: invalid regular expression 'monitoring-[0-9'",
+		},
+		{
+			name:       "ttl requeue ceiling",
+			configData: `ttlRequeueCeilingSeconds: 3600`,
+		},
+		{
+			name:       "negative ttl requeue ceiling",
+			configData: `ttlRequeueCeilingSeconds: -60`,
+			wantErrMsg: "ttlRequeueCeilingSeconds must be positive",
+		},
+		{
+			name: "ttl from start with abandoned after seconds",
+			configData: `
+ttlFrom: start
+abandonedAfterSeconds: 86400`,
+		},
+		{
+			name:       "invalid ttl from",
+			configData: `ttlFrom: creation`,
+			wantErrMsg: "invalid ttlFrom 'creation'",
+		},
+		{
+			name:       "abandoned after seconds without ttl from start",
+			configData: `abandonedAfterSeconds: 86400`,
+			wantErrMsg: "abandonedAfterSeconds requires ttlFrom to be start",
+		},
+		{
+			name:       "prune stuck after seconds",
+			configData: `pruneStuckAfterSeconds: 172800`,
+		},
+		{
+			name:       "prune stuck after seconds below the minimum",
+			configData: `pruneStuckAfterSeconds: 600`,
+			wantErrMsg: "pruneStuckAfterSeconds must be at least 3600",
+		},
+		{
+			name:       "absolute max age",
+			configData: `absoluteMaxAgeSeconds: 7776000`,
+		},
+		{
+			name:       "absolute max age below the minimum",
+			configData: `absoluteMaxAgeSeconds: 3600`,
+			wantErrMsg: "absoluteMaxAgeSeconds must be at least 86400",
+		},
+		{
+			name: "ephemeral namespace policy",
+			configData: `
+ephemeralNamespacePolicy:
+  namespaceSelector:
+    matchLabels:
+      preview: "true"
+  ttlSecondsAfterFinished: 300
+  deleteEmptyNamespace: true`,
+		},
+		{
+			name: "ephemeral namespace policy with an empty selector",
+			configData: `
+ephemeralNamespacePolicy:
+  ttlSecondsAfterFinished: 300`,
+			wantErrMsg: "ephemeralNamespacePolicy.namespaceSelector cannot be empty",
+		},
+		{
+			name: "safe mode",
+			configData: `
+safeMode:
+  maxTighteningFactor: 5
+  action: warn`,
+		},
+		{
+			name: "safe mode factor below the minimum",
+			configData: `
+safeMode:
+  maxTighteningFactor: 1`,
+			wantErrMsg: "safeMode.maxTighteningFactor must be at least 2",
+		},
+		{
+			name: "safe mode with an invalid action",
+			configData: `
+safeMode:
+  action: delete`,
+			wantErrMsg: "invalid safeMode.action 'delete'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: PrunerConfigMapName, Namespace: "tekton-pipelines"},
+				Data:       map[string]string{PrunerGlobalConfigKey: tt.configData},
+			}
+			err := ValidateConfigMap(cm)
+			if tt.wantErrMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErrMsg)
+		})
+	}
+}
+
+// TestLoadGlobalConfigMetricsNamespaceAggregation verifies the aggregation pattern is parsed on load.
+func TestLoadGlobalConfigMetricsNamespaceAggregation(t *testing.T) {
+	store := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+	cm := &corev1.ConfigMap{
+		Data: map[string]string{PrunerGlobalConfigKey: `
+metricsNamespaceAggregation: aggregate
+metricsAggregatedNamespacePattern: "^pr-[0-9]+$"`},
+	}
+	assert.NoError(t, store.LoadGlobalConfig(context.Background(), cm))
+
+	pattern, err := store.globalConfig.metricsAggregationPattern()
+	assert.NoError(t, err)
+	assert.True(t, pattern.MatchString("pr-42"))
+	assert.False(t, pattern.MatchString("production"))
+
+	cm.Data[PrunerGlobalConfigKey] = `ttlSecondsAfterFinished: 60`
+	assert.NoError(t, store.LoadGlobalConfig(context.Background(), cm))
+	pattern, err = store.globalConfig.metricsAggregationPattern()
+	assert.NoError(t, err)
+	assert.Nil(t, pattern)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package config
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -286,6 +287,137 @@ func TestSelectorMatching_ANDLogic(t *testing.T) {
 	}
 }
 
+// TestSelectorMatching_TriggerMetadata verifies that the labels and annotations added by Tekton Triggers
+// tell the runs created by a trigger apart from the runs started manually
+func TestSelectorMatching_TriggerMetadata(t *testing.T) {
+	ttl300 := int32(300)
+	ttl86400 := int32(86400)
+	namespaceSpec := map[string]NamespaceSpec{
+		"ci": {
+			PipelineRuns: []ResourceSpec{
+				{
+					Selector: []SelectorSpec{{MatchLabels: map[string]string{"triggers.tekton.dev/trigger": "nightly"}}},
+					PrunerConfig: PrunerConfig{
+						TTLSecondsAfterFinished: &ttl300,
+					},
+				},
+				{
+					Selector: []SelectorSpec{{MatchAnnotations: map[string]string{"example.com/event-type": "pull_request"}}},
+					PrunerConfig: PrunerConfig{
+						TTLSecondsAfterFinished: &ttl86400,
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		resource SelectorSpec
+		wantTTL  *int32
+	}{
+		{
+			name: "run created by the nightly trigger",
+			resource: SelectorSpec{MatchLabels: map[string]string{
+				"tekton.dev/pipeline":                  "build",
+				"triggers.tekton.dev/eventlistener":    "ci-listener",
+				"triggers.tekton.dev/trigger":          "nightly",
+				"triggers.tekton.dev/triggers-eventid": "4a7c0a5e",
+			}},
+			wantTTL: &ttl300,
+		},
+		{
+			name: "run created by another trigger",
+			resource: SelectorSpec{MatchLabels: map[string]string{
+				"tekton.dev/pipeline":         "build",
+				"triggers.tekton.dev/trigger": "on-push",
+			}},
+		},
+		{
+			name: "run created by a trigger annotating the event type",
+			resource: SelectorSpec{
+				MatchLabels:      map[string]string{"triggers.tekton.dev/trigger": "on-pull-request"},
+				MatchAnnotations: map[string]string{"example.com/event-type": "pull_request"},
+			},
+			wantTTL: &ttl86400,
+		},
+		{
+			name:     "run started manually",
+			resource: SelectorSpec{MatchLabels: map[string]string{"tekton.dev/pipeline": "build"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := getFromPrunerConfigResourceLevelwithSelector(
+				namespaceSpec,
+				"ci",
+				"",
+				tt.resource,
+				PrunerResourceTypePipelineRun,
+				PrunerFieldTypeTTLSecondsAfterFinished,
+			)
+			if !reflect.DeepEqual(result, tt.wantTTL) {
+				t.Errorf("expected TTL=%v, got %v", tt.wantTTL, result)
+			}
+		})
+	}
+}
+
+// TestSelectorSpec_Matches verifies the AND logic of a ConfigMap's selector against a resource
+func TestSelectorSpec_Matches(t *testing.T) {
+	selector := SelectorSpec{
+		MatchLabels:      map[string]string{"app": "myapp"},
+		MatchAnnotations: map[string]string{"version": "v1"},
+	}
+
+	tests := []struct {
+		name     string
+		selector SelectorSpec
+		resource SelectorSpec
+		want     bool
+	}{
+		{
+			name:     "both labels and annotations match",
+			selector: selector,
+			resource: SelectorSpec{
+				MatchLabels:      map[string]string{"app": "myapp", "team": "a"},
+				MatchAnnotations: map[string]string{"version": "v1"},
+			},
+			want: true,
+		},
+		{
+			name:     "label value differs",
+			selector: selector,
+			resource: SelectorSpec{
+				MatchLabels:      map[string]string{"app": "other"},
+				MatchAnnotations: map[string]string{"version": "v1"},
+			},
+			want: false,
+		},
+		{
+			name:     "resource has no annotations",
+			selector: selector,
+			resource: SelectorSpec{MatchLabels: map[string]string{"app": "myapp"}},
+			want:     false,
+		},
+		{
+			name:     "labels only selector",
+			selector: SelectorSpec{MatchLabels: map[string]string{"app": "myapp"}},
+			resource: SelectorSpec{MatchLabels: map[string]string{"app": "myapp"}},
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.selector.Matches(tt.resource); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // TestGetResourceFieldData_ConfigLevels verifies config level precedence
 func TestGetResourceFieldData_ConfigLevels(t *testing.T) {
 	ttl1800 := int32(1800)
@@ -355,3 +487,233 @@ func TestGetResourceFieldData_ConfigLevels(t *testing.T) {
 		})
 	}
 }
+
+// TestSelectorMatching_Overlapping verifies that the first matching entry wins when several selectors match a run
+func TestSelectorMatching_Overlapping(t *testing.T) {
+	ttl1800 := int32(1800)
+	ttl3600 := int32(3600)
+	ttl7200 := int32(7200)
+
+	namespaceSpec := map[string]NamespaceSpec{
+		"dev": {
+			PipelineRuns: []ResourceSpec{
+				{
+					Selector:     []SelectorSpec{{MatchLabels: map[string]string{"app": "myapp"}}},
+					PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: &ttl1800},
+				},
+				{
+					Selector:     []SelectorSpec{{MatchLabels: map[string]string{"app": "myapp"}}},
+					PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: &ttl3600},
+				},
+				{
+					Selector:     []SelectorSpec{{MatchLabels: map[string]string{"tier": "backend"}}},
+					PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: &ttl7200},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		selector SelectorSpec
+		wantTTL  int32
+	}{
+		{
+			name:     "identical selectors",
+			selector: SelectorSpec{MatchLabels: map[string]string{"app": "myapp"}},
+			wantTTL:  1800,
+		},
+		{
+			name:     "different selectors matching the same run",
+			selector: SelectorSpec{MatchLabels: map[string]string{"app": "myapp", "tier": "backend"}},
+			wantTTL:  1800,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := getFromPrunerConfigResourceLevelwithSelector(namespaceSpec, "dev", "", tt.selector,
+				PrunerResourceTypePipelineRun, PrunerFieldTypeTTLSecondsAfterFinished)
+			if result == nil || *result != tt.wantTTL {
+				t.Errorf("expected TTL=%d, got %v", tt.wantTTL, result)
+			}
+			matching := getMatchingSelectorFromConfig(namespaceSpec, "dev", "", tt.selector, PrunerResourceTypePipelineRun)
+			if matching == nil || !reflect.DeepEqual(matching.MatchLabels, map[string]string{"app": "myapp"}) {
+				t.Errorf("expected the first selector to match, got %v", matching)
+			}
+		})
+	}
+}
+
+// TestSelectorMatching_Priority verifies that the highest priority entry wins among the ones whose selectors match a run,
+// and that entries of the same priority apply in declaration order
+func TestSelectorMatching_Priority(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+	team := SelectorSpec{MatchLabels: map[string]string{"team": "a"}}
+	prod := SelectorSpec{MatchLabels: map[string]string{"env": "prod"}}
+	release := SelectorSpec{MatchAnnotations: map[string]string{"release": "true"}}
+
+	tests := []struct {
+		name          string
+		resourceSpecs []ResourceSpec
+		selector      SelectorSpec
+		wantTTL       int32
+		wantSelector  SelectorSpec
+		wantLevel     EnforcedConfigLevel
+	}{
+		{
+			name: "without priorities the first match wins",
+			resourceSpecs: []ResourceSpec{
+				{Selector: []SelectorSpec{team}, PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: int32Ptr(100)}},
+				{Selector: []SelectorSpec{prod}, PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: int32Ptr(200)}},
+			},
+			selector:     SelectorSpec{MatchLabels: map[string]string{"team": "a", "env": "prod"}},
+			wantTTL:      100,
+			wantSelector: team,
+		},
+		{
+			name: "a later entry of higher priority wins",
+			resourceSpecs: []ResourceSpec{
+				{Selector: []SelectorSpec{team}, PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: int32Ptr(100)}},
+				{Selector: []SelectorSpec{prod}, PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: int32Ptr(200)}, Priority: int32Ptr(10)},
+			},
+			selector:     SelectorSpec{MatchLabels: map[string]string{"team": "a", "env": "prod"}},
+			wantTTL:      200,
+			wantSelector: prod,
+		},
+		{
+			name: "the highest of several priorities wins",
+			resourceSpecs: []ResourceSpec{
+				{Selector: []SelectorSpec{team}, PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: int32Ptr(100)}, Priority: int32Ptr(5)},
+				{Selector: []SelectorSpec{release}, PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: int32Ptr(300)}, Priority: int32Ptr(20)},
+				{Selector: []SelectorSpec{prod}, PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: int32Ptr(200)}, Priority: int32Ptr(10)},
+			},
+			selector: SelectorSpec{
+				MatchLabels:      map[string]string{"team": "a", "env": "prod"},
+				MatchAnnotations: map[string]string{"release": "true"},
+			},
+			wantTTL:      300,
+			wantSelector: release,
+		},
+		{
+			name: "a higher priority entry which does not match is skipped",
+			resourceSpecs: []ResourceSpec{
+				{Selector: []SelectorSpec{team}, PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: int32Ptr(100)}},
+				{Selector: []SelectorSpec{release}, PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: int32Ptr(300)}, Priority: int32Ptr(20)},
+			},
+			selector:     SelectorSpec{MatchLabels: map[string]string{"team": "a"}},
+			wantTTL:      100,
+			wantSelector: team,
+		},
+		{
+			name: "ties fall back to declaration order",
+			resourceSpecs: []ResourceSpec{
+				{Selector: []SelectorSpec{team}, PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: int32Ptr(100)}, Priority: int32Ptr(10)},
+				{Selector: []SelectorSpec{prod}, PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: int32Ptr(200)}, Priority: int32Ptr(10)},
+			},
+			selector:     SelectorSpec{MatchLabels: map[string]string{"team": "a", "env": "prod"}},
+			wantTTL:      100,
+			wantSelector: team,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespaceSpec := map[string]NamespaceSpec{"dev": {PipelineRuns: tt.resourceSpecs}}
+			result, identifiedBy := getFromPrunerConfigResourceLevelwithSelector(namespaceSpec, "dev", "", tt.selector,
+				PrunerResourceTypePipelineRun, PrunerFieldTypeTTLSecondsAfterFinished)
+			if result == nil || *result != tt.wantTTL || identifiedBy != "identifiedBy_resource_selector" {
+				t.Errorf("expected TTL=%d identified by the selector, got %v %s", tt.wantTTL, result, identifiedBy)
+			}
+			matching := getMatchingSelectorFromConfig(namespaceSpec, "dev", "", tt.selector, PrunerResourceTypePipelineRun)
+			if matching == nil || !reflect.DeepEqual(*matching, tt.wantSelector) {
+				t.Errorf("expected the selector %v to match, got %v", tt.wantSelector, matching)
+			}
+		})
+	}
+}
+
+// TestConfigMapWarnings verifies that selectors with identical matchLabels are reported
+func TestConfigMapWarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     map[string]string
+		expected []string
+	}{
+		{
+			name: "identical matchLabels",
+			data: map[string]string{PrunerNamespaceConfigKey: `
+pipelineRuns:
+  - selector:
+      - matchLabels: {app: myapp, env: dev}
+    ttlSecondsAfterFinished: 1800
+  - selector:
+      - matchLabels: {env: dev, app: myapp}
+    ttlSecondsAfterFinished: 3600
+taskRuns:
+  - selector:
+      - matchLabels: {app: myapp}
+      - matchLabels: {app: myapp}
+    ttlSecondsAfterFinished: 1800
+`},
+			expected: []string{
+				"ns-config.pipelineRuns[1].selector[0]: matchLabels are identical to pipelineRuns[0].selector[0], the first matching entry wins",
+				"ns-config.taskRuns[0].selector[1]: matchLabels are identical to taskRuns[0].selector[0], the first matching entry wins",
+			},
+		},
+		{
+			name: "identical matchLabels of different priorities",
+			data: map[string]string{PrunerNamespaceConfigKey: `
+pipelineRuns:
+  - selector:
+      - matchLabels: {app: myapp}
+    ttlSecondsAfterFinished: 1800
+  - selector:
+      - matchLabels: {app: myapp}
+    ttlSecondsAfterFinished: 3600
+    priority: 10
+`},
+		},
+		{
+			name: "distinct matchLabels",
+			data: map[string]string{PrunerNamespaceConfigKey: `
+pipelineRuns:
+  - selector:
+      - matchLabels: {app: myapp}
+    ttlSecondsAfterFinished: 1800
+  - selector:
+      - matchLabels: {app: other}
+    ttlSecondsAfterFinished: 3600
+`},
+		},
+		{
+			name: "pipelineRuns and taskRuns are independent",
+			data: map[string]string{PrunerNamespaceConfigKey: `
+pipelineRuns:
+  - selector:
+      - matchLabels: {app: myapp}
+    ttlSecondsAfterFinished: 1800
+taskRuns:
+  - selector:
+      - matchLabels: {app: myapp}
+    ttlSecondsAfterFinished: 3600
+`},
+		},
+		{
+			name: "global config",
+			data: map[string]string{PrunerGlobalConfigKey: `ttlSecondsAfterFinished: 3600`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{Data: tt.data}
+			if err := ValidateConfigMap(cm); err != nil {
+				t.Fatalf("ValidateConfigMap() error = %v", err)
+			}
+			if got := ConfigMapWarnings(cm); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ConfigMapWarnings() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package config
 
 import (
+	"slices"
 	"strings"
 	"testing"
 
@@ -205,6 +206,36 @@ func TestValidateConfigMap_InvalidNamespaceConfig(t *testing.T) {
 			config:     `bad yaml: [[[`,
 			wantErrMsg: "failed to parse ns-config",
 		},
+		{
+			name:       "per label value history limit with an invalid label",
+			config:     `perLabelValueHistoryLimit: {label: "team name", limit: 5}`,
+			wantErrMsg: "ns-config: perLabelValueHistoryLimit: invalid label 'team name'",
+		},
+		{
+			name:       "per label value history limit without limit",
+			config:     `perLabelValueHistoryLimit: {label: team}`,
+			wantErrMsg: "ns-config: perLabelValueHistoryLimit: limit is required",
+		},
+		{
+			name:       "negative per label value history limit",
+			config:     `perLabelValueHistoryLimit: {label: team, limit: -1}`,
+			wantErrMsg: "ns-config: perLabelValueHistoryLimit: limit cannot be negative",
+		},
+		{
+			name: "negative selector priority",
+			config: `pipelineRuns:
+  - selector:
+      - matchLabels: {app: myapp}
+    priority: -1`,
+			wantErrMsg: "ns-config.pipelineRuns[0]: priority cannot be negative",
+		},
+		{
+			name: "priority without selector",
+			config: `taskRuns:
+  - name: build
+    priority: 10`,
+			wantErrMsg: "ns-config.taskRuns[0]: priority only orders the entries with a selector",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1017,3 +1048,53 @@ func TestValidateNamespaceSpec(t *testing.T) {
 		})
 	}
 }
+
+// TestConfigMapWarningsZeroHistoryLimits verifies that history limits of 0 without a TTL come with a warning
+// explaining their effect
+func TestConfigMapWarningsZeroHistoryLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     map[string]string
+		expected []string
+	}{
+		{
+			name: "zero limits without TTL",
+			data: map[string]string{PrunerGlobalConfigKey: `
+successfulHistoryLimit: 0
+failedHistoryLimit: 3`},
+			expected: []string{
+				"global-config.successfulHistoryLimit is 0 and ttlSecondsAfterFinished is not set: a limit of 0 deletes all successful runs as soon as they complete, " +
+					"while leaving the limit unset disables history-based pruning of them",
+			},
+		},
+		{
+			name: "zero limit with TTL",
+			data: map[string]string{PrunerGlobalConfigKey: `
+ttlSecondsAfterFinished: 3600
+failedHistoryLimit: 0`},
+		},
+		{
+			name: "zero limit of a selector",
+			data: map[string]string{PrunerNamespaceConfigKey: `
+pipelineRuns:
+  - name: build
+    historyLimit: 0`},
+			expected: []string{
+				"ns-config.pipelineRuns[0].historyLimit is 0 and ttlSecondsAfterFinished is not set: a limit of 0 deletes all completed runs as soon as they complete, " +
+					"while leaving the limit unset disables history-based pruning of them",
+			},
+		},
+		{
+			name: "unset limits",
+			data: map[string]string{PrunerGlobalConfigKey: `enforcedConfigLevel: global`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConfigMapWarnings(&corev1.ConfigMap{Data: tt.data}); !slices.Equal(got, tt.expected) {
+				t.Errorf("ConfigMapWarnings() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package config
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"strconv"
 )
@@ -35,6 +36,10 @@ const (
 	// used to specify the count of concurrent workers in action to prune taskruns
 	EnvTTLConcurrentWorkersTaskRun = "TTL_CONCURRENT_WORKERS_TASK_RUN"
 
+	// EnvFieldManager is the environment variable name used to specify
+	// the field manager recorded on the patches and updates made by the pruner
+	EnvFieldManager = "PRUNER_FIELD_MANAGER"
+
 	// LabelPipelineName represents the label key in a pipeline run's metadata,
 	// where its value corresponds to the name of the pipeline
 	LabelPipelineName = "tekton.dev/pipeline"
@@ -43,6 +48,10 @@ const (
 	// where its value corresponds to the name of the pipeline run
 	LabelPipelineRunName = "tekton.dev/pipelineRun"
 
+	// LabelPipelineTaskName represents the label key in a task run's metadata,
+	// where its value corresponds to the name of the pipeline task which created it, matrix fan-outs included
+	LabelPipelineTaskName = "tekton.dev/pipelineTask"
+
 	// LabelTaskName represents the label key in a task run's metadata,
 	// where its value corresponds to the name of the task
 	LabelTaskName = "tekton.dev/task"
@@ -73,10 +82,69 @@ const (
 	// that stores the failedHistoryLimit value for the resource.
 	AnnotationFailedHistoryLimit = "pruner.tekton.dev/failedHistoryLimit"
 
+	// AnnotationHistoryExempt represents the annotation key
+	// that exempts a resource from history-based pruning when set to "true", if the enforced config level is resource.
+	AnnotationHistoryExempt = "pruner.tekton.dev/history-exempt"
+
 	// AnnotationHistoryLimitCheckProcessed represents the annotation key
 	// that indicates whether history limit checks have been processed for the resource.
 	AnnotationHistoryLimitCheckProcessed = "pruner.tekton.dev/historyLimitCheckProcessed"
 
+	// AnnotationDeleteAfter represents the annotation key
+	// that stores an absolute RFC 3339 deadline after which the resource can be removed.
+	// When a resource also has a TTL, the absolute deadline takes precedence over the TTL, if the enforced config level is resource.
+	AnnotationDeleteAfter = "pruner.tekton.dev/deleteAfter"
+
+	// AnnotationExtendTTLUntil represents the annotation key
+	// that stores an RFC 3339 time until which the TTL of the resource is extended, if the enforced config level is resource.
+	AnnotationExtendTTLUntil = "pruner.tekton.dev/extend-ttl-until"
+
+	// AnnotationRetainDays represents the annotation key
+	// that stores the retainDays value for the resource, set along with its TTL annotation when the TTL comes from retainDays.
+	AnnotationRetainDays = "pruner.tekton.dev/retainDays"
+
+	// AnnotationPrunable represents the annotation key
+	// that marks a resource as selected for pruning when the deletion mode is annotate.
+	AnnotationPrunable = "pruner.tekton.dev/prunable"
+
+	// AnnotationPrunableReason represents the annotation key
+	// that stores why a resource was marked as prunable.
+	AnnotationPrunableReason = "pruner.tekton.dev/prunableReason"
+
+	// AnnotationConfigLoaded represents the annotation key
+	// that stores when the controller last loaded the config of a namespace ConfigMap.
+	AnnotationConfigLoaded = "pruner.tekton.dev/loaded"
+
+	// AnnotationConfigLoadError represents the annotation key
+	// that stores why the controller could not load the latest config of a namespace ConfigMap.
+	AnnotationConfigLoadError = "pruner.tekton.dev/loadError"
+
+	// AnnotationHeldRuns represents the annotation key of an ephemeral namespace
+	// that records when the pruner first found runs in it, its value is an RFC 3339 time.
+	// Only a namespace which held runs before can be deleted once empty.
+	AnnotationHeldRuns = "pruner.tekton.dev/heldRuns"
+
+	// AnnotationAcknowledgeConfig represents the annotation key of the global ConfigMap
+	// that acknowledges a config tightened beyond safe mode, its value is the digest of the acknowledged config.
+	AnnotationAcknowledgeConfig = "pruner.tekton.dev/acknowledgeConfig"
+
+	// AnnotationPromoteConfig represents the annotation key of the global ConfigMap
+	// that promotes a config tested in the canaryNamespace to every namespace, its value is the digest of the promoted config.
+	AnnotationPromoteConfig = "pruner.tekton.dev/promoteConfig"
+
+	// PrunableReasonTTL, PrunableReasonHistoryLimit, PrunableReasonNamespaceCap, PrunableReasonNamespaceBudget,
+	// PrunableReasonLargeStatus, PrunableReasonStuck and PrunableReasonOwner are the values of the prunable reason annotation,
+	// see PruneReason.PrunableAnnotationValue
+	PrunableReasonTTL                  = "ttlExpired"
+	PrunableReasonHistoryLimit         = "historyLimitExceeded"
+	PrunableReasonNamespaceCap         = "namespaceCapExceeded"
+	PrunableReasonNamespaceBudget      = "namespaceBudgetExceeded"
+	PrunableReasonLargeStatus          = "largeStatus"
+	PrunableReasonStuck                = "stuck"
+	PrunableReasonOwner                = "ownerPruned"
+	PrunableReasonMaxAge               = "maxAgeExceeded"
+	PrunableReasonTerminatingNamespace = "namespaceTerminating"
+
 	// PrunerConfigMapName represents the name of the config map
 	// that holds the cluster-wide pruner configuration data
 	PrunerConfigMapName = "tekton-pruner-default-spec"
@@ -85,6 +153,18 @@ const (
 	// that holds the namespace-level pruner configuration data
 	PrunerNamespaceConfigMapName = "tekton-pruner-namespace-spec"
 
+	// PrunerStateConfigMapName represents the name of the config map, written by the controller,
+	// that records the global configs accepted by safe mode and promoted from the canary namespace across restarts
+	PrunerStateConfigMapName = "tekton-pruner-state"
+
+	// PrunerStateAcceptedConfigKey represents the key name of the state config map
+	// holding the global config last accepted by safe mode
+	PrunerStateAcceptedConfigKey = "accepted-config"
+
+	// PrunerStatePromotedConfigKey represents the key name of the state config map
+	// holding the global config last applied to every namespace, rather than to the canary namespace only
+	PrunerStatePromotedConfigKey = "promoted-config"
+
 	// PrunerGlobalConfigKey represents the key name
 	// used to fetch the cluster-wide pruner configuration data
 	PrunerGlobalConfigKey = "global-config"
@@ -101,6 +181,9 @@ const (
 	// number of workers in the TaskRun controller
 	DefaultTTLConcurrentWorkersTaskRun = int(5)
 
+	// DefaultFieldManager represents the field manager of the patches and updates made by the pruner
+	DefaultFieldManager = "tekton-pruner"
+
 	// DefaultGCInterval represents
 	// interval in seconds for the periodic cleanup i.e garbage collector to run
 	DefaultPeriodicCleanupIntervalSeconds = 600 // 10 minutes
@@ -109,6 +192,53 @@ const (
 	// for cleaning up resources in a namespace concurrently
 	DefaultWorkerCountForNamespaceCleanup = 5
 
+	// NamespacesPerAutoWorker represents the number of namespaces handled by each worker
+	// when the worker count is sized after the number of namespaces
+	NamespacesPerAutoWorker = 10
+
+	// DefaultMaxWorkerCount represents the maximum number of workers
+	// when the worker count is sized after the number of namespaces
+	DefaultMaxWorkerCount = 20
+
+	// MinGCIntervalSeconds and MaxGCIntervalSeconds bound the interval
+	// of the periodic garbage collection, between 30 seconds and 24 hours
+	MinGCIntervalSeconds = 30
+	MaxGCIntervalSeconds = 24 * 60 * 60
+
+	// MaxInterNamespaceDelayMillis bounds the delay between the namespaces collected by a garbage collection worker, 1 minute
+	MaxInterNamespaceDelayMillis = 60 * 1000
+
+	// AnnotationFilterWarningThreshold is the number of runs a history limit selector filters by matchAnnotations in memory
+	// above which a warning suggests narrowing down the selector with matchLabels
+	AnnotationFilterWarningThreshold = 500
+
+	// MinPruneStuckAfterSeconds is the lowest pruneStuckAfterSeconds accepted,
+	// so that runs which are genuinely running are not pruned as stuck
+	MinPruneStuckAfterSeconds = 3600
+
+	// MinAbsoluteMaxAgeSeconds is the lowest absoluteMaxAgeSeconds accepted, 1 day,
+	// so that a typo does not prune every completed run of the cluster
+	MinAbsoluteMaxAgeSeconds = 24 * 60 * 60
+
+	// MaxRetainDays is the highest retainDays accepted,
+	// so that the TTL annotation holding retainDays in seconds does not overflow
+	MaxRetainDays = math.MaxInt32 / secondsPerDay
+
+	// secondsPerDay is the number of seconds of a day without a daylight saving time transition
+	secondsPerDay = 24 * 60 * 60
+
+	// DefaultListRetryAttempts represents the number of retries of a List call throttled by the API server
+	DefaultListRetryAttempts = 3
+
+	// DefaultListRetryBackoffMilliseconds represents the delay before the first retry of a throttled List call
+	DefaultListRetryBackoffMilliseconds = 500
+
+	// DefaultAPICallTimeoutSeconds represents the timeout of each API call made to prune a run
+	DefaultAPICallTimeoutSeconds = 60
+
+	// DefaultAnnotationPatchRetryAttempts represents the number of retries of a failed patch removing the processed annotation
+	DefaultAnnotationPatchRetryAttempts = 3
+
 	// DefaultHistoryLimit represents the default history limit for successful and failed resources
 	DefaultHistoryLimit = 100
 
@@ -139,3 +269,12 @@ func GetEnvValueAsInt(envKey string, defaultValue int) (int, error) {
 
 	return intValue, nil
 }
+
+// GetFieldManager returns the field manager to set on the patches and updates made by the pruner,
+// taken from the PRUNER_FIELD_MANAGER environment variable if set
+func GetFieldManager() string {
+	if fieldManager := os.Getenv(EnvFieldManager); fieldManager != "" {
+		return fieldManager
+	}
+	return DefaultFieldManager
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// deprecatedField is a field of a PrunerConfig which is still accepted, but replaced by another field.
+// The value of the deprecated field applies as the value of its replacement, unless the replacement is set too
+type deprecatedField struct {
+	name        string
+	replacement string
+	// isSet reports whether the deprecated field and its replacement are set
+	isSet func(pc *PrunerConfig) (deprecated, replacement bool)
+	// apply copies the value of the deprecated field to its replacement
+	apply func(pc *PrunerConfig)
+}
+
+// deprecatedFields lists the deprecated fields of a PrunerConfig, available at every level of the config
+var deprecatedFields = []deprecatedField{
+	{
+		// keep is the name of the history limit of the Tekton Operator job based pruner
+		name:        "keep",
+		replacement: "historyLimit",
+		isSet: func(pc *PrunerConfig) (bool, bool) {
+			return pc.Keep != nil, pc.HistoryLimit != nil
+		},
+		apply: func(pc *PrunerConfig) {
+			pc.HistoryLimit = pc.Keep
+		},
+	},
+}
+
+// parseGlobalConfig parses the global config of a ConfigMap, the deprecated fields applying to their replacements
+func parseGlobalConfig(data string) (*GlobalConfig, error) {
+	globalConfig := &GlobalConfig{}
+	if err := yaml.Unmarshal([]byte(data), globalConfig); err != nil {
+		return nil, err
+	}
+	globalConfig.forEachPrunerConfig("global-config", func(_ string, pc *PrunerConfig) {
+		applyDeprecatedFields(pc)
+	})
+	return globalConfig, nil
+}
+
+// parseNamespaceSpec parses the namespace config of a ConfigMap, the deprecated fields applying to their replacements
+func parseNamespaceSpec(data string) (*NamespaceSpec, error) {
+	namespaceSpec := &NamespaceSpec{}
+	if err := yaml.Unmarshal([]byte(data), namespaceSpec); err != nil {
+		return nil, err
+	}
+	namespaceSpec.forEachPrunerConfig("ns-config", func(_ string, pc *PrunerConfig) {
+		applyDeprecatedFields(pc)
+	})
+	return namespaceSpec, nil
+}
+
+// applyDeprecatedFields copies the deprecated fields of a PrunerConfig to their replacements which are not set
+func applyDeprecatedFields(pc *PrunerConfig) {
+	for _, field := range deprecatedFields {
+		if deprecated, replacement := field.isSet(pc); deprecated && !replacement {
+			field.apply(pc)
+		}
+	}
+}
+
+// deprecationWarnings returns a warning for every deprecated field of a PrunerConfig
+func deprecationWarnings(path string, pc *PrunerConfig) []string {
+	var warnings []string
+	for _, field := range deprecatedFields {
+		deprecated, replacement := field.isSet(pc)
+		switch {
+		case deprecated && replacement:
+			warnings = append(warnings, fmt.Sprintf("%s.%s is deprecated and ignored, as %s is set", path, field.name, field.replacement))
+		case deprecated:
+			warnings = append(warnings, fmt.Sprintf("%s.%s is deprecated, use %s instead", path, field.name, field.replacement))
+		}
+	}
+	return warnings
+}
+
+// forEachPrunerConfig calls fn with every PrunerConfig of the global config and its path, including the ones of the namespaces
+func (gc *GlobalConfig) forEachPrunerConfig(path string, fn func(path string, pc *PrunerConfig)) {
+	fn(path, &gc.PrunerConfig)
+	namespaces := make([]string, 0, len(gc.Namespaces))
+	for namespace := range gc.Namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	slices.Sort(namespaces)
+	for _, namespace := range namespaces {
+		nsSpec := gc.Namespaces[namespace]
+		nsSpec.forEachPrunerConfig(path+".namespaces."+namespace, fn)
+		gc.Namespaces[namespace] = nsSpec
+	}
+	profiles := make([]string, 0, len(gc.Profiles))
+	for profile := range gc.Profiles {
+		profiles = append(profiles, profile)
+	}
+	slices.Sort(profiles)
+	for _, profile := range profiles {
+		profileSpec := gc.Profiles[profile]
+		profileSpec.forEachPrunerConfig(path+".profiles."+profile, fn)
+		gc.Profiles[profile] = profileSpec
+	}
+}
+
+// forEachPrunerConfig calls fn with every PrunerConfig of the namespace config and its path, including the ones of the resources
+func (ns *NamespaceSpec) forEachPrunerConfig(path string, fn func(path string, pc *PrunerConfig)) {
+	fn(path, &ns.PrunerConfig)
+	for i := range ns.PipelineRuns {
+		ns.PipelineRuns[i].forEachPrunerConfig(fmt.Sprintf("%s.pipelineRuns[%d]", path, i), fn)
+	}
+	for i := range ns.TaskRuns {
+		ns.TaskRuns[i].forEachPrunerConfig(fmt.Sprintf("%s.taskRuns[%d]", path, i), fn)
+	}
+}
+
+// forEachPrunerConfig calls fn with every PrunerConfig of the resource config and its path, including the ones of its annotation values
+func (r *ResourceSpec) forEachPrunerConfig(path string, fn func(path string, pc *PrunerConfig)) {
+	fn(path, &r.PrunerConfig)
+	if r.ByAnnotationValue == nil {
+		return
+	}
+	for _, value := range slices.Sorted(maps.Keys(r.ByAnnotationValue.Values)) {
+		pc := r.ByAnnotationValue.Values[value]
+		fn(path+".byAnnotationValue.values."+value, &pc)
+		r.ByAnnotationValue.Values[value] = pc
+	}
+}
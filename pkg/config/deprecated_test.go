@@ -0,0 +1,94 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TestDeprecatedFields verifies that a deprecated field applies as its replacement at every level of the config,
+// unless the replacement is set too.
+func TestDeprecatedFields(t *testing.T) {
+	globalConfig, err := parseGlobalConfig(`
+keep: 5
+namespaces:
+  dev:
+    keep: 3
+    historyLimit: 7`)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), *globalConfig.HistoryLimit)
+	assert.Equal(t, int32(7), *globalConfig.Namespaces["dev"].HistoryLimit)
+
+	namespaceSpec, err := parseNamespaceSpec(`
+pipelineRuns:
+  - name: build
+    keep: 2
+taskRuns:
+  - name: lint
+    historyLimit: 4`)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), *namespaceSpec.PipelineRuns[0].HistoryLimit)
+	assert.Equal(t, int32(4), *namespaceSpec.TaskRuns[0].HistoryLimit)
+}
+
+// TestConfigMapWarningsDeprecatedFields verifies the warnings about deprecated fields.
+func TestConfigMapWarningsDeprecatedFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     map[string]string
+		expected []string
+	}{
+		{
+			name: "global config",
+			data: map[string]string{PrunerGlobalConfigKey: `
+keep: 5
+namespaces:
+  dev:
+    keep: 3
+    historyLimit: 7`},
+			expected: []string{
+				"global-config.keep is deprecated, use historyLimit instead",
+				"global-config.namespaces.dev.keep is deprecated and ignored, as historyLimit is set",
+			},
+		},
+		{
+			name: "namespace config",
+			data: map[string]string{PrunerNamespaceConfigKey: `
+keep: 5
+taskRuns:
+  - name: lint
+    keep: 2`},
+			expected: []string{
+				"ns-config.keep is deprecated, use historyLimit instead",
+				"ns-config.taskRuns[0].keep is deprecated, use historyLimit instead",
+			},
+		},
+		{
+			name: "no deprecated field",
+			data: map[string]string{PrunerGlobalConfigKey: `historyLimit: 5`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ConfigMapWarnings(&corev1.ConfigMap{Data: tt.data}))
+		})
+	}
+}
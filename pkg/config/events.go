@@ -0,0 +1,168 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// CloudEventTypePruned is the type of the CloudEvent emitted when a run is pruned
+	CloudEventTypePruned = "dev.tekton.pruner.pruned.v1"
+	// CloudEventSource is the source of the CloudEvents emitted by the pruner
+	CloudEventSource = "/tekton-pruner/controller"
+
+	// cloudEventsQueueSize is how many events wait for delivery before new ones are dropped,
+	// so that a slow sink never holds up pruning
+	cloudEventsQueueSize = 1000
+	// cloudEventsTimeout bounds the delivery of one event
+	cloudEventsTimeout = 5 * time.Second
+)
+
+// PrunedEvent describes a run which was pruned
+type PrunedEvent struct {
+	// UID of the run, it identifies the event
+	UID string `json:"-"`
+	// Kind of the run, PipelineRun or TaskRun
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Reason is why the run was pruned, e.g. ttl or failed_history_limit
+	Reason PruneReason `json:"reason"`
+}
+
+// NewPrunedEvent returns the event describing the pruned run of the given kind
+func NewPrunedEvent(kind string, resource metav1.Object, reason PruneReason) PrunedEvent {
+	return PrunedEvent{
+		UID:       string(resource.GetUID()),
+		Kind:      kind,
+		Namespace: resource.GetNamespace(),
+		Name:      resource.GetName(),
+		Reason:    reason,
+	}
+}
+
+// PrunedFunc is notified of every run deleted by the pruner, it must not block
+type PrunedFunc func(ctx context.Context, event PrunedEvent)
+
+// prunedFuncKey is used as the key for associating the pruned notification with the context.
+type prunedFuncKey struct{}
+
+// WithPrunedFunc attaches the function notified of the pruned runs to the context
+func WithPrunedFunc(ctx context.Context, fn PrunedFunc) context.Context {
+	return context.WithValue(ctx, prunedFuncKey{}, fn)
+}
+
+// NotifyPruned notifies the function attached to the context, if any, that a run was pruned
+func NotifyPruned(ctx context.Context, event PrunedEvent) {
+	if fn, _ := ctx.Value(prunedFuncKey{}).(PrunedFunc); fn != nil {
+		fn(ctx, event)
+	}
+}
+
+// CloudEventsSink delivers the pruned events as CloudEvents, in binary content mode, to a sink URL.
+// Delivery happens in the background and a failed delivery is only logged, it never fails pruning
+type CloudEventsSink struct {
+	url    string
+	client *http.Client
+	events chan PrunedEvent
+}
+
+// NewCloudEventsSink returns a sink delivering the pruned events to the given http or https URL
+func NewCloudEventsSink(sinkURL string) (*CloudEventsSink, error) {
+	parsed, err := url.Parse(sinkURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CloudEvents sink URL %q: %w", sinkURL, err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid CloudEvents sink URL %q: must be an http or https URL", sinkURL)
+	}
+	return &CloudEventsSink{
+		url:    sinkURL,
+		client: &http.Client{Timeout: cloudEventsTimeout},
+		events: make(chan PrunedEvent, cloudEventsQueueSize),
+	}, nil
+}
+
+// Notify queues the delivery of a pruned event, the event is dropped when the queue is full
+func (s *CloudEventsSink) Notify(ctx context.Context, event PrunedEvent) {
+	select {
+	case s.events <- event:
+	default:
+		logging.FromContext(ctx).Warnw("CloudEvents queue is full, dropping the pruned event",
+			"kind", event.Kind, "namespace", event.Namespace, "name", event.Name)
+	}
+}
+
+// Run delivers the queued events until the context is done
+func (s *CloudEventsSink) Run(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-s.events:
+			if err := s.send(ctx, event); err != nil {
+				logger.Warnw("failed to deliver the pruned event to the CloudEvents sink",
+					"kind", event.Kind, "namespace", event.Namespace, "name", event.Name, zap.Error(err))
+			}
+		}
+	}
+}
+
+// send delivers one event to the sink
+func (s *CloudEventsSink) send(ctx context.Context, event PrunedEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	id := event.UID
+	if id == "" {
+		id = fmt.Sprintf("%s/%s/%s", event.Kind, event.Namespace, event.Name)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Ce-Specversion", "1.0")
+	req.Header.Set("Ce-Type", CloudEventTypePruned)
+	req.Header.Set("Ce-Source", CloudEventSource)
+	req.Header.Set("Ce-Id", id)
+	req.Header.Set("Ce-Subject", event.Namespace+"/"+event.Name)
+	req.Header.Set("Ce-Time", time.Now().UTC().Format(time.RFC3339))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the sink responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktest "k8s.io/utils/clock/testing"
+)
+
+// TestNewCloudEventsSink verifies that only http and https sink URLs are accepted
+func TestNewCloudEventsSink(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: "http://event-display.default.svc.cluster.local"},
+		{url: "https://broker.example.com/default/pruner"},
+		{url: "broker.example.com", wantErr: true},
+		{url: "ftp://broker.example.com", wantErr: true},
+		{url: "http://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			_, err := NewCloudEventsSink(tt.url)
+			assert.Equal(t, tt.wantErr, err != nil, "error = %v", err)
+		})
+	}
+}
+
+// TestCloudEventsSinkDelivery verifies that a pruned event is delivered as a CloudEvent in binary content mode,
+// and that a failed delivery does not stop the delivery of the next events
+func TestCloudEventsSinkDelivery(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan received, 2)
+	failed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header, body: body}
+		if !failed {
+			failed = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink, err := NewCloudEventsSink(server.URL)
+	if err != nil {
+		t.Fatalf("NewCloudEventsSink() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sink.Run(ctx)
+
+	run := &metav1.ObjectMeta{Name: "build-1", Namespace: "ci", UID: "1234"}
+	sink.Notify(ctx, NewPrunedEvent(KindPipelineRun, run, "ttl"))
+	sink.Notify(ctx, NewPrunedEvent(KindTaskRun, &metav1.ObjectMeta{Name: "test-1", Namespace: "ci"}, "failed_history_limit"))
+
+	for i, want := range []struct {
+		id, subject string
+		event       PrunedEvent
+	}{
+		{id: "1234", subject: "ci/build-1", event: PrunedEvent{Kind: KindPipelineRun, Namespace: "ci", Name: "build-1", Reason: "ttl"}},
+		{id: "TaskRun/ci/test-1", subject: "ci/test-1", event: PrunedEvent{Kind: KindTaskRun, Namespace: "ci", Name: "test-1", Reason: "failed_history_limit"}},
+	} {
+		select {
+		case got := <-requests:
+			assert.Equal(t, "1.0", got.header.Get("Ce-Specversion"))
+			assert.Equal(t, CloudEventTypePruned, got.header.Get("Ce-Type"))
+			assert.Equal(t, CloudEventSource, got.header.Get("Ce-Source"))
+			assert.Equal(t, want.id, got.header.Get("Ce-Id"))
+			assert.Equal(t, want.subject, got.header.Get("Ce-Subject"))
+			assert.Equal(t, "application/json", got.header.Get("Content-Type"))
+			var event PrunedEvent
+			assert.NoError(t, json.Unmarshal(got.body, &event))
+			assert.Equal(t, want.event, event)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("event %d was not delivered", i)
+		}
+	}
+}
+
+// TestCloudEventsSinkQueueFull verifies that the events are dropped instead of blocking when the queue is full
+func TestCloudEventsSinkQueueFull(t *testing.T) {
+	sink, err := NewCloudEventsSink("http://event-display.default.svc.cluster.local")
+	if err != nil {
+		t.Fatalf("NewCloudEventsSink() error = %v", err)
+	}
+	run := &metav1.ObjectMeta{Name: "build-1", Namespace: "ci"}
+	for i := 0; i < cloudEventsQueueSize+10; i++ {
+		sink.Notify(context.Background(), NewPrunedEvent(KindPipelineRun, run, "ttl"))
+	}
+	assert.Len(t, sink.events, cloudEventsQueueSize)
+}
+
+// TestNotifyPrunedOnTTLDeletion verifies that the function attached to the context is notified of a run deleted by TTL
+func TestNotifyPrunedOnTTLDeletion(t *testing.T) {
+	var events []PrunedEvent
+	ctx := WithPrunedFunc(context.Background(), func(_ context.Context, event PrunedEvent) {
+		events = append(events, event)
+	})
+
+	fakeClock := clocktest.NewFakeClock(time.Now())
+	mockFuncs := newMockTTLFuncs()
+	handler, _ := NewTTLHandler(fakeClock, mockFuncs)
+	resource := &ttlMockResource{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "expired",
+			Namespace:   "default",
+			UID:         "5678",
+			Annotations: map[string]string{AnnotationTTLSecondsAfterFinished: "60"},
+		},
+		completed:       true,
+		completion_time: &metav1.Time{Time: fakeClock.Now().Add(-time.Hour)},
+	}
+	mockFuncs.resources["default/expired"] = resource
+
+	if err := handler.ProcessEvent(ctx, resource); err != nil {
+		t.Fatalf("ProcessEvent() error = %v", err)
+	}
+	assert.Equal(t, []PrunedEvent{{UID: "5678", Kind: "MockResource", Namespace: "default", Name: "expired", Reason: "ttl"}}, events)
+
+	// without a function attached, nothing is notified
+	NotifyPruned(context.Background(), PrunedEvent{})
+}
@@ -17,9 +17,18 @@ limitations under the License.
 package config
 
 import (
+	"context"
+	goerrors "errors"
+	"fmt"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/tektoncd/pruner/pkg/metrics"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/logging"
 )
 
 // common functions used across history limiter and ttl handler
@@ -34,6 +43,246 @@ func getResourceNameLabelKey(resource metav1.Object, defaultLabelKey string) str
 	return defaultLabelKey
 }
 
+// labelKeyFallbacks holds the label keys tried, in order, when a run does not carry the default label key
+var labelKeyFallbacks = map[string][]string{
+	KindPipelineRun: {LabelPipelineRunName},
+	KindTaskRun:     {LabelTaskRunName},
+}
+
+// getGroupingLabelKeys returns the prioritized label keys used to group runs of the given kind,
+// the configured keys take precedence over the default label key and its fallbacks
+func getGroupingLabelKeys(kind, defaultLabelKey string) []string {
+	if labelKeys := PrunerConfigStore.GetLabelKeys(kind); len(labelKeys) > 0 {
+		return labelKeys
+	}
+	return append([]string{defaultLabelKey}, labelKeyFallbacks[kind]...)
+}
+
+// getGroupingLabelKey returns the label key used to group a resource,
+// which is the user defined label key or the first of the label keys present on the resource.
+// returns the first label key, if none of them is present
+func getGroupingLabelKey(resource metav1.Object, labelKeys []string) string {
+	labelKey := getResourceNameLabelKey(resource, labelKeys[0])
+	if labelKey != labelKeys[0] {
+		return labelKey
+	}
+
+	labels := resource.GetLabels()
+	for _, key := range labelKeys {
+		if _, exists := labels[key]; exists {
+			return key
+		}
+	}
+	return labelKey
+}
+
+// IsMarkedPrunable reports whether a resource was marked as prunable by the annotate deletion mode
+func IsMarkedPrunable(resource metav1.Object) bool {
+	return resource.GetAnnotations()[AnnotationPrunable] == "true"
+}
+
+// OwnerSelector selects runs by one of their owner references, the fields left empty match any owner
+type OwnerSelector struct {
+	// Kind of the owner, e.g. PipelineRun
+	Kind string
+	// Name of the owner
+	Name string
+	// UID of the owner, which tells apart the owners recreated with the same name
+	UID types.UID
+}
+
+// HasOwnerReference reports whether one of the owner references of the resource matches the selector
+func HasOwnerReference(resource metav1.Object, selector OwnerSelector) bool {
+	for _, ownerReference := range resource.GetOwnerReferences() {
+		if (selector.Kind == "" || ownerReference.Kind == selector.Kind) &&
+			(selector.Name == "" || ownerReference.Name == selector.Name) &&
+			(selector.UID == "" || ownerReference.UID == selector.UID) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTaskRunParent reports whether the TaskRun is owned by a PipelineRun, or by one of the taskRunParentKinds
+// of the global config, such TaskRuns are pruned with their parent rather than on their own
+func HasTaskRunParent(taskRun metav1.Object) bool {
+	for _, kind := range PrunerConfigStore.GetTaskRunParentKinds() {
+		if HasOwnerReference(taskRun, OwnerSelector{Kind: kind}) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrunablePatch returns the patch which marks a resource as prunable for the given reason
+func PrunablePatch(resource metav1.Object, reason PruneReason) ([]byte, error) {
+	return AnnotationPatch(resource, map[string]string{
+		AnnotationPrunable:       "true",
+		AnnotationPrunableReason: reason.PrunableAnnotationValue(),
+	})
+}
+
+// deletionLimiterKey is used as the key for associating the concurrent deletions limiter with the context
+type deletionLimiterKey struct{}
+
+// WithDeletionLimit caps the number of concurrent Delete calls made through LimitDeletion with the context.
+// A limit of zero or less means unlimited
+func WithDeletionLimit(ctx context.Context, limit int) context.Context {
+	if limit <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, deletionLimiterKey{}, make(chan struct{}, limit))
+}
+
+// LimitDeletion runs deleteFn once a deletion slot of the context is available
+func LimitDeletion(ctx context.Context, deleteFn func() error) error {
+	slots, _ := ctx.Value(deletionLimiterKey{}).(chan struct{})
+	if slots == nil {
+		return deleteFn()
+	}
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-slots }()
+	return deleteFn()
+}
+
+// maxListRetryDelay caps the delay between two attempts of a throttled List call, including a suggested Retry-After
+const maxListRetryDelay = time.Minute
+
+// ListWithRetry calls listFn, and calls it again while the API server throttles it with 429 Too Many Requests,
+// up to listRetryAttempts times. Each retry waits for the Retry-After delay suggested by the API server if any,
+// or for an exponential backoff starting at listRetryBackoffMilliseconds otherwise
+func ListWithRetry[T any](ctx context.Context, listFn func() (T, error)) (T, error) {
+	attempts := PrunerConfigStore.GetListRetryAttempts()
+	backoff := PrunerConfigStore.GetListRetryBackoff()
+	for attempt := 0; ; attempt++ {
+		result, err := listFn()
+		if err == nil || !errors.IsTooManyRequests(err) || attempt >= attempts {
+			return result, err
+		}
+
+		delay := backoff << attempt
+		if seconds, ok := errors.SuggestsClientDelay(err); ok && seconds > 0 {
+			delay = time.Duration(seconds) * time.Second
+		}
+		delay = min(delay, maxListRetryDelay)
+		logging.FromContext(ctx).Infow("List call throttled by the API server, retrying", "attempt", attempt+1, "delay", delay)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return result, ctx.Err()
+		}
+	}
+}
+
+// CallWithTimeout calls callFn with a context bounded by the apiCallTimeoutSeconds of the global config, and gives up
+// on the call once the timeout passes, even if the client does not honor the context, so that a hung call does not
+// stall the worker. A timed out call is recorded as a timeout error of the resource type
+func CallWithTimeout(ctx context.Context, resourceType, namespace string, callFn func(ctx context.Context) error) error {
+	timeout := PrunerConfigStore.GetAPICallTimeout()
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// buffered, so that a call returning after the timeout does not block its goroutine forever
+	done := make(chan error, 1)
+	go func() {
+		done <- callFn(callCtx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-callCtx.Done():
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		metrics.GetRecorder().RecordResourceError(ctx, resourceType, namespace, metrics.ErrorTypeTimeout, "api_call_timeout")
+		logging.FromContext(ctx).Warnw("API call timed out, moving on", "resource", resourceType, "namespace", namespace, "timeout", timeout)
+		return fmt.Errorf("API call timed out after %s: %w", timeout, callCtx.Err())
+	}
+}
+
+// PatchWithRetry calls patchFn, and calls it again while it fails with an error other than NotFound,
+// up to annotationPatchRetryAttempts times. Each retry waits for an exponential backoff starting at listRetryBackoffMilliseconds
+func PatchWithRetry(ctx context.Context, patchFn func() error) error {
+	attempts := PrunerConfigStore.GetAnnotationPatchRetryAttempts()
+	backoff := PrunerConfigStore.GetListRetryBackoff()
+	for attempt := 0; ; attempt++ {
+		err := patchFn()
+		if err == nil || errors.IsNotFound(err) || attempt >= attempts {
+			return err
+		}
+
+		delay := min(backoff<<attempt, maxListRetryDelay)
+		logging.FromContext(ctx).Infow("Patch call failed, retrying", "attempt", attempt+1, "delay", delay, "error", err)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// historyDeletionBudgetKey is used as the key for associating the history limit deletion budget with the context
+type historyDeletionBudgetKey struct{}
+
+// historyDeletionBudget holds the number of history limit deletions left while the context is in use
+type historyDeletionBudget struct {
+	mutex     sync.Mutex
+	remaining int
+}
+
+// WithHistoryDeletionBudget caps the number of runs pruned by the history limiter with the context,
+// e.g. for a garbage collection cycle of a namespace, to the historyDeletionBatchSize of the global config.
+// The context is returned as is when no batch size is configured
+func WithHistoryDeletionBudget(ctx context.Context) context.Context {
+	batchSize := PrunerConfigStore.GetHistoryDeletionBatchSize()
+	if batchSize == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, historyDeletionBudgetKey{}, &historyDeletionBudget{remaining: int(*batchSize)})
+}
+
+// takeHistoryDeletions returns how many of the given history limit candidates can be pruned now.
+// The count is capped by the historyDeletionBatchSize of the global config and by the budget of the context, if any
+func takeHistoryDeletions(ctx context.Context, candidates int) int {
+	batchSize := PrunerConfigStore.GetHistoryDeletionBatchSize()
+	if batchSize == nil {
+		return candidates
+	}
+	count := min(candidates, int(*batchSize))
+
+	budget, _ := ctx.Value(historyDeletionBudgetKey{}).(*historyDeletionBudget)
+	if budget == nil {
+		return count
+	}
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+	count = min(count, budget.remaining)
+	budget.remaining -= count
+	return count
+}
+
+// markPrunable patches a resource with the prunable annotation instead of deleting it.
+// A resource is left unmarked when the annotationAllowlist does not allow the prunable annotations
+func markPrunable(ctx context.Context, patchFn func(context.Context, string, string, []byte) error, resource metav1.Object, reason PruneReason) error {
+	patchBytes, err := PrunablePatch(resource, reason)
+	if goerrors.Is(err, ErrAnnotationNotAllowed) {
+		logging.FromContext(ctx).Warnw("skipping marking the resource as prunable",
+			"namespace", resource.GetNamespace(), "name", resource.GetName(), "reason", reason, "error", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return patchFn(ctx, resource.GetNamespace(), resource.GetName(), patchBytes)
+}
+
 func getResourceName(resource metav1.Object, labelKey string) string {
 	labels := resource.GetLabels()
 	// if there is no label present, no option to filter
@@ -118,3 +367,39 @@ func MatchLabels(labels map[string]string, labelSelector string) bool {
 	}
 	return true
 }
+
+// maxWarnedResources bounds the number of resources remembered by a warnedResources set
+const maxWarnedResources = 10000
+
+// deleteAfterWarnings holds the resources already warned about their deleteAfter deadline
+var deleteAfterWarnings = &warnedResources{}
+
+// warnedResources remembers, by UID, the resources a warning was logged for, so that it is logged once per resource.
+// The oldest UIDs are evicted first once maxWarnedResources are remembered
+type warnedResources struct {
+	mutex sync.Mutex
+	seen  map[types.UID]bool
+	order []types.UID
+	next  int
+}
+
+// firstTime remembers a resource and reports whether it was not remembered yet
+func (w *warnedResources) firstTime(uid types.UID) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.seen[uid] {
+		return false
+	}
+	if w.seen == nil {
+		w.seen = map[types.UID]bool{}
+	}
+	if len(w.order) < maxWarnedResources {
+		w.order = append(w.order, uid)
+	} else {
+		delete(w.seen, w.order[w.next])
+		w.order[w.next] = uid
+		w.next = (w.next + 1) % maxWarnedResources
+	}
+	w.seen[uid] = true
+	return true
+}
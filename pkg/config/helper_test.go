@@ -0,0 +1,278 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tektoncd/pruner/pkg/metrics"
+	"go.uber.org/zap/zaptest"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/logging"
+)
+
+// TestHasOwnerReference verifies that runs are matched by the kind, name and UID of their owners
+func TestHasOwnerReference(t *testing.T) {
+	resource := &metav1.ObjectMeta{
+		OwnerReferences: []metav1.OwnerReference{
+			{Kind: "EventListener", Name: "listener", UID: "uid-1"},
+			{Kind: KindPipelineRun, Name: "build-run", UID: "uid-2"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		selector OwnerSelector
+		want     bool
+	}{
+		{name: "kind", selector: OwnerSelector{Kind: KindPipelineRun}, want: true},
+		{name: "name", selector: OwnerSelector{Name: "build-run"}, want: true},
+		{name: "uid", selector: OwnerSelector{UID: "uid-1"}, want: true},
+		{name: "kind and name", selector: OwnerSelector{Kind: KindPipelineRun, Name: "build-run"}, want: true},
+		{name: "name of another owner", selector: OwnerSelector{Kind: KindPipelineRun, Name: "listener"}, want: false},
+		{name: "stale uid", selector: OwnerSelector{Name: "build-run", UID: "uid-3"}, want: false},
+		{name: "unknown kind", selector: OwnerSelector{Kind: KindTaskRun}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HasOwnerReference(resource, tt.selector))
+		})
+	}
+	assert.False(t, HasOwnerReference(&metav1.ObjectMeta{}, OwnerSelector{}))
+}
+
+// TestLimitDeletion verifies that no more than the configured number of deletions run concurrently
+func TestLimitDeletion(t *testing.T) {
+	const limit = 2
+	ctx := WithDeletionLimit(context.Background(), limit)
+
+	var active, maxActive atomic.Int32
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := LimitDeletion(ctx, func() error {
+				current := active.Add(1)
+				defer active.Add(-1)
+				for observed := maxActive.Load(); current > observed; observed = maxActive.Load() {
+					if maxActive.CompareAndSwap(observed, current) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, maxActive.Load(), int32(limit))
+}
+
+// TestLimitDeletionCanceled verifies that waiting for a deletion slot stops when the context is canceled
+func TestLimitDeletionCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(WithDeletionLimit(context.Background(), 1))
+	acquired, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		_ = LimitDeletion(ctx, func() error {
+			close(acquired)
+			<-release
+			return nil
+		})
+	}()
+	defer close(release)
+	<-acquired
+
+	cancel()
+	called := false
+	err := LimitDeletion(ctx, func() error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, called)
+}
+
+// TestListWithRetry verifies that only List calls throttled with 429 Too Many Requests are retried,
+// up to the configured number of attempts
+func TestListWithRetry(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+	cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `
+listRetryAttempts: 2
+listRetryBackoffMilliseconds: 1`}}
+	assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	throttled := errors.NewTooManyRequests("throttled", 0)
+	notFound := errors.NewNotFound(schema.GroupResource{Resource: "pipelineruns"}, "")
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "succeeds at first",
+			wantCalls: 1,
+		},
+		{
+			name:      "succeeds after throttling",
+			errs:      []error{throttled, throttled},
+			wantCalls: 3,
+		},
+		{
+			name:      "gives up after the configured attempts",
+			errs:      []error{throttled, throttled, throttled},
+			wantErr:   throttled,
+			wantCalls: 3,
+		},
+		{
+			name:      "other errors are not retried",
+			errs:      []error{notFound},
+			wantErr:   notFound,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			result, err := ListWithRetry(ctx, func() (string, error) {
+				calls++
+				if calls <= len(tt.errs) {
+					return "", tt.errs[calls-1]
+				}
+				return "listed", nil
+			})
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantCalls, calls)
+			if tt.wantErr == nil {
+				assert.Equal(t, "listed", result)
+			}
+		})
+	}
+}
+
+// TestPatchWithRetry verifies that failed Patch calls are retried up to the configured number of attempts,
+// except when the resource is not found
+func TestPatchWithRetry(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+	cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `
+annotationPatchRetryAttempts: 2
+listRetryBackoffMilliseconds: 1`}}
+	assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	conflict := errors.NewConflict(schema.GroupResource{Resource: "pipelineruns"}, "run", nil)
+	notFound := errors.NewNotFound(schema.GroupResource{Resource: "pipelineruns"}, "run")
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "succeeds at first",
+			wantCalls: 1,
+		},
+		{
+			name:      "succeeds after failures",
+			errs:      []error{conflict, conflict},
+			wantCalls: 3,
+		},
+		{
+			name:      "gives up after the configured attempts",
+			errs:      []error{conflict, conflict, conflict},
+			wantErr:   conflict,
+			wantCalls: 3,
+		},
+		{
+			name:      "not found is not retried",
+			errs:      []error{notFound},
+			wantErr:   notFound,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := PatchWithRetry(ctx, func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+// TestCallWithTimeout verifies that a call hanging past apiCallTimeoutSeconds is given up and recorded as a timeout error,
+// while the calls returning in time are left as they are
+func TestCallWithTimeout(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+	cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: "apiCallTimeoutSeconds: 1"}}
+	assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	notFound := errors.NewNotFound(schema.GroupResource{Resource: "pipelineruns"}, "run")
+	assert.Equal(t, notFound, CallWithTimeout(ctx, metrics.ResourceTypePipelineRun, "default", func(context.Context) error {
+		return notFound
+	}))
+
+	errorsBefore := metrics.GetRecorder().Snapshot().Counters[metrics.MetricResourcesErrors]
+	release := make(chan struct{})
+	defer close(release)
+	err := CallWithTimeout(ctx, metrics.ResourceTypePipelineRun, "default", func(context.Context) error {
+		<-release
+		return nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, errorsBefore+1, metrics.GetRecorder().Snapshot().Counters[metrics.MetricResourcesErrors])
+
+	// a canceled context is not recorded as a timeout
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = CallWithTimeout(canceled, metrics.ResourceTypePipelineRun, "default", func(context.Context) error {
+		<-release
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, errorsBefore+1, metrics.GetRecorder().Snapshot().Counters[metrics.MetricResourcesErrors])
+}
@@ -19,10 +19,13 @@ package config
 import (
 	"context"
 	"encoding/json"
+	goerrors "errors"
 	"fmt"
 	"math"
 	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/tektoncd/pruner/pkg/metrics"
@@ -34,6 +37,10 @@ import (
 	"knative.dev/pkg/ptr"
 )
 
+// errHistoryDeletionsDeferred reports that runs over the history limit are left for a later cycle
+// because of the historyDeletionBatchSize of the global config, or because a freeze or safe mode paused the history limit
+var errHistoryDeletionsDeferred = goerrors.New("history limit deletions deferred to a later cycle")
+
 // HistoryLimiterResourceFuncs defines a set of methods that operate on resources
 // with history limit capabilities.
 type HistoryLimiterResourceFuncs interface {
@@ -43,11 +50,14 @@ type HistoryLimiterResourceFuncs interface {
 	Patch(ctx context.Context, namespace, name string, patchBytes []byte) error
 	Delete(ctx context.Context, namespace, name string) error
 	List(ctx context.Context, namespace, label string) ([]metav1.Object, error)
+	ListByNamespaces(ctx context.Context, namespaces []string) (map[string][]metav1.Object, error)
 	GetFailedHistoryLimitCount(namespace, name string, selectors SelectorSpec) (*int32, string)
 	GetSuccessHistoryLimitCount(namespace, name string, selectors SelectorSpec) (*int32, string)
 	IsSuccessful(resource metav1.Object) bool
 	IsFailed(resource metav1.Object) bool
+	IsCancelled(resource metav1.Object) bool
 	IsCompleted(resource metav1.Object) bool
+	GetCompletionTime(resource metav1.Object) (metav1.Time, error)
 	GetDefaultLabelKey() string
 	GetEnforcedConfigLevel(namespace, name string, selectors SelectorSpec) EnforcedConfigLevel
 	GetMatchingSelector(namespace, name string, selectors SelectorSpec) *SelectorSpec
@@ -78,7 +88,12 @@ func NewHistoryLimiter(resourceFn HistoryLimiterResourceFuncs) (*HistoryLimiter,
 // whether it has already been processed, and if it's in a completed state. Depending
 // on the resource's completion status, it will either trigger cleanup for successful
 // or failed resources
-func (hl *HistoryLimiter) ProcessEvent(ctx context.Context, resource metav1.Object) error {
+func (hl *HistoryLimiter) ProcessEvent(ctx context.Context, resource metav1.Object) (err error) {
+	ctx, span := metrics.StartSpan(ctx, "HistoryLimiter.ProcessEvent", metrics.SpanAttributes(hl.resourceFn.Type(), resource.GetNamespace(), resource.GetName())...)
+	defer func() { metrics.EndSpan(span, err) }()
+	// the decision is refined once the history limit is evaluated
+	metrics.SetSpanDecision(ctx, metrics.DecisionSkipped)
+
 	logger := logging.FromContext(ctx)
 	logger.Debugw("processing an event for limit logic", "resource", hl.resourceFn.Type(), "namespace", resource.GetNamespace(), "name", resource.GetName())
 
@@ -88,7 +103,9 @@ func (hl *HistoryLimiter) ProcessEvent(ctx context.Context, resource metav1.Obje
 		return nil
 	}
 
-	if hl.isProcessed(resource) {
+	// a namespace under quota pressure has its runs evaluated again against the tightened history limits
+	processed := hl.isProcessed(resource)
+	if processed && getHistoryLimitDivisor(ctx) <= 1 {
 		logger.Debugw("already processed", "resource", hl.resourceFn.Type(), "namespace", resource.GetNamespace(), "name", resource.GetName())
 		return nil
 	}
@@ -99,19 +116,28 @@ func (hl *HistoryLimiter) ProcessEvent(ctx context.Context, resource metav1.Obje
 		return nil
 	}
 
-	defer hl.markAsProcessed(ctx, resource)
-
-	if hl.resourceFn.IsSuccessful(resource) {
-		logger.Debugw("success - cleanup", "resource", hl.resourceFn.Type(), "namespace", resource.GetNamespace(), "name", resource.GetName())
-		return hl.DoSuccessfulResourceCleanup(ctx, resource)
+	// with the combined policy the history limits only keep the newest runs from the TTL, they never prune on their own
+	if PrunerConfigStore.GetTTLHistoryPolicy(resource.GetNamespace()) == TTLHistoryPolicyCombined {
+		logger.Debugw("history limits are combined with the TTL, no cleanup", "resource", hl.resourceFn.Type(), "namespace", resource.GetNamespace(), "name", resource.GetName())
+		return nil
 	}
 
-	if hl.resourceFn.IsFailed(resource) {
+	if hl.isSuccessfulResource(resource) {
+		logger.Debugw("success - cleanup", "resource", hl.resourceFn.Type(), "namespace", resource.GetNamespace(), "name", resource.GetName())
+		err = hl.DoSuccessfulResourceCleanup(ctx, resource)
+	} else if hl.isFailedResource(resource) {
 		logger.Debugw("failed - cleanup", "resource", hl.resourceFn.Type(), "namespace", resource.GetNamespace(), "name", resource.GetName())
-		return hl.DoFailedResourceCleanup(ctx, resource)
+		err = hl.DoFailedResourceCleanup(ctx, resource)
 	}
 
-	return nil
+	// the resource is left unprocessed while runs over the limit remain, so that it is checked again on the next cycle
+	if err == errHistoryDeletionsDeferred {
+		return nil
+	}
+	if !processed {
+		hl.markAsProcessed(ctx, resource)
+	}
+	return err
 }
 
 // adds an annotation, indicates this resource is already processed
@@ -135,21 +161,14 @@ func (hl *HistoryLimiter) markAsProcessed(ctx context.Context, resource metav1.O
 
 	// Prepare the annotation update
 	processedTimeAsString := time.Now().Format(time.RFC3339)
-	annotations := resourceLatest.GetAnnotations()
-	if annotations == nil {
-		annotations = map[string]string{}
-	}
-	annotations[AnnotationHistoryLimitCheckProcessed] = processedTimeAsString
 
-	// Create a patch with the new annotations
-	patchData := map[string]interface{}{
-		"metadata": map[string]interface{}{
-			"annotations": annotations,
-		},
+	// Create a patch with the processed annotation
+	patchBytes, err := AnnotationPatch(resourceLatest, map[string]string{PrunerConfigStore.GetProcessedAnnotationKey(): processedTimeAsString})
+	if goerrors.Is(err, ErrAnnotationNotAllowed) {
+		logger.Warnw("skipping marking the resource as processed", "resource", hl.resourceFn.Type(),
+			"namespace", resourceLatest.GetNamespace(), "name", resourceLatest.GetName(), zap.Error(err))
+		return
 	}
-
-	// Convert patchData to JSON
-	patchBytes, err := json.Marshal(patchData)
 	if err != nil {
 		logger.Errorw("Error marshaling patch data", zap.Error(err))
 		return
@@ -168,7 +187,7 @@ func (hl *HistoryLimiter) isProcessed(resource metav1.Object) bool {
 	if annotations == nil {
 		return false
 	}
-	_, found := annotations[AnnotationHistoryLimitCheckProcessed]
+	_, found := annotations[PrunerConfigStore.GetProcessedAnnotationKey()]
 	return found
 }
 
@@ -176,28 +195,99 @@ func (hl *HistoryLimiter) DoSuccessfulResourceCleanup(ctx context.Context, resou
 	logging := logging.FromContext(ctx)
 
 	logging.Debugw("processing a successful resource", "resource", hl.resourceFn.Type(), "namespace", resource.GetNamespace(), "name", resource.GetName())
+	if _, frozen := PrunerConfigStore.GetFreezeUntil(time.Now()); frozen {
+		logging.Debugw("history limit is frozen", "namespace", resource.GetNamespace(), "name", resource.GetName())
+		return errHistoryDeletionsDeferred
+	}
+	if PrunerConfigStore.IsPruningPaused(PrunerFieldTypeSuccessfulHistoryLimit) {
+		logging.Debugw("successful history limit is paused by safe mode", "namespace", resource.GetNamespace(), "name", resource.GetName())
+		return errHistoryDeletionsDeferred
+	}
 	return hl.doResourceCleanup(ctx, resource, AnnotationSuccessfulHistoryLimit, hl.resourceFn.GetSuccessHistoryLimitCount, hl.isSuccessfulResource)
 }
 
 func (hl *HistoryLimiter) DoFailedResourceCleanup(ctx context.Context, resource metav1.Object) error {
 	logging := logging.FromContext(ctx)
 	logging.Debugw("processing a failed resource", "resource", hl.resourceFn.Type(), "namespace", resource.GetNamespace(), "name", resource.GetName())
+	if _, frozen := PrunerConfigStore.GetFreezeUntil(time.Now()); frozen {
+		logging.Debugw("history limit is frozen", "namespace", resource.GetNamespace(), "name", resource.GetName())
+		return errHistoryDeletionsDeferred
+	}
+	if PrunerConfigStore.IsPruningPaused(PrunerFieldTypeFailedHistoryLimit) {
+		logging.Debugw("failed history limit is paused by safe mode", "namespace", resource.GetNamespace(), "name", resource.GetName())
+		return errHistoryDeletionsDeferred
+	}
 	return hl.doResourceCleanup(ctx, resource, AnnotationFailedHistoryLimit, hl.resourceFn.GetFailedHistoryLimitCount, hl.isFailedResource)
 }
 
+// IsRetained tells whether the resource is among the newest runs within its successful or failed history limit,
+// the runs the combined TTL and history policy keeps past their TTL
+func (hl *HistoryLimiter) IsRetained(ctx context.Context, resource metav1.Object) (bool, error) {
+	var group *historyGroup
+	var err error
+	switch {
+	case hl.isSuccessfulResource(resource):
+		group, err = hl.getHistoryGroup(ctx, resource, AnnotationSuccessfulHistoryLimit, hl.resourceFn.GetSuccessHistoryLimitCount, hl.isSuccessfulResource)
+	case hl.isFailedResource(resource):
+		group, err = hl.getHistoryGroup(ctx, resource, AnnotationFailedHistoryLimit, hl.resourceFn.GetFailedHistoryLimitCount, hl.isFailedResource)
+	default:
+		return false, nil
+	}
+	if err != nil || group.limit == nil || *group.limit < 0 {
+		return false, err
+	}
+
+	for index, res := range group.resources {
+		if index >= int(*group.limit) {
+			break
+		}
+		if res.GetUID() == resource.GetUID() && res.GetName() == resource.GetName() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isFailedResource tells whether the resource counts toward the failed history limit,
+// the cancelled runs count as configured by cancelledCountsAs
 func (hl *HistoryLimiter) isFailedResource(resource metav1.Object) bool {
-	return hl.resourceFn.IsCompleted(resource) && hl.resourceFn.IsFailed(resource)
+	if !hl.resourceFn.IsCompleted(resource) {
+		return false
+	}
+	if hl.resourceFn.IsCancelled(resource) {
+		return PrunerConfigStore.GetCancelledCountsAs(resource.GetNamespace()) == CancelledCountsAsFailed
+	}
+	return hl.resourceFn.IsFailed(resource)
 }
 
+// isSuccessfulResource tells whether the resource counts toward the successful history limit,
+// the cancelled runs count as configured by cancelledCountsAs
 func (hl *HistoryLimiter) isSuccessfulResource(resource metav1.Object) bool {
-	return hl.resourceFn.IsCompleted(resource) && hl.resourceFn.IsSuccessful(resource)
+	if !hl.resourceFn.IsCompleted(resource) {
+		return false
+	}
+	if hl.resourceFn.IsCancelled(resource) {
+		return PrunerConfigStore.GetCancelledCountsAs(resource.GetNamespace()) == CancelledCountsAsSuccessful
+	}
+	return hl.resourceFn.IsSuccessful(resource)
 }
 
-func (hl *HistoryLimiter) doResourceCleanup(ctx context.Context, resource metav1.Object, historyLimitAnnotation string, getHistoryLimitFn func(string, string, SelectorSpec) (*int32, string), getResourceFilterFn func(metav1.Object) bool) error {
+// historyGroup holds the history limit applying to a run, and the runs counted toward it newest first
+type historyGroup struct {
+	// limit is nil when no history limit applies, the runs are not listed then
+	limit        *int32
+	identifiedBy string
+	// shared tells whether the limit caps the successful and failed runs together
+	shared    bool
+	resources []metav1.Object
+}
+
+// getHistoryGroup returns the history limit applying to a run, with the runs counted toward it
+func (hl *HistoryLimiter) getHistoryGroup(ctx context.Context, resource metav1.Object, historyLimitAnnotation string, getHistoryLimitFn func(string, string, SelectorSpec) (*int32, string), getResourceFilterFn func(metav1.Object) bool) (*historyGroup, error) {
 	logger := logging.FromContext(ctx)
 
-	// get the label key and resource name
-	labelKey := getResourceNameLabelKey(resource, hl.resourceFn.GetDefaultLabelKey())
+	// get the label key and resource name, trying the grouping label keys in priority order
+	labelKey := getGroupingLabelKey(resource, getGroupingLabelKeys(hl.resourceFn.Type(), hl.resourceFn.GetDefaultLabelKey()))
 	resourceName := getResourceName(resource, labelKey)
 
 	// Get Annotations and Labels
@@ -234,7 +324,7 @@ func (hl *HistoryLimiter) doResourceCleanup(ctx context.Context, resource metav1
 				"annotation", historyLimitAnnotation,
 				"value", annotations[historyLimitAnnotation],
 				zap.Error(err))
-			return err
+			return nil, err
 		}
 		// Check bounds before converting to int32
 		if annotationLimit < 0 || annotationLimit > math.MaxInt32 {
@@ -244,7 +334,7 @@ func (hl *HistoryLimiter) doResourceCleanup(ctx context.Context, resource metav1
 				"name", resource.GetName(),
 				"annotation", historyLimitAnnotation,
 				"value", annotationLimit)
-			return fmt.Errorf("history limit value %d is out of bounds for type int32", annotationLimit)
+			return nil, fmt.Errorf("history limit value %d is out of bounds for type int32", annotationLimit)
 		}
 
 		// Only use annotation value if it matches configured value
@@ -260,10 +350,39 @@ func (hl *HistoryLimiter) doResourceCleanup(ctx context.Context, resource metav1
 		identifiedBy = configIdentifiedBy
 	}
 
+	// The successful runs carrying the label of a per label value limit are capped per value of the label,
+	// unless a name or selector entry applies to them
+	var perLabelValue *PerLabelValueHistoryLimit
+	if historyLimitAnnotation == AnnotationSuccessfulHistoryLimit && slices.Contains(namespaceLevelIdentifiers, identifiedBy) {
+		perLabelValueLevel := enforcedConfigLevel
+		if perLabelValueLevel == EnforcedConfigLevelNamespace && AreNamespaceConfigsIgnored(ctx) {
+			// without the namespace ConfigMap, only the namespace entry of the global config applies
+			perLabelValueLevel = EnforcedConfigLevelResource
+		}
+		perLabelValue = PrunerConfigStore.GetPerLabelValueHistoryLimit(resource.GetNamespace(), perLabelValueLevel)
+		if perLabelValue != nil {
+			if _, found := resourceLabels[perLabelValue.Label]; found {
+				historyLimit = perLabelValue.Limit
+				identifiedBy = "identifiedBy_label_value"
+			}
+		}
+	}
+	historyLimit = tightenHistoryLimit(ctx, historyLimit)
 	logger.Debugw("historylimit for the resource", "resourcename", resourceName, "limit", historyLimit, "identifiedBy", identifiedBy)
 
+	group := &historyGroup{limit: historyLimit, identifiedBy: identifiedBy}
 	if historyLimit == nil || *historyLimit < 0 {
-		return nil
+		return group, nil
+	}
+
+	// A limit shared by the successful and failed runs caps them together, unless the oldest runs of each status are evicted
+	evictionPriority := PrunerConfigStore.GetHistoryLimitEvictionPriority(resource.GetNamespace())
+	group.shared = evictionPriority != HistoryLimitEvictionOldest && identifiedBy != "identifiedBy_label_value" &&
+		hl.hasSharedHistoryLimit(resource.GetNamespace(), resourceName, resourceSelectors)
+	if group.shared {
+		getResourceFilterFn = func(res metav1.Object) bool {
+			return hl.isSuccessfulResource(res) || hl.isFailedResource(res)
+		}
 	}
 
 	// List Resources (using appropriate selector based on enforcement level and identifier)
@@ -276,6 +395,10 @@ func (hl *HistoryLimiter) doResourceCleanup(ctx context.Context, resource metav1
 		// Filter by name label (resource-level enforcement)
 		label := fmt.Sprintf("%s=%s", labelKey, resourceName)
 		resources, err = hl.resourceFn.List(ctx, resource.GetNamespace(), label)
+	case "identifiedBy_label_value":
+		// Filter by the value of the label of the per label value limit
+		label := fmt.Sprintf("%s=%s", perLabelValue.Label, resourceLabels[perLabelValue.Label])
+		resources, err = hl.resourceFn.List(ctx, resource.GetNamespace(), label)
 	case "identifiedBy_resource_selector":
 		// Filter by the ConfigMap's selector labels only
 		matchingSelector := hl.resourceFn.GetMatchingSelector(resource.GetNamespace(), resourceName, resourceSelectors)
@@ -294,9 +417,18 @@ func (hl *HistoryLimiter) doResourceCleanup(ctx context.Context, resource metav1
 			"labelSelector", labelSelector)
 		resources, err = hl.resourceFn.List(ctx, resource.GetNamespace(), labelSelector)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if matchingSelector != nil && len(matchingSelector.MatchAnnotations) > 0 {
+			// annotations cannot be selected by the API server, only the matchLabels of the selector narrow down the runs filtered in memory
+			if len(resources) > AnnotationFilterWarningThreshold {
+				logger.Warnw("filtering many runs by annotations in memory, consider narrowing down the selector with matchLabels",
+					"resource", hl.resourceFn.Type(),
+					"namespace", resource.GetNamespace(),
+					"count", len(resources),
+					"matchLabels", matchingSelector.MatchLabels,
+					"matchAnnotations", matchingSelector.MatchAnnotations)
+			}
 			filteredResources := []metav1.Object{}
 			for _, res := range resources {
 				resAnnotations := res.GetAnnotations()
@@ -342,40 +474,115 @@ func (hl *HistoryLimiter) doResourceCleanup(ctx context.Context, resource metav1
 	}
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Filter resources by status (success/failed)
+	// The resources already being deleted are not counted, as they are gone once their finalizers complete.
+	// The resources exempted by annotation are neither counted nor deleted, when the resource level config applies.
+	// Optionally exclude the resources already marked as prunable from the count
+	excludePrunable := PrunerConfigStore.GetExcludePrunableFromHistory(resource.GetNamespace())
+	honorExemption := enforcedConfigLevel == EnforcedConfigLevelResource
 	resourcesFiltered := []metav1.Object{}
 	for _, res := range resources {
+		if res.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if honorExemption && IsHistoryExempt(res) {
+			continue
+		}
+		if excludePrunable && IsMarkedPrunable(res) {
+			continue
+		}
 		if getResourceFilterFn(res) {
 			resourcesFiltered = append(resourcesFiltered, res)
 		}
 	}
-	resources = resourcesFiltered
 
-	if int(*historyLimit) > len(resources) {
-		return nil
+	// Sort resources newest first by completion time, then creation time, then name.
+	// Runs of bursty pipelines often share the same timestamps, the composite key
+	// keeps the same runs on every cycle
+	slices.SortFunc(resourcesFiltered, hl.compareNewestFirst)
+	group.resources = resourcesFiltered
+
+	// The byte budget keeps the newest runs until their cumulative size exceeds it, the stricter of both limits applies
+	if budget := PrunerConfigStore.GetHistoryLimitBytes(resource.GetNamespace()); budget != nil {
+		if fitting := countWithinBytes(resourcesFiltered, *budget); fitting < *group.limit {
+			logger.Debugw("historyLimitBytes is stricter than the history limit", "resourcename", resourceName, "limit", *group.limit, "withinBytes", fitting)
+			group.limit = ptr.Int32(fitting)
+		}
 	}
+	return group, nil
+}
 
-	// Sort resources by creation timestamp (newest first)
-	slices.SortStableFunc(resources, func(a, b metav1.Object) int {
-		objA := a.GetCreationTimestamp()
-		objB := b.GetCreationTimestamp()
-		if objA.Time.Before(objB.Time) {
-			return 1
-		} else if objA.Time.After(objB.Time) {
-			return -1
+// countWithinBytes returns how many of the given resources, newest first, fit in the budget of serialized bytes
+func countWithinBytes(resources []metav1.Object, budget int64) int32 {
+	var total int64
+	for index, res := range resources {
+		data, _ := json.Marshal(res)
+		total += int64(len(data))
+		if total > budget {
+			return int32(index)
 		}
-		return 0
-	})
+	}
+	return int32(len(resources))
+}
+
+// namespaceLevelIdentifiers are the identifiers of the history limits configured for a whole namespace, or globally
+var namespaceLevelIdentifiers = []string{"", "identified_by_ns_configmap", "identified_by_ns", "identified_by_global"}
+
+func (hl *HistoryLimiter) doResourceCleanup(ctx context.Context, resource metav1.Object, historyLimitAnnotation string, getHistoryLimitFn func(string, string, SelectorSpec) (*int32, string), getResourceFilterFn func(metav1.Object) bool) error {
+	logger := logging.FromContext(ctx)
+
+	group, err := hl.getHistoryGroup(ctx, resource, historyLimitAnnotation, getHistoryLimitFn, getResourceFilterFn)
+	if err != nil {
+		return err
+	}
+	metrics.SetSpanIdentifiedBy(ctx, group.identifiedBy)
+	metrics.SetSpanDecision(ctx, metrics.DecisionKept)
+
+	historyLimit, resources, shared := group.limit, group.resources, group.shared
+	if historyLimit == nil || *historyLimit < 0 || int(*historyLimit) > len(resources) {
+		return nil
+	}
+	evictionPriority := PrunerConfigStore.GetHistoryLimitEvictionPriority(resource.GetNamespace())
+	deletionReason := historyLimitDeletionReason(historyLimitAnnotation)
+	if shared {
+		deletionReason = PruneReasonHistoryLimit
+	}
 
 	// Select resources to delete (keep newest up to historyLimit)
 	var selectionForDeletion []metav1.Object
-	if *historyLimit == 0 {
-		selectionForDeletion = resources
+	if shared {
+		selectionForDeletion = hl.selectByEvictionPriority(resources, len(resources)-int(*historyLimit), evictionPriority)
 	} else {
-		selectionForDeletion = resources[*historyLimit:]
+		if *historyLimit == 0 {
+			selectionForDeletion = resources
+		} else {
+			selectionForDeletion = resources[*historyLimit:]
+		}
+
+		// Prune the oldest resources first, so that the newest survivors stabilize quickly when the deletions are batched
+		slices.Reverse(selectionForDeletion)
+	}
+
+	// The successful runs of the group are never pruned below the cluster-wide floor, whatever the limit of the namespace
+	if minSuccessful := PrunerConfigStore.GetMinSuccessfulToKeep(resource.GetNamespace()); minSuccessful != nil && (shared || historyLimitAnnotation == AnnotationSuccessfulHistoryLimit) {
+		selectionForDeletion, err = hl.keepMinSuccessful(ctx, selectionForDeletion, int(*minSuccessful))
+		if err != nil {
+			return err
+		}
+	}
+	deferred := false
+	if count := takeHistoryDeletions(ctx, len(selectionForDeletion)); count < len(selectionForDeletion) {
+		logger.Debugw("deferring history limit deletions to a later cycle",
+			"resource", hl.resourceFn.Type(),
+			"namespace", resource.GetNamespace(),
+			"name", resource.GetName(),
+			"deferred", len(selectionForDeletion)-count,
+		)
+		selectionForDeletion = selectionForDeletion[:count]
+		deferred = true
 	}
 
 	// Delete selected resources
@@ -385,7 +592,67 @@ func (hl *HistoryLimiter) doResourceCleanup(ctx context.Context, resource metav1
 		resourceType = metrics.ResourceTypeTaskRun
 	}
 
+	deletionMode := PrunerConfigStore.GetDeletionMode(resource.GetNamespace())
+	if len(selectionForDeletion) > 0 {
+		if deletionMode == DeletionModeAnnotate {
+			metrics.SetSpanDecision(ctx, metrics.DecisionMarkedPrunable)
+		} else {
+			metrics.SetSpanDecision(ctx, metrics.DecisionDeleted)
+		}
+	}
 	for _, res := range selectionForDeletion {
+		// The runs of the Pipelines and Tasks listed in neverPrune are kept whatever the history limit
+		if IsNeverPruned(hl.resourceFn.Type(), res) {
+			continue
+		}
+
+		// A resource finalized by another controller is kept, its deletion would hang until the finalizer is removed
+		if HasSkippedFinalizer(res) {
+			continue
+		}
+
+		// A resource referenced by an existing protecting resource is kept until that resource is gone
+		protected, err := IsProtected(ctx, res)
+		if err != nil {
+			logger.Errorw("error checking resource protection, skipping it",
+				"resource", hl.resourceFn.Type(),
+				"namespace", res.GetNamespace(),
+				"name", res.GetName(),
+				zap.Error(err),
+			)
+			continue
+		}
+		if protected {
+			continue
+		}
+
+		// In annotate mode, mark the resource as prunable and leave the actual removal to another process
+		if deletionMode == DeletionModeAnnotate {
+			if IsMarkedPrunable(res) {
+				continue
+			}
+			logger.Debugw("marking resource as prunable",
+				"resource", hl.resourceFn.Type(),
+				"namespace", res.GetNamespace(),
+				"name", res.GetName(),
+			)
+			if err := markPrunable(ctx, hl.resourceFn.Patch, res, deletionReason); err != nil {
+				if errors.IsNotFound(err) {
+					continue
+				}
+				errorType := metrics.ClassifyError(err)
+				metricsRecorder.RecordResourceError(ctx, resourceType, res.GetNamespace(), errorType, "history_annotation_failed")
+				logger.Errorw("error marking resource as prunable",
+					"resource", hl.resourceFn.Type(),
+					"namespace", res.GetNamespace(),
+					"name", res.GetName(),
+					zap.Error(err),
+				)
+				return err
+			}
+			continue
+		}
+
 		logger.Debugw("deleting resource",
 			"resource", hl.resourceFn.Type(),
 			"namespace", res.GetNamespace(),
@@ -400,7 +667,10 @@ func (hl *HistoryLimiter) doResourceCleanup(ctx context.Context, resource metav1
 			resourceAge = time.Since(creationTime.Time)
 		}
 
-		if err := hl.resourceFn.Delete(ctx, res.GetNamespace(), res.GetName()); err != nil {
+		err = LimitDeletion(ctx, func() error {
+			return hl.resourceFn.Delete(ctx, res.GetNamespace(), res.GetName())
+		})
+		if err != nil {
 			if errors.IsNotFound(err) {
 				continue
 			}
@@ -417,8 +687,186 @@ func (hl *HistoryLimiter) doResourceCleanup(ctx context.Context, resource metav1
 		}
 
 		// Record successful deletion
-		metricsRecorder.RecordResourceDeleted(ctx, resourceType, res.GetNamespace(), metrics.OperationHistory, resourceAge)
+		metricsRecorder.RecordResourceDeleted(ctx, resourceType, res.GetNamespace(), metrics.OperationHistory, deletionReason.String(), resourceAge)
+		NotifyPruned(ctx, NewPrunedEvent(hl.resourceFn.Type(), res, deletionReason))
 	}
 
+	if deferred {
+		return errHistoryDeletionsDeferred
+	}
 	return nil
 }
+
+// keepMinSuccessful returns the selection of resources to prune, oldest first, without the newest successful runs of each
+// Pipeline or Task which would leave fewer than minSuccessful successful runs of it across all the namespaces
+func (hl *HistoryLimiter) keepMinSuccessful(ctx context.Context, selection []metav1.Object, minSuccessful int) ([]metav1.Object, error) {
+	labelKeys := getGroupingLabelKeys(hl.resourceFn.Type(), hl.resourceFn.GetDefaultLabelKey())
+	groupOf := func(res metav1.Object) string {
+		return getResourceName(res, getGroupingLabelKey(res, labelKeys))
+	}
+
+	// the successful runs selected for pruning, by group. Runs without a Pipeline or Task name have no floor
+	selected := map[string]int{}
+	for _, res := range selection {
+		if group := groupOf(res); group != "" && hl.isSuccessfulResource(res) {
+			selected[group]++
+		}
+	}
+	if len(selected) == 0 {
+		return selection, nil
+	}
+
+	// the counts of a GC cycle are shared by its namespaces, which update them one after the other
+	counts := getSuccessfulCounts(ctx)
+	if counts != nil {
+		counts.mutex.Lock()
+		defer counts.mutex.Unlock()
+	}
+	successful, err := hl.countSuccessful(ctx, counts, groupOf)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := map[string]int{}
+	for group, count := range selected {
+		if keep := min(minSuccessful-(successful[group]-count), count); keep > 0 {
+			kept[group] = keep
+			logging.FromContext(ctx).Debugw("keeping successful runs to honor minSuccessfulToKeep",
+				"resource", hl.resourceFn.Type(),
+				"name", group,
+				"successful", successful[group],
+				"kept", keep,
+			)
+		}
+	}
+
+	// the selection is ordered oldest first, the newest successful runs of each group are kept
+	filtered := make([]metav1.Object, 0, len(selection))
+	for i := len(selection) - 1; i >= 0; i-- {
+		group := groupOf(selection[i])
+		if group == "" || !hl.isSuccessfulResource(selection[i]) {
+			filtered = append(filtered, selection[i])
+			continue
+		}
+		if kept[group] > 0 {
+			kept[group]--
+			continue
+		}
+		filtered = append(filtered, selection[i])
+		// the runs left to prune are counted out for the next namespaces of the cycle.
+		// A run whose deletion is deferred or fails is then undercounted, which only keeps more runs
+		successful[group]--
+	}
+	slices.Reverse(filtered)
+	return filtered, nil
+}
+
+// countSuccessful returns the number of successful runs of each Pipeline or Task across all the namespaces.
+// With the counts of a GC cycle, the runs are listed on the first call only, and the counts are shared with the next calls
+func (hl *HistoryLimiter) countSuccessful(ctx context.Context, counts *successfulCounts, groupOf func(metav1.Object) string) (map[string]int, error) {
+	if counts != nil {
+		if successful, found := counts.byKind[hl.resourceFn.Type()]; found {
+			return successful, nil
+		}
+	}
+
+	resourcesByNamespace, err := hl.resourceFn.ListByNamespaces(ctx, []string{metav1.NamespaceAll})
+	if err != nil {
+		return nil, err
+	}
+	// the runs being deleted or marked prunable are on their way out, they do not count towards the floor
+	successful := map[string]int{}
+	for _, resources := range resourcesByNamespace {
+		for _, res := range resources {
+			if res.GetDeletionTimestamp() != nil || IsMarkedPrunable(res) || !hl.isSuccessfulResource(res) {
+				continue
+			}
+			if group := groupOf(res); group != "" {
+				successful[group]++
+			}
+		}
+	}
+	if counts != nil {
+		counts.byKind[hl.resourceFn.Type()] = successful
+	}
+	return successful, nil
+}
+
+// successfulCountsKey is used as the key for associating the successful run counts of a GC cycle with the context
+type successfulCountsKey struct{}
+
+// successfulCounts holds the number of successful runs of each Pipeline and Task across all the namespaces, by kind
+type successfulCounts struct {
+	mutex  sync.Mutex
+	byKind map[string]map[string]int
+}
+
+// WithSuccessfulCounts attaches the successful run counts used by minSuccessfulToKeep to the context,
+// so that the runs of all the namespaces are listed at most once while the context is in use, e.g. for a GC cycle
+func WithSuccessfulCounts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, successfulCountsKey{}, &successfulCounts{byKind: map[string]map[string]int{}})
+}
+
+// getSuccessfulCounts returns the successful run counts of the context, nil if there are none
+func getSuccessfulCounts(ctx context.Context) *successfulCounts {
+	counts, _ := ctx.Value(successfulCountsKey{}).(*successfulCounts)
+	return counts
+}
+
+// hasSharedHistoryLimit tells whether the successful and failed runs of a group have the same history limit,
+// configured at the same level, as when only historyLimit is set
+func (hl *HistoryLimiter) hasSharedHistoryLimit(namespace, name string, selectors SelectorSpec) bool {
+	successLimit, successIdentifiedBy := hl.resourceFn.GetSuccessHistoryLimitCount(namespace, name, selectors)
+	failedLimit, failedIdentifiedBy := hl.resourceFn.GetFailedHistoryLimitCount(namespace, name, selectors)
+	return successLimit != nil && failedLimit != nil && *successLimit == *failedLimit && successIdentifiedBy == failedIdentifiedBy
+}
+
+// selectByEvictionPriority returns count of the given resources, sorted newest first, to prune over a shared history limit.
+// The runs of the prioritized status are selected first, the oldest first, then the oldest runs of the other status
+func (hl *HistoryLimiter) selectByEvictionPriority(resources []metav1.Object, count int, priority HistoryLimitEvictionPriority) []metav1.Object {
+	evicted := slices.Clone(resources)
+	slices.Reverse(evicted)
+	isPrioritized := hl.isFailedResource
+	if priority == HistoryLimitEvictionSuccessful {
+		isPrioritized = hl.isSuccessfulResource
+	}
+	slices.SortStableFunc(evicted, func(a, b metav1.Object) int {
+		switch prioritizedA, prioritizedB := isPrioritized(a), isPrioritized(b); {
+		case prioritizedA && !prioritizedB:
+			return -1
+		case !prioritizedA && prioritizedB:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return evicted[:count]
+}
+
+// compareNewestFirst orders resources from the most to the least recently completed.
+// Completion time ties are broken by creation time, then by name, so the order is deterministic
+func (hl *HistoryLimiter) compareNewestFirst(a, b metav1.Object) int {
+	// a missing completion time sorts as the oldest
+	completionA, _ := hl.resourceFn.GetCompletionTime(a)
+	completionB, _ := hl.resourceFn.GetCompletionTime(b)
+	if c := completionB.Compare(completionA.Time); c != 0 {
+		return c
+	}
+	creationA, creationB := a.GetCreationTimestamp(), b.GetCreationTimestamp()
+	if c := creationB.Compare(creationA.Time); c != 0 {
+		return c
+	}
+	return strings.Compare(b.GetName(), a.GetName())
+}
+
+// historyLimitDeletionReason returns the deletion reason reported for the history limit of the given annotation
+func historyLimitDeletionReason(historyLimitAnnotation string) PruneReason {
+	switch historyLimitAnnotation {
+	case AnnotationSuccessfulHistoryLimit:
+		return PruneReasonSuccessfulHistoryLimit
+	case AnnotationFailedHistoryLimit:
+		return PruneReasonFailedHistoryLimit
+	default:
+		return PruneReasonHistoryLimit
+	}
+}
@@ -2,11 +2,18 @@ package config
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/ptr"
@@ -15,9 +22,11 @@ import (
 // mockResource implements metav1.Object for testing
 type mockResource struct {
 	metav1.ObjectMeta
-	completed  bool
-	successful bool
-	failed     bool
+	completed      bool
+	successful     bool
+	failed         bool
+	cancelled      bool
+	completionTime metav1.Time
 }
 
 // mockResourceFuncs implements HistoryLimiterResourceFuncs for testing
@@ -27,9 +36,16 @@ type mockResourceFuncs struct {
 	failedLimit     *int32
 	enforceLevel    EnforcedConfigLevel
 	defaultLabelKey string
+	// kind overrides the resource type reported by Type
+	kind string
 }
 
-func (m *mockResourceFuncs) Type() string { return "MockResource" }
+func (m *mockResourceFuncs) Type() string {
+	if m.kind != "" {
+		return m.kind
+	}
+	return "MockResource"
+}
 
 func (m *mockResourceFuncs) Get(_ context.Context, namespace, name string) (metav1.Object, error) {
 	for _, res := range m.resources[namespace] {
@@ -64,6 +80,20 @@ func (m *mockResourceFuncs) List(_ context.Context, namespace, _ string) ([]meta
 	return m.resources[namespace], nil
 }
 
+func (m *mockResourceFuncs) ListByNamespaces(_ context.Context, namespaces []string) (map[string][]metav1.Object, error) {
+	results := make(map[string][]metav1.Object)
+	for _, namespace := range namespaces {
+		if namespace == metav1.NamespaceAll {
+			for ns, resources := range m.resources {
+				results[ns] = slices.Clone(resources)
+			}
+			continue
+		}
+		results[namespace] = slices.Clone(m.resources[namespace])
+	}
+	return results, nil
+}
+
 func (m *mockResourceFuncs) GetSuccessHistoryLimitCount(_, _ string, _ SelectorSpec) (*int32, string) {
 	return m.successLimit, "identified_by_global"
 }
@@ -86,6 +116,13 @@ func (m *mockResourceFuncs) IsFailed(resource metav1.Object) bool {
 	return false
 }
 
+func (m *mockResourceFuncs) IsCancelled(resource metav1.Object) bool {
+	if mr, ok := resource.(*mockResource); ok {
+		return mr.cancelled
+	}
+	return false
+}
+
 func (m *mockResourceFuncs) IsCompleted(resource metav1.Object) bool {
 	if mr, ok := resource.(*mockResource); ok {
 		return mr.completed
@@ -93,6 +130,13 @@ func (m *mockResourceFuncs) IsCompleted(resource metav1.Object) bool {
 	return false
 }
 
+func (m *mockResourceFuncs) GetCompletionTime(resource metav1.Object) (metav1.Time, error) {
+	if mr, ok := resource.(*mockResource); ok {
+		return mr.completionTime, nil
+	}
+	return metav1.Time{}, fmt.Errorf("completion time not set")
+}
+
 func (m *mockResourceFuncs) GetDefaultLabelKey() string { return m.defaultLabelKey }
 
 func (m *mockResourceFuncs) GetEnforcedConfigLevel(_, _ string, _ SelectorSpec) EnforcedConfigLevel {
@@ -338,3 +382,1132 @@ func TestDoResourceCleanup(t *testing.T) {
 		})
 	}
 }
+
+// groupingResourceFuncs is a mockResourceFuncs which identifies resources by name label
+// and lists only the resources matching the given "key=value" label selector
+type groupingResourceFuncs struct {
+	*mockResourceFuncs
+}
+
+func (g *groupingResourceFuncs) Type() string { return KindPipelineRun }
+
+func (g *groupingResourceFuncs) List(_ context.Context, namespace, label string) ([]metav1.Object, error) {
+	key, value, _ := strings.Cut(label, "=")
+	var resources []metav1.Object
+	for _, res := range g.resources[namespace] {
+		if labelValue, exists := res.GetLabels()[key]; exists && labelValue == value {
+			resources = append(resources, res)
+		}
+	}
+	return resources, nil
+}
+
+func (g *groupingResourceFuncs) GetSuccessHistoryLimitCount(_, _ string, _ SelectorSpec) (*int32, string) {
+	return g.successLimit, "identifiedBy_resource_name"
+}
+
+// TestGetGroupingLabelKey verifies that the first label key present on a resource is used for grouping
+func TestGetGroupingLabelKey(t *testing.T) {
+	labelKeys := []string{LabelPipelineName, LabelPipelineRunName}
+
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		want        string
+	}{
+		{
+			name:   "default label key present",
+			labels: map[string]string{LabelPipelineName: "build", LabelPipelineRunName: "build-run"},
+			want:   LabelPipelineName,
+		},
+		{
+			name:   "fallback label key present",
+			labels: map[string]string{LabelPipelineRunName: "build-run"},
+			want:   LabelPipelineRunName,
+		},
+		{
+			name: "no label key present",
+			want: LabelPipelineName,
+		},
+		{
+			name:        "user defined label key takes precedence",
+			labels:      map[string]string{LabelPipelineName: "build", "app": "web"},
+			annotations: map[string]string{AnnotationResourceNameLabelKey: "app"},
+			want:        "app",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := &mockResource{ObjectMeta: metav1.ObjectMeta{Labels: tt.labels, Annotations: tt.annotations}}
+			assert.Equal(t, tt.want, getGroupingLabelKey(resource, labelKeys))
+		})
+	}
+}
+
+// selectorResourceFuncs matches every run with the given history limit selector
+type selectorResourceFuncs struct {
+	*mockResourceFuncs
+	selector *SelectorSpec
+}
+
+func (s *selectorResourceFuncs) GetMatchingSelector(_, _ string, _ SelectorSpec) *SelectorSpec {
+	return s.selector
+}
+
+// TestGetHistoryGroupAnnotationFilterWarning verifies that a warning is logged when a history limit selector
+// filters more than AnnotationFilterWarningThreshold runs by matchAnnotations in memory
+func TestGetHistoryGroupAnnotationFilterWarning(t *testing.T) {
+	selector := &SelectorSpec{
+		MatchLabels:      map[string]string{"app": "build"},
+		MatchAnnotations: map[string]string{"owner": "team-a"},
+	}
+	newRuns := func(count int) []metav1.Object {
+		var runs []metav1.Object
+		for i := 0; i < count; i++ {
+			owner := "team-a"
+			if i%2 == 1 {
+				owner = "team-b"
+			}
+			runs = append(runs, &mockResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        fmt.Sprintf("run-%d", i),
+					Namespace:   "default",
+					Labels:      selector.MatchLabels,
+					Annotations: map[string]string{"owner": owner},
+				},
+				completed:  true,
+				successful: true,
+			})
+		}
+		return runs
+	}
+
+	tests := []struct {
+		name        string
+		runs        int
+		wantWarning bool
+	}{
+		{name: "runs within the threshold", runs: 10},
+		{name: "runs beyond the threshold", runs: AnnotationFilterWarningThreshold + 2, wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []string
+			logger := zaptest.NewLogger(t, zaptest.WrapOptions(zap.Hooks(func(entry zapcore.Entry) error {
+				if entry.Level == zapcore.WarnLevel {
+					warnings = append(warnings, entry.Message)
+				}
+				return nil
+			})))
+			ctx := logging.WithLogger(context.Background(), logger.Sugar())
+
+			runs := newRuns(tt.runs)
+			hl, err := NewHistoryLimiter(&selectorResourceFuncs{
+				mockResourceFuncs: &mockResourceFuncs{resources: map[string][]metav1.Object{"default": runs}, enforceLevel: EnforcedConfigLevelNamespace},
+				selector:          selector,
+			})
+			assert.NoError(t, err)
+
+			getHistoryLimit := func(_, _ string, _ SelectorSpec) (*int32, string) {
+				return ptr.Int32(1), "identifiedBy_resource_selector"
+			}
+			group, err := hl.getHistoryGroup(ctx, runs[0], AnnotationSuccessfulHistoryLimit, getHistoryLimit, hl.isSuccessfulResource)
+			assert.NoError(t, err)
+			assert.Len(t, group.resources, (tt.runs+1)/2, "only the runs matching the annotations are counted")
+
+			warned := slices.ContainsFunc(warnings, func(message string) bool {
+				return strings.Contains(message, "filtering many runs by annotations in memory")
+			})
+			assert.Equal(t, tt.wantWarning, warned)
+		})
+	}
+}
+
+// TestDoResourceCleanupMixedLabelKeys verifies that runs carrying different grouping label keys
+// are each pruned within their own group when the label keys are configured
+func TestDoResourceCleanupMixedLabelKeys(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+
+	cm := &corev1.ConfigMap{Data: map[string]string{
+		PrunerGlobalConfigKey: `pipelineRunLabelKeys: [tekton.dev/pipeline, legacy.tekton.dev/pipeline]`,
+	}}
+	assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	newRun := func(name, labelKey string, age time.Duration) *mockResource {
+		return &mockResource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				Labels:            map[string]string{labelKey: "build"},
+				CreationTimestamp: metav1.Time{Time: time.Now().Add(-age)},
+			},
+			completed:  true,
+			successful: true,
+		}
+	}
+	resources := []metav1.Object{
+		newRun("current-1", LabelPipelineName, 4*time.Hour),
+		newRun("current-2", LabelPipelineName, 3*time.Hour),
+		newRun("legacy-1", "legacy.tekton.dev/pipeline", 2*time.Hour),
+		newRun("legacy-2", "legacy.tekton.dev/pipeline", 1*time.Hour),
+	}
+
+	mockFuncs := &groupingResourceFuncs{&mockResourceFuncs{
+		resources:       map[string][]metav1.Object{"default": resources},
+		successLimit:    ptr.Int32(1),
+		enforceLevel:    EnforcedConfigLevelResource,
+		defaultLabelKey: LabelPipelineName,
+	}}
+
+	hl, err := NewHistoryLimiter(mockFuncs)
+	assert.NoError(t, err)
+	for _, res := range resources {
+		assert.NoError(t, hl.ProcessEvent(ctx, res))
+	}
+
+	var remaining []string
+	for _, res := range mockFuncs.resources["default"] {
+		remaining = append(remaining, res.GetName())
+	}
+	assert.ElementsMatch(t, []string{"current-2", "legacy-2"}, remaining)
+}
+
+// TestDoResourceCleanupEqualTimestamps verifies that runs sharing the same timestamps are kept or deleted
+// deterministically, whatever the order they are listed in
+func TestDoResourceCleanupEqualTimestamps(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+	completedAt := metav1.Time{Time: time.Now().Add(-time.Hour).Truncate(time.Second)}
+	createdAt := metav1.Time{Time: completedAt.Add(-time.Minute)}
+
+	newRun := func(name string, created, completed metav1.Time) *mockResource {
+		return &mockResource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				Labels:            map[string]string{LabelPipelineName: "build"},
+				CreationTimestamp: created,
+			},
+			completed:      true,
+			successful:     true,
+			completionTime: completed,
+		}
+	}
+	runs := []*mockResource{
+		newRun("build-a", createdAt, completedAt),
+		newRun("build-b", createdAt, completedAt),
+		newRun("build-c", createdAt, completedAt),
+		newRun("build-d", metav1.Time{Time: createdAt.Add(time.Second)}, completedAt),
+		newRun("build-e", createdAt, metav1.Time{Time: completedAt.Add(-time.Second)}),
+	}
+
+	for i := range runs {
+		// list the runs in a different order on every cycle
+		var resources []metav1.Object
+		for j := range runs {
+			resources = append(resources, runs[(i+j)%len(runs)])
+		}
+		mockFuncs := &mockResourceFuncs{
+			resources:    map[string][]metav1.Object{"default": resources},
+			successLimit: ptr.Int32(3),
+			enforceLevel: EnforcedConfigLevelGlobal,
+		}
+		hl, err := NewHistoryLimiter(mockFuncs)
+		assert.NoError(t, err)
+		assert.NoError(t, hl.DoSuccessfulResourceCleanup(ctx, resources[0]))
+
+		var remaining []string
+		for _, res := range mockFuncs.resources["default"] {
+			remaining = append(remaining, res.GetName())
+		}
+		assert.ElementsMatch(t, []string{"build-d", "build-c", "build-b"}, remaining, "cycle %d", i)
+	}
+}
+
+// TestDoResourceCleanupDeletionInProgress verifies that the runs already being deleted
+// are not counted toward the history limit, nor deleted again
+func TestDoResourceCleanupDeletionInProgress(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+
+	var resources []metav1.Object
+	for i, name := range []string{"oldest", "older", "deleting", "newest"} {
+		res := &mockResource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: metav1.Time{Time: time.Now().Add(-time.Duration(4-i) * time.Hour)},
+			},
+			completed:  true,
+			successful: true,
+		}
+		if name == "deleting" {
+			res.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			res.Finalizers = []string{"example.com/finalizer"}
+		}
+		resources = append(resources, res)
+	}
+
+	mockFuncs := &mockResourceFuncs{
+		resources:    map[string][]metav1.Object{"default": resources},
+		successLimit: ptr.Int32(2),
+		enforceLevel: EnforcedConfigLevelGlobal,
+	}
+	hl, err := NewHistoryLimiter(mockFuncs)
+	assert.NoError(t, err)
+	assert.NoError(t, hl.DoSuccessfulResourceCleanup(ctx, resources[3]))
+
+	var remaining []string
+	for _, res := range mockFuncs.resources["default"] {
+		remaining = append(remaining, res.GetName())
+	}
+	// the run being deleted stays until its finalizers complete, the two newest other runs are kept
+	assert.ElementsMatch(t, []string{"older", "deleting", "newest"}, remaining)
+}
+
+// TestDoResourceCleanupNeverPrune verifies that the runs listed in neverPrune survive the history limit
+func TestDoResourceCleanupNeverPrune(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+	cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `
+neverPrune:
+  taskRuns: [db-migrate]`}}
+	assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	var resources []metav1.Object
+	for i, run := range []struct{ name, task string }{
+		{"migrate-old", "db-migrate"},
+		{"build-old", "build"},
+		{"migrate-new", "db-migrate"},
+		{"build-new", "build"},
+	} {
+		resources = append(resources, &mockResource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              run.name,
+				Namespace:         "default",
+				Labels:            map[string]string{LabelTaskName: run.task},
+				CreationTimestamp: metav1.Time{Time: time.Now().Add(-time.Duration(4-i) * time.Hour)},
+			},
+			completed:  true,
+			successful: true,
+		})
+	}
+
+	mockFuncs := &mockResourceFuncs{
+		resources:    map[string][]metav1.Object{"default": resources},
+		successLimit: ptr.Int32(1),
+		enforceLevel: EnforcedConfigLevelGlobal,
+		kind:         KindTaskRun,
+	}
+	hl, err := NewHistoryLimiter(mockFuncs)
+	assert.NoError(t, err)
+	assert.NoError(t, hl.DoSuccessfulResourceCleanup(ctx, resources[3]))
+
+	var remaining []string
+	for _, res := range mockFuncs.resources["default"] {
+		remaining = append(remaining, res.GetName())
+	}
+	assert.ElementsMatch(t, []string{"migrate-old", "migrate-new", "build-new"}, remaining)
+}
+
+// TestDoResourceCleanupSkipRunsWithFinalizers verifies that the runs with a finalizer listed in skipRunsWithFinalizers
+// are not deleted over the history limit
+func TestDoResourceCleanupSkipRunsWithFinalizers(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+	cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `skipRunsWithFinalizers: [chains.tekton.dev]`}}
+	assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	var resources []metav1.Object
+	for i, run := range []struct {
+		name       string
+		finalizers []string
+	}{
+		{"signing-old", []string{"chains.tekton.dev"}},
+		{"audit-old", []string{"example.com/audit"}},
+		{"run-new", nil},
+	} {
+		resources = append(resources, &mockResource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              run.name,
+				Namespace:         "default",
+				Labels:            map[string]string{LabelPipelineName: "build"},
+				Finalizers:        run.finalizers,
+				CreationTimestamp: metav1.Time{Time: time.Now().Add(-time.Duration(3-i) * time.Hour)},
+			},
+			completed:  true,
+			successful: true,
+		})
+	}
+
+	mockFuncs := &mockResourceFuncs{
+		resources:    map[string][]metav1.Object{"default": resources},
+		successLimit: ptr.Int32(1),
+		enforceLevel: EnforcedConfigLevelGlobal,
+	}
+	hl, err := NewHistoryLimiter(mockFuncs)
+	assert.NoError(t, err)
+	assert.NoError(t, hl.DoSuccessfulResourceCleanup(ctx, resources[2]))
+
+	var remaining []string
+	for _, res := range mockFuncs.resources["default"] {
+		remaining = append(remaining, res.GetName())
+	}
+	assert.ElementsMatch(t, []string{"signing-old", "run-new"}, remaining)
+}
+
+// TestDoResourceCleanupHistoryExempt verifies that the runs exempted by annotation are neither counted nor deleted
+// when the resource level config applies, and are pruned as any other run otherwise
+func TestDoResourceCleanupHistoryExempt(t *testing.T) {
+	tests := []struct {
+		name          string
+		enforceLevel  EnforcedConfigLevel
+		wantRemaining []string
+	}{
+		{
+			name:          "resource level honors the exemption",
+			enforceLevel:  EnforcedConfigLevelResource,
+			wantRemaining: []string{"exempt-old", "run-new"},
+		},
+		{
+			name:          "namespace level ignores the exemption",
+			enforceLevel:  EnforcedConfigLevelNamespace,
+			wantRemaining: []string{"run-new"},
+		},
+		{
+			name:          "global level ignores the exemption",
+			enforceLevel:  EnforcedConfigLevelGlobal,
+			wantRemaining: []string{"run-new"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+
+			var resources []metav1.Object
+			for i, run := range []struct {
+				name   string
+				exempt bool
+			}{
+				{"exempt-old", true},
+				{"run-old", false},
+				{"run-new", false},
+			} {
+				res := &mockResource{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              run.name,
+						Namespace:         "default",
+						Labels:            map[string]string{LabelPipelineName: "build"},
+						CreationTimestamp: metav1.Time{Time: time.Now().Add(-time.Duration(3-i) * time.Hour)},
+					},
+					completed:  true,
+					successful: true,
+				}
+				if run.exempt {
+					res.Annotations = map[string]string{AnnotationHistoryExempt: "true"}
+				}
+				resources = append(resources, res)
+			}
+
+			mockFuncs := &mockResourceFuncs{
+				resources:    map[string][]metav1.Object{"default": resources},
+				successLimit: ptr.Int32(1),
+				enforceLevel: tt.enforceLevel,
+			}
+			hl, err := NewHistoryLimiter(mockFuncs)
+			assert.NoError(t, err)
+			assert.NoError(t, hl.DoSuccessfulResourceCleanup(ctx, resources[2]))
+
+			var remaining []string
+			for _, res := range mockFuncs.resources["default"] {
+				remaining = append(remaining, res.GetName())
+			}
+			assert.ElementsMatch(t, tt.wantRemaining, remaining)
+		})
+	}
+}
+
+// TestDoResourceCleanupEvictionPriority verifies the runs pruned from a mixed group with each historyLimitEvictionPriority
+func TestDoResourceCleanupEvictionPriority(t *testing.T) {
+	tests := []struct {
+		name          string
+		priority      string
+		failedLimit   int32
+		wantRemaining []string
+	}{
+		{
+			name:          "oldest applies the limit to each status",
+			priority:      "oldest",
+			failedLimit:   2,
+			wantRemaining: []string{"success-2", "failed-2", "success-3", "failed-3"},
+		},
+		{
+			name:          "failed runs evicted first",
+			priority:      "failed",
+			failedLimit:   2,
+			wantRemaining: []string{"success-2", "success-3"},
+		},
+		{
+			name:          "successful runs evicted first",
+			priority:      "successful",
+			failedLimit:   2,
+			wantRemaining: []string{"failed-2", "failed-3"},
+		},
+		{
+			name:          "granular limits apply to each status",
+			priority:      "failed",
+			failedLimit:   3,
+			wantRemaining: []string{"success-2", "success-3", "failed-1", "failed-2", "failed-3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: "historyLimitEvictionPriority: " + tt.priority}}
+			assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			// successful and failed runs alternate, from the oldest to the newest
+			now := time.Now()
+			var resources []metav1.Object
+			for i, name := range []string{"success-1", "failed-1", "success-2", "failed-2", "success-3", "failed-3"} {
+				completionTime := metav1.Time{Time: now.Add(-time.Duration(6-i) * time.Hour)}
+				resources = append(resources, &mockResource{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              name,
+						Namespace:         "default",
+						Labels:            map[string]string{LabelPipelineName: "build"},
+						CreationTimestamp: completionTime,
+					},
+					completed:      true,
+					successful:     strings.HasPrefix(name, "success"),
+					failed:         strings.HasPrefix(name, "failed"),
+					completionTime: completionTime,
+				})
+			}
+
+			mockFuncs := &mockResourceFuncs{
+				resources:    map[string][]metav1.Object{"default": resources},
+				successLimit: ptr.Int32(2),
+				failedLimit:  ptr.Int32(tt.failedLimit),
+				enforceLevel: EnforcedConfigLevelGlobal,
+			}
+			hl, err := NewHistoryLimiter(mockFuncs)
+			assert.NoError(t, err)
+			assert.NoError(t, hl.DoSuccessfulResourceCleanup(ctx, resources[5]))
+			assert.NoError(t, hl.DoFailedResourceCleanup(ctx, resources[5]))
+
+			var remaining []string
+			for _, res := range mockFuncs.resources["default"] {
+				remaining = append(remaining, res.GetName())
+			}
+			assert.ElementsMatch(t, tt.wantRemaining, remaining)
+		})
+	}
+}
+
+// TestDoResourceCleanupCancelledCountsAs verifies that the cancelled runs count toward the history limit
+// configured by cancelledCountsAs, or are left out of the history limits
+func TestDoResourceCleanupCancelledCountsAs(t *testing.T) {
+	tests := []struct {
+		name          string
+		countsAs      string
+		wantRemaining []string
+	}{
+		{
+			name:          "default counts cancelled runs as failed",
+			wantRemaining: []string{"success-1", "success-2", "cancelled-2", "failed-2"},
+		},
+		{
+			name:          "failed",
+			countsAs:      "failed",
+			wantRemaining: []string{"success-1", "success-2", "cancelled-2", "failed-2"},
+		},
+		{
+			name:          "successful",
+			countsAs:      "successful",
+			wantRemaining: []string{"success-2", "cancelled-2", "failed-1", "failed-2"},
+		},
+		{
+			name:          "ignored",
+			countsAs:      "ignored",
+			wantRemaining: []string{"success-1", "success-2", "cancelled-1", "cancelled-2", "failed-1", "failed-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+			cm := &corev1.ConfigMap{Data: map[string]string{}}
+			if tt.countsAs != "" {
+				cm.Data[PrunerGlobalConfigKey] = "cancelledCountsAs: " + tt.countsAs
+			}
+			assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			// a cancelled run reports a failure, as Tekton does, from the oldest to the newest
+			now := time.Now()
+			var resources []metav1.Object
+			for i, name := range []string{"success-1", "cancelled-1", "failed-1", "success-2", "cancelled-2", "failed-2"} {
+				completionTime := metav1.Time{Time: now.Add(-time.Duration(6-i) * time.Hour)}
+				resources = append(resources, &mockResource{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              name,
+						Namespace:         "default",
+						Labels:            map[string]string{LabelPipelineName: "build"},
+						CreationTimestamp: completionTime,
+					},
+					completed:      true,
+					successful:     strings.HasPrefix(name, "success"),
+					failed:         !strings.HasPrefix(name, "success"),
+					cancelled:      strings.HasPrefix(name, "cancelled"),
+					completionTime: completionTime,
+				})
+			}
+
+			mockFuncs := &mockResourceFuncs{
+				resources:    map[string][]metav1.Object{"default": resources},
+				successLimit: ptr.Int32(2),
+				failedLimit:  ptr.Int32(2),
+				enforceLevel: EnforcedConfigLevelGlobal,
+			}
+			hl, err := NewHistoryLimiter(mockFuncs)
+			assert.NoError(t, err)
+			assert.NoError(t, hl.DoSuccessfulResourceCleanup(ctx, resources[5]))
+			assert.NoError(t, hl.DoFailedResourceCleanup(ctx, resources[5]))
+
+			var remaining []string
+			for _, res := range mockFuncs.resources["default"] {
+				remaining = append(remaining, res.GetName())
+			}
+			assert.ElementsMatch(t, tt.wantRemaining, remaining)
+		})
+	}
+}
+
+// listCountingResourceFuncs is a mockResourceFuncs which counts the calls to ListByNamespaces
+type listCountingResourceFuncs struct {
+	*mockResourceFuncs
+	lists int
+}
+
+func (m *listCountingResourceFuncs) ListByNamespaces(ctx context.Context, namespaces []string) (map[string][]metav1.Object, error) {
+	m.lists++
+	return m.mockResourceFuncs.ListByNamespaces(ctx, namespaces)
+}
+
+// TestDoResourceCleanupMinSuccessfulToKeep verifies that the successful runs of a Pipeline are never pruned below
+// minSuccessfulToKeep across all the namespaces, and that a GC cycle lists the runs of the cluster at most once to count them
+func TestDoResourceCleanupMinSuccessfulToKeep(t *testing.T) {
+	tests := []struct {
+		name         string
+		globalConfig string
+		successLimit int32
+		// wantRemaining holds the number of release runs remaining in each namespace
+		wantRemaining map[string]int
+	}{
+		{
+			name:          "no floor",
+			globalConfig:  `enforcedConfigLevel: global`,
+			successLimit:  0,
+			wantRemaining: map[string]int{"team-a": 0, "team-b": 0},
+		},
+		{
+			name:          "floor held across namespaces",
+			globalConfig:  `minSuccessfulToKeep: 3`,
+			successLimit:  0,
+			wantRemaining: map[string]int{"team-a": 1, "team-b": 2},
+		},
+		{
+			name:          "floor below the namespace limits",
+			globalConfig:  `minSuccessfulToKeep: 2`,
+			successLimit:  1,
+			wantRemaining: map[string]int{"team-a": 1, "team-b": 1},
+		},
+		{
+			name:          "floor above all the runs",
+			globalConfig:  `minSuccessfulToKeep: 10`,
+			successLimit:  0,
+			wantRemaining: map[string]int{"team-a": 2, "team-b": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, cycle := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/cycle=%v", tt.name, cycle), func(t *testing.T) {
+				ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+				if cycle {
+					ctx = WithSuccessfulCounts(ctx)
+				}
+				cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: tt.globalConfig}}
+				assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+				defer func() {
+					_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+				}()
+
+				// each namespace holds two successful runs of the release pipeline, team-b also holds runs of another pipeline
+				now := time.Now()
+				newRun := func(namespace, pipeline string, hoursAgo int) metav1.Object {
+					completionTime := metav1.Time{Time: now.Add(-time.Duration(hoursAgo) * time.Hour)}
+					return &mockResource{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:              fmt.Sprintf("%s-%d", pipeline, hoursAgo),
+							Namespace:         namespace,
+							Labels:            map[string]string{LabelPipelineName: pipeline},
+							CreationTimestamp: completionTime,
+						},
+						completed:      true,
+						successful:     true,
+						completionTime: completionTime,
+					}
+				}
+				mockFuncs := &listCountingResourceFuncs{mockResourceFuncs: &mockResourceFuncs{
+					resources: map[string][]metav1.Object{
+						"team-a": {newRun("team-a", "release", 4), newRun("team-a", "release", 3)},
+						"team-b": {newRun("team-b", "release", 2), newRun("team-b", "release", 1), newRun("team-b", "build", 1), newRun("team-b", "build", 2)},
+					},
+					successLimit:    ptr.Int32(tt.successLimit),
+					enforceLevel:    EnforcedConfigLevelGlobal,
+					defaultLabelKey: LabelPipelineName,
+				}}
+				hl, err := NewHistoryLimiter(mockFuncs)
+				assert.NoError(t, err)
+				for _, namespace := range []string{"team-a", "team-b"} {
+					for _, res := range slices.Clone(mockFuncs.resources[namespace]) {
+						if res.GetLabels()[LabelPipelineName] == "release" {
+							assert.NoError(t, hl.DoSuccessfulResourceCleanup(ctx, res))
+							break
+						}
+					}
+				}
+
+				remaining := map[string]int{}
+				for namespace, resources := range mockFuncs.resources {
+					for _, res := range resources {
+						if res.GetLabels()[LabelPipelineName] == "release" {
+							remaining[namespace]++
+						}
+					}
+				}
+				for namespace, want := range tt.wantRemaining {
+					assert.Equal(t, want, remaining[namespace], "release runs remaining in %s", namespace)
+				}
+				if cycle {
+					assert.LessOrEqual(t, mockFuncs.lists, 1, "runs of the cluster listed more than once in a cycle")
+				}
+			})
+		}
+	}
+}
+
+// TestHistoryLimitDeletionReason verifies the deletion reason reported for each history limit
+func TestHistoryLimitDeletionReason(t *testing.T) {
+	assert.Equal(t, PruneReasonSuccessfulHistoryLimit, historyLimitDeletionReason(AnnotationSuccessfulHistoryLimit))
+	assert.Equal(t, PruneReasonFailedHistoryLimit, historyLimitDeletionReason(AnnotationFailedHistoryLimit))
+	assert.Equal(t, PruneReasonHistoryLimit, historyLimitDeletionReason("example.com/historyLimit"))
+}
+
+// annotationPatchResourceFuncs is a mockResourceFuncs which applies the annotations of a patch
+type annotationPatchResourceFuncs struct {
+	*mockResourceFuncs
+}
+
+func (m *annotationPatchResourceFuncs) Patch(_ context.Context, namespace, name string, patchBytes []byte) error {
+	for _, res := range m.resources[namespace] {
+		if res.GetName() != name {
+			continue
+		}
+		annotations, err := applyAnnotationPatch(res.GetAnnotations(), patchBytes)
+		if err != nil {
+			return err
+		}
+		res.SetAnnotations(annotations)
+	}
+	return nil
+}
+
+// TestDoResourceCleanupDeletionMode verifies that the resources beyond the history limit are deleted
+// in delete mode and marked as prunable, but kept, in annotate mode
+func TestDoResourceCleanupDeletionMode(t *testing.T) {
+	tests := []struct {
+		name          string
+		globalConfig  string
+		prunable      []string
+		wantRemaining []string
+		wantPrunable  []string
+	}{
+		{
+			name:          "delete mode",
+			globalConfig:  `deletionMode: delete`,
+			wantRemaining: []string{"newest"},
+		},
+		{
+			name:          "annotate mode",
+			globalConfig:  `deletionMode: annotate`,
+			wantRemaining: []string{"oldest", "middle", "newest"},
+			wantPrunable:  []string{"oldest", "middle"},
+		},
+		{
+			name:          "annotate mode counts the runs already marked as prunable",
+			globalConfig:  `deletionMode: annotate`,
+			prunable:      []string{"newest"},
+			wantRemaining: []string{"oldest", "middle", "newest"},
+			wantPrunable:  []string{"oldest", "middle", "newest"},
+		},
+		{
+			name: "annotate mode excludes the runs already marked as prunable",
+			globalConfig: `
+deletionMode: annotate
+excludePrunableFromHistory: true`,
+			prunable:      []string{"newest"},
+			wantRemaining: []string{"oldest", "middle", "newest"},
+			wantPrunable:  []string{"oldest", "newest"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: tt.globalConfig}}
+			assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			var resources []metav1.Object
+			for i, name := range []string{"oldest", "middle", "newest"} {
+				res := &mockResource{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              name,
+						Namespace:         "default",
+						CreationTimestamp: metav1.Time{Time: time.Now().Add(-time.Duration(3-i) * time.Hour)},
+					},
+					completed:  true,
+					successful: true,
+				}
+				if slices.Contains(tt.prunable, name) {
+					res.Annotations = map[string]string{AnnotationPrunable: "true"}
+				}
+				resources = append(resources, res)
+			}
+
+			mockFuncs := &annotationPatchResourceFuncs{&mockResourceFuncs{
+				resources:       map[string][]metav1.Object{"default": resources},
+				successLimit:    ptr.Int32(1),
+				enforceLevel:    EnforcedConfigLevelGlobal,
+				defaultLabelKey: "test.label/name",
+			}}
+			hl, err := NewHistoryLimiter(mockFuncs)
+			assert.NoError(t, err)
+
+			assert.NoError(t, hl.DoSuccessfulResourceCleanup(ctx, resources[2]))
+
+			var remaining, prunable []string
+			for _, res := range mockFuncs.resources["default"] {
+				remaining = append(remaining, res.GetName())
+				if IsMarkedPrunable(res) {
+					prunable = append(prunable, res.GetName())
+				}
+			}
+			assert.ElementsMatch(t, tt.wantRemaining, remaining)
+			assert.ElementsMatch(t, tt.wantPrunable, prunable)
+		})
+	}
+}
+
+// TestProcessEventHistoryDeletionBatchSize verifies that the runs over the history limit are pruned oldest first,
+// in batches bounded per cycle, and that the resource is checked again until the backlog is drained
+// TestDoResourceCleanupHistoryLimitBytes verifies that the newest runs are kept until their cumulative size
+// exceeds historyLimitBytes, and that the stricter of the byte budget and the history limit applies
+func TestDoResourceCleanupHistoryLimitBytes(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+
+	// runs from the newest to the oldest, padded to varying sizes
+	padding := map[string]int{"run-1": 100, "run-2": 5000, "run-3": 10, "run-4": 2000}
+	newRuns := func() []metav1.Object {
+		var runs []metav1.Object
+		for i, name := range []string{"run-1", "run-2", "run-3", "run-4"} {
+			runs = append(runs, &mockResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name,
+					Namespace:         "default",
+					Annotations:       map[string]string{"example.com/padding": strings.Repeat("x", padding[name])},
+					CreationTimestamp: metav1.Time{Time: time.Now().Add(-time.Duration(i+1) * time.Hour)},
+				},
+				completed:  true,
+				successful: true,
+			})
+		}
+		return runs
+	}
+	sizes := map[string]int64{}
+	for _, run := range newRuns() {
+		data, err := json.Marshal(run)
+		assert.NoError(t, err)
+		sizes[run.GetName()] = int64(len(data))
+	}
+
+	tests := []struct {
+		name          string
+		budget        int64
+		limit         int32
+		wantRemaining []string
+	}{
+		{
+			name:          "budget fits all the runs",
+			budget:        sizes["run-1"] + sizes["run-2"] + sizes["run-3"] + sizes["run-4"],
+			limit:         10,
+			wantRemaining: []string{"run-1", "run-2", "run-3", "run-4"},
+		},
+		{
+			name:          "budget exactly fits the newest runs",
+			budget:        sizes["run-1"] + sizes["run-2"],
+			limit:         10,
+			wantRemaining: []string{"run-1", "run-2"},
+		},
+		{
+			name:          "a large run exceeds the budget",
+			budget:        sizes["run-1"] + sizes["run-2"] - 1,
+			limit:         10,
+			wantRemaining: []string{"run-1"},
+		},
+		{
+			name:          "smaller older runs past the budget are pruned too",
+			budget:        sizes["run-1"] + sizes["run-2"] + sizes["run-3"],
+			limit:         10,
+			wantRemaining: []string{"run-1", "run-2", "run-3"},
+		},
+		{
+			name:          "history limit is stricter",
+			budget:        1 << 20,
+			limit:         2,
+			wantRemaining: []string{"run-1", "run-2"},
+		},
+		{
+			name:   "newest run alone exceeds the budget",
+			budget: sizes["run-1"] - 1,
+			limit:  10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: fmt.Sprintf("historyLimitBytes: %d", tt.budget)}}
+			assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			resources := newRuns()
+			mockFuncs := &mockResourceFuncs{
+				resources:    map[string][]metav1.Object{"default": resources},
+				successLimit: ptr.Int32(tt.limit),
+				enforceLevel: EnforcedConfigLevelGlobal,
+			}
+			hl, err := NewHistoryLimiter(mockFuncs)
+			assert.NoError(t, err)
+			assert.NoError(t, hl.DoSuccessfulResourceCleanup(ctx, resources[0]))
+
+			var remaining []string
+			for _, res := range mockFuncs.resources["default"] {
+				remaining = append(remaining, res.GetName())
+			}
+			assert.ElementsMatch(t, tt.wantRemaining, remaining)
+		})
+	}
+
+	assert.Error(t, ValidateGlobalConfig(&GlobalConfig{HistoryLimitBytes: ptr.Int64(0)}))
+}
+
+func TestProcessEventHistoryDeletionBatchSize(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+	cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `historyDeletionBatchSize: 2`}}
+	assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	var resources []metav1.Object
+	for i := 1; i <= 5; i++ {
+		resources = append(resources, &mockResource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              fmt.Sprintf("run-%d", i),
+				Namespace:         "default",
+				CreationTimestamp: metav1.Time{Time: time.Now().Add(-time.Duration(6-i) * time.Hour)},
+			},
+			completed:  true,
+			successful: true,
+		})
+	}
+	newest := resources[4]
+
+	mockFuncs := &annotationPatchResourceFuncs{&mockResourceFuncs{
+		resources:       map[string][]metav1.Object{"default": resources},
+		successLimit:    ptr.Int32(1),
+		enforceLevel:    EnforcedConfigLevelGlobal,
+		defaultLabelKey: "test.label/name",
+	}}
+	hl, err := NewHistoryLimiter(mockFuncs)
+	assert.NoError(t, err)
+
+	remaining := func() []string {
+		var names []string
+		for _, res := range mockFuncs.resources["default"] {
+			names = append(names, res.GetName())
+		}
+		return names
+	}
+
+	// first cycle: the two oldest runs are deleted, the budget of the cycle is then exhausted
+	cycleCtx := WithHistoryDeletionBudget(ctx)
+	assert.NoError(t, hl.ProcessEvent(cycleCtx, newest))
+	assert.ElementsMatch(t, []string{"run-3", "run-4", "run-5"}, remaining())
+	assert.False(t, hl.isProcessed(newest))
+
+	assert.NoError(t, hl.ProcessEvent(cycleCtx, newest))
+	assert.ElementsMatch(t, []string{"run-3", "run-4", "run-5"}, remaining())
+	assert.False(t, hl.isProcessed(newest))
+
+	// second cycle: the backlog is drained and the resource is marked as processed
+	assert.NoError(t, hl.ProcessEvent(WithHistoryDeletionBudget(ctx), newest))
+	assert.ElementsMatch(t, []string{"run-5"}, remaining())
+	assert.True(t, hl.isProcessed(newest))
+}
+
+// labelFilteringResourceFuncs is a mockResourceFuncs listing only the resources matching the given "key=value" label selector,
+// or all the resources of the namespace without a selector
+type labelFilteringResourceFuncs struct {
+	*mockResourceFuncs
+}
+
+func (l *labelFilteringResourceFuncs) List(ctx context.Context, namespace, label string) ([]metav1.Object, error) {
+	if label == "" {
+		return l.mockResourceFuncs.List(ctx, namespace, label)
+	}
+	key, value, _ := strings.Cut(label, "=")
+	var resources []metav1.Object
+	for _, res := range l.resources[namespace] {
+		if labelValue, exists := res.GetLabels()[key]; exists && labelValue == value {
+			resources = append(resources, res)
+		}
+	}
+	return resources, nil
+}
+
+// TestDoResourceCleanupPerLabelValue verifies that the successful runs are capped per value of the label
+// of a per label value limit, and that the runs without the label are left to the namespace limits
+func TestDoResourceCleanupPerLabelValue(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+	assert.NoError(t, PrunerConfigStore.LoadNamespaceConfig(ctx, "default", &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: PrunerNamespaceConfigMapName, Namespace: "default"},
+		Data:       map[string]string{PrunerNamespaceConfigKey: `perLabelValueHistoryLimit: {label: team, limit: 2}`},
+	}))
+	defer PrunerConfigStore.DeleteNamespaceConfig(ctx, "default")
+
+	newRun := func(name, team string, age time.Duration, successful bool) *mockResource {
+		run := &mockResource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: metav1.Time{Time: time.Now().Add(-age)},
+			},
+			completed:  true,
+			successful: successful,
+			failed:     !successful,
+		}
+		if team != "" {
+			run.Labels = map[string]string{"team": team}
+		}
+		return run
+	}
+	resources := []metav1.Object{
+		newRun("a-1", "a", 6*time.Hour, true),
+		newRun("a-2", "a", 5*time.Hour, true),
+		newRun("a-3", "a", 4*time.Hour, true),
+		newRun("a-failed", "a", 7*time.Hour, false),
+		newRun("b-1", "b", 3*time.Hour, true),
+		newRun("b-2", "b", 2*time.Hour, true),
+		newRun("b-3", "b", 1*time.Hour, true),
+		newRun("c-1", "c", 1*time.Hour, true),
+		newRun("unlabelled-1", "", 9*time.Hour, true),
+		newRun("unlabelled-2", "", 8*time.Hour, true),
+	}
+
+	mockFuncs := &labelFilteringResourceFuncs{&mockResourceFuncs{
+		resources:       map[string][]metav1.Object{"default": slices.Clone(resources)},
+		enforceLevel:    EnforcedConfigLevelNamespace,
+		defaultLabelKey: LabelPipelineName,
+	}}
+	hl, err := NewHistoryLimiter(mockFuncs)
+	assert.NoError(t, err)
+	for _, res := range resources {
+		assert.NoError(t, hl.ProcessEvent(ctx, res))
+	}
+
+	var remaining []string
+	for _, res := range mockFuncs.resources["default"] {
+		remaining = append(remaining, res.GetName())
+	}
+	assert.ElementsMatch(t, []string{"a-2", "a-3", "a-failed", "b-2", "b-3", "c-1", "unlabelled-1", "unlabelled-2"}, remaining)
+}
+
+// TestGetPerLabelValueHistoryLimit verifies that the namespace ConfigMap takes precedence over the namespace entry
+// of the global config, and that no per label value limit applies when the global config is enforced
+func TestGetPerLabelValueHistoryLimit(t *testing.T) {
+	ctx := context.Background()
+	assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `
+namespaces:
+  team-a:
+    perLabelValueHistoryLimit: {label: team, limit: 5}
+  team-b:
+    perLabelValueHistoryLimit: {label: team, limit: 5}`}}))
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+	assert.NoError(t, PrunerConfigStore.LoadNamespaceConfig(ctx, "team-b", &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: PrunerNamespaceConfigMapName, Namespace: "team-b"},
+		Data:       map[string]string{PrunerNamespaceConfigKey: `perLabelValueHistoryLimit: {label: component, limit: 3}`},
+	}))
+	defer PrunerConfigStore.DeleteNamespaceConfig(ctx, "team-b")
+
+	assert.Equal(t, &PerLabelValueHistoryLimit{Label: "team", Limit: ptr.Int32(5)},
+		PrunerConfigStore.GetPerLabelValueHistoryLimit("team-a", EnforcedConfigLevelNamespace))
+	assert.Equal(t, &PerLabelValueHistoryLimit{Label: "component", Limit: ptr.Int32(3)},
+		PrunerConfigStore.GetPerLabelValueHistoryLimit("team-b", EnforcedConfigLevelNamespace))
+	assert.Equal(t, &PerLabelValueHistoryLimit{Label: "team", Limit: ptr.Int32(5)},
+		PrunerConfigStore.GetPerLabelValueHistoryLimit("team-b", EnforcedConfigLevelResource))
+	assert.Nil(t, PrunerConfigStore.GetPerLabelValueHistoryLimit("team-b", EnforcedConfigLevelGlobal))
+	assert.Nil(t, PrunerConfigStore.GetPerLabelValueHistoryLimit("other", EnforcedConfigLevelNamespace))
+}
+
+// TestIsProcessedCustomAnnotationKey verifies that the configured processed annotation key replaces the default one
+func TestIsProcessedCustomAnnotationKey(t *testing.T) {
+	ctx := context.Background()
+	cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `processedAnnotationKey: example.com/history-processed`}}
+	assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	hl, err := NewHistoryLimiter(&mockResourceFuncs{})
+	assert.NoError(t, err)
+
+	defaultKey := &mockResource{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{AnnotationHistoryLimitCheckProcessed: "2025-01-01T00:00:00Z"}}}
+	customKey := &mockResource{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"example.com/history-processed": "2025-01-01T00:00:00Z"}}}
+	assert.False(t, hl.isProcessed(defaultKey))
+	assert.True(t, hl.isProcessed(customKey))
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tektoncd/pruner/pkg/metrics"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DeleteLeftoverPods deletes the pods of a deleted run which were not garbage collected along with it,
+// when deleteLeftoverPods is enabled in the global config. The pods are found by the label holding the run name,
+// e.g. tekton.dev/taskRun. It is a no-op when no kube client is given
+func DeleteLeftoverPods(ctx context.Context, kubeClient kubernetes.Interface, resourceType, namespace, labelKey, name string) error {
+	if kubeClient == nil || !PrunerConfigStore.GetDeleteLeftoverPods(namespace) {
+		return nil
+	}
+
+	podList, err := kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", labelKey, name)})
+	if err != nil {
+		return err
+	}
+
+	var deleted int64
+	for _, pod := range podList.Items {
+		err := LimitDeletion(ctx, func() error {
+			return kubeClient.CoreV1().Pods(namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		deleted++
+	}
+
+	if deleted > 0 {
+		metrics.GetRecorder().RecordLeftoverPodsDeleted(ctx, resourceType, namespace, deleted)
+	}
+	return nil
+}
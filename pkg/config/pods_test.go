@@ -0,0 +1,94 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tektoncd/pruner/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestDeleteLeftoverPods verifies that only the pods labeled with the deleted run name are deleted, and only when enabled
+func TestDeleteLeftoverPods(t *testing.T) {
+	tests := []struct {
+		name         string
+		globalConfig string
+		wantPods     []string
+	}{
+		{
+			name:     "disabled",
+			wantPods: []string{"build-1-pod", "build-1-retry-pod", "build-2-pod"},
+		},
+		{
+			name:         "enabled",
+			globalConfig: `deleteLeftoverPods: true`,
+			wantPods:     []string{"build-2-pod"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: tt.globalConfig}}
+			assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			newPod := func(name, taskRun string) *corev1.Pod {
+				return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+					Labels:    map[string]string{LabelTaskRunName: taskRun},
+				}}
+			}
+			kubeClient := fake.NewSimpleClientset(
+				newPod("build-1-pod", "build-1"),
+				newPod("build-1-retry-pod", "build-1"),
+				newPod("build-2-pod", "build-2"),
+			)
+
+			err := DeleteLeftoverPods(ctx, kubeClient, metrics.ResourceTypeTaskRun, "default", LabelTaskRunName, "build-1")
+			assert.NoError(t, err)
+
+			pods, err := kubeClient.CoreV1().Pods("default").List(ctx, metav1.ListOptions{})
+			assert.NoError(t, err)
+			var names []string
+			for _, pod := range pods.Items {
+				names = append(names, pod.Name)
+			}
+			assert.ElementsMatch(t, tt.wantPods, names)
+		})
+	}
+}
+
+// TestDeleteLeftoverPodsWithoutClient verifies that no kube client makes it a no-op
+func TestDeleteLeftoverPodsWithoutClient(t *testing.T) {
+	ctx := context.Background()
+	cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `deleteLeftoverPods: true`}}
+	assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	assert.NoError(t, DeleteLeftoverPods(ctx, nil, metrics.ResourceTypeTaskRun, "default", LabelTaskRunName, "build-1"))
+}
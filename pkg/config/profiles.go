@@ -0,0 +1,100 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// resolveProfile returns the namespace config with the fields it does not set inherited from the profile it references.
+// The root-level fields set by the namespace config override the ones of the profile one by one, while its pipelineRuns
+// and taskRuns, if any, replace the ones of the profile. A namespace config without profileRef is returned as is
+func resolveProfile(nsSpec NamespaceSpec, profiles map[string]NamespaceSpec) (NamespaceSpec, error) {
+	if nsSpec.ProfileRef == "" {
+		return nsSpec, nil
+	}
+	profile, found := profiles[nsSpec.ProfileRef]
+	if !found {
+		return nsSpec, fmt.Errorf("profileRef %q is not defined in the profiles of the global config", nsSpec.ProfileRef)
+	}
+
+	resolved := NamespaceSpec{
+		PrunerConfig: mergePrunerConfig(profile.PrunerConfig, nsSpec.PrunerConfig),
+		PipelineRuns: slices.Clone(profile.PipelineRuns),
+		TaskRuns:     slices.Clone(profile.TaskRuns),
+		ProfileRef:   nsSpec.ProfileRef,
+	}
+	resolved.PerLabelValueHistoryLimit = cmp.Or(nsSpec.PerLabelValueHistoryLimit, profile.PerLabelValueHistoryLimit)
+	if len(nsSpec.PipelineRuns) > 0 {
+		resolved.PipelineRuns = nsSpec.PipelineRuns
+	}
+	if len(nsSpec.TaskRuns) > 0 {
+		resolved.TaskRuns = nsSpec.TaskRuns
+	}
+	return resolved, nil
+}
+
+// mergePrunerConfig returns the base config with the fields set by the override replaced
+func mergePrunerConfig(base, override PrunerConfig) PrunerConfig {
+	if override.EnforcedConfigLevel != nil {
+		base.EnforcedConfigLevel = override.EnforcedConfigLevel
+	}
+	if override.TTLSecondsAfterFinished != nil {
+		base.TTLSecondsAfterFinished = override.TTLSecondsAfterFinished
+	}
+	if override.SuccessfulHistoryLimit != nil {
+		base.SuccessfulHistoryLimit = override.SuccessfulHistoryLimit
+	}
+	if override.FailedHistoryLimit != nil {
+		base.FailedHistoryLimit = override.FailedHistoryLimit
+	}
+	if override.HistoryLimit != nil {
+		base.HistoryLimit = override.HistoryLimit
+	}
+	if override.Keep != nil {
+		base.Keep = override.Keep
+	}
+	return base
+}
+
+// validateProfiles validates the profiles of the global config against the global limits. Profiles cannot reference
+// another profile, and only namespace ConfigMaps can reference a profile
+func validateProfiles(globalConfig *GlobalConfig, path string) error {
+	for name, profile := range globalConfig.Profiles {
+		profilePath := fmt.Sprintf("%s.profiles.%s", path, name)
+		if profile.ProfileRef != "" {
+			return fmt.Errorf("%s: a profile cannot reference another profile", profilePath)
+		}
+		if err := validatePrunerConfig(&profile.PrunerConfig, profilePath, &globalConfig.PrunerConfig); err != nil {
+			return err
+		}
+		if err := validateAnnotationValues(&profile, profilePath); err != nil {
+			return err
+		}
+		if err := validatePerLabelValueHistoryLimit(profile.PerLabelValueHistoryLimit, profilePath); err != nil {
+			return err
+		}
+	}
+	for ns, nsSpec := range globalConfig.Namespaces {
+		if nsSpec.ProfileRef != "" {
+			return fmt.Errorf("%s.namespaces.%s: profileRef is only supported in namespace ConfigMaps (%s)", path, ns, PrunerNamespaceConfigMapName)
+		}
+	}
+	return nil
+}
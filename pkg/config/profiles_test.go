@@ -0,0 +1,223 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/ptr"
+)
+
+// TestResolveProfile verifies that a namespace config inherits the fields of its profile it does not set
+func TestResolveProfile(t *testing.T) {
+	profiles := map[string]NamespaceSpec{
+		"standard-ci": {
+			PrunerConfig: PrunerConfig{
+				TTLSecondsAfterFinished: ptr.Int32(3600),
+				SuccessfulHistoryLimit:  ptr.Int32(5),
+				FailedHistoryLimit:      ptr.Int32(10),
+			},
+			PipelineRuns: []ResourceSpec{{Name: "release", PrunerConfig: PrunerConfig{HistoryLimit: ptr.Int32(20)}}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		nsSpec  NamespaceSpec
+		want    NamespaceSpec
+		wantErr string
+	}{
+		{
+			name:   "inherits the profile",
+			nsSpec: NamespaceSpec{ProfileRef: "standard-ci"},
+			want: NamespaceSpec{
+				PrunerConfig: PrunerConfig{
+					TTLSecondsAfterFinished: ptr.Int32(3600),
+					SuccessfulHistoryLimit:  ptr.Int32(5),
+					FailedHistoryLimit:      ptr.Int32(10),
+				},
+				PipelineRuns: []ResourceSpec{{Name: "release", PrunerConfig: PrunerConfig{HistoryLimit: ptr.Int32(20)}}},
+				ProfileRef:   "standard-ci",
+			},
+		},
+		{
+			name: "overrides the deltas",
+			nsSpec: NamespaceSpec{
+				PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: ptr.Int32(600)},
+				PipelineRuns: []ResourceSpec{{Name: "nightly", PrunerConfig: PrunerConfig{HistoryLimit: ptr.Int32(3)}}},
+				ProfileRef:   "standard-ci",
+			},
+			want: NamespaceSpec{
+				PrunerConfig: PrunerConfig{
+					TTLSecondsAfterFinished: ptr.Int32(600),
+					SuccessfulHistoryLimit:  ptr.Int32(5),
+					FailedHistoryLimit:      ptr.Int32(10),
+				},
+				PipelineRuns: []ResourceSpec{{Name: "nightly", PrunerConfig: PrunerConfig{HistoryLimit: ptr.Int32(3)}}},
+				ProfileRef:   "standard-ci",
+			},
+		},
+		{
+			name:   "without profileRef",
+			nsSpec: NamespaceSpec{PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: ptr.Int32(600)}},
+			want:   NamespaceSpec{PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: ptr.Int32(600)}},
+		},
+		{
+			name:    "missing profile",
+			nsSpec:  NamespaceSpec{PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: ptr.Int32(600)}, ProfileRef: "unknown"},
+			want:    NamespaceSpec{PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: ptr.Int32(600)}, ProfileRef: "unknown"},
+			wantErr: `profileRef "unknown" is not defined in the profiles of the global config`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveProfile(tt.nsSpec, profiles)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestLoadNamespaceConfigProfile verifies that the profile of a namespace config is resolved at load,
+// and again when the global config changes
+func TestLoadNamespaceConfigProfile(t *testing.T) {
+	ctx := context.Background()
+	ps := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+	loadGlobal := func(ttl string) {
+		t.Helper()
+		assert.NoError(t, ps.LoadGlobalConfig(ctx, &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `
+enforcedConfigLevel: namespace
+profiles:
+  standard-ci:
+    ttlSecondsAfterFinished: ` + ttl + `
+    successfulHistoryLimit: 5`}}))
+	}
+	loadGlobal("3600")
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: PrunerNamespaceConfigMapName, Namespace: "team-a"},
+		Data: map[string]string{PrunerNamespaceConfigKey: `
+profileRef: standard-ci
+successfulHistoryLimit: 2`},
+	}
+	assert.NoError(t, ps.LoadNamespaceConfig(ctx, "team-a", cm))
+
+	ttl, _ := ps.GetPipelineTTLSecondsAfterFinished("team-a", "build", SelectorSpec{})
+	assert.Equal(t, ptr.Int32(3600), ttl)
+	limit, _ := ps.GetPipelineSuccessHistoryLimitCount("team-a", "build", SelectorSpec{})
+	assert.Equal(t, ptr.Int32(2), limit)
+
+	// a change of the profile reaches the namespaces referencing it
+	loadGlobal("600")
+	ttl, _ = ps.GetPipelineTTLSecondsAfterFinished("team-a", "build", SelectorSpec{})
+	assert.Equal(t, ptr.Int32(600), ttl)
+
+	// a missing profile is reported, the namespace config applies on its own
+	cm.Data[PrunerNamespaceConfigKey] = `
+profileRef: unknown
+successfulHistoryLimit: 2`
+	assert.Error(t, ps.LoadNamespaceConfig(ctx, "team-a", cm))
+	ttl, _ = ps.GetPipelineTTLSecondsAfterFinished("team-a", "build", SelectorSpec{})
+	assert.Nil(t, ttl)
+	limit, _ = ps.GetPipelineSuccessHistoryLimitCount("team-a", "build", SelectorSpec{})
+	assert.Equal(t, ptr.Int32(2), limit)
+}
+
+// TestValidateConfigMapProfiles verifies the validation of the profiles and of the namespace configs referencing them
+func TestValidateConfigMapProfiles(t *testing.T) {
+	globalCM := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `
+ttlSecondsAfterFinished: 3600
+profiles:
+  standard-ci:
+    ttlSecondsAfterFinished: 1800`}}
+
+	tests := []struct {
+		name     string
+		cm       *corev1.ConfigMap
+		globalCM *corev1.ConfigMap
+		wantErr  string
+	}{
+		{
+			name: "valid profile",
+			cm:   globalCM,
+		},
+		{
+			name: "profile exceeding the global limit",
+			cm: &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `
+ttlSecondsAfterFinished: 3600
+profiles:
+  standard-ci:
+    ttlSecondsAfterFinished: 7200`}},
+			wantErr: "global-config.profiles.standard-ci",
+		},
+		{
+			name: "profile referencing a profile",
+			cm: &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `
+profiles:
+  standard-ci:
+    profileRef: other`}},
+			wantErr: "global-config.profiles.standard-ci: a profile cannot reference another profile",
+		},
+		{
+			name: "namespace of the global config referencing a profile",
+			cm: &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `
+namespaces:
+  team-a:
+    profileRef: standard-ci`}},
+			wantErr: "global-config.namespaces.team-a: profileRef is only supported in namespace ConfigMaps",
+		},
+		{
+			name: "namespace config referencing a profile",
+			cm: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Data:       map[string]string{PrunerNamespaceConfigKey: `profileRef: standard-ci`},
+			},
+			globalCM: globalCM,
+		},
+		{
+			name: "namespace config referencing a missing profile",
+			cm: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Data:       map[string]string{PrunerNamespaceConfigKey: `profileRef: unknown`},
+			},
+			globalCM: globalCM,
+			wantErr:  `ns-config: profileRef "unknown" is not defined in the profiles of the global config`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfigMapWithGlobal(tt.cm, tt.globalCM)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// ProtectionRule describes a resource which protects the runs referencing it from being pruned,
+// as long as it exists. A run references the protecting resource either through the label LabelKey,
+// whose value is the name of the resource, or through an owner reference when LabelKey is not set.
+// With StatusReferencePaths, the resource only protects the runs its status still references.
+type ProtectionRule struct {
+	// APIVersion of the protecting resource, e.g. triggers.tekton.dev/v1beta1
+	APIVersion string `yaml:"apiVersion" json:"apiVersion"`
+	// Kind of the protecting resource, matched against the owner references of the run, e.g. EventListener
+	Kind string `yaml:"kind" json:"kind"`
+	// Resource is the plural resource name used to look up the protecting resource, e.g. eventlisteners
+	Resource string `yaml:"resource" json:"resource"`
+	// LabelKey is the label of the run holding the name of the protecting resource
+	LabelKey string `yaml:"labelKey,omitempty" json:"labelKey,omitempty"`
+	// StatusReferencePaths are the dot separated paths, relative to the status of the protecting resource,
+	// which hold the names of the runs it references, e.g. childReferences.name. Lists are traversed element by element,
+	// and the keys of a map are taken as names
+	StatusReferencePaths []string `yaml:"statusReferencePaths,omitempty" json:"statusReferencePaths,omitempty"`
+}
+
+// ResourceExistsFunc reports whether the given resource exists
+type ResourceExistsFunc func(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (bool, error)
+
+// NewDynamicResourceExistsFunc returns a ResourceExistsFunc looking up resources with a dynamic client
+func NewDynamicResourceExistsFunc(client dynamic.Interface) ResourceExistsFunc {
+	return func(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (bool, error) {
+		_, err := client.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+}
+
+// ResourceGetFunc returns the given resource, or nil if it does not exist
+type ResourceGetFunc func(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error)
+
+// NewDynamicResourceGetFunc returns a ResourceGetFunc getting resources with a dynamic client
+func NewDynamicResourceGetFunc(client dynamic.Interface) ResourceGetFunc {
+	return func(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+		resource, err := client.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return resource, nil
+	}
+}
+
+// resourceExistsKey is used as the key for associating a ResourceExistsFunc with the context
+type resourceExistsKey struct{}
+
+// resourceGetKey is used as the key for associating a ResourceGetFunc with the context
+type resourceGetKey struct{}
+
+// protectionCacheKey is used as the key for associating a protection lookup cache with the context
+type protectionCacheKey struct{}
+
+// protectionCache holds the results of the protecting resource lookups
+type protectionCache struct {
+	mutex  sync.Mutex
+	exists map[string]bool
+	// resources holds the protecting resources whose status is read, nil for the ones which do not exist
+	resources map[string]*unstructured.Unstructured
+}
+
+// WithResourceExistsFunc attaches the function used to look up protecting resources to the context
+func WithResourceExistsFunc(ctx context.Context, fn ResourceExistsFunc) context.Context {
+	return context.WithValue(ctx, resourceExistsKey{}, fn)
+}
+
+// WithResourceGetFunc attaches the function used to get the protecting resources whose status is read to the context
+func WithResourceGetFunc(ctx context.Context, fn ResourceGetFunc) context.Context {
+	return context.WithValue(ctx, resourceGetKey{}, fn)
+}
+
+// WithProtectionCache attaches a cache of protecting resource lookups to the context,
+// so that a protecting resource is looked up at most once while the context is in use, e.g. for a GC cycle
+func WithProtectionCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, protectionCacheKey{}, &protectionCache{
+		exists:    make(map[string]bool),
+		resources: make(map[string]*unstructured.Unstructured),
+	})
+}
+
+// IsNeverPruned checks whether a run of the given kind belongs to a Pipeline or Task listed in neverPrune
+func IsNeverPruned(kind string, resource metav1.Object) bool {
+	names := PrunerConfigStore.GetNeverPruneNames(resource.GetNamespace(), kind)
+	if len(names) == 0 {
+		return false
+	}
+	labelKey := LabelPipelineName
+	if kind == KindTaskRun {
+		labelKey = LabelTaskName
+	}
+	name, found := resource.GetLabels()[labelKey]
+	return found && slices.Contains(names, name)
+}
+
+// HasSkippedFinalizer checks whether a resource carries one of the finalizers listed in skipRunsWithFinalizers.
+// Deleting such a resource would leave it terminating until the controller owning the finalizer removes it
+func HasSkippedFinalizer(resource metav1.Object) bool {
+	skipped := PrunerConfigStore.GetSkipRunsWithFinalizers(resource.GetNamespace())
+	if len(skipped) == 0 {
+		return false
+	}
+	for _, finalizer := range resource.GetFinalizers() {
+		if slices.Contains(skipped, "*") || slices.Contains(skipped, finalizer) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsHistoryExempt checks whether a resource carries the annotation exempting it from history-based pruning
+func IsHistoryExempt(resource metav1.Object) bool {
+	return resource.GetAnnotations()[AnnotationHistoryExempt] == "true"
+}
+
+// IsProtected checks whether a resource references an existing protecting resource of the protectIfReferencedBy rules,
+// or, for the rules with status reference paths, whether the status of the resource it references still holds its name.
+// It returns false without any lookup when no rule is configured.
+func IsProtected(ctx context.Context, resource metav1.Object) (bool, error) {
+	rules := PrunerConfigStore.GetProtectionRules(resource.GetNamespace())
+	if len(rules) == 0 {
+		return false, nil
+	}
+
+	existsFn, _ := ctx.Value(resourceExistsKey{}).(ResourceExistsFunc)
+	getFn, _ := ctx.Value(resourceGetKey{}).(ResourceGetFunc)
+	cache, _ := ctx.Value(protectionCacheKey{}).(*protectionCache)
+
+	for _, rule := range rules {
+		gv, err := schema.ParseGroupVersion(rule.APIVersion)
+		if err != nil {
+			return false, err
+		}
+		gvr := gv.WithResource(rule.Resource)
+
+		for _, name := range referencedNames(resource, rule) {
+			if len(rule.StatusReferencePaths) > 0 {
+				if getFn == nil {
+					return false, fmt.Errorf("protectIfReferencedBy is configured with statusReferencePaths but protecting resources cannot be read")
+				}
+				parent, err := getResource(ctx, getFn, cache, gvr, resource.GetNamespace(), name)
+				if err != nil {
+					return false, err
+				}
+				if parent != nil && statusReferences(parent.Object, rule.StatusReferencePaths, resource.GetName()) {
+					return true, nil
+				}
+				continue
+			}
+
+			if existsFn == nil {
+				return false, fmt.Errorf("protectIfReferencedBy is configured but protecting resources cannot be looked up")
+			}
+			exists, err := resourceExists(ctx, existsFn, cache, gvr, resource.GetNamespace(), name)
+			if err != nil {
+				return false, err
+			}
+			if exists {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// referencedNames returns the names of the resources a rule may protect the resource by
+func referencedNames(resource metav1.Object, rule ProtectionRule) []string {
+	if rule.LabelKey != "" {
+		if name := resource.GetLabels()[rule.LabelKey]; name != "" {
+			return []string{name}
+		}
+		return nil
+	}
+
+	var names []string
+	for _, ref := range resource.GetOwnerReferences() {
+		if ref.APIVersion == rule.APIVersion && ref.Kind == rule.Kind {
+			names = append(names, ref.Name)
+		}
+	}
+	return names
+}
+
+// resourceExists looks up a protecting resource, through the cache if one is available
+func resourceExists(ctx context.Context, existsFn ResourceExistsFunc, cache *protectionCache, gvr schema.GroupVersionResource, namespace, name string) (bool, error) {
+	if cache == nil {
+		return existsFn(ctx, gvr, namespace, name)
+	}
+
+	key := gvr.String() + "/" + namespace + "/" + name
+	cache.mutex.Lock()
+	exists, found := cache.exists[key]
+	cache.mutex.Unlock()
+	if found {
+		return exists, nil
+	}
+
+	exists, err := existsFn(ctx, gvr, namespace, name)
+	if err != nil {
+		return false, err
+	}
+	cache.mutex.Lock()
+	cache.exists[key] = exists
+	cache.mutex.Unlock()
+	return exists, nil
+}
+
+// getResource gets a protecting resource, through the cache if one is available
+func getResource(ctx context.Context, getFn ResourceGetFunc, cache *protectionCache, gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	if cache == nil {
+		return getFn(ctx, gvr, namespace, name)
+	}
+
+	key := gvr.String() + "/" + namespace + "/" + name
+	cache.mutex.Lock()
+	resource, found := cache.resources[key]
+	cache.mutex.Unlock()
+	if found {
+		return resource, nil
+	}
+
+	resource, err := getFn(ctx, gvr, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	cache.mutex.Lock()
+	cache.resources[key] = resource
+	cache.mutex.Unlock()
+	return resource, nil
+}
+
+// statusReferences checks whether the status of a resource holds the given name at one of the given paths
+func statusReferences(object map[string]interface{}, paths []string, name string) bool {
+	status, found := object["status"]
+	if !found {
+		return false
+	}
+	for _, path := range paths {
+		if fieldReferences(status, strings.Split(path, "."), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldReferences checks whether the field at the given path of a value holds the given name,
+// traversing the lists on the way. A string matches the name itself, and a map at the end of the path its keys
+func fieldReferences(value interface{}, path []string, name string) bool {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, element := range v {
+			if fieldReferences(element, path, name) {
+				return true
+			}
+		}
+		return false
+	case map[string]interface{}:
+		if len(path) == 0 {
+			_, found := v[name]
+			return found
+		}
+		field, found := v[path[0]]
+		return found && fieldReferences(field, path[1:], name)
+	case string:
+		return len(path) == 0 && v == name
+	default:
+		return false
+	}
+}
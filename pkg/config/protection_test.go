@@ -0,0 +1,270 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const protectionRulesConfig = `
+protectIfReferencedBy:
+  - apiVersion: triggers.tekton.dev/v1beta1
+    kind: EventListener
+    resource: eventlisteners
+  - apiVersion: example.com/v1
+    kind: Record
+    resource: records
+    labelKey: example.com/record`
+
+// existingResources returns a ResourceExistsFunc backed by the given resource keys, counting the lookups
+func existingResources(lookups *int, keys ...string) ResourceExistsFunc {
+	return func(_ context.Context, gvr schema.GroupVersionResource, namespace, name string) (bool, error) {
+		*lookups++
+		for _, key := range keys {
+			if key == gvr.Resource+"/"+namespace+"/"+name {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// TestIsProtected verifies that a run is protected only while a resource it references through a rule exists
+func TestIsProtected(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		resource metav1.Object
+		want     bool
+	}{
+		{
+			name:   "no rules configured",
+			config: `enforcedConfigLevel: global`,
+			resource: &metav1.ObjectMeta{Name: "run", Namespace: "ns", OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "triggers.tekton.dev/v1beta1", Kind: "EventListener", Name: "listener"},
+			}},
+		},
+		{
+			name:   "owned by an existing protecting resource",
+			config: protectionRulesConfig,
+			resource: &metav1.ObjectMeta{Name: "run", Namespace: "ns", OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "triggers.tekton.dev/v1beta1", Kind: "EventListener", Name: "listener"},
+			}},
+			want: true,
+		},
+		{
+			name:   "owned by a deleted protecting resource",
+			config: protectionRulesConfig,
+			resource: &metav1.ObjectMeta{Name: "run", Namespace: "ns", OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "triggers.tekton.dev/v1beta1", Kind: "EventListener", Name: "deleted"},
+			}},
+		},
+		{
+			name:     "labelled with an existing protecting resource",
+			config:   protectionRulesConfig,
+			resource: &metav1.ObjectMeta{Name: "run", Namespace: "ns", Labels: map[string]string{"example.com/record": "record"}},
+			want:     true,
+		},
+		{
+			name:     "not referencing any protecting resource",
+			config:   protectionRulesConfig,
+			resource: &metav1.ObjectMeta{Name: "run", Namespace: "ns", Labels: map[string]string{"app": "build"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: tt.config}}
+			assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			lookups := 0
+			ctx = WithResourceExistsFunc(ctx, existingResources(&lookups, "eventlisteners/ns/listener", "records/ns/record"))
+			protected, err := IsProtected(ctx, tt.resource)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, protected)
+		})
+	}
+}
+
+// TestIsProtectedCache verifies that a protecting resource is looked up once per cache
+// and that rules cannot be evaluated without a lookup function
+func TestIsProtectedCache(t *testing.T) {
+	ctx := context.Background()
+	cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: protectionRulesConfig}}
+	assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+	resource := &metav1.ObjectMeta{Name: "run", Namespace: "ns", Labels: map[string]string{"example.com/record": "record"}}
+
+	_, err := IsProtected(ctx, resource)
+	assert.Error(t, err)
+
+	lookups := 0
+	ctx = WithProtectionCache(WithResourceExistsFunc(ctx, existingResources(&lookups, "records/ns/record")))
+	for range 3 {
+		protected, err := IsProtected(ctx, resource)
+		assert.NoError(t, err)
+		assert.True(t, protected)
+	}
+	assert.Equal(t, 1, lookups)
+}
+
+const statusProtectionConfig = `
+protectIfReferencedBy:
+  - apiVersion: example.com/v1
+    kind: Workflow
+    resource: workflows
+    statusReferencePaths: [childReferences.name, runs]`
+
+// parentResources returns a ResourceGetFunc backed by the given parents, by name, counting the lookups
+func parentResources(lookups *int, parents ...*unstructured.Unstructured) ResourceGetFunc {
+	return func(_ context.Context, gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+		*lookups++
+		for _, parent := range parents {
+			if parent.GetNamespace() == namespace && parent.GetName() == name {
+				return parent, nil
+			}
+		}
+		return nil, nil
+	}
+}
+
+// TestIsProtectedStatusReferences verifies that a run owned by a protecting resource with status reference paths
+// is protected only while the status of that resource still references it
+func TestIsProtectedStatusReferences(t *testing.T) {
+	ctx := context.Background()
+	cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: statusProtectionConfig}}
+	assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	parent := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "workflow", "namespace": "ns"},
+		"status": map[string]interface{}{
+			"childReferences": []interface{}{
+				map[string]interface{}{"kind": "CustomRun", "name": "step-1"},
+				map[string]interface{}{"kind": "CustomRun", "name": "step-2"},
+			},
+			"runs": map[string]interface{}{"legacy-step": map[string]interface{}{}},
+		},
+	}}
+	child := func(name, owner string) metav1.Object {
+		return &metav1.ObjectMeta{Name: name, Namespace: "ns", OwnerReferences: []metav1.OwnerReference{
+			{APIVersion: "example.com/v1", Kind: "Workflow", Name: owner},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		resource metav1.Object
+		want     bool
+	}{
+		{name: "referenced in a list of the parent status", resource: child("step-2", "workflow"), want: true},
+		{name: "referenced as a key of a map of the parent status", resource: child("legacy-step", "workflow"), want: true},
+		{name: "no longer referenced by the parent status", resource: child("step-3", "workflow")},
+		{name: "owned by a deleted parent", resource: child("step-1", "deleted")},
+		{name: "not owned by a parent", resource: &metav1.ObjectMeta{Name: "step-1", Namespace: "ns"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookups := 0
+			protected, err := IsProtected(WithResourceGetFunc(ctx, parentResources(&lookups, parent)), tt.resource)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, protected)
+		})
+	}
+
+	// the parent is read once per cache, and not at all without a function to read it
+	_, err := IsProtected(ctx, child("step-1", "workflow"))
+	assert.Error(t, err)
+
+	lookups := 0
+	cachedCtx := WithProtectionCache(WithResourceGetFunc(ctx, parentResources(&lookups, parent)))
+	for _, name := range []string{"step-1", "step-2", "step-3"} {
+		_, err := IsProtected(cachedCtx, child(name, "workflow"))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, lookups)
+}
+
+// TestIsNeverPruned verifies that a run is never pruned only when its Pipeline or Task is listed for its kind
+func TestIsNeverPruned(t *testing.T) {
+	ctx := context.Background()
+	cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `
+neverPrune:
+  pipelineRuns: [prod-release]
+  taskRuns: [db-migrate]`}}
+	assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	tests := []struct {
+		name   string
+		kind   string
+		labels map[string]string
+		want   bool
+	}{
+		{
+			name:   "listed pipeline",
+			kind:   KindPipelineRun,
+			labels: map[string]string{LabelPipelineName: "prod-release"},
+			want:   true,
+		},
+		{
+			name:   "listed task",
+			kind:   KindTaskRun,
+			labels: map[string]string{LabelTaskName: "db-migrate"},
+			want:   true,
+		},
+		{
+			name:   "other pipeline",
+			kind:   KindPipelineRun,
+			labels: map[string]string{LabelPipelineName: "build"},
+		},
+		{
+			name:   "TaskRun of a listed pipeline",
+			kind:   KindTaskRun,
+			labels: map[string]string{LabelPipelineName: "prod-release", LabelTaskName: "deploy"},
+		},
+		{
+			name: "run without labels",
+			kind: KindPipelineRun,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := &metav1.ObjectMeta{Name: "run", Namespace: "ns", Labels: tt.labels}
+			assert.Equal(t, tt.want, IsNeverPruned(tt.kind, resource))
+		})
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+// PruneReason is why the pruner removed a run. Every emitter reports the same value: the reason label
+// of the deletion metrics, the reason of the pruned CloudEvents and of the logs, and, through
+// PrunableAnnotationValue, the prunable reason annotation set in annotate mode
+type PruneReason string
+
+const (
+	PruneReasonTTL                    PruneReason = "ttl"
+	PruneReasonAbandoned              PruneReason = "abandoned"
+	PruneReasonSuccessfulHistoryLimit PruneReason = "successful_history_limit"
+	PruneReasonFailedHistoryLimit     PruneReason = "failed_history_limit"
+	PruneReasonHistoryLimit           PruneReason = "history_limit"
+	PruneReasonMaxPerNamespace        PruneReason = "max_per_namespace"
+	PruneReasonNamespaceBudget        PruneReason = "namespace_budget"
+	PruneReasonLargeStatus            PruneReason = "large_status"
+	PruneReasonStuck                  PruneReason = "stuck"
+	PruneReasonOwner                  PruneReason = "owner"
+	PruneReasonMaxAge                 PruneReason = "max_age"
+	PruneReasonTerminatingNamespace   PruneReason = "terminating_namespace"
+)
+
+// prunableAnnotationValues maps each prune reason to the value of the prunable reason annotation,
+// which predates the prune reasons and keeps its own values
+var prunableAnnotationValues = map[PruneReason]string{
+	PruneReasonTTL:                    PrunableReasonTTL,
+	PruneReasonAbandoned:              PrunableReasonTTL,
+	PruneReasonSuccessfulHistoryLimit: PrunableReasonHistoryLimit,
+	PruneReasonFailedHistoryLimit:     PrunableReasonHistoryLimit,
+	PruneReasonHistoryLimit:           PrunableReasonHistoryLimit,
+	PruneReasonMaxPerNamespace:        PrunableReasonNamespaceCap,
+	PruneReasonNamespaceBudget:        PrunableReasonNamespaceBudget,
+	PruneReasonLargeStatus:            PrunableReasonLargeStatus,
+	PruneReasonStuck:                  PrunableReasonStuck,
+	PruneReasonOwner:                  PrunableReasonOwner,
+	PruneReasonMaxAge:                 PrunableReasonMaxAge,
+	PruneReasonTerminatingNamespace:   PrunableReasonTerminatingNamespace,
+}
+
+// String returns the reason as reported on the metrics, CloudEvents and logs
+func (r PruneReason) String() string {
+	return string(r)
+}
+
+// PrunableAnnotationValue returns the value of the prunable reason annotation for the reason
+func (r PruneReason) PrunableAnnotationValue() string {
+	if value, ok := prunableAnnotationValues[r]; ok {
+		return value
+	}
+	return string(r)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneReasonPrunableAnnotationValue(t *testing.T) {
+	tests := []struct {
+		reason PruneReason
+		want   string
+	}{
+		{PruneReasonTTL, PrunableReasonTTL},
+		{PruneReasonAbandoned, PrunableReasonTTL},
+		{PruneReasonSuccessfulHistoryLimit, PrunableReasonHistoryLimit},
+		{PruneReasonFailedHistoryLimit, PrunableReasonHistoryLimit},
+		{PruneReasonHistoryLimit, PrunableReasonHistoryLimit},
+		{PruneReasonMaxPerNamespace, PrunableReasonNamespaceCap},
+		{PruneReasonNamespaceBudget, PrunableReasonNamespaceBudget},
+		{PruneReasonLargeStatus, PrunableReasonLargeStatus},
+		{PruneReasonStuck, PrunableReasonStuck},
+		{PruneReasonOwner, PrunableReasonOwner},
+		{PruneReasonMaxAge, PrunableReasonMaxAge},
+		{PruneReason("unknown"), "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reason.String(), func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.reason.PrunableAnnotationValue())
+		})
+	}
+}
+
+// reasonArguments maps the functions reporting a prune reason to the position of their reason argument
+var reasonArguments = map[string]int{
+	"RecordResourceDeleted": 4,
+	"NewPrunedEvent":        2,
+	"PrunablePatch":         1,
+	"markPrunable":          3,
+	"pruneRuns":             3,
+	"pruneResource":         3,
+}
+
+// TestPruneReasonsAreShared verifies that every emitter of a prune reason is given one of the PruneReason
+// constants, or a variable holding one, rather than a literal which could drift from the others
+func TestPruneReasonsAreShared(t *testing.T) {
+	fset := token.NewFileSet()
+	calls := 0
+	for _, dir := range []string{"../../pkg", "../../cmd"} {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return err
+			}
+			file, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				return err
+			}
+			ast.Inspect(file, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				var name string
+				switch fn := call.Fun.(type) {
+				case *ast.Ident:
+					name = fn.Name
+				case *ast.SelectorExpr:
+					name = fn.Sel.Name
+				}
+				position, ok := reasonArguments[name]
+				if !ok || position >= len(call.Args) {
+					return true
+				}
+				calls++
+				arg := call.Args[position]
+				// RecordResourceDeleted takes the reason as a string, it must come from PruneReason.String
+				if name == "RecordResourceDeleted" {
+					if !isStringCall(arg) {
+						t.Errorf("%s: the reason of %s must be a PruneReason converted with String", fset.Position(arg.Pos()), name)
+					}
+					return true
+				}
+				if containsLiteral(arg) {
+					t.Errorf("%s: the reason of %s must be a PruneReason constant, not a literal", fset.Position(arg.Pos()), name)
+				}
+				return true
+			})
+			return nil
+		})
+		assert.NoError(t, err)
+	}
+	assert.NotZero(t, calls, "no prune reason emitter was found")
+}
+
+// isStringCall reports whether an expression is a call of a String method
+func isStringCall(expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == "String"
+}
+
+// containsLiteral reports whether an expression holds a string literal
+func containsLiteral(expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
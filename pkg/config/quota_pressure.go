@@ -0,0 +1,135 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultQuotaPressureThresholdPercent is the usage of a quota, in percent of its hard limit, above which
+	// the history limits of the namespace are tightened
+	DefaultQuotaPressureThresholdPercent = 80
+
+	// DefaultQuotaPressureHistoryLimitDivisor is the divisor applied to the history limits of a namespace under quota pressure
+	DefaultQuotaPressureHistoryLimitDivisor = 2
+)
+
+// quotaPressureResources lists the object count quotas whose usage puts a namespace under quota pressure
+var quotaPressureResources = []corev1.ResourceName{
+	"count/pipelineruns.tekton.dev",
+	"count/taskruns.tekton.dev",
+}
+
+// QuotaPressureSpec tightens the history limits of the namespaces whose ResourceQuota on the number of PipelineRuns
+// or TaskRuns is nearly used up, for the garbage collection cycle which found them so
+type QuotaPressureSpec struct {
+	// ThresholdPercent is the usage of the quota, in percent of its hard limit, above which the namespace
+	// is under quota pressure, between 1 and 100 (default: 80)
+	ThresholdPercent *int32 `yaml:"thresholdPercent,omitempty" json:"thresholdPercent,omitempty"`
+	// HistoryLimitDivisor divides the history limits of a namespace under quota pressure, a limit above zero
+	// keeping at least one run. It must be at least 2 (default: 2)
+	HistoryLimitDivisor *int32 `yaml:"historyLimitDivisor,omitempty" json:"historyLimitDivisor,omitempty"`
+}
+
+// thresholdPercent returns the configured threshold, or its default
+func (s *QuotaPressureSpec) thresholdPercent() int32 {
+	if s.ThresholdPercent == nil {
+		return DefaultQuotaPressureThresholdPercent
+	}
+	return *s.ThresholdPercent
+}
+
+// historyLimitDivisor returns the configured divisor, or its default
+func (s *QuotaPressureSpec) historyLimitDivisor() int32 {
+	if s.HistoryLimitDivisor == nil {
+		return DefaultQuotaPressureHistoryLimitDivisor
+	}
+	return *s.HistoryLimitDivisor
+}
+
+// validateQuotaPressure validates the quota pressure of the global config
+func validateQuotaPressure(spec *QuotaPressureSpec, path string) error {
+	if spec == nil {
+		return nil
+	}
+	if spec.ThresholdPercent != nil && (*spec.ThresholdPercent < 1 || *spec.ThresholdPercent > 100) {
+		return fmt.Errorf("%s: quotaPressure.thresholdPercent must be between 1 and 100, got %d", path, *spec.ThresholdPercent)
+	}
+	if spec.HistoryLimitDivisor != nil && *spec.HistoryLimitDivisor < 2 {
+		return fmt.Errorf("%s: quotaPressure.historyLimitDivisor must be at least 2, got %d", path, *spec.HistoryLimitDivisor)
+	}
+	return nil
+}
+
+// QuotaPressureDivisor returns the divisor of the history limits of a namespace with the given ResourceQuotas,
+// when one of the quotas on the number of PipelineRuns or TaskRuns is used above the quotaPressure threshold.
+// It returns 1, leaving the history limits as they are, otherwise or when quotaPressure is not configured
+func QuotaPressureDivisor(quotas []corev1.ResourceQuota) int32 {
+	spec := PrunerConfigStore.GetQuotaPressure()
+	if spec == nil {
+		return 1
+	}
+	for _, quota := range quotas {
+		for _, resource := range quotaPressureResources {
+			hard, found := quota.Status.Hard[resource]
+			if !found || hard.Value() <= 0 {
+				continue
+			}
+			used := quota.Status.Used[resource]
+			if used.Value()*100 >= hard.Value()*int64(spec.thresholdPercent()) {
+				return spec.historyLimitDivisor()
+			}
+		}
+	}
+	return 1
+}
+
+// historyLimitDivisorKey is used as the key for associating the divisor of the history limits with the context
+type historyLimitDivisorKey struct{}
+
+// WithHistoryLimitDivisor divides the history limits applied with the context, e.g. for the garbage collection cycle
+// of a namespace under quota pressure. The runs already processed by the history limiter are evaluated again.
+// A divisor of 1 or less returns the context as is
+func WithHistoryLimitDivisor(ctx context.Context, divisor int32) context.Context {
+	if divisor <= 1 {
+		return ctx
+	}
+	return context.WithValue(ctx, historyLimitDivisorKey{}, divisor)
+}
+
+// getHistoryLimitDivisor returns the divisor of the history limits of the context, 1 if there is none
+func getHistoryLimitDivisor(ctx context.Context) int32 {
+	divisor, ok := ctx.Value(historyLimitDivisorKey{}).(int32)
+	if !ok {
+		return 1
+	}
+	return divisor
+}
+
+// tightenHistoryLimit divides a history limit by the divisor of the context, a limit above zero keeping at least one run
+func tightenHistoryLimit(ctx context.Context, limit *int32) *int32 {
+	divisor := getHistoryLimitDivisor(ctx)
+	if limit == nil || *limit <= 0 || divisor <= 1 {
+		return limit
+	}
+	tightened := max(*limit/divisor, 1)
+	return &tightened
+}
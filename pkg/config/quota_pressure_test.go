@@ -0,0 +1,96 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"knative.dev/pkg/ptr"
+)
+
+func TestQuotaPressureDivisor(t *testing.T) {
+	ctx := context.Background()
+	quota := func(name corev1.ResourceName, hard, used string) corev1.ResourceQuota {
+		return corev1.ResourceQuota{Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{name: resource.MustParse(hard)},
+			Used: corev1.ResourceList{name: resource.MustParse(used)},
+		}}
+	}
+
+	tests := []struct {
+		name   string
+		config string
+		quotas []corev1.ResourceQuota
+		want   int32
+	}{
+		{
+			name:   "quota pressure not configured",
+			quotas: []corev1.ResourceQuota{quota("count/pipelineruns.tekton.dev", "10", "10")},
+			want:   1,
+		},
+		{
+			name:   "default threshold reached",
+			config: "quotaPressure: {}",
+			quotas: []corev1.ResourceQuota{quota("count/taskruns.tekton.dev", "100", "80")},
+			want:   DefaultQuotaPressureHistoryLimitDivisor,
+		},
+		{
+			name:   "below the threshold",
+			config: "quotaPressure: {thresholdPercent: 90, historyLimitDivisor: 4}",
+			quotas: []corev1.ResourceQuota{quota("count/pipelineruns.tekton.dev", "100", "89")},
+			want:   1,
+		},
+		{
+			name:   "configured divisor",
+			config: "quotaPressure: {thresholdPercent: 90, historyLimitDivisor: 4}",
+			quotas: []corev1.ResourceQuota{quota("count/pipelineruns.tekton.dev", "100", "95")},
+			want:   4,
+		},
+		{
+			name:   "other resources are ignored",
+			config: "quotaPressure: {}",
+			quotas: []corev1.ResourceQuota{quota("count/configmaps", "10", "10")},
+			want:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: tt.config}}
+			assert.NoError(t, PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+			assert.Equal(t, tt.want, QuotaPressureDivisor(tt.quotas))
+		})
+	}
+}
+
+func TestTightenHistoryLimit(t *testing.T) {
+	ctx := WithHistoryLimitDivisor(context.Background(), 3)
+	assert.Equal(t, ptr.Int32(3), tightenHistoryLimit(ctx, ptr.Int32(10)))
+	assert.Equal(t, ptr.Int32(1), tightenHistoryLimit(ctx, ptr.Int32(2)))
+	assert.Equal(t, ptr.Int32(0), tightenHistoryLimit(ctx, ptr.Int32(0)))
+	assert.Nil(t, tightenHistoryLimit(ctx, nil))
+	assert.Equal(t, ptr.Int32(10), tightenHistoryLimit(context.Background(), ptr.Int32(10)))
+	assert.Error(t, validateQuotaPressure(&QuotaPressureSpec{HistoryLimitDivisor: ptr.Int32(1)}, "global-config"))
+	assert.Error(t, validateQuotaPressure(&QuotaPressureSpec{ThresholdPercent: ptr.Int32(101)}, "global-config"))
+}
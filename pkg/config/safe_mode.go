@@ -0,0 +1,203 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+
+	"github.com/tektoncd/pruner/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/logging"
+)
+
+// SafeModeAction is a string type to manage what safe mode does when the global config tightens drastically
+type SafeModeAction string
+
+const (
+	// SafeModeActionPause pauses the pruning driven by the tightened fields until the config is acknowledged (default).
+	SafeModeActionPause SafeModeAction = "pause"
+
+	// SafeModeActionWarn applies the tightened config and only logs a warning.
+	SafeModeActionWarn SafeModeAction = "warn"
+
+	// DefaultSafeModeMaxTighteningFactor is the factor by which a field can tighten without triggering safe mode
+	DefaultSafeModeMaxTighteningFactor = 10
+
+	// safeModeDigestLength is the number of hexadecimal characters of the digest acknowledging a config
+	safeModeDigestLength = 12
+)
+
+// safeModeFields lists the fields of the global config guarded by safe mode
+var safeModeFields = []PrunerFieldType{
+	PrunerFieldTypeTTLSecondsAfterFinished,
+	PrunerFieldTypeSuccessfulHistoryLimit,
+	PrunerFieldTypeFailedHistoryLimit,
+}
+
+// SafeModeSpec guards against a global config which drastically tightens the TTL or the history limits,
+// e.g. a TTL slashed from 30 days to 60 seconds, which would otherwise prune most of the runs at once
+type SafeModeSpec struct {
+	// MaxTighteningFactor is the factor by which a root-level TTL or history limit can be divided
+	// by a config update before safe mode triggers, it must be at least 2 (default: 10)
+	MaxTighteningFactor *int32 `yaml:"maxTighteningFactor,omitempty" json:"maxTighteningFactor,omitempty"`
+	// Action allowed values: pause, warn (default: pause)
+	Action *SafeModeAction `yaml:"action,omitempty" json:"action,omitempty"`
+}
+
+// maxTighteningFactor returns the configured factor, or its default
+func (s *SafeModeSpec) maxTighteningFactor() int32 {
+	if s.MaxTighteningFactor == nil {
+		return DefaultSafeModeMaxTighteningFactor
+	}
+	return *s.MaxTighteningFactor
+}
+
+// action returns the configured action, or its default
+func (s *SafeModeSpec) action() SafeModeAction {
+	if s.Action == nil {
+		return SafeModeActionPause
+	}
+	return *s.Action
+}
+
+// validateSafeMode validates the safe mode of the global config
+func validateSafeMode(spec *SafeModeSpec, path string) error {
+	if spec == nil {
+		return nil
+	}
+	if spec.MaxTighteningFactor != nil && *spec.MaxTighteningFactor < 2 {
+		return fmt.Errorf("%s: safeMode.maxTighteningFactor must be at least 2, got %d", path, *spec.MaxTighteningFactor)
+	}
+	if spec.Action != nil && *spec.Action != SafeModeActionPause && *spec.Action != SafeModeActionWarn {
+		return fmt.Errorf("%s: invalid safeMode.action '%s', must be one of: pause, warn", path, *spec.Action)
+	}
+	return nil
+}
+
+// safeModeValue returns the root-level value of a field guarded by safe mode, the history limits falling back to historyLimit
+func safeModeValue(config PrunerConfig, field PrunerFieldType) *int32 {
+	switch field {
+	case PrunerFieldTypeTTLSecondsAfterFinished:
+		return config.TTLSecondsAfterFinished
+	case PrunerFieldTypeSuccessfulHistoryLimit:
+		if config.SuccessfulHistoryLimit != nil {
+			return config.SuccessfulHistoryLimit
+		}
+		return config.HistoryLimit
+	case PrunerFieldTypeFailedHistoryLimit:
+		if config.FailedHistoryLimit != nil {
+			return config.FailedHistoryLimit
+		}
+		return config.HistoryLimit
+	}
+	return nil
+}
+
+// tightenedFields returns the fields set in both configs whose current value is more than factor times lower
+func tightenedFields(previous, current PrunerConfig, factor int32) []PrunerFieldType {
+	var tightened []PrunerFieldType
+	for _, field := range safeModeFields {
+		previousValue, currentValue := safeModeValue(previous, field), safeModeValue(current, field)
+		if previousValue == nil || currentValue == nil {
+			continue
+		}
+		if int64(*currentValue)*int64(factor) < int64(*previousValue) {
+			tightened = append(tightened, field)
+		}
+	}
+	return tightened
+}
+
+// ConfigDigest returns the digest of the global config of a ConfigMap, the value of the AnnotationAcknowledgeConfig
+// annotation acknowledging a config tightened beyond safe mode
+func ConfigDigest(configMap *corev1.ConfigMap) string {
+	return digestOf(configMap.Data[PrunerGlobalConfigKey])
+}
+
+// digestOf returns the digest of the content of a global config
+func digestOf(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])[:safeModeDigestLength]
+}
+
+// setFields returns the fields guarded by safe mode which are set in a config
+func setFields(config PrunerConfig) []PrunerFieldType {
+	var set []PrunerFieldType
+	for _, field := range safeModeFields {
+		if safeModeValue(config, field) != nil {
+			set = append(set, field)
+		}
+	}
+	return set
+}
+
+// applySafeMode compares the loaded global config with the last accepted one, and pauses the pruning driven by
+// the fields tightened beyond the safe mode factor until the config is acknowledged. When no config was accepted yet,
+// e.g. on the first start with no state recorded, every guarded field set in the config waits for the acknowledgement.
+// It must be called with the lock held
+func (ps *prunerConfigStore) applySafeMode(ctx context.Context, configMap *corev1.ConfigMap, globalConfig *GlobalConfig) {
+	logger := logging.FromContext(ctx)
+
+	var tightened []PrunerFieldType
+	switch {
+	case globalConfig.SafeMode == nil:
+	case ps.acceptedConfig == nil:
+		tightened = setFields(globalConfig.PrunerConfig)
+	default:
+		tightened = tightenedFields(*ps.acceptedConfig, globalConfig.PrunerConfig, globalConfig.SafeMode.maxTighteningFactor())
+	}
+
+	digest := ConfigDigest(configMap)
+	switch {
+	case len(tightened) == 0:
+		ps.pausedFields = nil
+	case configMap.Annotations[AnnotationAcknowledgeConfig] == digest:
+		logger.Infow("Global config tightened beyond safe mode was acknowledged", "fields", tightened)
+		ps.pausedFields = nil
+	case globalConfig.SafeMode.action() == SafeModeActionWarn:
+		logger.Warnw("Global config tightened beyond safe mode, applying it", "fields", tightened,
+			"maxTighteningFactor", globalConfig.SafeMode.maxTighteningFactor())
+		ps.pausedFields = nil
+	default:
+		logger.Warnw("Global config tightened beyond safe mode, pruning by these fields is paused until the config is acknowledged",
+			"fields", tightened, "maxTighteningFactor", globalConfig.SafeMode.maxTighteningFactor(),
+			"acknowledgeAnnotation", fmt.Sprintf("%s=%s", AnnotationAcknowledgeConfig, digest))
+		ps.pausedFields = tightened
+	}
+
+	// the config stays the reference while pruning is paused, so that reloading it does not resume pruning
+	if ps.pausedFields == nil {
+		accepted := globalConfig.PrunerConfig
+		ps.acceptedConfig = &accepted
+		ps.acceptedData = configMap.Data[PrunerGlobalConfigKey]
+	}
+
+	for _, field := range safeModeFields {
+		metrics.GetRecorder().SetPruningPaused(ctx, string(field), slices.Contains(ps.pausedFields, field))
+	}
+}
+
+// IsPruningPaused reports whether safe mode paused the pruning driven by the given field
+func (ps *prunerConfigStore) IsPruningPaused(field PrunerFieldType) bool {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return slices.Contains(ps.pausedFields, field)
+}
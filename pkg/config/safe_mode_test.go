@@ -0,0 +1,176 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/ptr"
+)
+
+func TestTightenedFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous PrunerConfig
+		current  PrunerConfig
+		want     []PrunerFieldType
+	}{
+		{
+			name:     "ttl slashed",
+			previous: PrunerConfig{TTLSecondsAfterFinished: ptr.Int32(2592000)},
+			current:  PrunerConfig{TTLSecondsAfterFinished: ptr.Int32(60)},
+			want:     []PrunerFieldType{PrunerFieldTypeTTLSecondsAfterFinished},
+		},
+		{
+			name:     "ttl tightened within the factor",
+			previous: PrunerConfig{TTLSecondsAfterFinished: ptr.Int32(3600)},
+			current:  PrunerConfig{TTLSecondsAfterFinished: ptr.Int32(360)},
+		},
+		{
+			name:     "ttl newly set",
+			previous: PrunerConfig{},
+			current:  PrunerConfig{TTLSecondsAfterFinished: ptr.Int32(60)},
+		},
+		{
+			name:     "history limit falls back to historyLimit",
+			previous: PrunerConfig{HistoryLimit: ptr.Int32(100)},
+			current:  PrunerConfig{HistoryLimit: ptr.Int32(100), FailedHistoryLimit: ptr.Int32(1)},
+			want:     []PrunerFieldType{PrunerFieldTypeFailedHistoryLimit},
+		},
+		{
+			name:     "history limits slashed",
+			previous: PrunerConfig{HistoryLimit: ptr.Int32(100)},
+			current:  PrunerConfig{HistoryLimit: ptr.Int32(0)},
+			want:     []PrunerFieldType{PrunerFieldTypeSuccessfulHistoryLimit, PrunerFieldTypeFailedHistoryLimit},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tightenedFields(tt.previous, tt.current, DefaultSafeModeMaxTighteningFactor))
+		})
+	}
+}
+
+func TestLoadGlobalConfigSafeMode(t *testing.T) {
+	ctx := context.Background()
+	newConfigMap := func(data string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: PrunerConfigMapName, Namespace: "tekton-pipelines"},
+			Data:       map[string]string{PrunerGlobalConfigKey: data},
+		}
+	}
+	const relaxed = `
+ttlSecondsAfterFinished: 2592000
+safeMode: {}`
+	const tightened = `
+ttlSecondsAfterFinished: 60
+safeMode: {}`
+
+	newState := func(accepted string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: PrunerStateConfigMapName, Namespace: "tekton-pipelines"},
+			Data:       map[string]string{PrunerStateAcceptedConfigKey: accepted},
+		}
+	}
+
+	t.Run("tightened config pauses pruning until acknowledged", func(t *testing.T) {
+		store := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+		assert.NoError(t, store.LoadState(ctx, newState(relaxed)))
+		assert.NoError(t, store.LoadGlobalConfig(ctx, newConfigMap(relaxed)))
+		assert.False(t, store.IsPruningPaused(PrunerFieldTypeTTLSecondsAfterFinished))
+
+		cm := newConfigMap(tightened)
+		assert.NoError(t, store.LoadGlobalConfig(ctx, cm))
+		assert.True(t, store.IsPruningPaused(PrunerFieldTypeTTLSecondsAfterFinished))
+		assert.False(t, store.IsPruningPaused(PrunerFieldTypeSuccessfulHistoryLimit))
+
+		// reloading the same config keeps pruning paused
+		assert.NoError(t, store.LoadGlobalConfig(ctx, cm))
+		assert.True(t, store.IsPruningPaused(PrunerFieldTypeTTLSecondsAfterFinished))
+
+		// an acknowledgement of another config does not resume pruning
+		cm.Annotations = map[string]string{AnnotationAcknowledgeConfig: ConfigDigest(newConfigMap(relaxed))}
+		assert.NoError(t, store.LoadGlobalConfig(ctx, cm))
+		assert.True(t, store.IsPruningPaused(PrunerFieldTypeTTLSecondsAfterFinished))
+
+		cm.Annotations[AnnotationAcknowledgeConfig] = ConfigDigest(cm)
+		assert.NoError(t, store.LoadGlobalConfig(ctx, cm))
+		assert.False(t, store.IsPruningPaused(PrunerFieldTypeTTLSecondsAfterFinished))
+
+		// the acknowledged config is the new reference
+		cm.Annotations = nil
+		assert.NoError(t, store.LoadGlobalConfig(ctx, cm))
+		assert.False(t, store.IsPruningPaused(PrunerFieldTypeTTLSecondsAfterFinished))
+	})
+
+	t.Run("first config pauses pruning until acknowledged", func(t *testing.T) {
+		store := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+		assert.NoError(t, store.LoadState(ctx, nil))
+		cm := newConfigMap(relaxed)
+		assert.NoError(t, store.LoadGlobalConfig(ctx, cm))
+		assert.True(t, store.IsPruningPaused(PrunerFieldTypeTTLSecondsAfterFinished))
+		assert.NotContains(t, store.State(), PrunerStateAcceptedConfigKey)
+
+		cm.Annotations = map[string]string{AnnotationAcknowledgeConfig: ConfigDigest(cm)}
+		assert.NoError(t, store.LoadGlobalConfig(ctx, cm))
+		assert.False(t, store.IsPruningPaused(PrunerFieldTypeTTLSecondsAfterFinished))
+		assert.Equal(t, relaxed, store.State()[PrunerStateAcceptedConfigKey])
+	})
+
+	t.Run("restart keeps comparing with the accepted config", func(t *testing.T) {
+		store := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+		assert.NoError(t, store.LoadState(ctx, newState(relaxed)))
+		assert.NoError(t, store.LoadGlobalConfig(ctx, newConfigMap(relaxed)))
+		state := store.State()
+
+		restarted := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+		assert.NoError(t, restarted.LoadState(ctx, &corev1.ConfigMap{Data: state}))
+		assert.NoError(t, restarted.LoadGlobalConfig(ctx, newConfigMap(tightened)))
+		assert.True(t, restarted.IsPruningPaused(PrunerFieldTypeTTLSecondsAfterFinished))
+		// the paused config is not recorded as accepted
+		assert.Equal(t, relaxed, restarted.State()[PrunerStateAcceptedConfigKey])
+	})
+
+	t.Run("invalid state", func(t *testing.T) {
+		store := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+		assert.Error(t, store.LoadState(ctx, newState("ttlSecondsAfterFinished: [")))
+		assert.NoError(t, store.LoadGlobalConfig(ctx, newConfigMap(relaxed)))
+		assert.True(t, store.IsPruningPaused(PrunerFieldTypeTTLSecondsAfterFinished))
+	})
+
+	t.Run("warn action applies the tightened config", func(t *testing.T) {
+		store := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+		assert.NoError(t, store.LoadGlobalConfig(ctx, newConfigMap(relaxed)))
+		assert.NoError(t, store.LoadGlobalConfig(ctx, newConfigMap(`
+ttlSecondsAfterFinished: 60
+safeMode:
+  action: warn`)))
+		assert.False(t, store.IsPruningPaused(PrunerFieldTypeTTLSecondsAfterFinished))
+	})
+
+	t.Run("safe mode disabled", func(t *testing.T) {
+		store := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+		assert.NoError(t, store.LoadGlobalConfig(ctx, newConfigMap(`ttlSecondsAfterFinished: 2592000`)))
+		assert.NoError(t, store.LoadGlobalConfig(ctx, newConfigMap(`ttlSecondsAfterFinished: 60`)))
+		assert.False(t, store.IsPruningPaused(PrunerFieldTypeTTLSecondsAfterFinished))
+	})
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/logging"
+)
+
+// LoadState restores the global configs last accepted by safe mode and promoted from the canary namespace,
+// as recorded in the state ConfigMap, so that a restarted controller compares the loaded config against them
+// rather than accepting it as is. It must be called before the global config is loaded,
+// a nil ConfigMap meaning that nothing was recorded
+func (ps *prunerConfigStore) LoadState(ctx context.Context, configMap *corev1.ConfigMap) error {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	ps.acceptedConfig, ps.acceptedData = nil, ""
+	ps.promotedConfig, ps.promotedData = nil, ""
+	if configMap == nil {
+		return nil
+	}
+
+	if data, ok := configMap.Data[PrunerStateAcceptedConfigKey]; ok {
+		accepted, err := parseStateConfig(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse the accepted config of the state ConfigMap: %w", err)
+		}
+		ps.acceptedConfig, ps.acceptedData = &accepted.PrunerConfig, data
+	}
+	if data, ok := configMap.Data[PrunerStatePromotedConfigKey]; ok {
+		promoted, err := parseStateConfig(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse the promoted config of the state ConfigMap: %w", err)
+		}
+		ps.promotedConfig, ps.promotedData = promoted, data
+	}
+
+	logging.FromContext(ctx).Debugw("Restored the state of the global config",
+		"acceptedDigest", digestOf(ps.acceptedData), "promotedDigest", digestOf(ps.promotedData))
+	return nil
+}
+
+// State returns the data of the state ConfigMap recording the global configs last accepted by safe mode
+// and promoted from the canary namespace, if any
+func (ps *prunerConfigStore) State() map[string]string {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	data := map[string]string{}
+	if ps.acceptedConfig != nil {
+		data[PrunerStateAcceptedConfigKey] = ps.acceptedData
+	}
+	if ps.promotedConfig != nil {
+		data[PrunerStatePromotedConfigKey] = ps.promotedData
+	}
+	return data
+}
+
+// parseStateConfig parses a global config recorded in the state ConfigMap, an empty one being the empty config
+func parseStateConfig(data string) (*GlobalConfig, error) {
+	if data == "" {
+		return &GlobalConfig{}, nil
+	}
+	return parseGlobalConfig(data)
+}
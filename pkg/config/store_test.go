@@ -17,12 +17,19 @@ limitations under the License.
 package config
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/tektoncd/pruner/pkg/metrics"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/logging"
 )
 
 // TestLoadGlobalConfig verifies global configuration loading from ConfigMap.
@@ -132,7 +139,7 @@ taskRuns:
 		t.Run(tt.name, func(t *testing.T) {
 			ps := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
 			cm := &corev1.ConfigMap{
-				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: tt.namespace},
+				ObjectMeta: metav1.ObjectMeta{Name: PrunerNamespaceConfigMapName, Namespace: tt.namespace},
 				Data:       map[string]string{PrunerNamespaceConfigKey: tt.configData},
 			}
 
@@ -149,6 +156,85 @@ taskRuns:
 	}
 }
 
+// TestLoadNamespaceConfigNonCanonicalName verifies that a ConfigMap not named tekton-pruner-namespace-spec is ignored.
+func TestLoadNamespaceConfigNonCanonicalName(t *testing.T) {
+	ps := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+	ttl := int32(3600)
+	ps.namespaceConfig["test-ns"] = NamespaceSpec{PrunerConfig: PrunerConfig{TTLSecondsAfterFinished: &ttl}}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "tekton-pruner-namespace-spec-old", Namespace: "test-ns"},
+		Data:       map[string]string{PrunerNamespaceConfigKey: `ttlSecondsAfterFinished: 60`},
+	}
+
+	err := ps.LoadNamespaceConfig(context.Background(), "test-ns", cm)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3600), *ps.namespaceConfig["test-ns"].TTLSecondsAfterFinished)
+}
+
+// TestLoadConfigMetrics verifies that a config failing to load is counted, and that the outcome of the last load
+// of each scope and namespace is recorded
+func TestLoadConfigMetrics(t *testing.T) {
+	ps := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+	ctx := context.Background()
+	recorder := metrics.GetRecorder()
+	errorsBefore := recorder.Snapshot().Counters[metrics.MetricConfigLoadErrors]
+	namespaceCM := func(data string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: PrunerNamespaceConfigMapName, Namespace: "metrics-ns"},
+			Data:       map[string]string{PrunerNamespaceConfigKey: data},
+		}
+	}
+
+	assert.Error(t, ps.LoadGlobalConfig(ctx, &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: "ttlSecondsAfterFinished: [1"}}))
+	assert.Error(t, ps.LoadNamespaceConfig(ctx, "metrics-ns", namespaceCM("ttlSecondsAfterFinished: [1")))
+	snapshot := recorder.Snapshot()
+	assert.Equal(t, errorsBefore+2, snapshot.Counters[metrics.MetricConfigLoadErrors])
+	assert.Equal(t, float64(0), snapshot.Gauges[metrics.MetricConfigLastLoadSuccess]["metrics-ns"])
+
+	assert.NoError(t, ps.LoadNamespaceConfig(ctx, "metrics-ns", namespaceCM("ttlSecondsAfterFinished: 60")))
+	snapshot = recorder.Snapshot()
+	assert.Equal(t, errorsBefore+2, snapshot.Counters[metrics.MetricConfigLoadErrors])
+	assert.Equal(t, float64(1), snapshot.Gauges[metrics.MetricConfigLastLoadSuccess]["metrics-ns"])
+}
+
+// TestLoadGlobalConfigLogging verifies that the parsed global config is logged at info level only when enabled.
+func TestLoadGlobalConfigLogging(t *testing.T) {
+	cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `
+keep: 3
+namespaces:
+  team-a:
+    ttlSecondsAfterFinished: 60
+    pipelineRuns:
+    - selector:
+      - matchLabels:
+          app: build
+      historyLimit: 2`}}
+
+	for _, enabled := range []bool{false, true} {
+		var buf bytes.Buffer
+		logger := zap.New(zapcore.NewCore(zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()), zapcore.AddSync(&buf), zapcore.InfoLevel))
+		ctx := logging.WithLogger(context.Background(), logger.Sugar())
+		if enabled {
+			ctx = WithConfigLogging(ctx)
+		}
+
+		ps := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+		assert.NoError(t, ps.LoadGlobalConfig(ctx, cm))
+
+		output := buf.String()
+		if !enabled {
+			assert.NotContains(t, output, "Loaded global config")
+			continue
+		}
+		assert.Contains(t, output, "Loaded global config")
+		// the deprecated keep field is logged as the historyLimit it resolves to
+		assert.Contains(t, output, `"historyLimit": 3`)
+		assert.Contains(t, output, `"team-a"`)
+		assert.Contains(t, output, `"app": "build"`)
+	}
+}
+
 // TestDeleteNamespaceConfig verifies namespace configuration deletion.
 func TestDeleteNamespaceConfig(t *testing.T) {
 	ps := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
@@ -243,4 +329,48 @@ func TestConcurrentAccess(t *testing.T) {
 	<-done
 }
 
+// TestConcurrentLoadNamespaceConfig verifies that many namespace configs loaded at once, as on a mass rollout,
+// each end up in the store with their own values, while the store is read and other namespaces are deleted
+func TestConcurrentLoadNamespaceConfig(t *testing.T) {
+	const namespaces = 200
+	ps := &prunerConfigStore{namespaceConfig: make(map[string]NamespaceSpec)}
+	ctx := context.Background()
+	for i := 0; i < namespaces; i++ {
+		ps.namespaceConfig[fmt.Sprintf("deleted-ns-%d", i)] = NamespaceSpec{}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < namespaces; i++ {
+		namespace := fmt.Sprintf("ns-%d", i)
+		wg.Add(3)
+		go func(ttl int) {
+			defer wg.Done()
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: PrunerNamespaceConfigMapName, Namespace: namespace},
+				Data:       map[string]string{PrunerNamespaceConfigKey: fmt.Sprintf("ttlSecondsAfterFinished: %d", ttl)},
+			}
+			if err := ps.LoadNamespaceConfig(ctx, namespace, cm); err != nil {
+				t.Errorf("LoadNamespaceConfig(%s) error = %v", namespace, err)
+			}
+		}(3600 + i)
+		go func() {
+			defer wg.Done()
+			_, _ = ps.GetPipelineTTLSecondsAfterFinished(namespace, "", SelectorSpec{})
+		}()
+		go func(deleted string) {
+			defer wg.Done()
+			ps.DeleteNamespaceConfig(ctx, deleted)
+		}(fmt.Sprintf("deleted-ns-%d", i))
+	}
+	wg.Wait()
+
+	assert.Len(t, ps.namespaceConfig, namespaces)
+	for i := 0; i < namespaces; i++ {
+		ttl := ps.namespaceConfig[fmt.Sprintf("ns-%d", i)].TTLSecondsAfterFinished
+		if assert.NotNil(t, ttl, "namespace ns-%d", i) {
+			assert.Equal(t, int32(3600+i), *ttl, "namespace ns-%d", i)
+		}
+	}
+}
+
 func intPtr(i int32) *int32 { return &i }
@@ -18,7 +18,7 @@ package config
 
 import (
 	"context"
-	"encoding/json"
+	goerrors "errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -37,6 +37,15 @@ const (
 	NoTTL = "-1"
 	// DefaultTTL represents the default TTL duration if none is specified
 	DefaultTTL = 0
+
+	// minTTLRequeueDelay and maxTTLRequeueDelay bound the delay before a resource with
+	// an unexpired TTL is reconciled again. The floor avoids hot loops on a TTL about to expire,
+	// the cap makes a long TTL re-evaluated regularly, in case its configuration changes
+	minTTLRequeueDelay = time.Second
+	maxTTLRequeueDelay = 24 * time.Hour
+
+	// identifiedByRetainDays is the level a TTL coming from the retainDays of the global config is identified by
+	identifiedByRetainDays = "identified_by_retain_days"
 )
 
 // TTLResourceFuncs defines the set of functions that should be implemented for
@@ -51,6 +60,9 @@ type TTLResourceFuncs interface {
 	Update(ctx context.Context, resource metav1.Object) error
 	IsCompleted(resource metav1.Object) bool
 	GetCompletionTime(resource metav1.Object) (metav1.Time, error)
+	GetStartTime(resource metav1.Object) (metav1.Time, error)
+	// HasResults reports whether the resource emitted results, true when it cannot be told
+	HasResults(resource metav1.Object) bool
 	Ignore(resource metav1.Object) bool
 	GetTTLSecondsAfterFinished(namespace, name string, selectors SelectorSpec) (*int32, string)
 	GetDefaultLabelKey() string
@@ -61,6 +73,8 @@ type TTLResourceFuncs interface {
 type TTLHandler struct {
 	clock      clockUtil.Clock // the clock for tracking time
 	resourceFn TTLResourceFuncs
+	// historyLimiter tells the runs kept past their TTL by the combined TTL and history policy
+	historyLimiter *HistoryLimiter
 }
 
 // NewTTLHandler creates a new instance of TTLHandler, which is responsible for managing
@@ -82,28 +96,84 @@ func NewTTLHandler(clock clockUtil.Clock, resourceFn TTLResourceFuncs) (*TTLHand
 	return tq, nil
 }
 
+// SetHistoryLimiter sets the history limiter of the same resources, the combined TTL and history policy
+// keeps the runs it retains past their TTL. Without it, the TTL applies alone
+func (th *TTLHandler) SetHistoryLimiter(hl *HistoryLimiter) {
+	th.historyLimiter = hl
+}
+
 // ProcessEvent handles an event for a resource by processing its TTL-based actions.
 // It evaluates the resource's state, checks whether it should be cleaned up,
 // and updates the TTL annotation if needed
-func (th *TTLHandler) ProcessEvent(ctx context.Context, resource metav1.Object) error {
+func (th *TTLHandler) ProcessEvent(ctx context.Context, resource metav1.Object) (err error) {
+	ctx, span := metrics.StartSpan(ctx, "TTLHandler.ProcessEvent", metrics.SpanAttributes(th.resourceFn.Type(), resource.GetNamespace(), resource.GetName())...)
+	defer func() {
+		// waiting for the TTL to expire is not a failure
+		if isRequeueKey, _ := controller.IsRequeueKey(err); isRequeueKey {
+			metrics.SetSpanDecision(ctx, metrics.DecisionRequeued)
+			metrics.EndSpan(span, nil)
+			return
+		}
+		metrics.EndSpan(span, err)
+	}()
+	// the decision is refined once the TTL is evaluated
+	metrics.SetSpanDecision(ctx, metrics.DecisionSkipped)
+
 	// if a resource is in deletion state, no further action needed
 	if resource.GetDeletionTimestamp() != nil {
 		return nil
 	}
 
+	// if a resource is already marked as prunable, it is waiting to be removed by another process
+	if PrunerConfigStore.GetDeletionMode(resource.GetNamespace()) == DeletionModeAnnotate && IsMarkedPrunable(resource) {
+		return nil
+	}
+
 	// if a resource is not completed state, no further action needed
 	if !th.resourceFn.IsCompleted(resource) && th.resourceFn.Ignore(resource) {
+		metrics.GetRecorder().RecordEventSkipped(ctx, th.metricsResourceType(), resource.GetNamespace(), metrics.SkipReasonIgnored)
 		return nil
 	}
 
-	// update ttl annotation, if not present
-	err := th.updateAnnotationTTLSeconds(ctx, resource)
-	if err != nil {
+	// update ttl annotation, if not present or out of date with the config.
+	// The TTL annotation is the only state cached on the resource, so the cleanup check continues
+	// with the updated resource, a changed TTL takes effect right away instead of on the next reconcile
+	resource, err = th.updateAnnotationTTLSeconds(ctx, resource)
+	if err != nil || resource == nil {
 		return err
 	}
 
+	// a deleteAfter deadline and a TTL express contradictory intent, let the user know which one wins
+	th.warnDeleteAfter(ctx, resource)
+
+	// a completed resource older than absoluteMaxAgeSeconds is pruned whatever its TTL
+	overAge := th.exceedsMaxAge(resource)
+
 	// if the resource is not available for cleanup, no further action needed
-	if !th.needsCleanup(resource) {
+	if !overAge && !th.needsCleanup(resource) {
+		metrics.SetSpanDecision(ctx, metrics.DecisionKept)
+		// the history limiter skips the resources which are not completed too, nothing is pruned on this event
+		if !th.resourceFn.IsCompleted(resource) && !th.mayBeAbandoned(resource) {
+			metrics.GetRecorder().RecordEventSkipped(ctx, th.metricsResourceType(), resource.GetNamespace(), metrics.SkipReasonNotCompleted)
+		}
+		return nil
+	}
+
+	// a freeze keeps the runs, they are reconciled again once it ends
+	if until, frozen := PrunerConfigStore.GetFreezeUntil(th.clock.Now()); frozen {
+		metrics.SetSpanDecision(ctx, metrics.DecisionKept)
+		return th.enqueueAfter(logging.FromContext(ctx), resource, until.Sub(th.clock.Now()))
+	}
+
+	if overAge {
+		return th.removeOverAgeResource(ctx, resource)
+	}
+
+	// safe mode keeps the runs until the tightened TTL is acknowledged
+	if PrunerConfigStore.IsPruningPaused(PrunerFieldTypeTTLSecondsAfterFinished) {
+		logging.FromContext(ctx).Debugw("TTL pruning is paused by safe mode, keeping the resource",
+			"resource", th.resourceFn.Type(), "namespace", resource.GetNamespace(), "name", resource.GetName())
+		metrics.SetSpanDecision(ctx, metrics.DecisionKept)
 		return nil
 	}
 
@@ -111,7 +181,8 @@ func (th *TTLHandler) ProcessEvent(ctx context.Context, resource metav1.Object)
 }
 
 // updateAnnotationTTLSeconds updates the TTL annotation of a resource if needed
-func (th *TTLHandler) updateAnnotationTTLSeconds(ctx context.Context, resource metav1.Object) error {
+// and returns the resource with its current TTL annotation, or nil if the resource no longer exists
+func (th *TTLHandler) updateAnnotationTTLSeconds(ctx context.Context, resource metav1.Object) (metav1.Object, error) {
 	logger := logging.FromContext(ctx)
 
 	// get resource name and selectors first to avoid redundant work if no update needed
@@ -129,11 +200,12 @@ func (th *TTLHandler) updateAnnotationTTLSeconds(ctx context.Context, resource m
 
 	// Check if update is needed
 	if !th.needsTTLUpdate(resource, enforcedLevel) {
-		return nil
+		return resource, nil
 	}
 
 	// Get TTL value
-	ttl, identifiedBy := th.resourceFn.GetTTLSecondsAfterFinished(resource.GetNamespace(), resourceName, resourceSelectors)
+	ttl, identifiedBy := th.configuredTTL(resource.GetNamespace(), resourceName, resourceSelectors)
+	metrics.SetSpanIdentifiedBy(ctx, identifiedBy)
 	logger.Debugw("TTL configuration found",
 		"ttl", ttl,
 		"source", identifiedBy,
@@ -145,66 +217,65 @@ func (th *TTLHandler) updateAnnotationTTLSeconds(ctx context.Context, resource m
 	resourceLatest, err := th.resourceFn.Get(ctx, resource.GetNamespace(), resource.GetName())
 	if err != nil {
 		if errors.IsNotFound(err) {
-			return nil
+			return nil, nil
 		}
-		return fmt.Errorf("failed to get resource: %w", err)
+		return nil, fmt.Errorf("failed to get resource: %w", err)
 	}
 
-	// Update annotations
-	annotations := resourceLatest.GetAnnotations()
-	if annotations == nil {
-		annotations = make(map[string]string)
+	// Update annotations, removing the ones no longer configured
+	desired := ttlAnnotations(ttl, identifiedBy)
+	if !ttlAnnotationsChanged(resourceLatest, desired) {
+		return resourceLatest, nil
 	}
-
-	if ttl == nil {
-		// If TTL is nil, remove the annotation if it exists
-		if _, exists := annotations[AnnotationTTLSecondsAfterFinished]; exists {
-			delete(annotations, AnnotationTTLSecondsAfterFinished)
-			logger.Debugw("removing TTL annotation - no TTL configuration found",
-				"resource", th.resourceFn.Type(),
-				"namespace", resource.GetNamespace(),
-				"name", resource.GetName())
-		}
-	} else {
-		// Set new TTL annotation
-		newTTL := strconv.Itoa(int(*ttl))
-		currentTTL, hasCurrentTTL := annotations[AnnotationTTLSecondsAfterFinished]
-		if !hasCurrentTTL || currentTTL != newTTL {
-			annotations[AnnotationTTLSecondsAfterFinished] = newTTL
-			logger.Debugw("updating TTL annotation",
-				"resource", th.resourceFn.Type(),
-				"namespace", resource.GetNamespace(),
-				"name", resource.GetName(),
-				"oldTTL", currentTTL,
-				"newTTL", newTTL,
-				"hadPreviousTTL", hasCurrentTTL)
-		} else {
-			return nil
-		}
+	annotations := make(map[string]string)
+	for key, value := range resourceLatest.GetAnnotations() {
+		annotations[key] = value
 	}
-
-	patchData := map[string]interface{}{
-		"metadata": map[string]interface{}{
-			"annotations": annotations,
-		},
+	logger.Debugw("updating TTL annotation",
+		"resource", th.resourceFn.Type(),
+		"namespace", resource.GetNamespace(),
+		"name", resource.GetName(),
+		"oldTTL", annotations[AnnotationTTLSecondsAfterFinished],
+		"newTTL", desired[AnnotationTTLSecondsAfterFinished],
+		"retainDays", desired[AnnotationRetainDays])
+	delete(annotations, AnnotationTTLSecondsAfterFinished)
+	delete(annotations, AnnotationRetainDays)
+	for key, value := range desired {
+		annotations[key] = value
+	}
+
+	patchBytes, err := AnnotationPatch(resourceLatest, desired, AnnotationTTLSecondsAfterFinished, AnnotationRetainDays)
+	if goerrors.Is(err, ErrAnnotationNotAllowed) {
+		// the TTL still applies to this event, it is only not cached on the resource
+		logger.Warnw("skipping the TTL annotation update of the resource",
+			"resource", th.resourceFn.Type(), "namespace", resource.GetNamespace(), "name", resource.GetName(), zap.Error(err))
+		resourceLatest.SetAnnotations(annotations)
+		return resourceLatest, nil
 	}
-
-	patchBytes, err := json.Marshal(patchData)
 	if err != nil {
-		return fmt.Errorf("failed to marshal patch data: %w", err)
+		return nil, fmt.Errorf("failed to marshal patch data: %w", err)
 	}
 
 	if err := th.resourceFn.Patch(ctx, resourceLatest.GetNamespace(), resourceLatest.GetName(), patchBytes); err != nil {
-		return fmt.Errorf("failed to patch resource with TTL annotation: %w", err)
+		return nil, fmt.Errorf("failed to patch resource with TTL annotation: %w", err)
 	}
+	resourceLatest.SetAnnotations(annotations)
 
-	return nil
+	return resourceLatest, nil
 }
 
-// needsCleanup checks whether a Resource has finished and has a TTL set.
+// metricsResourceType returns the resource type label value of the metrics recorded for the handled resources
+func (th *TTLHandler) metricsResourceType() string {
+	if th.resourceFn.Type() == KindTaskRun {
+		return metrics.ResourceTypeTaskRun
+	}
+	return metrics.ResourceTypePipelineRun
+}
+
+// needsCleanup checks whether a Resource has finished, or may be abandoned, and has a TTL set.
 func (th *TTLHandler) needsCleanup(resource metav1.Object) bool {
 	// Check completion state first as it's likely to be the most expensive operation
-	if !th.resourceFn.IsCompleted(resource) {
+	if !th.resourceFn.IsCompleted(resource) && !th.mayBeAbandoned(resource) {
 		return false
 	}
 
@@ -215,7 +286,76 @@ func (th *TTLHandler) needsCleanup(resource metav1.Object) bool {
 	}
 
 	ttlValue := annotations[AnnotationTTLSecondsAfterFinished]
-	return ttlValue != "" && ttlValue != NoTTL
+	if ttlValue != "" && ttlValue != NoTTL {
+		return true
+	}
+	// a deleteAfter deadline removes the resource even without a TTL
+	_, hasDeadline := th.getDeleteAfter(resource)
+	return hasDeadline
+}
+
+// mayBeAbandoned checks whether a Resource which is not completed can be removed once abandoned,
+// that is when the TTL is counted from the start time, abandonedAfterSeconds is set and the resource has started
+func (th *TTLHandler) mayBeAbandoned(resource metav1.Object) bool {
+	if PrunerConfigStore.GetTTLFrom(resource.GetNamespace()) != TTLFromStart || PrunerConfigStore.GetAbandonedAfterSeconds(resource.GetNamespace()) == nil {
+		return false
+	}
+	_, err := th.resourceFn.GetStartTime(resource)
+	return err == nil
+}
+
+// hasConflictingDeadlines checks whether a Resource has both a TTL and a deleteAfter deadline
+func hasConflictingDeadlines(resource metav1.Object) bool {
+	annotations := resource.GetAnnotations()
+	ttlValue := annotations[AnnotationTTLSecondsAfterFinished]
+	return annotations[AnnotationDeleteAfter] != "" && ttlValue != "" && ttlValue != NoTTL
+}
+
+// getDeleteAfter returns the deadline set by the deleteAfter annotation of a resource, if any.
+// The annotation is ignored when it is malformed, or when the enforced config level does not allow resource-level overrides
+func (th *TTLHandler) getDeleteAfter(resource metav1.Object) (time.Time, bool) {
+	value, found := resource.GetAnnotations()[AnnotationDeleteAfter]
+	if !found {
+		return time.Time{}, false
+	}
+	resourceName := getResourceName(resource, getResourceNameLabelKey(resource, th.resourceFn.GetDefaultLabelKey()))
+	if th.resourceFn.GetEnforcedConfigLevel(resource.GetNamespace(), resourceName, th.getResourceSelectors(resource)) != EnforcedConfigLevelResource {
+		return time.Time{}, false
+	}
+	deleteAfter, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return deleteAfter, true
+}
+
+// warnDeleteAfter warns about a malformed deleteAfter deadline, or about a deadline taking precedence over the TTL.
+// The warning is logged once per resource, not on every event
+func (th *TTLHandler) warnDeleteAfter(ctx context.Context, resource metav1.Object) {
+	value, found := resource.GetAnnotations()[AnnotationDeleteAfter]
+	if !found {
+		return
+	}
+	var message string
+	if _, honored := th.getDeleteAfter(resource); honored {
+		if !hasConflictingDeadlines(resource) {
+			return
+		}
+		message = "resource has both a TTL and a deleteAfter deadline, the deleteAfter deadline takes precedence"
+	} else if _, err := time.Parse(time.RFC3339, value); err != nil {
+		message = "ignoring malformed deleteAfter deadline, it must be an RFC 3339 time"
+	} else {
+		return
+	}
+	if !deleteAfterWarnings.firstTime(resource.GetUID()) {
+		return
+	}
+	logging.FromContext(ctx).Warnw(message,
+		"resource", th.resourceFn.Type(),
+		"namespace", resource.GetNamespace(),
+		"name", resource.GetName(),
+		"ttlSecondsAfterFinished", resource.GetAnnotations()[AnnotationTTLSecondsAfterFinished],
+		"deleteAfter", value)
 }
 
 // removeResource checks the TTL and deletes the Resource if it has expired
@@ -260,6 +400,73 @@ func (th *TTLHandler) removeResource(ctx context.Context, resource metav1.Object
 		"expiredAt", expiredAt,
 	)
 
+	// the combined policy keeps the newest runs within the history limits past their TTL
+	if th.historyLimiter != nil && PrunerConfigStore.GetTTLHistoryPolicy(resource.GetNamespace()) == TTLHistoryPolicyCombined &&
+		th.resourceFn.IsCompleted(freshResource) {
+		retained, err := th.historyLimiter.IsRetained(ctx, freshResource)
+		if err != nil {
+			return fmt.Errorf("failed to check the history limits: %w", err)
+		}
+		if retained {
+			logger.Debugw("keeping expired resource within its history limit",
+				"resourceType", th.resourceFn.Type(),
+				"namespace", resource.GetNamespace(),
+				"name", resource.GetName(),
+			)
+			metrics.SetSpanDecision(ctx, metrics.DecisionKept)
+			return nil
+		}
+	}
+
+	reason := PruneReasonTTL
+	if !th.resourceFn.IsCompleted(resource) {
+		reason = PruneReasonAbandoned
+	}
+	return th.pruneResource(ctx, resource, freshResource, reason, metrics.OperationTTL)
+}
+
+// exceedsMaxAge checks whether a Resource is completed and was created more than absoluteMaxAgeSeconds ago
+func (th *TTLHandler) exceedsMaxAge(resource metav1.Object) bool {
+	maxAge := PrunerConfigStore.GetAbsoluteMaxAgeSeconds(resource.GetNamespace())
+	if maxAge == nil || resource.GetDeletionTimestamp() != nil || !th.resourceFn.IsCompleted(resource) {
+		return false
+	}
+	creationTime := resource.GetCreationTimestamp()
+	if creationTime.IsZero() {
+		return false
+	}
+	return th.clock.Since(creationTime.Time) >= time.Duration(*maxAge)*time.Second
+}
+
+// removeOverAgeResource prunes a Resource older than absoluteMaxAgeSeconds, whatever its TTL and history limits
+func (th *TTLHandler) removeOverAgeResource(ctx context.Context, resource metav1.Object) error {
+	freshResource, err := th.resourceFn.Get(ctx, resource.GetNamespace(), resource.GetName())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get fresh resource: %w", err)
+	}
+	if !th.exceedsMaxAge(freshResource) {
+		return nil
+	}
+
+	// the backstop overrides every other setting, so that an admin can tell why a run was pruned
+	logging.FromContext(ctx).Warnw("pruning resource older than absoluteMaxAgeSeconds, whatever its TTL and history limits",
+		"resourceType", th.resourceFn.Type(),
+		"namespace", resource.GetNamespace(),
+		"name", resource.GetName(),
+		"creationTimestamp", freshResource.GetCreationTimestamp(),
+		"absoluteMaxAgeSeconds", *PrunerConfigStore.GetAbsoluteMaxAgeSeconds(resource.GetNamespace()),
+	)
+	return th.pruneResource(ctx, resource, freshResource, PruneReasonMaxAge, metrics.OperationMaxAge)
+}
+
+// pruneResource deletes a Resource, or marks it as prunable in annotate mode, unless neverPrune,
+// skipRunsWithFinalizers or a protecting resource keeps it. freshResource is the latest version of the Resource
+func (th *TTLHandler) pruneResource(ctx context.Context, resource, freshResource metav1.Object, reason PruneReason, operation string) error {
+	logger := logging.FromContext(ctx)
+
 	// Calculate resource age for metrics
 	var resourceAge time.Duration
 	creationTime := resource.GetCreationTimestamp()
@@ -267,26 +474,80 @@ func (th *TTLHandler) removeResource(ctx context.Context, resource metav1.Object
 		resourceAge = time.Since(creationTime.Time)
 	}
 
-	// Get resource type for metrics
-	resourceType := metrics.ResourceTypePipelineRun
-	if th.resourceFn.Type() == KindTaskRun {
-		resourceType = metrics.ResourceTypeTaskRun
+	resourceType := th.metricsResourceType()
+
+	// the runs of the Pipelines and Tasks listed in neverPrune are kept whatever their TTL
+	if IsNeverPruned(th.resourceFn.Type(), freshResource) {
+		metrics.SetSpanDecision(ctx, metrics.DecisionProtected)
+		logger.Debugw("skipping expired resource listed in neverPrune",
+			"resourceType", th.resourceFn.Type(),
+			"namespace", resource.GetNamespace(),
+			"name", resource.GetName(),
+		)
+		return nil
 	}
 
-	if err := th.resourceFn.Delete(ctx, resource.GetNamespace(), resource.GetName()); err != nil {
+	// a resource finalized by another controller is kept, its deletion would hang until that controller removes the finalizer
+	if HasSkippedFinalizer(freshResource) {
+		metrics.SetSpanDecision(ctx, metrics.DecisionProtected)
+		logger.Debugw("skipping expired resource with a finalizer listed in skipRunsWithFinalizers",
+			"resourceType", th.resourceFn.Type(),
+			"namespace", resource.GetNamespace(),
+			"name", resource.GetName(),
+			"finalizers", freshResource.GetFinalizers(),
+		)
+		return nil
+	}
+
+	// a resource referenced by an existing protecting resource is kept until that resource is gone
+	protected, err := IsProtected(ctx, freshResource)
+	if err != nil {
+		return fmt.Errorf("failed to check resource protection: %w", err)
+	}
+	if protected {
+		metrics.SetSpanDecision(ctx, metrics.DecisionProtected)
+		logger.Debugw("skipping expired resource referenced by a protecting resource",
+			"resourceType", th.resourceFn.Type(),
+			"namespace", resource.GetNamespace(),
+			"name", resource.GetName(),
+		)
+		return nil
+	}
+
+	// in annotate mode, mark the resource as prunable and leave the actual removal to another process
+	if PrunerConfigStore.GetDeletionMode(resource.GetNamespace()) == DeletionModeAnnotate {
+		if err := markPrunable(ctx, th.resourceFn.Patch, resource, reason); err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			metricsRecorder := metrics.GetRecorder()
+			errorType := metrics.ClassifyError(err)
+			metricsRecorder.RecordResourceError(ctx, resourceType, resource.GetNamespace(), errorType, operation+"_annotation_failed")
+			return fmt.Errorf("failed to mark resource as prunable: %w", err)
+		}
+		metrics.SetSpanDecision(ctx, metrics.DecisionMarkedPrunable)
+		return nil
+	}
+
+	err = LimitDeletion(ctx, func() error {
+		return th.resourceFn.Delete(ctx, resource.GetNamespace(), resource.GetName())
+	})
+	if err != nil {
 		if errors.IsNotFound(err) {
 			return nil
 		}
 		// Record deletion error
 		metricsRecorder := metrics.GetRecorder()
 		errorType := metrics.ClassifyError(err)
-		metricsRecorder.RecordResourceError(ctx, resourceType, resource.GetNamespace(), errorType, "ttl_deletion_failed")
+		metricsRecorder.RecordResourceError(ctx, resourceType, resource.GetNamespace(), errorType, operation+"_deletion_failed")
 		return fmt.Errorf("failed to delete resource: %w", err)
 	}
 
 	// Record successful deletion
+	metrics.SetSpanDecision(ctx, metrics.DecisionDeleted)
 	metricsRecorder := metrics.GetRecorder()
-	metricsRecorder.RecordResourceDeleted(ctx, resourceType, resource.GetNamespace(), metrics.OperationTTL, resourceAge)
+	metricsRecorder.RecordResourceDeleted(ctx, resourceType, resource.GetNamespace(), operation, reason.String(), resourceAge)
+	NotifyPruned(ctx, NewPrunedEvent(th.resourceFn.Type(), resource, reason))
 
 	return nil
 }
@@ -315,7 +576,7 @@ func (th *TTLHandler) processTTL(logger *zap.SugaredLogger, resource metav1.Obje
 
 // calculates the remaining time to hold this resource
 func (th *TTLHandler) timeLeft(logger *zap.SugaredLogger, resource metav1.Object, since *time.Time) (*time.Duration, *time.Time, error) {
-	finishAt, expireAt, err := th.getFinishAndExpireTime(resource)
+	finishAt, expireAt, err := th.getFinishAndExpireTime(logger, resource)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -330,25 +591,114 @@ func (th *TTLHandler) timeLeft(logger *zap.SugaredLogger, resource metav1.Object
 	return &remaining, expireAt, nil
 }
 
+// getTTLAnchor returns the time the TTL of the resource is counted from: the timestamp of the ttlAnchorAnnotation
+// when it is valid, otherwise the completion time, or the start time when ttlFrom is start
+func (th *TTLHandler) getTTLAnchor(logger *zap.SugaredLogger, resource metav1.Object) (metav1.Time, error) {
+	if anchorAnnotation := PrunerConfigStore.GetTTLAnchorAnnotation(resource.GetNamespace()); anchorAnnotation != "" {
+		value, found := resource.GetAnnotations()[anchorAnnotation]
+		anchor, err := time.Parse(time.RFC3339, value)
+		if found && err == nil {
+			return metav1.NewTime(anchor), nil
+		}
+		logger.Debugw("TTL anchor annotation is missing or invalid, falling back to ttlFrom",
+			"annotation", anchorAnnotation, "value", value, "found", found)
+	}
+	if PrunerConfigStore.GetTTLFrom(resource.GetNamespace()) == TTLFromStart {
+		return th.resourceFn.GetStartTime(resource)
+	}
+	return th.resourceFn.GetCompletionTime(resource)
+}
+
 // returns finished and expire time of the Resource
-func (th *TTLHandler) getFinishAndExpireTime(resource metav1.Object) (*time.Time, *time.Time, error) {
+func (th *TTLHandler) getFinishAndExpireTime(logger *zap.SugaredLogger, resource metav1.Object) (*time.Time, *time.Time, error) {
 	if !th.needsCleanup(resource) {
 		return nil, nil, fmt.Errorf("resource '%s/%s' should not be cleaned up", resource.GetNamespace(), resource.GetName())
 	}
-	t, err := th.resourceFn.GetCompletionTime(resource)
+	t, err := th.getTTLAnchor(logger, resource)
 	if err != nil {
 		return nil, nil, err
 	}
 	finishAt := t.Time
-	// get ttl duration
-	ttlDuration, err := th.getTTLSeconds(resource)
-	if err != nil {
-		return nil, nil, err
+	var expireAt time.Time
+	deleteAfter, hasDeadline := th.getDeleteAfter(resource)
+	if hasDeadline {
+		// an absolute deadline takes precedence over the TTL
+		expireAt = deleteAfter
+	} else if retainDays, found := resource.GetAnnotations()[AnnotationRetainDays]; found {
+		// the TTL comes from retainDays, it is counted in calendar days
+		days, err := strconv.Atoi(retainDays)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid retainDays value %q: %w", retainDays, err)
+		}
+		expireAt = retainDeadline(finishAt, days, PrunerConfigStore.GetRetainDaysLocation(resource.GetNamespace()))
+	} else {
+		// get ttl duration
+		ttlDuration, err := th.getTTLSeconds(resource)
+		if err != nil {
+			return nil, nil, err
+		}
+		expireAt = finishAt.Add(*ttlDuration)
+	}
+
+	// a completed resource which emitted no results expires sooner, with ttlNoResultsSeconds
+	if noResultsTTL := PrunerConfigStore.GetTTLNoResultsSeconds(resource.GetNamespace()); noResultsTTL != nil && !hasDeadline &&
+		th.resourceFn.IsCompleted(resource) && !th.resourceFn.HasResults(resource) {
+		if noResultsAt := finishAt.Add(time.Duration(*noResultsTTL) * time.Second); noResultsAt.Before(expireAt) {
+			logger.Debugw("resource emitted no results, its TTL is shortened", "ttlNoResultsSeconds", *noResultsTTL)
+			expireAt = noResultsAt
+		}
+	}
+
+	// a resource under investigation is kept until its TTL extension expires
+	if extendUntil, ok := th.getExtendTTLUntil(logger, resource); ok && extendUntil.After(expireAt) {
+		logger.Debugw("resource TTL is extended by annotation", "extendTTLUntil", extendUntil.UTC())
+		expireAt = extendUntil
+	}
+
+	// a resource which is not completed expires no sooner than abandonedAfterSeconds after its start,
+	// so that a long running resource is not removed by a short TTL
+	if !th.resourceFn.IsCompleted(resource) {
+		abandonedAfter := PrunerConfigStore.GetAbandonedAfterSeconds(resource.GetNamespace())
+		if abandonedAfter == nil {
+			return nil, nil, fmt.Errorf("resource '%s/%s' is not completed", resource.GetNamespace(), resource.GetName())
+		}
+		if abandonedAt := finishAt.Add(time.Duration(*abandonedAfter) * time.Second); abandonedAt.After(expireAt) {
+			expireAt = abandonedAt
+		}
 	}
-	expireAt := finishAt.Add(*ttlDuration)
 	return &finishAt, &expireAt, nil
 }
 
+// getExtendTTLUntil returns the time set by the extend-ttl-until annotation of a resource, if any.
+// The annotation is ignored when it is malformed, or when the enforced config level does not allow resource-level overrides
+func (th *TTLHandler) getExtendTTLUntil(logger *zap.SugaredLogger, resource metav1.Object) (time.Time, bool) {
+	value, found := resource.GetAnnotations()[AnnotationExtendTTLUntil]
+	if !found {
+		return time.Time{}, false
+	}
+	resourceName := getResourceName(resource, getResourceNameLabelKey(resource, th.resourceFn.GetDefaultLabelKey()))
+	if th.resourceFn.GetEnforcedConfigLevel(resource.GetNamespace(), resourceName, th.getResourceSelectors(resource)) != EnforcedConfigLevelResource {
+		logger.Debugw("ignoring the TTL extension, the enforced config level does not allow resource-level overrides",
+			"resource", th.resourceFn.Type(), "namespace", resource.GetNamespace(), "name", resource.GetName())
+		return time.Time{}, false
+	}
+	extendUntil, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		logger.Warnw("ignoring malformed TTL extension, it must be an RFC 3339 time",
+			"resource", th.resourceFn.Type(), "namespace", resource.GetNamespace(), "name", resource.GetName(),
+			"annotation", AnnotationExtendTTLUntil, "value", value, zap.Error(err))
+		return time.Time{}, false
+	}
+	return extendUntil, true
+}
+
+// retainDeadline returns the midnight, in the given location, that many calendar days after the day of finishAt.
+// A day of a daylight saving time transition counts as one day, whatever its length
+func retainDeadline(finishAt time.Time, days int, location *time.Location) time.Time {
+	finished := finishAt.In(location)
+	return time.Date(finished.Year(), finished.Month(), finished.Day()+days, 0, 0, 0, 0, location)
+}
+
 // returns ttl of the resource
 func (th *TTLHandler) getTTLSeconds(resource metav1.Object) (*time.Duration, error) {
 	annotations := resource.GetAnnotations()
@@ -378,14 +728,29 @@ func (th *TTLHandler) getTTLSeconds(resource metav1.Object) (*time.Duration, err
 }
 
 // enqueue the Resource for later reconcile
-// the resource expire duration is in the future
+// the resource expire duration is in the future, the Resource is reconciled again when its TTL expires,
+// unless it expires beyond the requeue ceiling of the global config
 func (th *TTLHandler) enqueueAfter(logger *zap.SugaredLogger, resource metav1.Object, after time.Duration) error {
+	// a resource expiring beyond the requeue ceiling is not held in the work queue,
+	// it is evaluated again on the next resync or garbage collection
+	if ceiling := PrunerConfigStore.GetTTLRequeueCeilingSeconds(); ceiling != nil && after > time.Duration(*ceiling)*time.Second {
+		logger.Debugw("the resource expires beyond the requeue ceiling, not requeued",
+			"resource", th.resourceFn.Type(), "namespace", resource.GetNamespace(), "name", resource.GetName(), "expiresIn", after,
+		)
+		return nil
+	}
+	after = requeueDelay(after)
 	logger.Debugw("the resource to be reconciled later, it has expire in the future",
 		"resource", th.resourceFn.Type(), "namespace", resource.GetNamespace(), "name", resource.GetName(), "waitDuration", after,
 	)
 	return controller.NewRequeueAfter(after)
 }
 
+// requeueDelay bounds the remaining time until TTL expiry to [minTTLRequeueDelay, maxTTLRequeueDelay]
+func requeueDelay(remaining time.Duration) time.Duration {
+	return min(max(remaining, minTTLRequeueDelay), maxTTLRequeueDelay)
+}
+
 // getResourceSelectors constructs the selector spec for a resource
 func (th *TTLHandler) getResourceSelectors(resource metav1.Object) SelectorSpec {
 	selectors := SelectorSpec{}
@@ -398,15 +763,53 @@ func (th *TTLHandler) getResourceSelectors(resource metav1.Object) SelectorSpec
 	return selectors
 }
 
-// needsTTLUpdate determines if a resource needs its TTL annotation updated
-func (th *TTLHandler) needsTTLUpdate(resource metav1.Object, enforcedLevel EnforcedConfigLevel) bool {
+// configuredTTL returns the TTL of a resource and the level it is identified by.
+// The retainDays of the global config applies when no TTL is configured at any level,
+// and the fallback TTL only when retainDays is not configured either
+func (th *TTLHandler) configuredTTL(namespace, name string, selectors SelectorSpec) (*int32, string) {
+	ttl, identifiedBy := th.resourceFn.GetTTLSecondsAfterFinished(namespace, name, selectors)
+	if ttl != nil {
+		return ttl, identifiedBy
+	}
+	if retainDays := PrunerConfigStore.GetRetainDays(namespace); retainDays != nil {
+		retainSeconds := *retainDays * secondsPerDay
+		return &retainSeconds, identifiedByRetainDays
+	}
+	if fallbackTTL := PrunerConfigStore.GetFallbackTTLSecondsAfterFinished(namespace); fallbackTTL != nil {
+		return fallbackTTL, "identified_by_fallback"
+	}
+	return nil, identifiedBy
+}
+
+// ttlAnnotations returns the TTL annotations of a resource for its configured TTL, or nil if no TTL is configured.
+// A TTL coming from retainDays is annotated in days too, its deadline is counted in calendar days
+func ttlAnnotations(ttl *int32, identifiedBy string) map[string]string {
+	if ttl == nil {
+		return nil
+	}
+	annotations := map[string]string{AnnotationTTLSecondsAfterFinished: strconv.Itoa(int(*ttl))}
+	if identifiedBy == identifiedByRetainDays {
+		annotations[AnnotationRetainDays] = strconv.Itoa(int(*ttl / secondsPerDay))
+	}
+	return annotations
+}
+
+// ttlAnnotationsChanged checks whether the TTL annotations of a resource differ from the given ones
+func ttlAnnotationsChanged(resource metav1.Object, want map[string]string) bool {
 	annotations := resource.GetAnnotations()
-	if annotations == nil {
-		return true
+	for _, key := range []string{AnnotationTTLSecondsAfterFinished, AnnotationRetainDays} {
+		current, exists := annotations[key]
+		wanted, wantExists := want[key]
+		if exists != wantExists || current != wanted {
+			return true
+		}
 	}
+	return false
+}
 
-	currentTTL, exists := annotations[AnnotationTTLSecondsAfterFinished]
-	if !exists {
+// needsTTLUpdate determines if a resource needs its TTL annotations updated
+func (th *TTLHandler) needsTTLUpdate(resource metav1.Object, enforcedLevel EnforcedConfigLevel) bool {
+	if _, exists := resource.GetAnnotations()[AnnotationTTLSecondsAfterFinished]; !exists {
 		return true
 	}
 
@@ -415,14 +818,6 @@ func (th *TTLHandler) needsTTLUpdate(resource metav1.Object, enforcedLevel Enfor
 	resourceName := getResourceName(resource, labelKey)
 	resourceSelectors := th.getResourceSelectors(resource)
 
-	configTTL, _ := th.resourceFn.GetTTLSecondsAfterFinished(resource.GetNamespace(), resourceName, resourceSelectors)
-
-	// If there's no config TTL, we should remove the annotation
-	if configTTL == nil {
-		return true
-	}
-
-	// Compare current TTL with config TTL
-	configTTLStr := strconv.Itoa(int(*configTTL))
-	return currentTTL != configTTLStr
+	configTTL, identifiedBy := th.configuredTTL(resource.GetNamespace(), resourceName, resourceSelectors)
+	return ttlAnnotationsChanged(resource, ttlAnnotations(configTTL, identifiedBy))
 }
@@ -3,13 +3,18 @@ package config
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	clocktest "k8s.io/utils/clock/testing"
+	"knative.dev/pkg/controller"
 	"knative.dev/pkg/ptr"
 )
 
@@ -18,6 +23,8 @@ type ttlMockResource struct {
 	metav1.ObjectMeta
 	completed       bool
 	completion_time *metav1.Time
+	start_time      *metav1.Time
+	noResults       bool
 }
 
 // mockTTLFuncs implements TTLResourceFuncs for testing
@@ -25,6 +32,10 @@ type mockTTLFuncs struct {
 	resources           map[string]*ttlMockResource
 	enforcedConfigLevel EnforcedConfigLevel
 	ttl                 *int32
+	// unconfigured makes GetTTLSecondsAfterFinished report that no TTL is configured at any level
+	unconfigured bool
+	// kind overrides the resource type reported by Type
+	kind string
 }
 
 func newMockTTLFuncs() *mockTTLFuncs {
@@ -33,7 +44,12 @@ func newMockTTLFuncs() *mockTTLFuncs {
 	}
 }
 
-func (m *mockTTLFuncs) Type() string { return "MockResource" }
+func (m *mockTTLFuncs) Type() string {
+	if m.kind != "" {
+		return m.kind
+	}
+	return "MockResource"
+}
 
 func (m *mockTTLFuncs) Get(_ context.Context, namespace, name string) (metav1.Object, error) {
 	key := namespace + "/" + name
@@ -87,9 +103,26 @@ func (m *mockTTLFuncs) GetCompletionTime(resource metav1.Object) (metav1.Time, e
 	return metav1.Time{}, fmt.Errorf("completion time not set")
 }
 
+func (m *mockTTLFuncs) GetStartTime(resource metav1.Object) (metav1.Time, error) {
+	if mr, ok := resource.(*ttlMockResource); ok && mr.start_time != nil {
+		return *mr.start_time, nil
+	}
+	return metav1.Time{}, fmt.Errorf("start time not set")
+}
+
+func (m *mockTTLFuncs) HasResults(resource metav1.Object) bool {
+	if mr, ok := resource.(*ttlMockResource); ok {
+		return !mr.noResults
+	}
+	return true
+}
+
 func (m *mockTTLFuncs) Ignore(resource metav1.Object) bool { return false }
 
 func (m *mockTTLFuncs) GetTTLSecondsAfterFinished(_, _ string, _ SelectorSpec) (*int32, string) {
+	if m.unconfigured {
+		return nil, ""
+	}
 	ttl := int32(60) // Default test TTL
 	return &ttl, "test"
 }
@@ -308,3 +341,1334 @@ func TestResourceNeedsCleanup(t *testing.T) {
 		})
 	}
 }
+
+// annotationPatchTTLFuncs is a mockTTLFuncs which applies the annotations of a patch
+type annotationPatchTTLFuncs struct {
+	*mockTTLFuncs
+	patches int
+}
+
+func (m *annotationPatchTTLFuncs) Patch(_ context.Context, namespace, name string, patchBytes []byte) error {
+	res, ok := m.resources[namespace+"/"+name]
+	if !ok {
+		return errors.NewNotFound(schema.GroupResource{Group: "test", Resource: "mock"}, name)
+	}
+	annotations, err := applyAnnotationPatch(res.Annotations, patchBytes)
+	if err != nil {
+		return err
+	}
+	res.Annotations = annotations
+	m.patches++
+	return nil
+}
+
+// TestProcessEventDeletionMode verifies that an expired resource is deleted in delete mode
+// and marked as prunable, but kept, in annotate mode
+func TestProcessEventDeletionMode(t *testing.T) {
+	tests := []struct {
+		name         string
+		globalConfig string
+		annotations  map[string]string
+		wantDeleted  bool
+		wantPrunable bool
+		wantPatches  int
+	}{
+		{
+			name:         "delete mode deletes the expired resource",
+			globalConfig: `deletionMode: delete`,
+			annotations:  map[string]string{AnnotationTTLSecondsAfterFinished: "60"},
+			wantDeleted:  true,
+		},
+		{
+			name:         "annotate mode marks the expired resource as prunable",
+			globalConfig: `deletionMode: annotate`,
+			annotations:  map[string]string{AnnotationTTLSecondsAfterFinished: "60"},
+			wantPrunable: true,
+			wantPatches:  1,
+		},
+		{
+			name:         "annotate mode skips a resource already marked as prunable",
+			globalConfig: `deletionMode: annotate`,
+			annotations: map[string]string{
+				AnnotationTTLSecondsAfterFinished: "60",
+				AnnotationPrunable:                "true",
+			},
+			wantPrunable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			fakeClock := clocktest.NewFakeClock(time.Now())
+			mockFuncs := &annotationPatchTTLFuncs{mockTTLFuncs: newMockTTLFuncs()}
+			handler, _ := NewTTLHandler(fakeClock, mockFuncs)
+
+			resource := &ttlMockResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "expired",
+					Namespace:   "default",
+					Annotations: tt.annotations,
+				},
+				completed:       true,
+				completion_time: &metav1.Time{Time: fakeClock.Now().Add(-2 * time.Hour)},
+			}
+			mockFuncs.resources["default/expired"] = resource
+
+			if err := handler.ProcessEvent(ctx, resource); err != nil {
+				t.Fatalf("ProcessEvent() unexpected error = %v", err)
+			}
+
+			stored, exists := mockFuncs.resources["default/expired"]
+			if exists == tt.wantDeleted {
+				t.Fatalf("resource deleted = %v, want %v", !exists, tt.wantDeleted)
+			}
+			if exists && IsMarkedPrunable(stored) != tt.wantPrunable {
+				t.Errorf("resource prunable = %v, want %v", IsMarkedPrunable(stored), tt.wantPrunable)
+			}
+			if tt.wantPatches > 0 && stored.Annotations[AnnotationPrunableReason] != PrunableReasonTTL {
+				t.Errorf("prunable reason = %q, want %q", stored.Annotations[AnnotationPrunableReason], PrunableReasonTTL)
+			}
+			if mockFuncs.patches != tt.wantPatches {
+				t.Errorf("patches = %d, want %d", mockFuncs.patches, tt.wantPatches)
+			}
+		})
+	}
+}
+
+// TestProcessEventSkipRunsWithFinalizers verifies that an expired run carrying a finalizer listed in skipRunsWithFinalizers survives its TTL
+func TestProcessEventSkipRunsWithFinalizers(t *testing.T) {
+	tests := []struct {
+		name        string
+		skipped     string
+		finalizers  []string
+		wantDeleted bool
+	}{
+		{
+			name:        "not configured",
+			skipped:     `enforcedConfigLevel: global`,
+			finalizers:  []string{"chains.tekton.dev"},
+			wantDeleted: true,
+		},
+		{
+			name:       "matching finalizer is kept",
+			skipped:    `skipRunsWithFinalizers: [chains.tekton.dev]`,
+			finalizers: []string{"example.com/audit", "chains.tekton.dev"},
+		},
+		{
+			name:        "other finalizer is deleted",
+			skipped:     `skipRunsWithFinalizers: [chains.tekton.dev]`,
+			finalizers:  []string{"example.com/audit"},
+			wantDeleted: true,
+		},
+		{
+			name:       "any finalizer is kept",
+			skipped:    `skipRunsWithFinalizers: ["*"]`,
+			finalizers: []string{"example.com/audit"},
+		},
+		{
+			name:        "no finalizer is deleted",
+			skipped:     `skipRunsWithFinalizers: ["*"]`,
+			wantDeleted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: tt.skipped}}
+			if err := PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			fakeClock := clocktest.NewFakeClock(time.Now())
+			mockFuncs := newMockTTLFuncs()
+			handler, _ := NewTTLHandler(fakeClock, mockFuncs)
+
+			resource := &ttlMockResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "expired",
+					Namespace:   "default",
+					Finalizers:  tt.finalizers,
+					Annotations: map[string]string{AnnotationTTLSecondsAfterFinished: "60"},
+				},
+				completed:       true,
+				completion_time: &metav1.Time{Time: fakeClock.Now().Add(-2 * time.Hour)},
+			}
+			mockFuncs.resources["default/expired"] = resource
+
+			if err := handler.ProcessEvent(ctx, resource); err != nil {
+				t.Fatalf("ProcessEvent() unexpected error = %v", err)
+			}
+			if _, exists := mockFuncs.resources["default/expired"]; exists == tt.wantDeleted {
+				t.Errorf("resource deleted = %v, want %v", !exists, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+// TestProcessEventNeverPrune verifies that an expired run listed in neverPrune survives its TTL
+func TestProcessEventNeverPrune(t *testing.T) {
+	ctx := context.Background()
+	cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `
+neverPrune:
+  pipelineRuns: [prod-release]`}}
+	if err := PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	tests := []struct {
+		name        string
+		kind        string
+		pipeline    string
+		wantDeleted bool
+	}{
+		{
+			name:     "listed pipeline is kept",
+			kind:     KindPipelineRun,
+			pipeline: "prod-release",
+		},
+		{
+			name:        "other pipeline is deleted",
+			kind:        KindPipelineRun,
+			pipeline:    "build",
+			wantDeleted: true,
+		},
+		{
+			name:        "pipelineRuns list does not apply to TaskRuns",
+			kind:        KindTaskRun,
+			pipeline:    "prod-release",
+			wantDeleted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClock := clocktest.NewFakeClock(time.Now())
+			mockFuncs := newMockTTLFuncs()
+			mockFuncs.kind = tt.kind
+			handler, _ := NewTTLHandler(fakeClock, mockFuncs)
+
+			resource := &ttlMockResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "expired",
+					Namespace:   "default",
+					Labels:      map[string]string{LabelPipelineName: tt.pipeline},
+					Annotations: map[string]string{AnnotationTTLSecondsAfterFinished: "60"},
+				},
+				completed:       true,
+				completion_time: &metav1.Time{Time: fakeClock.Now().Add(-2 * time.Hour)},
+			}
+			mockFuncs.resources["default/expired"] = resource
+
+			if err := handler.ProcessEvent(ctx, resource); err != nil {
+				t.Fatalf("ProcessEvent() unexpected error = %v", err)
+			}
+			if _, exists := mockFuncs.resources["default/expired"]; exists == tt.wantDeleted {
+				t.Errorf("resource deleted = %v, want %v", !exists, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+// TestHasConflictingDeadlines verifies that a conflict is reported only when both a TTL and a deleteAfter deadline are set
+func TestHasConflictingDeadlines(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        bool
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name:        "only TTL",
+			annotations: map[string]string{AnnotationTTLSecondsAfterFinished: "60"},
+		},
+		{
+			name:        "only deleteAfter",
+			annotations: map[string]string{AnnotationDeleteAfter: "2025-01-01T00:00:00Z"},
+		},
+		{
+			name: "deleteAfter with disabled TTL",
+			annotations: map[string]string{
+				AnnotationTTLSecondsAfterFinished: NoTTL,
+				AnnotationDeleteAfter:             "2025-01-01T00:00:00Z",
+			},
+		},
+		{
+			name: "TTL and deleteAfter",
+			annotations: map[string]string{
+				AnnotationTTLSecondsAfterFinished: "60",
+				AnnotationDeleteAfter:             "2025-01-01T00:00:00Z",
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := &metav1.ObjectMeta{Name: "test", Namespace: "default", Annotations: tt.annotations}
+			if got := hasConflictingDeadlines(resource); got != tt.want {
+				t.Errorf("hasConflictingDeadlines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestProcessEventDeleteAfter verifies that a deleteAfter deadline takes precedence over the TTL,
+// and removes a resource which has no TTL
+func TestProcessEventDeleteAfter(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	completedAt := now.Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		ttl           string
+		deleteAfter   string
+		enforcedLevel EnforcedConfigLevel
+		wantRequeue   bool
+		wantDeleted   bool
+	}{
+		{
+			name:          "deadline later than the expired TTL",
+			ttl:           "60",
+			deleteAfter:   now.Add(time.Hour).Format(time.RFC3339),
+			enforcedLevel: EnforcedConfigLevelResource,
+			wantRequeue:   true,
+		},
+		{
+			name:          "deadline earlier than the TTL",
+			ttl:           "86400",
+			deleteAfter:   now.Add(-time.Minute).Format(time.RFC3339),
+			enforcedLevel: EnforcedConfigLevelResource,
+			wantDeleted:   true,
+		},
+		{
+			name:          "passed deadline without a TTL",
+			deleteAfter:   now.Add(-time.Minute).Format(time.RFC3339),
+			enforcedLevel: EnforcedConfigLevelResource,
+			wantDeleted:   true,
+		},
+		{
+			name:          "malformed deadline falls back to the TTL",
+			ttl:           "60",
+			deleteAfter:   "tomorrow",
+			enforcedLevel: EnforcedConfigLevelResource,
+			wantDeleted:   true,
+		},
+		{
+			name:          "deadline ignored at namespace level",
+			ttl:           "60",
+			deleteAfter:   now.Add(time.Hour).Format(time.RFC3339),
+			enforcedLevel: EnforcedConfigLevelNamespace,
+			wantDeleted:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFuncs := newMockTTLFuncs()
+			mockFuncs.enforcedConfigLevel = tt.enforcedLevel
+			handler, _ := NewTTLHandler(clocktest.NewFakeClock(now), mockFuncs)
+
+			annotations := map[string]string{AnnotationDeleteAfter: tt.deleteAfter}
+			if tt.ttl != "" {
+				annotations[AnnotationTTLSecondsAfterFinished] = tt.ttl
+			}
+			resource := &ttlMockResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "run",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+				completed:       true,
+				start_time:      &metav1.Time{Time: completedAt.Add(-time.Minute)},
+				completion_time: &metav1.Time{Time: completedAt},
+			}
+			mockFuncs.resources["default/run"] = resource
+
+			err := handler.ProcessEvent(context.Background(), resource)
+			isRequeue, _ := controller.IsRequeueKey(err)
+			if isRequeue != tt.wantRequeue {
+				t.Errorf("ProcessEvent() error = %v, want requeue %v", err, tt.wantRequeue)
+			}
+			if !isRequeue && err != nil {
+				t.Errorf("ProcessEvent() unexpected error = %v", err)
+			}
+			if _, found := mockFuncs.resources["default/run"]; found == tt.wantDeleted {
+				t.Errorf("resource deleted = %v, want %v", !found, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+// TestWarnedResources verifies that a resource is reported the first time only, and that the oldest resources are forgotten
+func TestWarnedResources(t *testing.T) {
+	warned := &warnedResources{}
+	if !warned.firstTime("uid-1") {
+		t.Error("firstTime() of a new resource = false, want true")
+	}
+	if warned.firstTime("uid-1") {
+		t.Error("firstTime() of a warned resource = true, want false")
+	}
+	for i := 0; i < maxWarnedResources; i++ {
+		warned.firstTime(types.UID(fmt.Sprintf("other-%d", i)))
+	}
+	if !warned.firstTime("uid-1") {
+		t.Error("firstTime() of an evicted resource = false, want true")
+	}
+}
+
+// TestProcessEventRequeueDelay verifies that a resource with an unexpired TTL is requeued
+// for exactly the remaining time until its TTL expires
+func TestProcessEventRequeueDelay(t *testing.T) {
+	ctx := context.Background()
+	fakeClock := clocktest.NewFakeClock(time.Now())
+	mockFuncs := newMockTTLFuncs()
+	handler, _ := NewTTLHandler(fakeClock, mockFuncs)
+
+	resource := &ttlMockResource{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "pending",
+			Namespace:   "default",
+			Annotations: map[string]string{AnnotationTTLSecondsAfterFinished: "60"},
+		},
+		completed:       true,
+		completion_time: &metav1.Time{Time: fakeClock.Now().Add(-20 * time.Second)},
+	}
+	mockFuncs.resources["default/pending"] = resource
+
+	err := handler.ProcessEvent(ctx, resource)
+	isRequeue, delay := controller.IsRequeueKey(err)
+	if !isRequeue {
+		t.Fatalf("ProcessEvent() error = %v, want a requeue key error", err)
+	}
+	if delay != 40*time.Second {
+		t.Errorf("requeue delay = %v, want %v", delay, 40*time.Second)
+	}
+}
+
+// TestProcessEventRequeueCeiling verifies that a resource expiring beyond the requeue ceiling is not requeued,
+// while one expiring within the ceiling is
+func TestProcessEventRequeueCeiling(t *testing.T) {
+	tests := []struct {
+		name        string
+		ceiling     string
+		wantRequeue bool
+	}{
+		{name: "expires within the ceiling", ceiling: "120", wantRequeue: true},
+		{name: "expires beyond the ceiling", ceiling: "30", wantRequeue: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: "ttlRequeueCeilingSeconds: " + tt.ceiling}}
+			if err := PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("LoadGlobalConfig() error = %v", err)
+			}
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			fakeClock := clocktest.NewFakeClock(time.Now())
+			mockFuncs := newMockTTLFuncs()
+			handler, _ := NewTTLHandler(fakeClock, mockFuncs)
+
+			// the mock TTL is 60 seconds
+			resource := &ttlMockResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "pending",
+					Namespace:   "default",
+					Annotations: map[string]string{AnnotationTTLSecondsAfterFinished: "60"},
+				},
+				completed:       true,
+				completion_time: &metav1.Time{Time: fakeClock.Now()},
+			}
+			mockFuncs.resources["default/pending"] = resource
+
+			err := handler.ProcessEvent(ctx, resource)
+			isRequeue, _ := controller.IsRequeueKey(err)
+			if isRequeue != tt.wantRequeue {
+				t.Errorf("ProcessEvent() error = %v, want requeue %v", err, tt.wantRequeue)
+			}
+			if !isRequeue && err != nil {
+				t.Errorf("ProcessEvent() unexpected error = %v", err)
+			}
+			if _, found := mockFuncs.resources["default/pending"]; !found {
+				t.Errorf("unexpired resource was deleted")
+			}
+		})
+	}
+}
+
+// TestProcessEventFreezeUntil verifies that an expired resource is kept and requeued until the freeze ends,
+// and removed from the freezeUntil timestamp on
+func TestProcessEventFreezeUntil(t *testing.T) {
+	freezeUntil := time.Now().Truncate(time.Second).Add(time.Hour)
+	tests := []struct {
+		name        string
+		now         time.Time
+		wantRequeue time.Duration
+		wantDeleted bool
+	}{
+		{name: "a minute before the end of the freeze", now: freezeUntil.Add(-time.Minute), wantRequeue: time.Minute},
+		{name: "a second before the end of the freeze", now: freezeUntil.Add(-time.Second), wantRequeue: time.Second},
+		{name: "at the end of the freeze", now: freezeUntil, wantDeleted: true},
+		{name: "after the freeze", now: freezeUntil.Add(time.Second), wantDeleted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: "freezeUntil: " + freezeUntil.Format(time.RFC3339)}}
+			if err := PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("LoadGlobalConfig() error = %v", err)
+			}
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			fakeClock := clocktest.NewFakeClock(tt.now)
+			mockFuncs := newMockTTLFuncs()
+			handler, _ := NewTTLHandler(fakeClock, mockFuncs)
+
+			// the mock TTL is 60 seconds, the resource expired long ago
+			resource := &ttlMockResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "expired",
+					Namespace:   "default",
+					Annotations: map[string]string{AnnotationTTLSecondsAfterFinished: "60"},
+				},
+				completed:       true,
+				completion_time: &metav1.Time{Time: tt.now.Add(-24 * time.Hour)},
+			}
+			mockFuncs.resources["default/expired"] = resource
+
+			err := handler.ProcessEvent(ctx, resource)
+			isRequeue, delay := controller.IsRequeueKey(err)
+			if tt.wantDeleted {
+				if err != nil {
+					t.Errorf("ProcessEvent() error = %v, want nil", err)
+				}
+			} else if !isRequeue || delay != tt.wantRequeue {
+				t.Errorf("ProcessEvent() error = %v, want a requeue after %v", err, tt.wantRequeue)
+			}
+			if _, found := mockFuncs.resources["default/expired"]; found == tt.wantDeleted {
+				t.Errorf("resource deleted = %v, want %v", !found, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+// rejectingPatchTTLFuncs rejects every patch, as a strict admission policy protecting the annotations of the pruner would
+type rejectingPatchTTLFuncs struct {
+	*mockTTLFuncs
+	patches int
+}
+
+func (r *rejectingPatchTTLFuncs) Patch(_ context.Context, _, name string, _ []byte) error {
+	r.patches++
+	return errors.NewForbidden(schema.GroupResource{Group: "test", Resource: "mock"}, name, fmt.Errorf("annotation prefix is protected"))
+}
+
+// TestProcessEventAnnotationAllowlist verifies that the writes touching annotations the annotationAllowlist does not allow
+// are skipped, so that a policy rejecting them does not fail every reconcile, while the TTL still applies
+func TestProcessEventAnnotationAllowlist(t *testing.T) {
+	tests := []struct {
+		name         string
+		globalConfig string
+		wantErr      bool
+		wantPatches  int
+		wantDeleted  bool
+	}{
+		{name: "without allowlist the patch is rejected", wantErr: true, wantPatches: 1},
+		{name: "allowlist skips the TTL annotation", globalConfig: "annotationAllowlist: [example.com/]", wantDeleted: true},
+		{name: "allowlist skips the prunable annotations", globalConfig: "annotationAllowlist: [example.com/]\ndeletionMode: annotate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("LoadGlobalConfig() error = %v", err)
+			}
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			fakeClock := clocktest.NewFakeClock(time.Now())
+			mockFuncs := &rejectingPatchTTLFuncs{mockTTLFuncs: newMockTTLFuncs()}
+			handler, _ := NewTTLHandler(fakeClock, mockFuncs)
+
+			// the resource has no TTL annotation yet, its TTL of 60 seconds expired long ago
+			resource := &ttlMockResource{
+				ObjectMeta:      metav1.ObjectMeta{Name: "run", Namespace: "default"},
+				completed:       true,
+				completion_time: &metav1.Time{Time: fakeClock.Now().Add(-time.Hour)},
+			}
+			mockFuncs.resources["default/run"] = resource
+
+			err := handler.ProcessEvent(ctx, resource)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if mockFuncs.patches != tt.wantPatches {
+				t.Errorf("patches = %d, want %d", mockFuncs.patches, tt.wantPatches)
+			}
+			if _, found := mockFuncs.resources["default/run"]; found == tt.wantDeleted {
+				t.Errorf("resource deleted = %v, want %v", !found, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+// TestProcessEventTTLNoResults verifies that ttlNoResultsSeconds shortens the TTL of the completed resources
+// which emitted no results, and leaves the TTL of the other resources alone
+func TestProcessEventTTLNoResults(t *testing.T) {
+	tests := []struct {
+		name         string
+		globalConfig string
+		finishedAgo  time.Duration
+		noResults    bool
+		wantDeleted  bool
+	}{
+		{name: "no results past ttlNoResultsSeconds", globalConfig: "ttlNoResultsSeconds: 10", finishedAgo: 20 * time.Second, noResults: true, wantDeleted: true},
+		{name: "no results within ttlNoResultsSeconds", globalConfig: "ttlNoResultsSeconds: 10", finishedAgo: 5 * time.Second, noResults: true},
+		{name: "results past ttlNoResultsSeconds", globalConfig: "ttlNoResultsSeconds: 10", finishedAgo: 20 * time.Second},
+		{name: "no results without ttlNoResultsSeconds", finishedAgo: 20 * time.Second, noResults: true},
+		{name: "ttlNoResultsSeconds longer than the TTL", globalConfig: "ttlNoResultsSeconds: 120", finishedAgo: 90 * time.Second, noResults: true, wantDeleted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("LoadGlobalConfig() error = %v", err)
+			}
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			fakeClock := clocktest.NewFakeClock(time.Now())
+			mockFuncs := newMockTTLFuncs()
+			handler, _ := NewTTLHandler(fakeClock, mockFuncs)
+
+			// the mock TTL is 60 seconds
+			resource := &ttlMockResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "run",
+					Namespace:   "default",
+					Annotations: map[string]string{AnnotationTTLSecondsAfterFinished: "60"},
+				},
+				completed:       true,
+				completion_time: &metav1.Time{Time: fakeClock.Now().Add(-tt.finishedAgo)},
+				noResults:       tt.noResults,
+			}
+			mockFuncs.resources["default/run"] = resource
+
+			err := handler.ProcessEvent(ctx, resource)
+			if isRequeue, _ := controller.IsRequeueKey(err); err != nil && !isRequeue {
+				t.Fatalf("ProcessEvent() error = %v", err)
+			}
+			if _, found := mockFuncs.resources["default/run"]; found == tt.wantDeleted {
+				t.Errorf("resource deleted = %v, want %v", !found, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+// TestProcessEventAbsoluteMaxAge verifies that a completed resource older than absoluteMaxAgeSeconds is removed
+// whatever its TTL, and that the resources within the max age, or not completed, are left to the TTL
+func TestProcessEventAbsoluteMaxAge(t *testing.T) {
+	const maxAge = 90 * 24 * time.Hour
+	tests := []struct {
+		name         string
+		age          time.Duration
+		completed    bool
+		unconfigured bool
+		wantDeleted  bool
+	}{
+		{name: "older than the max age without a TTL", age: maxAge + time.Hour, completed: true, unconfigured: true, wantDeleted: true},
+		{name: "older than the max age with an unexpired TTL", age: maxAge + time.Hour, completed: true, wantDeleted: true},
+		{name: "exactly the max age", age: maxAge, completed: true, unconfigured: true, wantDeleted: true},
+		{name: "within the max age", age: maxAge - time.Hour, completed: true, unconfigured: true},
+		{name: "older than the max age but not completed", age: maxAge + time.Hour, unconfigured: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: "absoluteMaxAgeSeconds: 7776000"}}
+			if err := PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("LoadGlobalConfig() error = %v", err)
+			}
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			fakeClock := clocktest.NewFakeClock(time.Now())
+			mockFuncs := newMockTTLFuncs()
+			mockFuncs.unconfigured = tt.unconfigured
+			handler, _ := NewTTLHandler(fakeClock, mockFuncs)
+
+			// with a TTL, the resource completed just now, so that its TTL of 60 seconds has not expired
+			resource := &ttlMockResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "old",
+					Namespace:         "default",
+					CreationTimestamp: metav1.Time{Time: fakeClock.Now().Add(-tt.age)},
+				},
+				completed:       tt.completed,
+				completion_time: &metav1.Time{Time: fakeClock.Now()},
+			}
+			if !tt.unconfigured {
+				resource.Annotations = map[string]string{AnnotationTTLSecondsAfterFinished: "60"}
+			}
+			mockFuncs.resources["default/old"] = resource
+
+			err := handler.ProcessEvent(ctx, resource)
+			if isRequeue, _ := controller.IsRequeueKey(err); err != nil && !isRequeue {
+				t.Fatalf("ProcessEvent() error = %v", err)
+			}
+			if _, found := mockFuncs.resources["default/old"]; found == tt.wantDeleted {
+				t.Errorf("resource deleted = %v, want %v", !found, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+// TestProcessEventTTLFrom verifies that the TTL is counted from the start time when ttlFrom is start,
+// and that a run which is not completed is removed only once abandoned
+func TestProcessEventTTLFrom(t *testing.T) {
+	tests := []struct {
+		name         string
+		globalConfig string
+		completed    bool
+		startedAgo   time.Duration
+		completedAgo time.Duration
+		wantRequeue  bool
+		wantDeleted  bool
+	}{
+		{
+			name:         "completion: counted from the completion time",
+			completed:    true,
+			startedAgo:   90 * time.Second,
+			completedAgo: 30 * time.Second,
+			wantRequeue:  true,
+		},
+		{
+			name:         "start: counted from the start time",
+			globalConfig: `ttlFrom: start`,
+			completed:    true,
+			startedAgo:   90 * time.Second,
+			completedAgo: 30 * time.Second,
+			wantDeleted:  true,
+		},
+		{
+			name:         "start: running resource without abandonedAfterSeconds is kept",
+			globalConfig: `ttlFrom: start`,
+			startedAgo:   2 * time.Hour,
+		},
+		{
+			name: "start: running resource is kept until abandoned",
+			globalConfig: `
+ttlFrom: start
+abandonedAfterSeconds: 3600`,
+			startedAgo:  10 * time.Minute,
+			wantRequeue: true,
+		},
+		{
+			name: "start: abandoned resource is deleted",
+			globalConfig: `
+ttlFrom: start
+abandonedAfterSeconds: 3600`,
+			startedAgo:  2 * time.Hour,
+			wantDeleted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("LoadGlobalConfig() error = %v", err)
+			}
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			fakeClock := clocktest.NewFakeClock(time.Now())
+			mockFuncs := newMockTTLFuncs()
+			handler, _ := NewTTLHandler(fakeClock, mockFuncs)
+
+			// the mock TTL is 60 seconds
+			resource := &ttlMockResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "run",
+					Namespace:   "default",
+					Annotations: map[string]string{AnnotationTTLSecondsAfterFinished: "60"},
+				},
+				completed:  tt.completed,
+				start_time: &metav1.Time{Time: fakeClock.Now().Add(-tt.startedAgo)},
+			}
+			if tt.completed {
+				resource.completion_time = &metav1.Time{Time: fakeClock.Now().Add(-tt.completedAgo)}
+			}
+			mockFuncs.resources["default/run"] = resource
+
+			err := handler.ProcessEvent(ctx, resource)
+			isRequeue, _ := controller.IsRequeueKey(err)
+			if isRequeue != tt.wantRequeue {
+				t.Errorf("ProcessEvent() error = %v, want requeue %v", err, tt.wantRequeue)
+			}
+			if !isRequeue && err != nil {
+				t.Errorf("ProcessEvent() unexpected error = %v", err)
+			}
+			if _, found := mockFuncs.resources["default/run"]; found == tt.wantDeleted {
+				t.Errorf("resource deleted = %v, want %v", !found, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+// TestProcessEventTTLAnchorAnnotation verifies that the TTL is counted from the timestamp of the ttlAnchorAnnotation,
+// and from the completion time when the annotation is missing or malformed
+func TestProcessEventTTLAnchorAnnotation(t *testing.T) {
+	const anchorAnnotation = "example.com/results-published"
+
+	tests := []struct {
+		name        string
+		anchor      func(now time.Time) string
+		wantRequeue bool
+		wantDeleted bool
+	}{
+		{
+			name:        "anchor present: counted from the anchor",
+			anchor:      func(now time.Time) string { return now.Add(-10 * time.Second).Format(time.RFC3339) },
+			wantRequeue: true,
+		},
+		{
+			name:        "anchor absent: counted from the completion time",
+			wantDeleted: true,
+		},
+		{
+			name:        "anchor malformed: counted from the completion time",
+			anchor:      func(time.Time) string { return "yesterday" },
+			wantDeleted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: "ttlAnchorAnnotation: " + anchorAnnotation}}
+			if err := PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("LoadGlobalConfig() error = %v", err)
+			}
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			fakeClock := clocktest.NewFakeClock(time.Now())
+			mockFuncs := newMockTTLFuncs()
+			handler, _ := NewTTLHandler(fakeClock, mockFuncs)
+
+			// the mock TTL is 60 seconds, the run completed 2 minutes ago
+			annotations := map[string]string{AnnotationTTLSecondsAfterFinished: "60"}
+			if tt.anchor != nil {
+				annotations[anchorAnnotation] = tt.anchor(fakeClock.Now())
+			}
+			resource := &ttlMockResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "run",
+					Namespace:   "default",
+					Annotations: annotations,
+				},
+				completed:       true,
+				start_time:      &metav1.Time{Time: fakeClock.Now().Add(-3 * time.Minute)},
+				completion_time: &metav1.Time{Time: fakeClock.Now().Add(-2 * time.Minute)},
+			}
+			mockFuncs.resources["default/run"] = resource
+
+			err := handler.ProcessEvent(ctx, resource)
+			isRequeue, _ := controller.IsRequeueKey(err)
+			if isRequeue != tt.wantRequeue {
+				t.Errorf("ProcessEvent() error = %v, want requeue %v", err, tt.wantRequeue)
+			}
+			if !isRequeue && err != nil {
+				t.Errorf("ProcessEvent() unexpected error = %v", err)
+			}
+			if _, found := mockFuncs.resources["default/run"]; found == tt.wantDeleted {
+				t.Errorf("resource deleted = %v, want %v", !found, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+// TestRequeueDelay verifies that the requeue delay is bounded
+func TestRequeueDelay(t *testing.T) {
+	tests := []struct {
+		remaining time.Duration
+		want      time.Duration
+	}{
+		{remaining: 100 * time.Millisecond, want: minTTLRequeueDelay},
+		{remaining: 90 * time.Second, want: 90 * time.Second},
+		{remaining: 30 * 24 * time.Hour, want: maxTTLRequeueDelay},
+	}
+
+	for _, tt := range tests {
+		if got := requeueDelay(tt.remaining); got != tt.want {
+			t.Errorf("requeueDelay(%v) = %v, want %v", tt.remaining, got, tt.want)
+		}
+	}
+}
+
+// TestConfiguredTTLFallback verifies that the fallback TTL applies only to the resources no TTL is configured for
+func TestConfiguredTTLFallback(t *testing.T) {
+	ctx := context.Background()
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	tests := []struct {
+		name             string
+		fallback         string
+		unconfigured     bool
+		wantTTL          *int32
+		wantIdentifiedBy string
+	}{
+		{name: "configured TTL wins over the fallback", fallback: "fallbackTTLSecondsAfterFinished: 600", wantTTL: ptr.Int32(60), wantIdentifiedBy: "test"},
+		{name: "fallback applies without configured TTL", fallback: "fallbackTTLSecondsAfterFinished: 600", unconfigured: true, wantTTL: ptr.Int32(600), wantIdentifiedBy: "identified_by_fallback"},
+		{name: "no fallback configured", unconfigured: true},
+		{name: "retain days win over the fallback", fallback: "{retainDays: 2, fallbackTTLSecondsAfterFinished: 600}", unconfigured: true, wantTTL: ptr.Int32(172800), wantIdentifiedBy: "identified_by_retain_days"},
+		{name: "configured TTL wins over retain days", fallback: "retainDays: 2", wantTTL: ptr.Int32(60), wantIdentifiedBy: "test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: tt.fallback}})
+			if err != nil {
+				t.Fatalf("LoadGlobalConfig() error = %v", err)
+			}
+			mockFuncs := newMockTTLFuncs()
+			mockFuncs.unconfigured = tt.unconfigured
+			th := &TTLHandler{resourceFn: mockFuncs}
+
+			ttl, identifiedBy := th.configuredTTL("default", "run", SelectorSpec{})
+			if (ttl == nil) != (tt.wantTTL == nil) || (ttl != nil && *ttl != *tt.wantTTL) {
+				t.Errorf("configuredTTL() ttl = %v, want %v", ttl, tt.wantTTL)
+			}
+			if tt.wantTTL != nil && identifiedBy != tt.wantIdentifiedBy {
+				t.Errorf("configuredTTL() identifiedBy = %q, want %q", identifiedBy, tt.wantIdentifiedBy)
+			}
+		})
+	}
+}
+
+// TestRetainDeadline verifies that retainDays counts calendar days in the configured time zone, across daylight saving time transitions
+func TestRetainDeadline(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		finishAt time.Time
+		days     int
+		location *time.Location
+		want     time.Time
+	}{
+		{
+			name:     "UTC",
+			finishAt: time.Date(2025, 1, 31, 15, 0, 0, 0, time.UTC),
+			days:     30,
+			location: time.UTC,
+			want:     time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "spring forward, the day is 23 hours long",
+			finishAt: time.Date(2025, 3, 8, 15, 0, 0, 0, newYork),
+			days:     2,
+			location: newYork,
+			want:     time.Date(2025, 3, 10, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:     "fall back, the day is 25 hours long",
+			finishAt: time.Date(2025, 11, 1, 15, 0, 0, 0, newYork),
+			days:     2,
+			location: newYork,
+			want:     time.Date(2025, 11, 3, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:     "finished on a different day in the configured time zone",
+			finishAt: time.Date(2025, 3, 29, 23, 30, 0, 0, time.UTC),
+			days:     1,
+			location: paris,
+			want:     time.Date(2025, 3, 31, 0, 0, 0, 0, paris),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := retainDeadline(tt.finishAt, tt.days, tt.location)
+			if !got.Equal(tt.want) {
+				t.Errorf("retainDeadline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// the deadline is a calendar deadline, not a multiple of 24 hours
+	finishAt := time.Date(2025, 3, 8, 0, 0, 0, 0, newYork)
+	if got := retainDeadline(finishAt, 2, newYork).Sub(finishAt); got != 47*time.Hour {
+		t.Errorf("retained across spring forward for %v, want %v", got, 47*time.Hour)
+	}
+}
+
+// TestProcessEventRetainDays verifies that a resource whose TTL comes from retainDays expires at midnight in the configured time zone
+func TestProcessEventRetainDays(t *testing.T) {
+	ctx := context.Background()
+	cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: `
+retainDays: 2
+retainDaysTimeZone: America/New_York`}}
+	if err := PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+		t.Fatalf("LoadGlobalConfig() error = %v", err)
+	}
+	defer func() {
+		_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	completedAt := time.Date(2025, 3, 8, 15, 0, 0, 0, newYork)
+	deadline := time.Date(2025, 3, 10, 0, 0, 0, 0, newYork)
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		wantRequeue bool
+		wantDeleted bool
+	}{
+		{
+			name:        "before midnight",
+			now:         deadline.Add(-time.Minute),
+			wantRequeue: true,
+		},
+		{
+			// 2 days of 24 hours after the completion would be 1 hour later, the spring forward day is 23 hours long
+			name:        "at midnight",
+			now:         deadline,
+			wantDeleted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClock := clocktest.NewFakeClock(tt.now)
+			mockFuncs := newMockTTLFuncs()
+			mockFuncs.unconfigured = true
+			handler, _ := NewTTLHandler(fakeClock, mockFuncs)
+
+			resource := &ttlMockResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "run",
+					Namespace: "default",
+					Annotations: map[string]string{
+						AnnotationTTLSecondsAfterFinished: "172800",
+						AnnotationRetainDays:              "2",
+					},
+				},
+				completed:       true,
+				start_time:      &metav1.Time{Time: completedAt.Add(-time.Minute)},
+				completion_time: &metav1.Time{Time: completedAt},
+			}
+			mockFuncs.resources["default/run"] = resource
+
+			err := handler.ProcessEvent(ctx, resource)
+			isRequeue, _ := controller.IsRequeueKey(err)
+			if isRequeue != tt.wantRequeue {
+				t.Errorf("ProcessEvent() error = %v, want requeue %v", err, tt.wantRequeue)
+			}
+			if !isRequeue && err != nil {
+				t.Errorf("ProcessEvent() unexpected error = %v", err)
+			}
+			if _, found := mockFuncs.resources["default/run"]; found == tt.wantDeleted {
+				t.Errorf("resource deleted = %v, want %v", !found, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+// TestTTLAnnotations verifies the TTL annotations of a resource depending on where its TTL comes from
+func TestTTLAnnotations(t *testing.T) {
+	tests := []struct {
+		name         string
+		ttl          *int32
+		identifiedBy string
+		current      map[string]string
+		want         map[string]string
+		wantChanged  bool
+	}{
+		{
+			name:         "no TTL",
+			identifiedBy: "identified_by_global",
+			current:      map[string]string{"other": "value"},
+		},
+		{
+			name:         "seconds TTL",
+			ttl:          ptr.Int32(3600),
+			identifiedBy: "identified_by_global",
+			current:      map[string]string{AnnotationTTLSecondsAfterFinished: "3600", AnnotationRetainDays: "1"},
+			want:         map[string]string{AnnotationTTLSecondsAfterFinished: "3600"},
+			wantChanged:  true,
+		},
+		{
+			name:         "retain days",
+			ttl:          ptr.Int32(2 * 86400),
+			identifiedBy: identifiedByRetainDays,
+			current:      map[string]string{AnnotationTTLSecondsAfterFinished: "172800", AnnotationRetainDays: "2"},
+			want:         map[string]string{AnnotationTTLSecondsAfterFinished: "172800", AnnotationRetainDays: "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ttlAnnotations(tt.ttl, tt.identifiedBy)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ttlAnnotations() = %v, want %v", got, tt.want)
+			}
+			resource := &metav1.ObjectMeta{Annotations: tt.current}
+			if changed := ttlAnnotationsChanged(resource, got); changed != tt.wantChanged {
+				t.Errorf("ttlAnnotationsChanged() = %v, want %v", changed, tt.wantChanged)
+			}
+		})
+	}
+}
+
+// historyTTLFuncs implements HistoryLimiterResourceFuncs over the TTL mock resources, the completed ones count as successful
+type historyTTLFuncs struct {
+	*mockTTLFuncs
+	successLimit *int32
+}
+
+func (m *historyTTLFuncs) List(_ context.Context, namespace, _ string) ([]metav1.Object, error) {
+	var resources []metav1.Object
+	for _, res := range m.resources {
+		if res.Namespace == namespace {
+			resources = append(resources, res)
+		}
+	}
+	return resources, nil
+}
+
+func (m *historyTTLFuncs) ListByNamespaces(ctx context.Context, namespaces []string) (map[string][]metav1.Object, error) {
+	results := make(map[string][]metav1.Object)
+	for _, namespace := range namespaces {
+		results[namespace], _ = m.List(ctx, namespace, "")
+	}
+	return results, nil
+}
+
+func (m *historyTTLFuncs) GetSuccessHistoryLimitCount(_, _ string, _ SelectorSpec) (*int32, string) {
+	return m.successLimit, "identified_by_global"
+}
+
+func (m *historyTTLFuncs) GetFailedHistoryLimitCount(_, _ string, _ SelectorSpec) (*int32, string) {
+	return nil, ""
+}
+
+func (m *historyTTLFuncs) IsSuccessful(resource metav1.Object) bool { return m.IsCompleted(resource) }
+func (m *historyTTLFuncs) IsFailed(_ metav1.Object) bool            { return false }
+func (m *historyTTLFuncs) IsCancelled(_ metav1.Object) bool         { return false }
+
+func (m *historyTTLFuncs) GetMatchingSelector(_, _ string, _ SelectorSpec) *SelectorSpec {
+	return nil
+}
+
+// TestTTLHistoryPolicy verifies that with the combined policy the runs whose TTL expired are kept while they are among
+// the newest runs within the history limit, and that the history limiter no longer prunes on its own
+func TestTTLHistoryPolicy(t *testing.T) {
+	tests := []struct {
+		name         string
+		globalConfig string
+		successLimit *int32
+		wantRemained []string
+	}{
+		{
+			name:         "independent policy prunes every expired run",
+			successLimit: ptr.Int32(2),
+		},
+		{
+			name:         "combined policy keeps the newest runs within the history limit",
+			globalConfig: `ttlHistoryPolicy: combined`,
+			successLimit: ptr.Int32(2),
+			wantRemained: []string{"run-2", "run-3"},
+		},
+		{
+			name:         "combined policy without a history limit prunes every expired run",
+			globalConfig: `ttlHistoryPolicy: combined`,
+		},
+		{
+			name:         "combined policy with a history limit over the runs keeps every run",
+			globalConfig: `ttlHistoryPolicy: combined`,
+			successLimit: ptr.Int32(5),
+			wantRemained: []string{"run-1", "run-2", "run-3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := &corev1.ConfigMap{Data: map[string]string{PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			defer func() {
+				_ = PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			fakeClock := clocktest.NewFakeClock(time.Now())
+			mockFuncs := &historyTTLFuncs{mockTTLFuncs: newMockTTLFuncs(), successLimit: tt.successLimit}
+			handler, _ := NewTTLHandler(fakeClock, mockFuncs)
+			historyLimiter, _ := NewHistoryLimiter(mockFuncs)
+			handler.SetHistoryLimiter(historyLimiter)
+
+			// every run is past its TTL, run-3 is the newest
+			var runs []*ttlMockResource
+			for i := 1; i <= 3; i++ {
+				run := &ttlMockResource{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        fmt.Sprintf("run-%d", i),
+						Namespace:   "default",
+						UID:         types.UID(fmt.Sprintf("uid-%d", i)),
+						Annotations: map[string]string{AnnotationTTLSecondsAfterFinished: "60"},
+					},
+					completed:       true,
+					completion_time: &metav1.Time{Time: fakeClock.Now().Add(time.Duration(i-10) * time.Hour)},
+				}
+				mockFuncs.resources["default/"+run.Name] = run
+				runs = append(runs, run)
+			}
+
+			for _, run := range runs {
+				// the history limiter only keeps runs with the combined policy, so it prunes nothing here
+				if PrunerConfigStore.GetTTLHistoryPolicy(run.Namespace) == TTLHistoryPolicyCombined {
+					if err := historyLimiter.ProcessEvent(ctx, run); err != nil {
+						t.Fatalf("HistoryLimiter.ProcessEvent() error = %v", err)
+					}
+				}
+				if err := handler.ProcessEvent(ctx, run); err != nil {
+					t.Fatalf("ProcessEvent() error = %v", err)
+				}
+			}
+
+			var remained []string
+			for _, run := range runs {
+				if _, ok := mockFuncs.resources["default/"+run.Name]; ok {
+					remained = append(remained, run.Name)
+				}
+			}
+			assert.Equal(t, tt.wantRemained, remained)
+		})
+	}
+}
+
+// TestProcessEventExtendTTLUntil verifies that a valid TTL extension defers the deletion of an expired resource
+// until it expires, when the enforced config level is resource
+func TestProcessEventExtendTTLUntil(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	completedAt := now.Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		extendUntil   string
+		enforcedLevel EnforcedConfigLevel
+		wantRequeue   bool
+		wantDeleted   bool
+	}{
+		{
+			name:          "valid extension",
+			extendUntil:   now.Add(time.Hour).Format(time.RFC3339),
+			enforcedLevel: EnforcedConfigLevelResource,
+			wantRequeue:   true,
+		},
+		{
+			name:          "expired extension",
+			extendUntil:   now.Add(-time.Minute).Format(time.RFC3339),
+			enforcedLevel: EnforcedConfigLevelResource,
+			wantDeleted:   true,
+		},
+		{
+			name:          "malformed extension",
+			extendUntil:   "tomorrow",
+			enforcedLevel: EnforcedConfigLevelResource,
+			wantDeleted:   true,
+		},
+		{
+			name:          "extension ignored at namespace level",
+			extendUntil:   now.Add(time.Hour).Format(time.RFC3339),
+			enforcedLevel: EnforcedConfigLevelNamespace,
+			wantDeleted:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFuncs := newMockTTLFuncs()
+			mockFuncs.enforcedConfigLevel = tt.enforcedLevel
+			handler, _ := NewTTLHandler(clocktest.NewFakeClock(now), mockFuncs)
+
+			resource := &ttlMockResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "run",
+					Namespace: "default",
+					Annotations: map[string]string{
+						AnnotationTTLSecondsAfterFinished: "60",
+						AnnotationExtendTTLUntil:          tt.extendUntil,
+					},
+				},
+				completed:       true,
+				start_time:      &metav1.Time{Time: completedAt.Add(-time.Minute)},
+				completion_time: &metav1.Time{Time: completedAt},
+			}
+			mockFuncs.resources["default/run"] = resource
+
+			err := handler.ProcessEvent(context.Background(), resource)
+			isRequeue, _ := controller.IsRequeueKey(err)
+			if isRequeue != tt.wantRequeue {
+				t.Errorf("ProcessEvent() error = %v, want requeue %v", err, tt.wantRequeue)
+			}
+			if !isRequeue && err != nil {
+				t.Errorf("ProcessEvent() unexpected error = %v", err)
+			}
+			if _, found := mockFuncs.resources["default/run"]; found == tt.wantDeleted {
+				t.Errorf("resource deleted = %v, want %v", !found, tt.wantDeleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logformat sets the encoding of the logs written by the pruner components
+package logformat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/injection/sharedmain"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// JSON writes every log entry as a JSON object, for log pipelines
+	JSON = "json"
+	// Console writes the log entries as human readable lines
+	Console = "console"
+)
+
+// Validate checks that the format is one of json and console, an empty format keeps the encoding of the logging config
+func Validate(format string) error {
+	if format != "" && format != JSON && format != Console {
+		return fmt.Errorf("invalid log format '%s', must be one of: json, console", format)
+	}
+	return nil
+}
+
+// WithFormat returns the context carrying the logging config of the component with its encoding set to the format,
+// sharedmain sets up the logger from that config. An empty format leaves the context unchanged, the encoding
+// of the logging ConfigMap then applies
+func WithFormat(ctx context.Context, cfg *rest.Config, format string) (context.Context, error) {
+	if format == "" {
+		return ctx, nil
+	}
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the kubernetes client: %w", err)
+	}
+	return withFormat(ctx, client, format)
+}
+
+// withFormat reads the logging config with the given client and sets its encoding to the format
+func withFormat(ctx context.Context, client kubernetes.Interface, format string) (context.Context, error) {
+	if err := Validate(format); err != nil {
+		return nil, err
+	}
+	loggingConfig, err := sharedmain.GetLoggingConfig(context.WithValue(ctx, kubeclient.Key{}, client))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the logging config: %w", err)
+	}
+	loggingConfig.LoggingConfig, err = setEncoding(loggingConfig.LoggingConfig, format)
+	if err != nil {
+		return nil, err
+	}
+	return logging.WithConfig(ctx, loggingConfig), nil
+}
+
+// setEncoding returns the zap logger config with its encoding set to the format, the other settings are kept
+func setEncoding(zapConfig, format string) (string, error) {
+	fields := map[string]any{}
+	if zapConfig != "" {
+		if err := json.Unmarshal([]byte(zapConfig), &fields); err != nil {
+			return "", fmt.Errorf("failed to parse the zap logger config: %w", err)
+		}
+	}
+	fields["encoding"] = format
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logformat
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+
+	// Required for system.Namespace() in tests
+	_ "knative.dev/pkg/system/testing"
+)
+
+// TestValidate verifies that only the json and console formats are accepted, besides the empty format
+func TestValidate(t *testing.T) {
+	for _, format := range []string{"", JSON, Console} {
+		assert.NoError(t, Validate(format), format)
+	}
+	assert.EqualError(t, Validate("text"), "invalid log format 'text', must be one of: json, console")
+}
+
+// TestWithFormat verifies that the encoding of the logging config is overridden and its other settings are kept
+func TestWithFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		configMap  *corev1.ConfigMap
+		format     string
+		wantFields map[string]any
+	}{
+		{
+			name: "logging config map",
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: logging.ConfigMapName(), Namespace: system.Namespace()},
+				Data:       map[string]string{"zap-logger-config": `{"level": "debug", "encoding": "json"}`},
+			},
+			format:     Console,
+			wantFields: map[string]any{"level": "debug", "encoding": "console"},
+		},
+		{
+			name:       "no logging config map",
+			format:     JSON,
+			wantFields: map[string]any{"encoding": "json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			if tt.configMap != nil {
+				client = fake.NewSimpleClientset(tt.configMap)
+			}
+
+			ctx, err := withFormat(context.Background(), client, tt.format)
+			if err != nil {
+				t.Fatalf("withFormat() error = %v", err)
+			}
+			loggingConfig := logging.GetConfig(ctx)
+			if loggingConfig == nil {
+				t.Fatal("logging config is not attached to the context")
+			}
+			fields := map[string]any{}
+			assert.NoError(t, json.Unmarshal([]byte(loggingConfig.LoggingConfig), &fields))
+			assert.Equal(t, tt.wantFields, fields)
+		})
+	}
+}
+
+// TestWithFormatEmpty verifies that an empty format leaves the context unchanged
+func TestWithFormatEmpty(t *testing.T) {
+	ctx := context.Background()
+	got, err := WithFormat(ctx, nil, "")
+	assert.NoError(t, err)
+	assert.Equal(t, ctx, got)
+}
@@ -18,7 +18,10 @@ package metrics
 
 import (
 	"context"
+	"regexp"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -40,6 +43,21 @@ const (
 	MetricActiveResourcesCount      = "tekton_pruner_controller_active_resources"
 	MetricPendingDeletionsCount     = "tekton_pruner_controller_pending_deletions"
 	MetricResourceAgeAtDeletion     = "tekton_pruner_controller_resource_age_at_deletion"
+	MetricUnusedSelectors           = "tekton_pruner_controller_unused_selector"
+	MetricLeftoverPodsDeleted       = "tekton_pruner_controller_leftover_pods_deleted"
+	MetricNamespaceBudgetEnforced   = "tekton_pruner_controller_namespace_budget_enforced"
+	MetricEventsSkipped             = "tekton_pruner_controller_events_skipped"
+	MetricNamespaceLastPrune        = "tekton_pruner_controller_namespace_last_prune_timestamp"
+	MetricWebhookAdmissions         = "tekton_pruner_webhook_admission_decisions"
+	MetricRequeues                  = "tekton_pruner_controller_requeues"
+	MetricRequeueDelay              = "tekton_pruner_controller_requeue_delay"
+	MetricPartialListFailures       = "tekton_pruner_controller_partial_list_failures"
+	MetricNamespaceRunsEvaluated    = "tekton_pruner_controller_namespace_runs_evaluated"
+	MetricPruningPaused             = "tekton_pruner_controller_pruning_paused"
+	MetricConfigLoadErrors          = "tekton_pruner_controller_config_load_errors"
+	MetricConfigLastLoadSuccess     = "tekton_pruner_controller_config_last_load_success"
+	MetricAnnotationPatchFailures   = "tekton_pruner_controller_annotation_patch_failures"
+	MetricSurvivingResourceAge      = "tekton_pruner_controller_surviving_resource_age"
 
 	// Label keys
 	LabelNamespace    = "namespace"
@@ -48,20 +66,62 @@ const (
 	LabelReason       = "reason"
 	LabelErrorType    = "error_type"
 	LabelOperation    = "operation"
+	LabelConfigType   = "config_type"
+	LabelDecision     = "decision"
+	LabelField        = "field"
+	LabelScope        = "scope"
+	LabelStatistic    = "statistic"
 
 	// Label values for resource types
 	ResourceTypePipelineRun = "pipelinerun"
 	ResourceTypeTaskRun     = "taskrun"
 
 	// Label values for operations
-	OperationTTL     = "ttl"
-	OperationHistory = "history"
+	OperationTTL                  = "ttl"
+	OperationHistory              = "history"
+	OperationNamespaceCap         = "namespace_cap"
+	OperationNamespaceBudget      = "namespace_budget"
+	OperationLargeStatus          = "large_status"
+	OperationStuck                = "stuck"
+	OperationOwner                = "owner"
+	OperationMaxAge               = "max_age"
+	OperationTerminatingNamespace = "terminating_namespace"
+
+	// Label values for skip reasons
+	SkipReasonNonStandalone = "non_standalone"
+	SkipReasonNotCompleted  = "not_completed"
+	SkipReasonIgnored       = "ignored"
+
+	// Label values for webhook admission decisions
+	DecisionAdmitted = "admitted"
+	DecisionRejected = "rejected"
+
+	// Label values for the config types of webhook admission decisions and the scopes of config loads,
+	// ConfigTypeUnknown is used for ConfigMaps without a valid config-type label
+	ConfigTypeGlobal    = "global"
+	ConfigTypeNamespace = "namespace"
+	ConfigTypeUnknown   = "unknown"
+
+	// Label values for the reasons of webhook admission decisions
+	AdmissionReasonNone               = "none"
+	AdmissionReasonBadLabel           = "bad_label"
+	AdmissionReasonBadName            = "bad_name"
+	AdmissionReasonForbiddenNamespace = "forbidden_namespace"
+	AdmissionReasonDependentsExist    = "dependents_exist"
+	AdmissionReasonNegativeValue      = "negative_value"
+	AdmissionReasonExceedsLimit       = "exceeds_limit"
+	AdmissionReasonInvalidConfig      = "invalid_config"
 
 	// Label values for status
 	StatusSuccess = "success"
 	StatusFailed  = "failed"
 	StatusError   = "error"
 
+	// Label values for the statistics of the surviving resource ages
+	StatisticMin    = "min"
+	StatisticMedian = "median"
+	StatisticMax    = "max"
+
 	// Label values for error types
 	ErrorTypeAPI        = "api_error"
 	ErrorTypeTimeout    = "timeout"
@@ -69,29 +129,65 @@ const (
 	ErrorTypeInternal   = "internal"
 	ErrorTypeNotFound   = "not_found"
 	ErrorTypePermission = "permission"
+
+	// NamespaceAggregated is the namespace label value used for namespaces
+	// collapsed into a single series by namespace aggregation
+	NamespaceAggregated = "<aggregated>"
+
+	// DefaultSeenResourcesLimit is the default number of UIDs tracked to count the unique resources processed
+	DefaultSeenResourcesLimit = 100000
 )
 
 // Recorder holds all the OpenTelemetry instruments for recording metrics
 type Recorder struct {
 	// Counters
-	resourcesProcessed   metric.Int64Counter
-	reconciliationEvents metric.Int64Counter
-	resourcesDeleted     metric.Int64Counter
-	resourcesErrors      metric.Int64Counter
+	resourcesProcessed      metric.Int64Counter
+	reconciliationEvents    metric.Int64Counter
+	resourcesDeleted        metric.Int64Counter
+	resourcesErrors         metric.Int64Counter
+	unusedSelectors         metric.Int64Counter
+	leftoverPodsDeleted     metric.Int64Counter
+	namespaceBudgetEnforced metric.Int64Counter
+	eventsSkipped           metric.Int64Counter
+	webhookAdmissions       metric.Int64Counter
+	requeues                metric.Int64Counter
+	partialListFailures     metric.Int64Counter
+	configLoadErrors        metric.Int64Counter
+	annotationPatchFailures metric.Int64Counter
 
 	// Histograms for duration measurements
 	reconciliationDuration    metric.Float64Histogram
 	ttlProcessingDuration     metric.Float64Histogram
 	historyProcessingDuration metric.Float64Histogram
 	resourceAgeAtDeletion     metric.Float64Histogram
+	requeueDelay              metric.Float64Histogram
+	namespaceRunsEvaluated    metric.Int64Histogram
 
 	// UpDownCounters for gauge-like metrics
 	activeResourcesCount  metric.Int64UpDownCounter
 	pendingDeletionsCount metric.Int64UpDownCounter
 
-	// Cache for tracking unique resources
+	// Gauges
+	namespaceLastPrune metric.Float64Gauge
+	pruningPaused      metric.Int64Gauge
+	configLastLoad     metric.Int64Gauge
+	survivingAge       metric.Float64Gauge
+
+	// highCardinality enables the metrics whose series grow with the number of namespaces on top of the others
+	highCardinality atomic.Bool
+
+	// Cache for tracking unique resources, bounded to seenLimit UIDs. seenOrder holds the tracked UIDs
+	// in a ring, the oldest one at seenNext is evicted first once the cache is full
 	seenResources map[types.UID]bool
+	seenOrder     []types.UID
+	seenNext      int
+	seenLimit     int
 	cacheMutex    sync.RWMutex
+
+	// Totals of the counters and values of the gauges, kept for the snapshot dump
+	counterTotals map[string]int64
+	gaugeValues   map[string]map[string]float64
+	snapshotMutex sync.Mutex
 }
 
 var (
@@ -99,6 +195,31 @@ var (
 	once     sync.Once
 )
 
+var (
+	// aggregatedNamespacePattern holds the pattern of namespaces whose metrics are
+	// recorded under NamespaceAggregated instead of their own name
+	aggregatedNamespacePattern *regexp.Regexp
+	aggregationMutex           sync.RWMutex
+)
+
+// SetNamespaceAggregation sets the pattern of namespaces to be collapsed into a single
+// namespace label value. A nil pattern restores the per-namespace behavior
+func SetNamespaceAggregation(pattern *regexp.Regexp) {
+	aggregationMutex.Lock()
+	defer aggregationMutex.Unlock()
+	aggregatedNamespacePattern = pattern
+}
+
+// namespaceLabelValue returns the value to be recorded in the namespace label
+func namespaceLabelValue(namespace string) string {
+	aggregationMutex.RLock()
+	defer aggregationMutex.RUnlock()
+	if aggregatedNamespacePattern != nil && aggregatedNamespacePattern.MatchString(namespace) {
+		return NamespaceAggregated
+	}
+	return namespace
+}
+
 // GetRecorder returns the singleton metrics recorder instance
 func GetRecorder() *Recorder {
 	once.Do(func() {
@@ -115,6 +236,11 @@ func newRecorder() *Recorder {
 
 	// Initialize cache for unique resource tracking
 	r.seenResources = make(map[types.UID]bool)
+	r.seenLimit = DefaultSeenResourcesLimit
+
+	// Initialize the values kept for the snapshot dump
+	r.counterTotals = make(map[string]int64)
+	r.gaugeValues = make(map[string]map[string]float64)
 
 	// Initialize counters
 	r.resourcesProcessed, _ = meter.Int64Counter(
@@ -141,6 +267,60 @@ func newRecorder() *Recorder {
 		metric.WithUnit("1"),
 	)
 
+	r.unusedSelectors, _ = meter.Int64Counter(
+		MetricUnusedSelectors,
+		metric.WithDescription("Total number of configured selectors which matched no resource during a garbage collection cycle"),
+		metric.WithUnit("1"),
+	)
+
+	r.leftoverPodsDeleted, _ = meter.Int64Counter(
+		MetricLeftoverPodsDeleted,
+		metric.WithDescription("Total number of pods deleted because they were left over by a pruned Tekton resource"),
+		metric.WithUnit("1"),
+	)
+
+	r.namespaceBudgetEnforced, _ = meter.Int64Counter(
+		MetricNamespaceBudgetEnforced,
+		metric.WithDescription("Total number of garbage collection cycles which pruned runs to bring a namespace within its object budget"),
+		metric.WithUnit("1"),
+	)
+
+	r.eventsSkipped, _ = meter.Int64Counter(
+		MetricEventsSkipped,
+		metric.WithDescription("Total number of reconciliation events which did not lead to any pruning decision, by reason"),
+		metric.WithUnit("1"),
+	)
+
+	r.webhookAdmissions, _ = meter.Int64Counter(
+		MetricWebhookAdmissions,
+		metric.WithDescription("Total number of pruner ConfigMaps admitted or rejected by the validating webhook, by config type and rejection reason"),
+		metric.WithUnit("1"),
+	)
+
+	r.requeues, _ = meter.Int64Counter(
+		MetricRequeues,
+		metric.WithDescription("Total number of runs requeued until their TTL expires"),
+		metric.WithUnit("1"),
+	)
+
+	r.partialListFailures, _ = meter.Int64Counter(
+		MetricPartialListFailures,
+		metric.WithDescription("Total number of namespaces whose runs could not be listed by a listing across namespaces"),
+		metric.WithUnit("1"),
+	)
+
+	r.configLoadErrors, _ = meter.Int64Counter(
+		MetricConfigLoadErrors,
+		metric.WithDescription("Total number of pruner configs which failed to parse or validate when loaded by the controller"),
+		metric.WithUnit("1"),
+	)
+
+	r.annotationPatchFailures, _ = meter.Int64Counter(
+		MetricAnnotationPatchFailures,
+		metric.WithDescription("Total number of runs whose processed annotation could not be removed by a garbage collection cycle, even after retries"),
+		metric.WithUnit("1"),
+	)
+
 	// Initialize histograms
 	r.reconciliationDuration, _ = meter.Float64Histogram(
 		MetricReconciliationDuration,
@@ -172,6 +352,24 @@ func newRecorder() *Recorder {
 		), // 1m, 5m, 10m, 30m, 1h, 2h, 4h, 8h, 1d, 2d, 4d, 1w
 	)
 
+	r.requeueDelay, _ = meter.Float64Histogram(
+		MetricRequeueDelay,
+		metric.WithDescription("Delay after which the runs waiting for their TTL to expire are requeued"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(
+			1, 10, 60, 300, 600, 1800, 3600, 7200, 21600, 43200, 86400,
+		), // 1s, 10s, 1m, 5m, 10m, 30m, 1h, 2h, 6h, 12h, 1d
+	)
+
+	r.namespaceRunsEvaluated, _ = meter.Int64Histogram(
+		MetricNamespaceRunsEvaluated,
+		metric.WithDescription("Number of runs of a namespace inspected by a garbage collection cycle"),
+		metric.WithUnit("1"),
+		metric.WithExplicitBucketBoundaries(
+			0, 10, 50, 100, 500, 1000, 5000, 10000, 50000,
+		),
+	)
+
 	// Initialize up-down counters
 	r.activeResourcesCount, _ = meter.Int64UpDownCounter(
 		MetricActiveResourcesCount,
@@ -185,6 +383,30 @@ func newRecorder() *Recorder {
 		metric.WithUnit("1"),
 	)
 
+	// Initialize gauges
+	r.namespaceLastPrune, _ = meter.Float64Gauge(
+		MetricNamespaceLastPrune,
+		metric.WithDescription("Unix time at which the garbage collection of the PipelineRuns and TaskRuns of a namespace last succeeded"),
+		metric.WithUnit("s"),
+	)
+
+	// no unit, the exporter would otherwise name the gauge as a ratio
+	r.pruningPaused, _ = meter.Int64Gauge(
+		MetricPruningPaused,
+		metric.WithDescription("Whether safe mode paused the pruning driven by a field of the global config, 1 if paused"),
+	)
+
+	r.configLastLoad, _ = meter.Int64Gauge(
+		MetricConfigLastLoadSuccess,
+		metric.WithDescription("Whether the last load of a pruner config succeeded, 1 if it did"),
+	)
+
+	r.survivingAge, _ = meter.Float64Gauge(
+		MetricSurvivingResourceAge,
+		metric.WithDescription("Minimum, median and maximum age of the completed runs left in a namespace by the last garbage collection cycle"),
+		metric.WithUnit("s"),
+	)
+
 	return r
 }
 
@@ -226,39 +448,75 @@ func (t *Timer) RecordHistoryProcessingDuration(ctx context.Context) {
 func (r *Recorder) RecordReconciliationEvent(ctx context.Context, resourceType, namespace, status string) {
 	labels := []attribute.KeyValue{
 		attribute.String(LabelResourceType, resourceType),
-		attribute.String(LabelNamespace, namespace),
+		attribute.String(LabelNamespace, namespaceLabelValue(namespace)),
 		attribute.String(LabelStatus, status),
 	}
 	r.reconciliationEvents.Add(ctx, 1, metric.WithAttributes(labels...))
+	r.addToCounter(MetricReconciliationEvents, 1)
+}
+
+// SetSeenResourcesLimit bounds the number of UIDs tracked to count the unique resources processed, the oldest UIDs
+// are evicted first. A limit of 0 disables the tracking, every processed resource is then counted. The tracked UIDs are reset
+func (r *Recorder) SetSeenResourcesLimit(limit int) {
+	r.cacheMutex.Lock()
+	defer r.cacheMutex.Unlock()
+	r.seenResources = make(map[types.UID]bool)
+	r.seenOrder = nil
+	r.seenNext = 0
+	r.seenLimit = limit
 }
 
-// RecordResourceProcessed increments the unique resources counter if this UID hasn't been seen before
+// trackResource tracks a processed UID, evicting the oldest one when the cache is full,
+// and reports whether the UID was not tracked yet
+func (r *Recorder) trackResource(resourceUID types.UID) bool {
+	if r.seenLimit <= 0 {
+		return true
+	}
+	if r.seenResources[resourceUID] {
+		return false
+	}
+	if len(r.seenOrder) < r.seenLimit {
+		r.seenOrder = append(r.seenOrder, resourceUID)
+	} else {
+		delete(r.seenResources, r.seenOrder[r.seenNext])
+		r.seenOrder[r.seenNext] = resourceUID
+		r.seenNext = (r.seenNext + 1) % r.seenLimit
+	}
+	r.seenResources[resourceUID] = true
+	return true
+}
+
+// RecordResourceProcessed increments the unique resources counter if this UID hasn't been seen before,
+// among the UIDs still tracked
 func (r *Recorder) RecordResourceProcessed(ctx context.Context, resourceUID types.UID, resourceType, namespace, status string) {
 	r.cacheMutex.Lock()
 	defer r.cacheMutex.Unlock()
 
 	// Only count if we haven't seen this UID before
-	if !r.seenResources[resourceUID] {
-		r.seenResources[resourceUID] = true
+	if r.trackResource(resourceUID) {
 
 		labels := []attribute.KeyValue{
 			attribute.String(LabelResourceType, resourceType),
-			attribute.String(LabelNamespace, namespace),
+			attribute.String(LabelNamespace, namespaceLabelValue(namespace)),
 			attribute.String(LabelStatus, status),
 		}
 		r.resourcesProcessed.Add(ctx, 1, metric.WithAttributes(labels...))
+		r.addToCounter(MetricResourcesProcessed, 1)
 	}
 }
 
-// RecordResourceDeleted increments the resources deleted counter and records age
-func (r *Recorder) RecordResourceDeleted(ctx context.Context, resourceType, namespace, operation string, resourceAge time.Duration) {
+// RecordResourceDeleted increments the resources deleted counter and records age,
+// the reason tells which rule selected the resource for deletion
+func (r *Recorder) RecordResourceDeleted(ctx context.Context, resourceType, namespace, operation, reason string, resourceAge time.Duration) {
 	// Record deletion count
 	labels := []attribute.KeyValue{
 		attribute.String(LabelResourceType, resourceType),
-		attribute.String(LabelNamespace, namespace),
+		attribute.String(LabelNamespace, namespaceLabelValue(namespace)),
 		attribute.String(LabelOperation, operation),
+		attribute.String(LabelReason, reason),
 	}
 	r.resourcesDeleted.Add(ctx, 1, metric.WithAttributes(labels...))
+	r.addToCounter(MetricResourcesDeleted, 1)
 
 	// Record resource age at deletion
 	r.resourceAgeAtDeletion.Record(ctx, resourceAge.Seconds(), metric.WithAttributes(labels...))
@@ -268,29 +526,214 @@ func (r *Recorder) RecordResourceDeleted(ctx context.Context, resourceType, name
 func (r *Recorder) RecordResourceError(ctx context.Context, resourceType, namespace, errorType, reason string) {
 	labels := []attribute.KeyValue{
 		attribute.String(LabelResourceType, resourceType),
-		attribute.String(LabelNamespace, namespace),
+		attribute.String(LabelNamespace, namespaceLabelValue(namespace)),
 		attribute.String(LabelErrorType, errorType),
 		attribute.String(LabelReason, reason),
 	}
 	r.resourcesErrors.Add(ctx, 1, metric.WithAttributes(labels...))
+	r.addToCounter(MetricResourcesErrors, 1)
+}
+
+// RecordUnusedSelector increments the unused selectors counter
+func (r *Recorder) RecordUnusedSelector(ctx context.Context, resourceType, namespace string) {
+	labels := []attribute.KeyValue{
+		attribute.String(LabelResourceType, resourceType),
+		attribute.String(LabelNamespace, namespaceLabelValue(namespace)),
+	}
+	r.unusedSelectors.Add(ctx, 1, metric.WithAttributes(labels...))
+	r.addToCounter(MetricUnusedSelectors, 1)
+}
+
+// RecordLeftoverPodsDeleted increments the leftover pods deleted counter by the given count
+func (r *Recorder) RecordLeftoverPodsDeleted(ctx context.Context, resourceType, namespace string, count int64) {
+	labels := []attribute.KeyValue{
+		attribute.String(LabelResourceType, resourceType),
+		attribute.String(LabelNamespace, namespaceLabelValue(namespace)),
+	}
+	r.leftoverPodsDeleted.Add(ctx, count, metric.WithAttributes(labels...))
+	r.addToCounter(MetricLeftoverPodsDeleted, count)
+}
+
+// RecordNamespaceBudgetEnforced increments the counter of namespace object budget enforcements
+func (r *Recorder) RecordNamespaceBudgetEnforced(ctx context.Context, namespace string) {
+	labels := []attribute.KeyValue{
+		attribute.String(LabelNamespace, namespaceLabelValue(namespace)),
+	}
+	r.namespaceBudgetEnforced.Add(ctx, 1, metric.WithAttributes(labels...))
+	r.addToCounter(MetricNamespaceBudgetEnforced, 1)
+}
+
+// RecordEventSkipped increments the counter of reconciliation events skipped for the given reason
+func (r *Recorder) RecordEventSkipped(ctx context.Context, resourceType, namespace, reason string) {
+	labels := []attribute.KeyValue{
+		attribute.String(LabelResourceType, resourceType),
+		attribute.String(LabelNamespace, namespaceLabelValue(namespace)),
+		attribute.String(LabelReason, reason),
+	}
+	r.eventsSkipped.Add(ctx, 1, metric.WithAttributes(labels...))
+	r.addToCounter(MetricEventsSkipped, 1)
+}
+
+// RecordAdmission increments the counter of webhook admission decisions,
+// the reason tells which rule rejected the ConfigMap and is ignored for admitted ones
+func (r *Recorder) RecordAdmission(ctx context.Context, configType string, admitted bool, reason string) {
+	decision := DecisionRejected
+	if admitted {
+		decision = DecisionAdmitted
+		reason = AdmissionReasonNone
+	}
+	labels := []attribute.KeyValue{
+		attribute.String(LabelConfigType, configType),
+		attribute.String(LabelDecision, decision),
+		attribute.String(LabelReason, reason),
+	}
+	r.webhookAdmissions.Add(ctx, 1, metric.WithAttributes(labels...))
+	r.addToCounter(MetricWebhookAdmissions, 1)
+}
+
+// RecordRequeue increments the counter of requeued runs and records the delay after which the run is requeued
+func (r *Recorder) RecordRequeue(ctx context.Context, resourceType string, delay time.Duration) {
+	labels := []attribute.KeyValue{
+		attribute.String(LabelResourceType, resourceType),
+	}
+	r.requeues.Add(ctx, 1, metric.WithAttributes(labels...))
+	r.addToCounter(MetricRequeues, 1)
+	r.requeueDelay.Record(ctx, delay.Seconds(), metric.WithAttributes(labels...))
+}
+
+// RecordPartialListFailure increments the counter of namespaces which could not be listed by a listing across namespaces
+func (r *Recorder) RecordPartialListFailure(ctx context.Context, resourceType, namespace string) {
+	labels := []attribute.KeyValue{
+		attribute.String(LabelResourceType, resourceType),
+		attribute.String(LabelNamespace, namespaceLabelValue(namespace)),
+	}
+	r.partialListFailures.Add(ctx, 1, metric.WithAttributes(labels...))
+	r.addToCounter(MetricPartialListFailures, 1)
+}
+
+// RecordAnnotationPatchFailure increments the counter of runs whose processed annotation could not be removed after retries,
+// such runs being evaluated again by every garbage collection cycle
+func (r *Recorder) RecordAnnotationPatchFailure(ctx context.Context, resourceType, namespace string) {
+	labels := []attribute.KeyValue{
+		attribute.String(LabelResourceType, resourceType),
+		attribute.String(LabelNamespace, namespaceLabelValue(namespace)),
+	}
+	r.annotationPatchFailures.Add(ctx, 1, metric.WithAttributes(labels...))
+	r.addToCounter(MetricAnnotationPatchFailures, 1)
+}
+
+// RecordNamespaceRunsEvaluated records the number of runs of a namespace inspected by a garbage collection cycle.
+// The namespace is left out of the labels, to keep the cardinality of the histogram low
+func (r *Recorder) RecordNamespaceRunsEvaluated(ctx context.Context, resourceType string, count int) {
+	labels := []attribute.KeyValue{
+		attribute.String(LabelResourceType, resourceType),
+	}
+	r.namespaceRunsEvaluated.Record(ctx, int64(count), metric.WithAttributes(labels...))
+}
+
+// RecordNamespacePruned records the time at which the garbage collection of a namespace succeeded
+func (r *Recorder) RecordNamespacePruned(ctx context.Context, namespace string, prunedAt time.Time) {
+	namespace = namespaceLabelValue(namespace)
+	labels := []attribute.KeyValue{
+		attribute.String(LabelNamespace, namespace),
+	}
+	prunedAtSeconds := float64(prunedAt.UnixNano()) / float64(time.Second)
+	r.namespaceLastPrune.Record(ctx, prunedAtSeconds, metric.WithAttributes(labels...))
+	r.setGauge(MetricNamespaceLastPrune, namespace, prunedAtSeconds, false)
+}
+
+// SetPruningPaused records whether safe mode paused the pruning driven by the given field of the global config
+func (r *Recorder) SetPruningPaused(ctx context.Context, field string, paused bool) {
+	value := int64(0)
+	if paused {
+		value = 1
+	}
+	labels := []attribute.KeyValue{
+		attribute.String(LabelField, field),
+	}
+	r.pruningPaused.Record(ctx, value, metric.WithAttributes(labels...))
+}
+
+// RecordConfigLoad records the outcome of loading the global config, or the config of a namespace.
+// A failed load increments the counter of load errors, and the gauge holds whether the last load succeeded.
+// The namespace is empty for the global config
+func (r *Recorder) RecordConfigLoad(ctx context.Context, scope, namespace string, succeeded bool) {
+	namespace = namespaceLabelValue(namespace)
+	labels := []attribute.KeyValue{
+		attribute.String(LabelScope, scope),
+		attribute.String(LabelNamespace, namespace),
+	}
+	value := int64(1)
+	if !succeeded {
+		value = 0
+		r.configLoadErrors.Add(ctx, 1, metric.WithAttributes(labels...))
+		r.addToCounter(MetricConfigLoadErrors, 1)
+	}
+	r.configLastLoad.Record(ctx, value, metric.WithAttributes(labels...))
+	r.setGauge(MetricConfigLastLoadSuccess, namespace, float64(value), false)
+}
+
+// SetHighCardinality enables the metrics recorded only on demand, as they add series for every namespace
+func (r *Recorder) SetHighCardinality(enabled bool) {
+	r.highCardinality.Store(enabled)
+}
+
+// HighCardinality tells whether the metrics recorded only on demand are enabled
+func (r *Recorder) HighCardinality() bool {
+	return r.highCardinality.Load()
+}
+
+// RecordSurvivingResourceAges records the minimum, median and maximum of the ages of the completed runs
+// left in a namespace by a garbage collection cycle. Nothing is recorded unless the high cardinality metrics are enabled
+func (r *Recorder) RecordSurvivingResourceAges(ctx context.Context, resourceType, namespace string, ages []time.Duration) {
+	if !r.HighCardinality() || len(ages) == 0 {
+		return
+	}
+	namespace = namespaceLabelValue(namespace)
+	minAge, medianAge, maxAge := ageStatistics(ages)
+	for statistic, age := range map[string]time.Duration{StatisticMin: minAge, StatisticMedian: medianAge, StatisticMax: maxAge} {
+		labels := []attribute.KeyValue{
+			attribute.String(LabelResourceType, resourceType),
+			attribute.String(LabelNamespace, namespace),
+			attribute.String(LabelStatistic, statistic),
+		}
+		r.survivingAge.Record(ctx, age.Seconds(), metric.WithAttributes(labels...))
+	}
+}
+
+// ageStatistics returns the minimum, median and maximum of the given ages, which must not be empty.
+// The median of an even number of ages is the mean of the two middle ones
+func ageStatistics(ages []time.Duration) (time.Duration, time.Duration, time.Duration) {
+	sorted := slices.Clone(ages)
+	slices.Sort(sorted)
+	middle := len(sorted) / 2
+	median := sorted[middle]
+	if len(sorted)%2 == 0 {
+		median = (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[0], median, sorted[len(sorted)-1]
 }
 
 // UpdateActiveResourcesCount updates the active resources gauge
 func (r *Recorder) UpdateActiveResourcesCount(ctx context.Context, resourceType, namespace string, delta int64) {
+	namespace = namespaceLabelValue(namespace)
 	labels := []attribute.KeyValue{
 		attribute.String(LabelResourceType, resourceType),
 		attribute.String(LabelNamespace, namespace),
 	}
 	r.activeResourcesCount.Add(ctx, delta, metric.WithAttributes(labels...))
+	r.setGauge(MetricActiveResourcesCount, namespace, float64(delta), true)
 }
 
 // UpdatePendingDeletionsCount updates the pending deletions gauge
 func (r *Recorder) UpdatePendingDeletionsCount(ctx context.Context, resourceType, namespace string, delta int64) {
+	namespace = namespaceLabelValue(namespace)
 	labels := []attribute.KeyValue{
 		attribute.String(LabelResourceType, resourceType),
 		attribute.String(LabelNamespace, namespace),
 	}
 	r.pendingDeletionsCount.Add(ctx, delta, metric.WithAttributes(labels...))
+	r.setGauge(MetricPendingDeletionsCount, namespace, float64(delta), true)
 }
 
 // Helper functions for creating common attribute sets
@@ -299,7 +742,7 @@ func (r *Recorder) UpdatePendingDeletionsCount(ctx context.Context, resourceType
 func ResourceAttributes(resourceType, namespace string) []attribute.KeyValue {
 	return []attribute.KeyValue{
 		attribute.String(LabelResourceType, resourceType),
-		attribute.String(LabelNamespace, namespace),
+		attribute.String(LabelNamespace, namespaceLabelValue(namespace)),
 	}
 }
 
@@ -307,7 +750,7 @@ func ResourceAttributes(resourceType, namespace string) []attribute.KeyValue {
 func ErrorAttributes(resourceType, namespace, errorType, reason string) []attribute.KeyValue {
 	return []attribute.KeyValue{
 		attribute.String(LabelResourceType, resourceType),
-		attribute.String(LabelNamespace, namespace),
+		attribute.String(LabelNamespace, namespaceLabelValue(namespace)),
 		attribute.String(LabelErrorType, errorType),
 		attribute.String(LabelReason, reason),
 	}
@@ -317,7 +760,7 @@ func ErrorAttributes(resourceType, namespace, errorType, reason string) []attrib
 func OperationAttributes(resourceType, namespace, operation string) []attribute.KeyValue {
 	return []attribute.KeyValue{
 		attribute.String(LabelResourceType, resourceType),
-		attribute.String(LabelNamespace, namespace),
+		attribute.String(LabelNamespace, namespaceLabelValue(namespace)),
 		attribute.String(LabelOperation, operation),
 	}
 }
@@ -18,10 +18,14 @@ package metrics
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -107,13 +111,44 @@ func TestRecordResourceProcessed(t *testing.T) {
 	assert.Len(t, r.seenResources, 2)
 }
 
+// TestRecordResourceProcessedBoundedCache verifies that the UID cache never grows past its limit, that duplicates are
+// still detected within the tracked window, and that every resource is counted when the tracking is disabled.
+func TestRecordResourceProcessedBoundedCache(t *testing.T) {
+	r := newRecorder()
+	r.SetSeenResourcesLimit(3)
+	ctx := context.Background()
+
+	for i := range 10 {
+		r.RecordResourceProcessed(ctx, types.UID(fmt.Sprintf("uid-%d", i)), ResourceTypePipelineRun, "default", StatusSuccess)
+		assert.LessOrEqual(t, len(r.seenResources), 3)
+		assert.LessOrEqual(t, len(r.seenOrder), 3)
+	}
+	assert.Equal(t, int64(10), r.Snapshot().Counters[MetricResourcesProcessed])
+
+	// the three newest UIDs are still tracked, the oldest ones were evicted
+	for _, uid := range []types.UID{"uid-7", "uid-8", "uid-9"} {
+		r.RecordResourceProcessed(ctx, uid, ResourceTypePipelineRun, "default", StatusSuccess)
+	}
+	assert.Equal(t, int64(10), r.Snapshot().Counters[MetricResourcesProcessed])
+	r.RecordResourceProcessed(ctx, "uid-0", ResourceTypePipelineRun, "default", StatusSuccess)
+	assert.Equal(t, int64(11), r.Snapshot().Counters[MetricResourcesProcessed])
+	assert.Len(t, r.seenResources, 3)
+
+	r.SetSeenResourcesLimit(0)
+	r.RecordResourceProcessed(ctx, "uid-0", ResourceTypePipelineRun, "default", StatusSuccess)
+	r.RecordResourceProcessed(ctx, "uid-0", ResourceTypePipelineRun, "default", StatusSuccess)
+	assert.Equal(t, int64(13), r.Snapshot().Counters[MetricResourcesProcessed])
+	assert.Empty(t, r.seenResources)
+}
+
 // TestRecordResourceDeleted verifies deletion tracking with resource age.
 func TestRecordResourceDeleted(t *testing.T) {
 	r := newRecorder()
 	ctx := context.Background()
 
 	assert.NotPanics(t, func() {
-		r.RecordResourceDeleted(ctx, ResourceTypePipelineRun, "default", OperationTTL, 1*time.Hour)
+		r.RecordResourceDeleted(ctx, ResourceTypePipelineRun, "default", OperationTTL, "ttl", 1*time.Hour)
+		r.RecordResourceDeleted(ctx, ResourceTypeTaskRun, "default", OperationHistory, "failed_history_limit", 2*time.Hour)
 	})
 }
 
@@ -137,6 +172,202 @@ func TestRecordResourceError(t *testing.T) {
 	}
 }
 
+// TestRecordUnusedSelector verifies unused selector recording.
+func TestRecordUnusedSelector(t *testing.T) {
+	r := newRecorder()
+	ctx := context.Background()
+
+	assert.NotPanics(t, func() {
+		r.RecordUnusedSelector(ctx, ResourceTypePipelineRun, "default")
+		r.RecordUnusedSelector(ctx, ResourceTypeTaskRun, "default")
+	})
+}
+
+// TestRecordLeftoverPodsDeleted verifies leftover pods deletion recording.
+func TestRecordLeftoverPodsDeleted(t *testing.T) {
+	r := newRecorder()
+	ctx := context.Background()
+
+	assert.NotPanics(t, func() {
+		r.RecordLeftoverPodsDeleted(ctx, ResourceTypePipelineRun, "default", 3)
+		r.RecordLeftoverPodsDeleted(ctx, ResourceTypeTaskRun, "default", 1)
+	})
+}
+
+// TestRecordNamespaceBudgetEnforced verifies namespace object budget enforcement recording.
+func TestRecordNamespaceBudgetEnforced(t *testing.T) {
+	r := newRecorder()
+	ctx := context.Background()
+
+	assert.NotPanics(t, func() {
+		r.RecordNamespaceBudgetEnforced(ctx, "default")
+	})
+}
+
+// TestRecordEventSkipped verifies skipped event recording for every skip reason.
+func TestRecordEventSkipped(t *testing.T) {
+	r := newRecorder()
+	ctx := context.Background()
+
+	for _, reason := range []string{SkipReasonNonStandalone, SkipReasonNotCompleted, SkipReasonIgnored} {
+		assert.NotPanics(t, func() {
+			r.RecordEventSkipped(ctx, ResourceTypeTaskRun, "default", reason)
+		})
+	}
+}
+
+// TestRecordAdmission verifies the recording of webhook admission decisions.
+func TestRecordAdmission(t *testing.T) {
+	r := newRecorder()
+	ctx := context.Background()
+
+	assert.NotPanics(t, func() {
+		r.RecordAdmission(ctx, ConfigTypeGlobal, true, "")
+		r.RecordAdmission(ctx, ConfigTypeNamespace, false, AdmissionReasonExceedsLimit)
+	})
+	assert.Equal(t, int64(2), r.Snapshot().Counters[MetricWebhookAdmissions])
+}
+
+// TestRecordRequeue verifies the requeue counter and delay recording.
+func TestRecordRequeue(t *testing.T) {
+	r := newRecorder()
+	ctx := context.Background()
+
+	assert.NotPanics(t, func() {
+		r.RecordRequeue(ctx, ResourceTypePipelineRun, 10*time.Minute)
+		r.RecordRequeue(ctx, ResourceTypeTaskRun, 0)
+	})
+	assert.Equal(t, int64(2), r.Snapshot().Counters[MetricRequeues])
+}
+
+// TestRecordPartialListFailure verifies the partial list failures counter.
+func TestRecordPartialListFailure(t *testing.T) {
+	r := newRecorder()
+	ctx := context.Background()
+
+	assert.NotPanics(t, func() {
+		r.RecordPartialListFailure(ctx, ResourceTypePipelineRun, "default")
+	})
+	assert.Equal(t, int64(1), r.Snapshot().Counters[MetricPartialListFailures])
+}
+
+// TestRecordAnnotationPatchFailure verifies the annotation patch failures counter.
+func TestRecordAnnotationPatchFailure(t *testing.T) {
+	r := newRecorder()
+	ctx := context.Background()
+
+	assert.NotPanics(t, func() {
+		r.RecordAnnotationPatchFailure(ctx, ResourceTypeTaskRun, "default")
+	})
+	assert.Equal(t, int64(1), r.Snapshot().Counters[MetricAnnotationPatchFailures])
+}
+
+// TestRecordSurvivingResourceAges verifies that the ages of the surviving runs are only recorded with the high cardinality metrics.
+func TestRecordSurvivingResourceAges(t *testing.T) {
+	r := newRecorder()
+	ctx := context.Background()
+	assert.False(t, r.HighCardinality())
+
+	assert.NotPanics(t, func() {
+		r.RecordSurvivingResourceAges(ctx, ResourceTypePipelineRun, "default", []time.Duration{time.Hour})
+		r.SetHighCardinality(true)
+		r.RecordSurvivingResourceAges(ctx, ResourceTypePipelineRun, "default", []time.Duration{time.Hour, time.Minute})
+		r.RecordSurvivingResourceAges(ctx, ResourceTypeTaskRun, "default", nil)
+	})
+	assert.True(t, r.HighCardinality())
+}
+
+// TestAgeStatistics verifies the minimum, median and maximum of the surviving run ages.
+func TestAgeStatistics(t *testing.T) {
+	tests := []struct {
+		name                         string
+		ages                         []time.Duration
+		wantMin, wantMedian, wantMax time.Duration
+	}{
+		{
+			name:       "single run",
+			ages:       []time.Duration{time.Hour},
+			wantMin:    time.Hour,
+			wantMedian: time.Hour,
+			wantMax:    time.Hour,
+		},
+		{
+			name:       "odd number of runs",
+			ages:       []time.Duration{3 * time.Hour, time.Minute, time.Hour},
+			wantMin:    time.Minute,
+			wantMedian: time.Hour,
+			wantMax:    3 * time.Hour,
+		},
+		{
+			name:       "even number of runs",
+			ages:       []time.Duration{4 * time.Hour, time.Hour, 2 * time.Hour, 3 * time.Hour},
+			wantMin:    time.Hour,
+			wantMedian: 150 * time.Minute,
+			wantMax:    4 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ages := slices.Clone(tt.ages)
+			gotMin, gotMedian, gotMax := ageStatistics(ages)
+			assert.Equal(t, tt.wantMin, gotMin)
+			assert.Equal(t, tt.wantMedian, gotMedian)
+			assert.Equal(t, tt.wantMax, gotMax)
+			assert.Equal(t, tt.ages, ages, "the ages must not be reordered")
+		})
+	}
+}
+
+// TestRecordNamespaceRunsEvaluated verifies the recording of the runs evaluated per namespace.
+func TestRecordNamespaceRunsEvaluated(t *testing.T) {
+	r := newRecorder()
+	ctx := context.Background()
+
+	assert.NotPanics(t, func() {
+		r.RecordNamespaceRunsEvaluated(ctx, ResourceTypePipelineRun, 120)
+		r.RecordNamespaceRunsEvaluated(ctx, ResourceTypeTaskRun, 0)
+	})
+}
+
+// TestSetPruningPaused verifies the recording of the fields paused by safe mode.
+func TestSetPruningPaused(t *testing.T) {
+	r := newRecorder()
+	ctx := context.Background()
+
+	assert.NotPanics(t, func() {
+		r.SetPruningPaused(ctx, "ttlSecondsAfterFinished", true)
+		r.SetPruningPaused(ctx, "ttlSecondsAfterFinished", false)
+	})
+}
+
+// TestRecordConfigLoad verifies the recording of config load failures and of the outcome of the last load.
+func TestRecordConfigLoad(t *testing.T) {
+	r := newRecorder()
+	ctx := context.Background()
+
+	assert.NotPanics(t, func() {
+		r.RecordConfigLoad(ctx, ConfigTypeGlobal, "", true)
+		r.RecordConfigLoad(ctx, ConfigTypeNamespace, "team-a", false)
+	})
+	snapshot := r.Snapshot()
+	assert.Equal(t, int64(1), snapshot.Counters[MetricConfigLoadErrors])
+	assert.Equal(t, map[string]float64{"": 1, "team-a": 0}, snapshot.Gauges[MetricConfigLastLoadSuccess])
+
+	r.RecordConfigLoad(ctx, ConfigTypeNamespace, "team-a", true)
+	assert.Equal(t, float64(1), r.Snapshot().Gauges[MetricConfigLastLoadSuccess]["team-a"])
+	assert.Equal(t, int64(1), r.Snapshot().Counters[MetricConfigLoadErrors])
+}
+
+// TestRecordNamespacePruned verifies the last prune time recording of a namespace.
+func TestRecordNamespacePruned(t *testing.T) {
+	r := newRecorder()
+	ctx := context.Background()
+
+	assert.NotPanics(t, func() {
+		r.RecordNamespacePruned(ctx, "default", time.Now())
+	})
+}
+
 // TestUpdateActiveResourcesCount verifies gauge updates for resource tracking.
 func TestUpdateActiveResourcesCount(t *testing.T) {
 	r := newRecorder()
@@ -249,3 +480,28 @@ func TestIsPermissionError(t *testing.T) {
 		assert.Equal(t, tt.expected, isPermissionError(tt.err))
 	}
 }
+
+// TestNamespaceAggregation verifies namespaces matching the pattern are collapsed into one series.
+func TestNamespaceAggregation(t *testing.T) {
+	defer SetNamespaceAggregation(nil)
+
+	namespaceValue := func(attrs []attribute.KeyValue) string {
+		for _, attr := range attrs {
+			if string(attr.Key) == LabelNamespace {
+				return attr.Value.AsString()
+			}
+		}
+		return ""
+	}
+
+	// default behavior, records per namespace
+	assert.Equal(t, "pr-123", namespaceValue(ResourceAttributes(ResourceTypePipelineRun, "pr-123")))
+
+	SetNamespaceAggregation(regexp.MustCompile(`^pr-[0-9]+$`))
+	assert.Equal(t, NamespaceAggregated, namespaceValue(ResourceAttributes(ResourceTypePipelineRun, "pr-123")))
+	assert.Equal(t, NamespaceAggregated, namespaceValue(OperationAttributes(ResourceTypeTaskRun, "pr-456", OperationTTL)))
+	assert.Equal(t, "production", namespaceValue(ResourceAttributes(ResourceTypePipelineRun, "production")))
+
+	SetNamespaceAggregation(nil)
+	assert.Equal(t, "pr-123", namespaceValue(ResourceAttributes(ResourceTypePipelineRun, "pr-123")))
+}
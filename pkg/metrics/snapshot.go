@@ -0,0 +1,103 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Snapshot holds the current values of the counters and gauges of the recorder,
+// for clusters which cannot scrape the metrics endpoint
+type Snapshot struct {
+	// Timestamp is the time the snapshot was taken at
+	Timestamp time.Time `json:"timestamp"`
+	// Counters holds the total of each counter since the controller started, across all labels
+	Counters map[string]int64 `json:"counters"`
+	// Gauges holds the value of each gauge by namespace label value
+	Gauges map[string]map[string]float64 `json:"gauges"`
+}
+
+// addToCounter adds the given value to the total of a counter kept for the snapshot
+func (r *Recorder) addToCounter(name string, value int64) {
+	r.snapshotMutex.Lock()
+	defer r.snapshotMutex.Unlock()
+	r.counterTotals[name] += value
+}
+
+// setGauge sets the value of a gauge kept for the snapshot, or adds to it for up-down counters
+func (r *Recorder) setGauge(name, namespace string, value float64, add bool) {
+	r.snapshotMutex.Lock()
+	defer r.snapshotMutex.Unlock()
+	if r.gaugeValues[name] == nil {
+		r.gaugeValues[name] = make(map[string]float64)
+	}
+	if add {
+		value += r.gaugeValues[name][namespace]
+	}
+	r.gaugeValues[name][namespace] = value
+}
+
+// Snapshot returns the current values of the counters and gauges
+func (r *Recorder) Snapshot() Snapshot {
+	r.snapshotMutex.Lock()
+	defer r.snapshotMutex.Unlock()
+	snapshot := Snapshot{
+		Timestamp: time.Now().UTC(),
+		Counters:  maps.Clone(r.counterTotals),
+		Gauges:    make(map[string]map[string]float64, len(r.gaugeValues)),
+	}
+	for name, values := range r.gaugeValues {
+		snapshot.Gauges[name] = maps.Clone(values)
+	}
+	return snapshot
+}
+
+// WriteSnapshot writes the current snapshot as JSON to the given file. The file is replaced
+// atomically, so that a reader never sees a partially written snapshot
+func (r *Recorder) WriteSnapshot(path string) error {
+	data, err := json.MarshalIndent(r.Snapshot(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the metrics snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to write the metrics snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	// the snapshot is meant to be read by other processes, e.g. a sidecar shipping it
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the metrics snapshot: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the metrics snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the metrics snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write the metrics snapshot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSnapshot verifies that the snapshot holds the totals of the counters and the values of the gauges.
+func TestSnapshot(t *testing.T) {
+	r := newRecorder()
+	ctx := context.Background()
+	prunedAt := time.Unix(1700000000, 0)
+
+	r.RecordResourceDeleted(ctx, ResourceTypePipelineRun, "default", OperationTTL, "ttl", time.Hour)
+	r.RecordResourceDeleted(ctx, ResourceTypeTaskRun, "other", OperationHistory, "history_limit", time.Hour)
+	r.RecordLeftoverPodsDeleted(ctx, ResourceTypeTaskRun, "default", 3)
+	r.UpdateActiveResourcesCount(ctx, ResourceTypePipelineRun, "default", 5)
+	r.UpdateActiveResourcesCount(ctx, ResourceTypePipelineRun, "default", -2)
+	r.RecordNamespacePruned(ctx, "default", prunedAt)
+
+	snapshot := r.Snapshot()
+	assert.Equal(t, int64(2), snapshot.Counters[MetricResourcesDeleted])
+	assert.Equal(t, int64(3), snapshot.Counters[MetricLeftoverPodsDeleted])
+	assert.Equal(t, 3.0, snapshot.Gauges[MetricActiveResourcesCount]["default"])
+	assert.Equal(t, 1700000000.0, snapshot.Gauges[MetricNamespaceLastPrune]["default"])
+
+	// the snapshot is a copy, not a view of the recorder
+	r.RecordResourceDeleted(ctx, ResourceTypePipelineRun, "default", OperationTTL, "ttl", time.Hour)
+	assert.Equal(t, int64(2), snapshot.Counters[MetricResourcesDeleted])
+}
+
+// TestWriteSnapshot verifies that the snapshot is written as JSON, replacing the previous one.
+func TestWriteSnapshot(t *testing.T) {
+	r := newRecorder()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	r.RecordEventSkipped(ctx, ResourceTypeTaskRun, "default", SkipReasonNotCompleted)
+	assert.NoError(t, r.WriteSnapshot(path))
+	r.RecordEventSkipped(ctx, ResourceTypeTaskRun, "default", SkipReasonNotCompleted)
+	assert.NoError(t, r.WriteSnapshot(path))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the snapshot: %v", err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("Failed to decode the snapshot: %v", err)
+	}
+	assert.Equal(t, int64(2), snapshot.Counters[MetricEventsSkipped])
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files should not be left behind")
+
+	assert.Error(t, r.WriteSnapshot(filepath.Join(path, "not-a-directory", "metrics.json")))
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// TracerName is the name of the tracer of the pruner spans
+	TracerName = "tekton_pruner_controller"
+
+	// Span attribute keys
+	AttributeKind         = "kind"
+	AttributeName         = "name"
+	AttributeDecision     = "decision"
+	AttributeIdentifiedBy = "identified_by"
+
+	// Values of the decision span attribute
+	DecisionDeleted        = "deleted"
+	DecisionMarkedPrunable = "marked_prunable"
+	DecisionRequeued       = "requeued"
+	DecisionKept           = "kept"
+	DecisionProtected      = "protected"
+	DecisionSkipped        = "skipped"
+)
+
+// StartSpan starts a span of the pruner tracer as a child of the span of the context, if any.
+// Spans are exported through the globally registered tracer provider, they are no-ops when none is configured
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records the error, if any, on the span and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// SpanAttributes creates the attributes identifying a resource on a span
+func SpanAttributes(kind, namespace, name string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String(AttributeKind, kind),
+		attribute.String(LabelNamespace, namespace),
+		attribute.String(AttributeName, name),
+	}
+}
+
+// SetSpanDecision records on the span of the context what was decided for the resource
+func SetSpanDecision(ctx context.Context, decision string) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String(AttributeDecision, decision))
+}
+
+// SetSpanIdentifiedBy records on the span of the context which configuration the applied setting comes from
+func SetSpanIdentifiedBy(ctx context.Context, identifiedBy string) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String(AttributeIdentifiedBy, identifiedBy))
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestSpanAttributesAndDecision verifies the attributes, decision and error recorded on a span.
+func TestSpanAttributesAndDecision(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	ctx, span := StartSpan(context.Background(), "PipelineRun.ReconcileKind", SpanAttributes("PipelineRun", "test-ns", "pr-1")...)
+	SetSpanIdentifiedBy(ctx, "identifiedBy_ns")
+	SetSpanDecision(ctx, DecisionDeleted)
+	EndSpan(span, errors.New("delete failed"))
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "PipelineRun.ReconcileKind", spans[0].Name())
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String(AttributeKind, "PipelineRun"),
+		attribute.String(LabelNamespace, "test-ns"),
+		attribute.String(AttributeName, "pr-1"),
+		attribute.String(AttributeIdentifiedBy, "identifiedBy_ns"),
+		attribute.String(AttributeDecision, DecisionDeleted),
+	}, spans[0].Attributes())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "delete failed", spans[0].Status().Description)
+}
+
+// TestStartSpanWithoutProvider verifies spans are no-ops when no tracer provider is configured.
+func TestStartSpanWithoutProvider(t *testing.T) {
+	assert.NotPanics(t, func() {
+		ctx, span := StartSpan(context.Background(), "TaskRun.ReconcileKind")
+		SetSpanDecision(ctx, DecisionKept)
+		EndSpan(span, nil)
+	})
+}
@@ -2,6 +2,7 @@ package namespaceprunerconfig
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/tektoncd/pruner/pkg/config"
 	"go.uber.org/zap"
@@ -30,6 +31,7 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 	impl := controller.NewContext(ctx, r, controller.ControllerOptions{
 		Logger:        logger,
 		WorkQueueName: "namespace-pruner-config",
+		Concurrency:   getThreads(ctx),
 	})
 
 	// Add event handler to watch ConfigMaps with the specified name across filtered namespaces
@@ -41,11 +43,26 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 				return false
 			}
 			// Only react to ConfigMaps with the namespace-level pruner config name
-			return cm.Name == config.PrunerNamespaceConfigMapName
+			if cm.Name == config.PrunerNamespaceConfigMapName {
+				return true
+			}
+			if isLabeledNamespaceConfig(cm) {
+				logger.Warnw("Skipping pruner-labeled ConfigMap which is not named "+config.PrunerNamespaceConfigMapName,
+					"namespace", cm.Namespace, "name", cm.Name)
+			}
+			return false
 		},
 		Handler: cache.ResourceEventHandlerFuncs{
-			AddFunc:    func(obj interface{}) { impl.Enqueue(obj) },
-			UpdateFunc: func(oldObj, newObj interface{}) { impl.Enqueue(newObj) },
+			AddFunc: func(obj interface{}) { impl.Enqueue(obj) },
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				// Skip the updates which leave the config untouched, e.g. the load status annotations written by the reconciler
+				oldCM, oldOK := oldObj.(*corev1.ConfigMap)
+				newCM, newOK := newObj.(*corev1.ConfigMap)
+				if oldOK && newOK && reflect.DeepEqual(oldCM.Data, newCM.Data) {
+					return
+				}
+				impl.Enqueue(newObj)
+			},
 			DeleteFunc: func(obj interface{}) { impl.Enqueue(obj) },
 		},
 	})
@@ -83,3 +100,18 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 
 	return impl
 }
+
+// threadsKey is used as the key for associating the number of reconciler threads with the context.
+type threadsKey struct{}
+
+// WithThreads sets how many threads (goroutines) reconcile the namespace configs,
+// e.g. to absorb a mass rollout of namespace ConfigMaps. Zero uses the default threads per controller.
+func WithThreads(ctx context.Context, threads int) context.Context {
+	return context.WithValue(ctx, threadsKey{}, threads)
+}
+
+// getThreads returns how many threads reconcile the namespace configs, 0 for the default threads per controller
+func getThreads(ctx context.Context) int {
+	threads, _ := ctx.Value(threadsKey{}).(int)
+	return threads
+}
@@ -2,11 +2,15 @@ package namespaceprunerconfig
 
 import (
 	"context"
+	goerrors "errors"
 	"strings"
+	"time"
 
 	"github.com/tektoncd/pruner/pkg/config"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
@@ -29,6 +33,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
 		return err
 	}
 
+	// Only the canonical ConfigMap holds the config of a namespace, another one must neither load nor delete it
+	if name != config.PrunerNamespaceConfigMapName {
+		logger.Warnf("Skipping ConfigMap %s/%s, only %s is loaded as namespace config", namespace, name, config.PrunerNamespaceConfigMapName)
+		return nil
+	}
+
 	// Get the ConfigMap
 	cm, err := r.kubeclient.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
@@ -46,13 +56,52 @@ func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
 	logger.Infof("Loading namespace config from ConfigMap: %s/%s", namespace, name)
 	if err := config.PrunerConfigStore.LoadNamespaceConfig(ctx, namespace, cm); err != nil {
 		logger.Errorf("Failed to load namespace config from ConfigMap %s/%s: %v", namespace, name, err)
+		r.updateLoadStatus(ctx, cm, err)
 		return err
 	}
 
 	logger.Infof("Successfully loaded namespace config: %s/%s", namespace, name)
+	r.updateLoadStatus(ctx, cm, nil)
 	return nil
 }
 
+// updateLoadStatus annotates the ConfigMap with the outcome of loading its config, so that operators can tell
+// from the ConfigMap itself whether it is in use. On success the load time is recorded and any previous error cleared.
+// On failure the error is recorded, and the load time of the config still in use is kept.
+// Failing to annotate is only logged, as it does not affect the loaded config
+func (r *Reconciler) updateLoadStatus(ctx context.Context, cm *corev1.ConfigMap, loadErr error) {
+	logger := logging.FromContext(ctx)
+
+	var patchBytes []byte
+	var err error
+	if loadErr == nil {
+		patchBytes, err = config.AnnotationPatch(cm, map[string]string{config.AnnotationConfigLoaded: time.Now().Format(time.RFC3339)}, config.AnnotationConfigLoadError)
+	} else {
+		if cm.Annotations[config.AnnotationConfigLoadError] == loadErr.Error() {
+			return
+		}
+		patchBytes, err = config.AnnotationPatch(cm, map[string]string{config.AnnotationConfigLoadError: loadErr.Error()})
+	}
+	if goerrors.Is(err, config.ErrAnnotationNotAllowed) {
+		logger.Warnf("Skipping recording load status on ConfigMap %s/%s: %v", cm.Namespace, cm.Name, err)
+		return
+	}
+	if err != nil {
+		logger.Errorf("Failed to marshal load status patch for ConfigMap %s/%s: %v", cm.Namespace, cm.Name, err)
+		return
+	}
+
+	_, err = r.kubeclient.CoreV1().ConfigMaps(cm.Namespace).Patch(ctx, cm.Name, types.JSONPatchType, patchBytes, metav1.PatchOptions{FieldManager: config.GetFieldManager()})
+	if err != nil {
+		logger.Warnf("Failed to record load status on ConfigMap %s/%s: %v", cm.Namespace, cm.Name, err)
+	}
+}
+
+// isLabeledNamespaceConfig checks whether a ConfigMap is labeled as a namespace-level pruner config, whatever its name
+func isLabeledNamespaceConfig(cm *corev1.ConfigMap) bool {
+	return cm.Labels["app.kubernetes.io/part-of"] == "tekton-pruner" && cm.Labels["pruner.tekton.dev/config-type"] == "namespace"
+}
+
 // parseKey parses the key in the format "namespace/name" and returns namespace and name
 func parseKey(key string) (namespace, name string, err error) {
 	parts := strings.SplitN(key, "/", 2)
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/tektoncd/pruner/pkg/config"
@@ -229,3 +230,130 @@ func TestConcurrentReconciliation(t *testing.T) {
 		<-done
 	}
 }
+
+// TestThreads verifies the number of threads reconciling the namespace configs is carried by the context.
+func TestThreads(t *testing.T) {
+	assert.Equal(t, 0, getThreads(context.Background()))
+	assert.Equal(t, 16, getThreads(WithThreads(context.Background(), 16)))
+}
+
+// TestReconcileLoadStatus verifies the load status annotations written on the ConfigMap.
+func TestReconcileLoadStatus(t *testing.T) {
+	tests := []struct {
+		name            string
+		configData      string
+		annotations     map[string]string
+		wantLoaded      bool
+		wantLoadedValue string
+		wantLoadError   bool
+	}{
+		{
+			name:       "valid config",
+			configData: "ttlSecondsAfterFinished: 3600",
+			wantLoaded: true,
+		},
+		{
+			name:        "valid config clears a previous error",
+			configData:  "ttlSecondsAfterFinished: 3600",
+			annotations: map[string]string{config.AnnotationConfigLoadError: "previous error"},
+			wantLoaded:  true,
+		},
+		{
+			name:          "invalid config",
+			configData:    "invalid: yaml: ::::",
+			wantLoadError: true,
+		},
+		{
+			name:            "invalid config keeps the load time of the config in use",
+			configData:      "invalid: yaml: ::::",
+			annotations:     map[string]string{config.AnnotationConfigLoaded: "2025-01-01T00:00:00Z"},
+			wantLoaded:      true,
+			wantLoadedValue: "2025-01-01T00:00:00Z",
+			wantLoadError:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        config.PrunerNamespaceConfigMapName,
+					Namespace:   "test-ns",
+					Annotations: tt.annotations,
+				},
+				Data: map[string]string{config.PrunerNamespaceConfigKey: tt.configData},
+			}
+
+			kubeClient := fake.NewSimpleClientset(cm)
+			reconciler := &Reconciler{kubeclient: kubeClient}
+			err := reconciler.Reconcile(ctx, "test-ns/"+config.PrunerNamespaceConfigMapName)
+			assert.Equal(t, tt.wantLoadError, err != nil)
+
+			got, err := kubeClient.CoreV1().ConfigMaps("test-ns").Get(ctx, config.PrunerNamespaceConfigMapName, metav1.GetOptions{})
+			assert.NoError(t, err)
+
+			loaded, found := got.Annotations[config.AnnotationConfigLoaded]
+			assert.Equal(t, tt.wantLoaded, found)
+			if tt.wantLoadedValue != "" {
+				assert.Equal(t, tt.wantLoadedValue, loaded)
+			} else if found {
+				_, err := time.Parse(time.RFC3339, loaded)
+				assert.NoError(t, err)
+			}
+
+			loadError, found := got.Annotations[config.AnnotationConfigLoadError]
+			assert.Equal(t, tt.wantLoadError, found)
+			if found {
+				assert.NotEmpty(t, loadError)
+			}
+		})
+	}
+}
+
+// TestReconcileNonCanonicalConfigMap verifies that a correctly labeled ConfigMap with another name
+// neither loads nor deletes the config of its namespace.
+func TestReconcileNonCanonicalConfigMap(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+	labels := map[string]string{
+		"app.kubernetes.io/part-of":     "tekton-pruner",
+		"pruner.tekton.dev/config-type": "namespace",
+	}
+	newConfigMap := func(name, app string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "misnamed-ns", Labels: labels},
+			Data: map[string]string{config.PrunerNamespaceConfigKey: fmt.Sprintf(`
+pipelineRuns:
+  - selector:
+      - matchLabels:
+          app: %s
+    ttlSecondsAfterFinished: 60`, app)},
+		}
+	}
+	canonical := newConfigMap(config.PrunerNamespaceConfigMapName, "canonical")
+	misnamed := newConfigMap("tekton-pruner-namespace-spec-old", "misnamed")
+	defer config.PrunerConfigStore.DeleteNamespaceConfig(ctx, "misnamed-ns")
+
+	client := fake.NewSimpleClientset(canonical, misnamed)
+	reconciler := &Reconciler{kubeclient: client}
+	assert.NoError(t, reconciler.Reconcile(ctx, "misnamed-ns/"+config.PrunerNamespaceConfigMapName))
+
+	assertSelectedApp := func() {
+		t.Helper()
+		selectors := config.PrunerConfigStore.GetNamespaceSelectors("misnamed-ns", config.PrunerResourceTypePipelineRun)
+		if assert.Len(t, selectors, 1) {
+			assert.Equal(t, "canonical", selectors[0].MatchLabels["app"])
+		}
+	}
+
+	assert.NoError(t, reconciler.Reconcile(ctx, "misnamed-ns/"+misnamed.Name))
+	assertSelectedApp()
+	got, err := client.CoreV1().ConfigMaps("misnamed-ns").Get(ctx, misnamed.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, got.Annotations, config.AnnotationConfigLoaded)
+
+	// the deletion of the misnamed ConfigMap leaves the config of the namespace in place
+	assert.NoError(t, client.CoreV1().ConfigMaps("misnamed-ns").Delete(ctx, misnamed.Name, metav1.DeleteOptions{}))
+	assert.NoError(t, reconciler.Reconcile(ctx, "misnamed-ns/"+misnamed.Name))
+	assertSelectedApp()
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/tektoncd/pruner/pkg/config"
 	"go.uber.org/zap"
 	"k8s.io/utils/clock"
+	"knative.dev/pkg/apis"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
@@ -26,7 +27,10 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 	logger := logging.FromContext(ctx)
 
 	pipelineRunFuncs := &PrFuncs{
-		client: pipelineclient.Get(ctx),
+		client:        pipelineclient.Get(ctx),
+		kubeclient:    kubeclient.Get(ctx),
+		conditionType: apis.ConditionSucceeded,
+		lister:        pipelineRunInformer.Lister(),
 	}
 	ttlHandler, err := config.NewTTLHandler(clock.RealClock{}, pipelineRunFuncs)
 	if err != nil {
@@ -37,6 +41,7 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 	if err != nil {
 		logger.Fatal("error on getting history limiter", zap.Error(err))
 	}
+	ttlHandler.SetHistoryLimiter(historyLimiter)
 
 	r := &Reconciler{
 		// The client will be needed to create/delete Pods via the API.
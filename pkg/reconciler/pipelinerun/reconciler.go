@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	"github.com/tektoncd/pruner/pkg/config"
@@ -11,9 +12,11 @@ import (
 
 	pipelineversioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	pipelinerunreconciler "github.com/tektoncd/pipeline/pkg/client/injection/reconciler/pipeline/v1/pipelinerun"
+	pipelinev1listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"knative.dev/pkg/apis"
@@ -29,11 +32,27 @@ type Reconciler struct {
 	historyLimiter *config.HistoryLimiter
 }
 
+// defaultSuccessfulReasons holds the condition reasons of a PipelineRun treated as successful,
+// when successfulReasons is not configured
+var defaultSuccessfulReasons = []string{
+	pipelinev1.PipelineRunReasonSuccessful.String(),
+	pipelinev1.PipelineRunReasonCompleted.String(),
+}
+
 // Check that our Reconciler implements Interface
 var _ pipelinerunreconciler.Interface = (*Reconciler)(nil)
 
 // ReconcileKind implements Interface.ReconcileKind.
-func (r *Reconciler) ReconcileKind(ctx context.Context, pr *pipelinev1.PipelineRun) reconciler.Event {
+func (r *Reconciler) ReconcileKind(ctx context.Context, pr *pipelinev1.PipelineRun) (event reconciler.Event) {
+	ctx, span := metrics.StartSpan(ctx, "PipelineRun.ReconcileKind", metrics.SpanAttributes(config.KindPipelineRun, pr.Namespace, pr.Name)...)
+	defer func() {
+		if isRequeueKey, _ := controller.IsRequeueKey(event); isRequeueKey {
+			metrics.EndSpan(span, nil)
+			return
+		}
+		metrics.EndSpan(span, event)
+	}()
+
 	logger := logging.FromContext(ctx)
 	logger.Debugw("received a PipelineRun event", "namespace", pr.Namespace, "name", pr.Name, "status", pr.Status)
 
@@ -72,9 +91,12 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, pr *pipelinev1.PipelineR
 	ttlTimer.RecordTTLProcessingDuration(ctx)
 
 	if err != nil {
-		isRequeueKey, _ := controller.IsRequeueKey(err)
-		// the error is not a requeue error, print the error
-		if !isRequeueKey {
+		isRequeueKey, requeueDelay := controller.IsRequeueKey(err)
+		if isRequeueKey {
+			// the run waits for its TTL to expire
+			metricsRecorder.RecordRequeue(ctx, metrics.ResourceTypePipelineRun, requeueDelay)
+		} else {
+			// the error is not a requeue error, print the error
 			status = metrics.StatusError
 			errorType := metrics.ClassifyError(err)
 			metricsRecorder.RecordResourceError(ctx, metrics.ResourceTypePipelineRun, pr.Namespace, errorType, "ttl_processing_failed")
@@ -91,6 +113,12 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, pr *pipelinev1.PipelineR
 // it contains a client to interact with the pipeline API and manage PipelineRuns
 type PrFuncs struct {
 	client pipelineversioned.Interface
+	// kubeclient is used to delete the leftover pods of the deleted PipelineRuns, it can be nil
+	kubeclient kubernetes.Interface
+	// conditionType is the type of the condition reporting the completion of a run, Succeeded if empty
+	conditionType apis.ConditionType
+	// lister lists the PipelineRuns of all the namespaces from the informer cache for ListByNamespaces, the client is used if nil
+	lister pipelinev1listers.PipelineRunLister
 }
 
 // Type returns the kind of resource represented by the PRFuncs struct, which is "PipelineRun".
@@ -100,8 +128,18 @@ func (prf *PrFuncs) Type() string {
 
 // NewPrFuncs creates a new instance of PrFuncs with the provided pipeline client.
 // This client is used to interact with the Tekton Pipeline API.
-func NewPrFuncs(client pipelineversioned.Interface) *PrFuncs {
-	return &PrFuncs{client: client}
+// The optional kube client is used to delete the leftover pods of the deleted runs.
+// The condition type reports the completion of a run, an empty one defaults to Succeeded.
+func NewPrFuncs(client pipelineversioned.Interface, kubeClient kubernetes.Interface, conditionType apis.ConditionType) *PrFuncs {
+	return &PrFuncs{client: client, kubeclient: kubeClient, conditionType: conditionType}
+}
+
+// completionConditionType returns the type of the condition reporting the completion of a run
+func (prf *PrFuncs) completionConditionType() apis.ConditionType {
+	if prf.conditionType == "" {
+		return apis.ConditionSucceeded
+	}
+	return prf.conditionType
 }
 
 // List returns a list of PipelineRuns in a given namespace with a label selector.
@@ -109,7 +147,9 @@ func (prf *PrFuncs) List(ctx context.Context, namespace, label string) ([]metav1
 	logger := logging.FromContext(ctx)
 
 	// TODO: should we have to implement pagination support?
-	prsList, err := prf.client.TektonV1().PipelineRuns(namespace).List(ctx, metav1.ListOptions{LabelSelector: label})
+	prsList, err := config.ListWithRetry(ctx, func() (*pipelinev1.PipelineRunList, error) {
+		return prf.client.TektonV1().PipelineRuns(namespace).List(ctx, metav1.ListOptions{LabelSelector: label})
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -131,7 +171,9 @@ func (prf *PrFuncs) ListByLabels(ctx context.Context, namespace string, labels m
 	logger := logging.FromContext(ctx)
 	selector := metav1.FormatLabelSelector(&metav1.LabelSelector{MatchLabels: labels})
 
-	prsList, err := prf.client.TektonV1().PipelineRuns(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	prsList, err := config.ListWithRetry(ctx, func() (*pipelinev1.PipelineRunList, error) {
+		return prf.client.TektonV1().PipelineRuns(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -174,13 +216,25 @@ func (prf *PrFuncs) ListByAnnotations(ctx context.Context, namespace string, ann
 }
 
 // ListByNamespaces returns a list of PipelineRuns across multiple namespaces.
+// The namespaces which cannot be listed are left out, unless strictNamespaceListing is set, then an error is returned.
+// With a lister, the PipelineRuns are read from the informer cache, and must not be modified.
 func (prf *PrFuncs) ListByNamespaces(ctx context.Context, namespaces []string) (map[string][]metav1.Object, error) {
 	logger := logging.FromContext(ctx)
 	results := make(map[string][]metav1.Object)
 
 	for _, ns := range namespaces {
-		prs, err := prf.List(ctx, ns, "")
+		var prs []metav1.Object
+		var err error
+		if prf.lister != nil {
+			prs, err = prf.listCached(ns)
+		} else {
+			prs, err = prf.List(ctx, ns, "")
+		}
 		if err != nil {
+			metrics.GetRecorder().RecordPartialListFailure(ctx, metrics.ResourceTypePipelineRun, ns)
+			if config.PrunerConfigStore.GetStrictNamespaceListing() {
+				return nil, fmt.Errorf("failed to list PipelineRuns in namespace %q: %w", ns, err)
+			}
 			logger.Errorw("Failed to list PipelineRuns", "namespace", ns, "error", err)
 			continue
 		}
@@ -192,6 +246,25 @@ func (prf *PrFuncs) ListByNamespaces(ctx context.Context, namespaces []string) (
 	return results, nil
 }
 
+// listCached returns the PipelineRuns of a namespace, or of all the namespaces, from the informer cache
+func (prf *PrFuncs) listCached(namespace string) ([]metav1.Object, error) {
+	var prs []*pipelinev1.PipelineRun
+	var err error
+	if namespace == metav1.NamespaceAll {
+		prs, err = prf.lister.List(labels.Everything())
+	} else {
+		prs, err = prf.lister.PipelineRuns(namespace).List(labels.Everything())
+	}
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]metav1.Object, 0, len(prs))
+	for _, run := range prs {
+		objects = append(objects, run)
+	}
+	return objects, nil
+}
+
 /*
 // List returns a list of PipelineRuns in a given namespace with label and annotation selectors.
 // Annotations take higher priority. If annotations match, labels are ignored for that resource.
@@ -290,12 +363,30 @@ func (prf *PrFuncs) List(ctx context.Context, namespace string, annotations inte
 
 // Get retrieves a specific PipelineRun by name in the given namespace.
 func (prf *PrFuncs) Get(ctx context.Context, namespace, name string) (metav1.Object, error) {
-	return prf.client.TektonV1().PipelineRuns(namespace).Get(ctx, name, metav1.GetOptions{})
+	var pr *pipelinev1.PipelineRun
+	err := config.CallWithTimeout(ctx, metrics.ResourceTypePipelineRun, namespace, func(ctx context.Context) (err error) {
+		pr, err = prf.client.TektonV1().PipelineRuns(namespace).Get(ctx, name, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pr, nil
 }
 
 // Delete removes a specific PipelineRun by name in the given namespace.
 func (prf *PrFuncs) Delete(ctx context.Context, namespace, name string) error {
-	return prf.client.TektonV1().PipelineRuns(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err := config.CallWithTimeout(ctx, metrics.ResourceTypePipelineRun, namespace, func(ctx context.Context) error {
+		return prf.client.TektonV1().PipelineRuns(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	})
+	if err != nil {
+		return err
+	}
+	// failing to delete the leftover pods does not fail the deletion of the PipelineRun
+	if err := config.DeleteLeftoverPods(ctx, prf.kubeclient, metrics.ResourceTypePipelineRun, namespace, config.LabelPipelineRunName, name); err != nil {
+		logging.FromContext(ctx).Warnw("error deleting the leftover pods of a PipelineRun", "namespace", namespace, "name", name, zap.Error(err))
+	}
+	return nil
 }
 
 // Update modifies an existing PipelineRun resource.
@@ -304,20 +395,25 @@ func (prf *PrFuncs) Update(ctx context.Context, resource metav1.Object) error {
 	if !ok {
 		return fmt.Errorf("invalid type received. namespace:%s, Name:%s", resource.GetNamespace(), resource.GetName())
 	}
-	_, err := prf.client.TektonV1().PipelineRuns(resource.GetNamespace()).Update(ctx, pr, metav1.UpdateOptions{})
-	return err
+	return config.CallWithTimeout(ctx, metrics.ResourceTypePipelineRun, resource.GetNamespace(), func(ctx context.Context) error {
+		_, err := prf.client.TektonV1().PipelineRuns(resource.GetNamespace()).Update(ctx, pr, metav1.UpdateOptions{FieldManager: config.GetFieldManager()})
+		return err
+	})
 }
 
-// Patch modifies an existing PipelineRun resource using a Merge Patch
+// Patch modifies an existing PipelineRun resource using a JSON Patch, as created by config.AnnotationPatch.
 // This is useful for updating only specific fields of the resource.
 func (prf *PrFuncs) Patch(ctx context.Context, namespace, name string, patchBytes []byte) error {
-	_, err := prf.client.TektonV1().PipelineRuns(namespace).Patch(
-		ctx,
-		name,
-		types.MergePatchType,
-		patchBytes,
-		metav1.PatchOptions{},
-	)
+	err := config.CallWithTimeout(ctx, metrics.ResourceTypePipelineRun, namespace, func(ctx context.Context) error {
+		_, err := prf.client.TektonV1().PipelineRuns(namespace).Patch(
+			ctx,
+			name,
+			types.JSONPatchType,
+			patchBytes,
+			metav1.PatchOptions{FieldManager: config.GetFieldManager()},
+		)
+		return err
+	})
 
 	if err != nil {
 		return fmt.Errorf("failed to patch PipelineRun %s/%s: %w", namespace, name, err)
@@ -337,7 +433,7 @@ func (prf *PrFuncs) GetCompletionTime(resource metav1.Object) (metav1.Time, erro
 		return *pr.Status.CompletionTime, nil
 	}
 	for _, c := range pr.Status.Conditions {
-		if c.Type == apis.ConditionSucceeded && c.Status != corev1.ConditionUnknown {
+		if c.Type == prf.completionConditionType() && c.Status != corev1.ConditionUnknown {
 			finishAt := c.LastTransitionTime
 			if finishAt.Inner.IsZero() {
 				return metav1.Time{}, fmt.Errorf("unable to find the time when the resource '%s/%s' finished", pr.Namespace, pr.Name)
@@ -350,6 +446,29 @@ func (prf *PrFuncs) GetCompletionTime(resource metav1.Object) (metav1.Time, erro
 	return metav1.Time{}, fmt.Errorf("unable to find the status of the finished resource: %s/%s", pr.Namespace, pr.Name)
 }
 
+// GetStartTime retrieves the start time of a PipelineRun resource.
+func (prf *PrFuncs) GetStartTime(resource metav1.Object) (metav1.Time, error) {
+	pr, ok := resource.(*pipelinev1.PipelineRun)
+	if !ok {
+		return metav1.Time{}, fmt.Errorf("resource type error, this is not a PipelineRun resource. namespace:%s, name:%s, type:%T",
+			resource.GetNamespace(), resource.GetName(), resource)
+	}
+	if pr.Status.StartTime == nil {
+		return metav1.Time{}, fmt.Errorf("the resource '%s/%s' has not started", pr.Namespace, pr.Name)
+	}
+	return *pr.Status.StartTime, nil
+}
+
+// HasResults checks if the PipelineRun resource emitted pipeline results.
+// The results of its tasks are not looked at, a resource which is not a PipelineRun is reported to have results.
+func (prf *PrFuncs) HasResults(resource metav1.Object) bool {
+	pr, ok := resource.(*pipelinev1.PipelineRun)
+	if !ok {
+		return true
+	}
+	return len(pr.Status.Results) > 0
+}
+
 // Ignore returns true if the resource should be ignored based on labels and annotations.
 func (prf *PrFuncs) Ignore(resource metav1.Object) bool {
 	// labels and annotations are not populated, lets wait sometime
@@ -381,7 +500,7 @@ func (prf *PrFuncs) IsCompleted(resource metav1.Object) bool {
 	}
 
 	// check the status from conditions
-	condition := pr.Status.GetCondition(apis.ConditionSucceeded)
+	condition := pr.Status.GetCondition(prf.completionConditionType())
 	if condition == nil || condition.Status == corev1.ConditionUnknown {
 		return false
 	}
@@ -400,18 +519,17 @@ func (prf *PrFuncs) IsSuccessful(resource metav1.Object) bool {
 		return false
 	}
 
-	condition := pr.Status.GetCondition(apis.ConditionSucceeded)
+	condition := pr.Status.GetCondition(prf.completionConditionType())
 	if condition == nil {
 		return false
 	}
 
-	runReason := pipelinev1.PipelineRunReason(condition.Reason)
-
-	if runReason == pipelinev1.PipelineRunReasonSuccessful || runReason == pipelinev1.PipelineRunReasonCompleted {
-		return true
+	successfulReasons := config.PrunerConfigStore.GetSuccessfulReasons(pr.Namespace, config.KindPipelineRun)
+	if len(successfulReasons) == 0 {
+		successfulReasons = defaultSuccessfulReasons
 	}
 
-	return false
+	return slices.Contains(successfulReasons, condition.Reason)
 }
 
 // IsFailed checks if the PipelineRun resource has failed.
@@ -428,9 +546,25 @@ func (prf *PrFuncs) IsFailed(resource metav1.Object) bool {
 	return !prf.IsSuccessful(resource)
 }
 
-// GetDefaultLabelKey returns the default label key for PipelineRun resources.
+// IsCancelled checks if the PipelineRun resource completed on its cancellation.
+func (prf *PrFuncs) IsCancelled(resource metav1.Object) bool {
+	pr, ok := resource.(*pipelinev1.PipelineRun)
+	if !ok {
+		return false
+	}
+
+	condition := pr.Status.GetCondition(prf.completionConditionType())
+	if condition == nil {
+		return false
+	}
+
+	return condition.Reason == pipelinev1.PipelineRunReasonCancelled.String()
+}
+
+// GetDefaultLabelKey returns the default label key for PipelineRun resources,
+// the first of the pipelineRunLabelKeys of the global config, if set.
 func (prf *PrFuncs) GetDefaultLabelKey() string {
-	return config.LabelPipelineName
+	return config.PrunerConfigStore.GetDefaultLabelKey(config.KindPipelineRun)
 }
 
 // GetTTLSecondsAfterFinished retrieves the TTL (time-to-live) in seconds after a PipelineRun finishes.
@@ -2,21 +2,27 @@ package pipelinerun
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	fakepipelineclientset "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	pipelinev1listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1"
+	"github.com/tektoncd/pruner/pkg/config"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 	"knative.dev/pkg/logging"
 	logtesting "knative.dev/pkg/logging/testing"
 )
 
 func TestNewPrFuncs(t *testing.T) {
 	client := fakepipelineclientset.NewSimpleClientset()
-	prFuncs := NewPrFuncs(client)
+	prFuncs := NewPrFuncs(client, nil, "")
 
 	assert.NotNil(t, prFuncs)
 	assert.NotNil(t, prFuncs.client)
@@ -112,7 +118,7 @@ func TestListByLabels(t *testing.T) {
 				runtimeObjs = append(runtimeObjs, pr)
 			}
 			client := fakepipelineclientset.NewSimpleClientset(runtimeObjs...)
-			prFuncs := NewPrFuncs(client)
+			prFuncs := NewPrFuncs(client, nil, "")
 
 			// Call ListByLabels
 			result, err := prFuncs.ListByLabels(ctx, tt.namespace, tt.labels)
@@ -203,7 +209,7 @@ func TestListByAnnotations(t *testing.T) {
 				runtimeObjs = append(runtimeObjs, pr)
 			}
 			client := fakepipelineclientset.NewSimpleClientset(runtimeObjs...)
-			prFuncs := NewPrFuncs(client)
+			prFuncs := NewPrFuncs(client, nil, "")
 
 			// Call ListByAnnotations
 			result, err := prFuncs.ListByAnnotations(ctx, tt.namespace, tt.annotations)
@@ -273,7 +279,7 @@ func TestListByNamespaces(t *testing.T) {
 				runtimeObjs = append(runtimeObjs, pr)
 			}
 			client := fakepipelineclientset.NewSimpleClientset(runtimeObjs...)
-			prFuncs := NewPrFuncs(client)
+			prFuncs := NewPrFuncs(client, nil, "")
 
 			// Call ListByNamespaces
 			result, err := prFuncs.ListByNamespaces(ctx, tt.namespaces)
@@ -284,6 +290,79 @@ func TestListByNamespaces(t *testing.T) {
 	}
 }
 
+// TestListByNamespacesLister verifies that the PipelineRuns are read from the lister, without listing them from the API server
+func TestListByNamespacesLister(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, pr := range []*pipelinev1.PipelineRun{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "ns1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pr-2", Namespace: "ns2"}},
+	} {
+		assert.NoError(t, indexer.Add(pr))
+	}
+	client := fakepipelineclientset.NewSimpleClientset()
+	prFuncs := &PrFuncs{client: client, lister: pipelinev1listers.NewPipelineRunLister(indexer)}
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+
+	result, err := prFuncs.ListByNamespaces(ctx, []string{metav1.NamespaceAll, "ns2"})
+	assert.NoError(t, err)
+	assert.Len(t, result[metav1.NamespaceAll], 2)
+	assert.Len(t, result["ns2"], 1)
+	assert.Empty(t, client.Actions())
+}
+
+func TestListByNamespacesPartialFailure(t *testing.T) {
+	tests := []struct {
+		name               string
+		globalConfig       string
+		expectError        bool
+		expectedNamespaces []string
+	}{
+		{
+			name:               "lenient by default",
+			expectedNamespaces: []string{"ns1"},
+		},
+		{
+			name:         "strict",
+			globalConfig: "strictNamespaceListing: true",
+			expectError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+			cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: tt.globalConfig}}
+			assert.NoError(t, config.PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			client := fakepipelineclientset.NewSimpleClientset(&pipelinev1.PipelineRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "ns1"},
+			})
+			client.PrependReactor("list", "pipelineruns", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if action.GetNamespace() == "ns2" {
+					return true, nil, errors.New("list failed")
+				}
+				return false, nil, nil
+			})
+			prFuncs := NewPrFuncs(client, nil, "")
+
+			result, err := prFuncs.ListByNamespaces(ctx, []string{"ns1", "ns2"})
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			var namespaces []string
+			for ns := range result {
+				namespaces = append(namespaces, ns)
+			}
+			assert.ElementsMatch(t, tt.expectedNamespaces, namespaces)
+		})
+	}
+}
+
 func TestUpdate(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -333,7 +412,7 @@ func TestUpdate(t *testing.T) {
 
 			// Create fake client with PipelineRun
 			client := fakepipelineclientset.NewSimpleClientset(tt.pr)
-			prFuncs := NewPrFuncs(client)
+			prFuncs := NewPrFuncs(client, nil, "")
 
 			// Apply update function
 			if tt.updateFunc != nil {
@@ -400,7 +479,7 @@ func TestGetCompletionTime(t *testing.T) {
 			ctx = logging.WithLogger(ctx, logger)
 
 			client := fakepipelineclientset.NewSimpleClientset(tt.pr)
-			prFuncs := NewPrFuncs(client)
+			prFuncs := NewPrFuncs(client, nil, "")
 
 			// Call GetCompletionTime
 			completionTime, err := prFuncs.GetCompletionTime(tt.pr)
@@ -469,7 +548,7 @@ func TestIgnore(t *testing.T) {
 			ctx = logging.WithLogger(ctx, logger)
 
 			client := fakepipelineclientset.NewSimpleClientset(tt.pr)
-			prFuncs := NewPrFuncs(client)
+			prFuncs := NewPrFuncs(client, nil, "")
 
 			// Call Ignore (cast to metav1.Object)
 			result := prFuncs.Ignore(metav1.Object(tt.pr))
@@ -478,3 +557,28 @@ func TestIgnore(t *testing.T) {
 		})
 	}
 }
+
+// TestDeleteSlowClient verifies that a Delete call hanging past apiCallTimeoutSeconds fails, instead of blocking the worker
+func TestDeleteSlowClient(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+	cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: "apiCallTimeoutSeconds: 1"}}
+	assert.NoError(t, config.PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+	defer func() {
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	client := fakepipelineclientset.NewSimpleClientset()
+	// the fake client ignores the context, the reactor hangs until the test ends
+	release := make(chan struct{})
+	defer close(release)
+	client.PrependReactor("delete", "pipelineruns", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		<-release
+		return true, nil, nil
+	})
+	prFuncs := NewPrFuncs(client, nil, "")
+
+	start := time.Now()
+	err := prFuncs.Delete(ctx, "default", "slow-run")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
@@ -14,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	clocktest "k8s.io/utils/clock/testing"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
@@ -93,6 +94,135 @@ func TestPrFuncs_IsCompleted(t *testing.T) {
 	}
 }
 
+func TestPrFuncs_IsSuccessful(t *testing.T) {
+	newRun := func(reason string) *pipelinev1.PipelineRun {
+		return &pipelinev1.PipelineRun{
+			Status: pipelinev1.PipelineRunStatus{
+				Status: duckv1.Status{
+					Conditions: []apis.Condition{{
+						Type:   apis.ConditionSucceeded,
+						Status: corev1.ConditionTrue,
+						Reason: reason,
+					}},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name              string
+		successfulReasons string
+		reason            string
+		wantSuccessful    bool
+	}{
+		{
+			name:           "default reasons - succeeded",
+			reason:         "Succeeded",
+			wantSuccessful: true,
+		},
+		{
+			name:           "default reasons - failed",
+			reason:         "Failed",
+			wantSuccessful: false,
+		},
+		{
+			name:              "configured reasons - listed reason",
+			successfulReasons: "successfulReasons: {pipelineRuns: [Failed]}",
+			reason:            "Failed",
+			wantSuccessful:    true,
+		},
+		{
+			name:              "configured reasons - unlisted reason",
+			successfulReasons: "successfulReasons: {pipelineRuns: [Failed]}",
+			reason:            "Succeeded",
+			wantSuccessful:    false,
+		},
+		{
+			name:              "reasons configured for the other resource type",
+			successfulReasons: "successfulReasons: {taskRuns: [Failed]}",
+			reason:            "Failed",
+			wantSuccessful:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+			cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: tt.successfulReasons}}
+			if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			funcs := &PrFuncs{client: fakepipelineclientset.NewSimpleClientset()}
+			pipelineRun := newRun(tt.reason)
+			if got := funcs.IsSuccessful(pipelineRun); got != tt.wantSuccessful {
+				t.Errorf("PrFuncs.IsSuccessful() = %v, want %v", got, tt.wantSuccessful)
+			}
+			if got := funcs.IsFailed(pipelineRun); got == tt.wantSuccessful {
+				t.Errorf("PrFuncs.IsFailed() = %v, want %v", got, !tt.wantSuccessful)
+			}
+		})
+	}
+}
+
+// TestPrFuncs_ConditionType verifies that the completion of a run is read from the configured condition type
+func TestPrFuncs_ConditionType(t *testing.T) {
+	transitionTime := apis.VolatileTime{Inner: metav1.Time{Time: time.Now().Add(-time.Hour).Truncate(time.Second)}}
+	run := &pipelinev1.PipelineRun{
+		Status: pipelinev1.PipelineRunStatus{
+			Status: duckv1.Status{
+				Conditions: []apis.Condition{
+					{Type: apis.ConditionSucceeded, Status: corev1.ConditionUnknown, Reason: "Running"},
+					{Type: "Done", Status: corev1.ConditionTrue, Reason: "Succeeded", LastTransitionTime: transitionTime},
+				},
+			},
+			PipelineRunStatusFields: pipelinev1.PipelineRunStatusFields{
+				StartTime: &metav1.Time{Time: time.Now().Add(-2 * time.Hour)},
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		conditionType  apis.ConditionType
+		wantCompleted  bool
+		wantSuccessful bool
+	}{
+		{
+			name: "default condition type",
+		},
+		{
+			name:           "custom condition type",
+			conditionType:  "Done",
+			wantCompleted:  true,
+			wantSuccessful: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			funcs := NewPrFuncs(fakepipelineclientset.NewSimpleClientset(), nil, tt.conditionType)
+			if got := funcs.IsCompleted(run); got != tt.wantCompleted {
+				t.Errorf("PrFuncs.IsCompleted() = %v, want %v", got, tt.wantCompleted)
+			}
+			if got := funcs.IsSuccessful(run); got != tt.wantSuccessful {
+				t.Errorf("PrFuncs.IsSuccessful() = %v, want %v", got, tt.wantSuccessful)
+			}
+			completionTime, err := funcs.GetCompletionTime(run)
+			if tt.wantCompleted {
+				if err != nil || !completionTime.Equal(&transitionTime.Inner) {
+					t.Errorf("PrFuncs.GetCompletionTime() = %v, %v, want %v", completionTime, err, transitionTime.Inner)
+				}
+			} else if err == nil {
+				t.Errorf("PrFuncs.GetCompletionTime() expected an error for a run which is not completed")
+			}
+		})
+	}
+}
+
 func TestPrFuncs_Ignore(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -264,3 +394,154 @@ failedHistoryLimit: %d`, tt.ttl, tt.successLimit, tt.failureLimit),
 		})
 	}
 }
+
+// TestPrFuncs_FieldManager verifies that the field manager is set on the patches and updates of PipelineRuns
+func TestPrFuncs_FieldManager(t *testing.T) {
+	t.Setenv(config.EnvFieldManager, "policy-compliant-pruner")
+
+	pr := &pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: "test-pr", Namespace: "default"}}
+	client := fakepipelineclientset.NewSimpleClientset(pr)
+	funcs := &PrFuncs{client: client}
+	ctx := context.Background()
+
+	if err := funcs.Patch(ctx, "default", "test-pr", []byte(`[{"op":"add","path":"/metadata/annotations","value":{"a":"b"}}]`)); err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if err := funcs.Update(ctx, pr); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	for _, action := range client.Actions() {
+		var fieldManager string
+		switch a := action.(type) {
+		case k8stesting.PatchActionImpl:
+			fieldManager = a.PatchOptions.FieldManager
+		case k8stesting.UpdateActionImpl:
+			fieldManager = a.UpdateOptions.FieldManager
+		default:
+			continue
+		}
+		if fieldManager != "policy-compliant-pruner" {
+			t.Errorf("%s field manager = %q, want %q", action.GetVerb(), fieldManager, "policy-compliant-pruner")
+		}
+	}
+}
+
+// TestPrFuncs_DeleteLeftoverPods verifies that deleting a PipelineRun deletes its leftover pods when enabled
+func TestPrFuncs_DeleteLeftoverPods(t *testing.T) {
+	ctx := context.Background()
+	cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: `deleteLeftoverPods: true`}}
+	if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+		t.Fatalf("LoadGlobalConfig() error = %v", err)
+	}
+	defer func() {
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	pr := &pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: "test-pr", Namespace: "default"}}
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pr-build-pod", Namespace: "default", Labels: map[string]string{config.LabelPipelineRunName: "test-pr"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other-pr-build-pod", Namespace: "default", Labels: map[string]string{config.LabelPipelineRunName: "other-pr"}}},
+	)
+	funcs := NewPrFuncs(fakepipelineclientset.NewSimpleClientset(pr), kubeClient, "")
+
+	if err := funcs.Delete(ctx, "default", "test-pr"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	pods, err := kubeClient.CoreV1().Pods("default").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(pods.Items) != 1 || pods.Items[0].Name != "other-pr-build-pod" {
+		t.Errorf("remaining pods = %v, want only other-pr-build-pod", pods.Items)
+	}
+}
+
+func TestPrFuncs_IsCancelled(t *testing.T) {
+	tests := []struct {
+		name          string
+		reason        string
+		wantCancelled bool
+	}{
+		{name: "cancelled", reason: pipelinev1.PipelineRunReasonCancelled.String(), wantCancelled: true},
+		{name: "failed", reason: pipelinev1.PipelineRunReasonFailed.String(), wantCancelled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := &pipelinev1.PipelineRun{
+				Status: pipelinev1.PipelineRunStatus{
+					Status: duckv1.Status{
+						Conditions: []apis.Condition{{
+							Type:   apis.ConditionSucceeded,
+							Status: corev1.ConditionFalse,
+							Reason: tt.reason,
+						}},
+					},
+				},
+			}
+			funcs := &PrFuncs{}
+			if got := funcs.IsCancelled(run); got != tt.wantCancelled {
+				t.Errorf("PrFuncs.IsCancelled() = %v, want %v", got, tt.wantCancelled)
+			}
+		})
+	}
+}
+
+// TestPrFuncs_HasResults verifies that a PipelineRun has results only when it emitted pipeline results
+func TestPrFuncs_HasResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource metav1.Object
+		want     bool
+	}{
+		{
+			name: "pipeline results",
+			resource: &pipelinev1.PipelineRun{Status: pipelinev1.PipelineRunStatus{PipelineRunStatusFields: pipelinev1.PipelineRunStatusFields{
+				Results: []pipelinev1.PipelineRunResult{{Name: "digest", Value: *pipelinev1.NewStructuredValues("sha256:abc")}},
+			}}},
+			want: true,
+		},
+		{name: "no results", resource: &pipelinev1.PipelineRun{}, want: false},
+		{name: "not a PipelineRun", resource: &pipelinev1.TaskRun{}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			funcs := &PrFuncs{}
+			if got := funcs.HasResults(tt.resource); got != tt.want {
+				t.Errorf("PrFuncs.HasResults() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPrFuncs_GetDefaultLabelKey verifies that the first of the pipelineRunLabelKeys of the global config overrides the default label key
+func TestPrFuncs_GetDefaultLabelKey(t *testing.T) {
+	tests := []struct {
+		name         string
+		globalConfig string
+		want         string
+	}{
+		{name: "tekton default", want: config.LabelPipelineName},
+		{name: "overridden label key", globalConfig: "pipelineRunLabelKeys: [example.com/name, tekton.dev/pipeline]", want: "example.com/name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("LoadGlobalConfig() error = %v", err)
+			}
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			if got := (&PrFuncs{}).GetDefaultLabelKey(); got != tt.want {
+				t.Errorf("GetDefaultLabelKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
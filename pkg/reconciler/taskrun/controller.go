@@ -12,6 +12,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/clock"
+	"knative.dev/pkg/apis"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
@@ -29,7 +30,10 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 	logger := logging.FromContext(ctx)
 
 	taskRunFuncs := &TrFuncs{
-		client: pipelineclient.Get(ctx),
+		client:        pipelineclient.Get(ctx),
+		kubeclient:    kubeclient.Get(ctx),
+		conditionType: apis.ConditionSucceeded,
+		lister:        taskRunInformer.Lister(),
 	}
 	ttlHandler, err := config.NewTTLHandler(clock.RealClock{}, taskRunFuncs)
 	if err != nil {
@@ -40,6 +44,7 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 	if err != nil {
 		logger.Fatal("error on getting history limiter", zap.Error(err))
 	}
+	ttlHandler.SetHistoryLimiter(historyLimiter)
 
 	r := &Reconciler{
 		// The client will be needed to create/delete Pods via the API.
@@ -88,22 +93,21 @@ func filterTaskRun(logger *zap.SugaredLogger, impl *controller.Impl) func(obj in
 	}
 }
 
-// returns true if the TaskRun is part of a PipelineRun
+// isStandaloneTaskRun returns false if the TaskRun is part of a PipelineRun, such TaskRuns are pruned with their PipelineRun
 func isStandaloneTaskRun(taskRun metav1.Object) bool {
 	// verify the taskRun is not part of a pipelineRun
-	if taskRun.GetLabels() != nil && taskRun.GetLabels()[config.LabelPipelineRunName] != "" {
+	labels := taskRun.GetLabels()
+	if labels[config.LabelPipelineRunName] != "" {
 		return false
 	}
 
-	// if the resource has owner reference as PipelineRun, it is not a standalone TaskRun
-	// if so, ignore this taskRun
-	if len(taskRun.GetOwnerReferences()) > 0 {
-		for _, ownerReference := range taskRun.GetOwnerReferences() {
-			if ownerReference.Kind == config.KindPipelineRun {
-				return false
-			}
-		}
+	// a TaskRun created for a pipeline task, e.g. one of the TaskRuns of a matrix fan-out, belongs to its PipelineRun
+	// even when it lost the other labels, pruning it on its own would corrupt a PipelineRun still running
+	if labels[config.LabelPipelineTaskName] != "" {
+		return false
 	}
 
-	return true
+	// if the resource has owner reference as PipelineRun, or as one of the configured parent kinds,
+	// it is not a standalone TaskRun, if so, ignore this taskRun
+	return !config.HasTaskRunParent(taskRun)
 }
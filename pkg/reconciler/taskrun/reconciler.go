@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/types"
@@ -12,10 +13,12 @@ import (
 	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	pipelineversioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	taskrunreconciler "github.com/tektoncd/pipeline/pkg/client/injection/reconciler/pipeline/v1/taskrun"
+	pipelinev1listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1"
 	"github.com/tektoncd/pruner/pkg/config"
 	"github.com/tektoncd/pruner/pkg/metrics"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"knative.dev/pkg/apis"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
@@ -30,11 +33,26 @@ type Reconciler struct {
 	historyLimiter *config.HistoryLimiter
 }
 
+// defaultSuccessfulReasons holds the condition reasons of a TaskRun treated as successful,
+// when successfulReasons is not configured
+var defaultSuccessfulReasons = []string{
+	pipelinev1.TaskRunReasonSuccessful.String(),
+}
+
 // Check that our Reconciler implements Interface
 var _ taskrunreconciler.Interface = (*Reconciler)(nil)
 
 // ReconcileKind implements Interface.ReconcileKind.
-func (r *Reconciler) ReconcileKind(ctx context.Context, tr *pipelinev1.TaskRun) reconciler.Event {
+func (r *Reconciler) ReconcileKind(ctx context.Context, tr *pipelinev1.TaskRun) (event reconciler.Event) {
+	ctx, span := metrics.StartSpan(ctx, "TaskRun.ReconcileKind", metrics.SpanAttributes(config.KindTaskRun, tr.Namespace, tr.Name)...)
+	defer func() {
+		if isRequeueKey, _ := controller.IsRequeueKey(event); isRequeueKey {
+			metrics.EndSpan(span, nil)
+			return
+		}
+		metrics.EndSpan(span, event)
+	}()
+
 	logger := logging.FromContext(ctx)
 	logger.Debugw("received a TaskRun event",
 		"namespace", tr.Namespace, "name", tr.Name,
@@ -43,6 +61,7 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, tr *pipelinev1.TaskRun)
 	// if the TaskRun is not a standalone, no action needed
 	// if so, will be handled by it is parent resource(PipelineRun)
 	if !isStandaloneTaskRun(tr) {
+		metrics.GetRecorder().RecordEventSkipped(ctx, metrics.ResourceTypeTaskRun, tr.Namespace, metrics.SkipReasonNonStandalone)
 		return nil
 	}
 
@@ -84,9 +103,12 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, tr *pipelinev1.TaskRun)
 	ttlTimer.RecordTTLProcessingDuration(ctx)
 
 	if err != nil {
-		isRequeueKey, _ := controller.IsRequeueKey(err)
-		// the error is not a requeue error, print the error
-		if !isRequeueKey {
+		isRequeueKey, requeueDelay := controller.IsRequeueKey(err)
+		if isRequeueKey {
+			// the run waits for its TTL to expire
+			metricsRecorder.RecordRequeue(ctx, metrics.ResourceTypeTaskRun, requeueDelay)
+		} else {
+			// the error is not a requeue error, print the error
 			status = metrics.StatusError
 			errorType := metrics.ClassifyError(err)
 			metricsRecorder.RecordResourceError(ctx, metrics.ResourceTypeTaskRun, tr.Namespace, errorType, "ttl_processing_failed")
@@ -107,6 +129,12 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, tr *pipelinev1.TaskRun)
 // it contains a client to interact with the pipeline API and manage TaskRuns
 type TrFuncs struct {
 	client pipelineversioned.Interface
+	// kubeclient is used to delete the leftover pods of the deleted TaskRuns, it can be nil
+	kubeclient kubernetes.Interface
+	// conditionType is the type of the condition reporting the completion of a run, Succeeded if empty
+	conditionType apis.ConditionType
+	// lister lists the TaskRuns of all the namespaces from the informer cache for ListByNamespaces, the client is used if nil
+	lister pipelinev1listers.TaskRunLister
 }
 
 // Type returns the kind of resource represented by the TaskRunFuncs struct, which is "TaskRun".
@@ -116,14 +144,26 @@ func (trf *TrFuncs) Type() string {
 
 // NewTrFuncs creates a new instance of TrFuncs with the provided pipeline client.
 // This client is used to interact with the Tekton pipeline API.
-func NewTrFuncs(client pipelineversioned.Interface) *TrFuncs {
-	return &TrFuncs{client: client}
+// The optional kube client is used to delete the leftover pods of the deleted runs.
+// The condition type reports the completion of a run, an empty one defaults to Succeeded.
+func NewTrFuncs(client pipelineversioned.Interface, kubeClient kubernetes.Interface, conditionType apis.ConditionType) *TrFuncs {
+	return &TrFuncs{client: client, kubeclient: kubeClient, conditionType: conditionType}
+}
+
+// completionConditionType returns the type of the condition reporting the completion of a run
+func (trf *TrFuncs) completionConditionType() apis.ConditionType {
+	if trf.conditionType == "" {
+		return apis.ConditionSucceeded
+	}
+	return trf.conditionType
 }
 
 // List returns a list of TaskRuns in a given namespace with a label selector.
 func (trf *TrFuncs) List(ctx context.Context, namespace, labelSelector string) ([]metav1.Object, error) {
 	// TODO: should we have to implement pagination support?
-	prsList, err := trf.client.TektonV1().TaskRuns(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	prsList, err := config.ListWithRetry(ctx, func() (*pipelinev1.TaskRunList, error) {
+		return trf.client.TektonV1().TaskRuns(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +180,9 @@ func (trf *TrFuncs) ListByLabels(ctx context.Context, namespace string, labels m
 	logger := logging.FromContext(ctx)
 	selector := metav1.FormatLabelSelector(&metav1.LabelSelector{MatchLabels: labels})
 
-	trsList, err := trf.client.TektonV1().TaskRuns(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	trsList, err := config.ListWithRetry(ctx, func() (*pipelinev1.TaskRunList, error) {
+		return trf.client.TektonV1().TaskRuns(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -183,13 +225,25 @@ func (trf *TrFuncs) ListByAnnotations(ctx context.Context, namespace string, ann
 }
 
 // ListByNamespaces returns a list of TaskRuns across multiple namespaces.
+// The namespaces which cannot be listed are left out, unless strictNamespaceListing is set, then an error is returned.
+// With a lister, the TaskRuns are read from the informer cache, and must not be modified.
 func (trf *TrFuncs) ListByNamespaces(ctx context.Context, namespaces []string) (map[string][]metav1.Object, error) {
 	logger := logging.FromContext(ctx)
 	results := make(map[string][]metav1.Object)
 
 	for _, ns := range namespaces {
-		trs, err := trf.List(ctx, ns, "")
+		var trs []metav1.Object
+		var err error
+		if trf.lister != nil {
+			trs, err = trf.listCached(ns)
+		} else {
+			trs, err = trf.List(ctx, ns, "")
+		}
 		if err != nil {
+			metrics.GetRecorder().RecordPartialListFailure(ctx, metrics.ResourceTypeTaskRun, ns)
+			if config.PrunerConfigStore.GetStrictNamespaceListing() {
+				return nil, fmt.Errorf("failed to list TaskRuns in namespace %q: %w", ns, err)
+			}
 			logger.Errorw("Failed to list TaskRuns", "namespace", ns, "error", err)
 			continue
 		}
@@ -201,6 +255,25 @@ func (trf *TrFuncs) ListByNamespaces(ctx context.Context, namespaces []string) (
 	return results, nil
 }
 
+// listCached returns the TaskRuns of a namespace, or of all the namespaces, from the informer cache
+func (trf *TrFuncs) listCached(namespace string) ([]metav1.Object, error) {
+	var trs []*pipelinev1.TaskRun
+	var err error
+	if namespace == metav1.NamespaceAll {
+		trs, err = trf.lister.List(labels.Everything())
+	} else {
+		trs, err = trf.lister.TaskRuns(namespace).List(labels.Everything())
+	}
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]metav1.Object, 0, len(trs))
+	for _, run := range trs {
+		objects = append(objects, run)
+	}
+	return objects, nil
+}
+
 /*
 // List returns a list of TaskRuns in a given namespace with label and annotation selectors.
 // Annotations take higher priority. If annotations match, labels are ignored for that resource.
@@ -298,12 +371,30 @@ func (trf *TrFuncs) List(ctx context.Context, namespace string, annotations inte
 
 // Get retrieves a specific TaskRun by name in the given namespace.
 func (trf *TrFuncs) Get(ctx context.Context, namespace, name string) (metav1.Object, error) {
-	return trf.client.TektonV1().TaskRuns(namespace).Get(ctx, name, metav1.GetOptions{})
+	var tr *pipelinev1.TaskRun
+	err := config.CallWithTimeout(ctx, metrics.ResourceTypeTaskRun, namespace, func(ctx context.Context) (err error) {
+		tr, err = trf.client.TektonV1().TaskRuns(namespace).Get(ctx, name, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tr, nil
 }
 
 // Delete removes a specific TaskRun by name in the given namespace.
 func (trf *TrFuncs) Delete(ctx context.Context, namespace, name string) error {
-	return trf.client.TektonV1().TaskRuns(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err := config.CallWithTimeout(ctx, metrics.ResourceTypeTaskRun, namespace, func(ctx context.Context) error {
+		return trf.client.TektonV1().TaskRuns(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	})
+	if err != nil {
+		return err
+	}
+	// failing to delete the leftover pods does not fail the deletion of the TaskRun
+	if err := config.DeleteLeftoverPods(ctx, trf.kubeclient, metrics.ResourceTypeTaskRun, namespace, config.LabelTaskRunName, name); err != nil {
+		logging.FromContext(ctx).Warnw("error deleting the leftover pods of a TaskRun", "namespace", namespace, "name", name, zap.Error(err))
+	}
+	return nil
 }
 
 // Update modifies an existing TaskRun resource.
@@ -312,20 +403,25 @@ func (trf *TrFuncs) Update(ctx context.Context, resource metav1.Object) error {
 	if !ok {
 		return fmt.Errorf("invalid type received. namespace:%s, Name:%s", resource.GetNamespace(), resource.GetName())
 	}
-	_, err := trf.client.TektonV1().TaskRuns(resource.GetNamespace()).Update(ctx, tr, metav1.UpdateOptions{})
-	return err
+	return config.CallWithTimeout(ctx, metrics.ResourceTypeTaskRun, resource.GetNamespace(), func(ctx context.Context) error {
+		_, err := trf.client.TektonV1().TaskRuns(resource.GetNamespace()).Update(ctx, tr, metav1.UpdateOptions{FieldManager: config.GetFieldManager()})
+		return err
+	})
 }
 
-// Patch modifies an existing TaskRun resource using a JSON patch.
+// Patch modifies an existing TaskRun resource using a JSON Patch, as created by config.AnnotationPatch.
 // This is useful for updating only specific fields of the resource.
 func (trf *TrFuncs) Patch(ctx context.Context, namespace, name string, patchBytes []byte) error {
-	_, err := trf.client.TektonV1().TaskRuns(namespace).Patch(
-		ctx,
-		name,
-		types.MergePatchType,
-		patchBytes,
-		metav1.PatchOptions{},
-	)
+	err := config.CallWithTimeout(ctx, metrics.ResourceTypeTaskRun, namespace, func(ctx context.Context) error {
+		_, err := trf.client.TektonV1().TaskRuns(namespace).Patch(
+			ctx,
+			name,
+			types.JSONPatchType,
+			patchBytes,
+			metav1.PatchOptions{FieldManager: config.GetFieldManager()},
+		)
+		return err
+	})
 
 	if err != nil {
 		return fmt.Errorf("failed to patch TaskRun %s/%s: %w", namespace, name, err)
@@ -346,7 +442,7 @@ func (trf *TrFuncs) GetCompletionTime(resource metav1.Object) (metav1.Time, erro
 	}
 
 	// check the status from conditions
-	condition := tr.Status.GetCondition(apis.ConditionSucceeded)
+	condition := tr.Status.GetCondition(trf.completionConditionType())
 	if condition != nil && condition.Status != corev1.ConditionUnknown {
 		finishAt := condition.LastTransitionTime
 		if finishAt.Inner.IsZero() {
@@ -359,6 +455,29 @@ func (trf *TrFuncs) GetCompletionTime(resource metav1.Object) (metav1.Time, erro
 	return metav1.Time{}, fmt.Errorf("unable to find the status of the finished resource: %s/%s", tr.Namespace, tr.Name)
 }
 
+// GetStartTime retrieves the start time of a TaskRun resource.
+func (trf *TrFuncs) GetStartTime(resource metav1.Object) (metav1.Time, error) {
+	tr, ok := resource.(*pipelinev1.TaskRun)
+	if !ok {
+		return metav1.Time{}, fmt.Errorf("resource type error, this is not a TaskRun resource. namespace:%s, name:%s, type:%T",
+			resource.GetNamespace(), resource.GetName(), resource)
+	}
+	if tr.Status.StartTime == nil {
+		return metav1.Time{}, fmt.Errorf("the resource '%s/%s' has not started", tr.Namespace, tr.Name)
+	}
+	return *tr.Status.StartTime, nil
+}
+
+// HasResults checks if the TaskRun resource emitted results or output artifacts.
+// A resource which is not a TaskRun is reported to have results.
+func (trf *TrFuncs) HasResults(resource metav1.Object) bool {
+	tr, ok := resource.(*pipelinev1.TaskRun)
+	if !ok {
+		return true
+	}
+	return len(tr.Status.Results) > 0 || (tr.Status.Artifacts != nil && len(tr.Status.Artifacts.Outputs) > 0)
+}
+
 // Ignore returns true if the resource should be ignored based on labels and annotations.
 func (trf *TrFuncs) Ignore(resource metav1.Object) bool {
 	// labels and annotations are not populated, lets wait sometime
@@ -386,7 +505,7 @@ func (trf *TrFuncs) IsCompleted(resource metav1.Object) bool {
 	}
 
 	// check the status from conditions
-	condition := tr.Status.GetCondition(apis.ConditionSucceeded)
+	condition := tr.Status.GetCondition(trf.completionConditionType())
 	if condition == nil || condition.Status == corev1.ConditionUnknown {
 		return false
 	}
@@ -401,13 +520,17 @@ func (trf *TrFuncs) IsSuccessful(resource metav1.Object) bool {
 		return false
 	}
 
-	condition := tr.Status.GetCondition(apis.ConditionSucceeded)
+	condition := tr.Status.GetCondition(trf.completionConditionType())
 	if condition == nil {
 		return false
 	}
 
-	runReason := pipelinev1.TaskRunReason(condition.Reason)
-	return runReason == pipelinev1.TaskRunReasonSuccessful
+	successfulReasons := config.PrunerConfigStore.GetSuccessfulReasons(tr.Namespace, config.KindTaskRun)
+	if len(successfulReasons) == 0 {
+		successfulReasons = defaultSuccessfulReasons
+	}
+
+	return slices.Contains(successfulReasons, condition.Reason)
 }
 
 // IsFailed checks if the TaskRun resource has failed.
@@ -420,9 +543,25 @@ func (trf *TrFuncs) IsFailed(resource metav1.Object) bool {
 	return !trf.IsSuccessful(resource)
 }
 
-// GetDefaultLabelKey returns the default label key for TaskRun resources.
+// IsCancelled checks if the TaskRun resource completed on its cancellation.
+func (trf *TrFuncs) IsCancelled(resource metav1.Object) bool {
+	tr, ok := resource.(*pipelinev1.TaskRun)
+	if !ok {
+		return false
+	}
+
+	condition := tr.Status.GetCondition(trf.completionConditionType())
+	if condition == nil {
+		return false
+	}
+
+	return condition.Reason == pipelinev1.TaskRunReasonCancelled.String()
+}
+
+// GetDefaultLabelKey returns the default label key for TaskRun resources,
+// the first of the taskRunLabelKeys of the global config, if set.
 func (trf *TrFuncs) GetDefaultLabelKey() string {
-	return config.LabelTaskName
+	return config.PrunerConfigStore.GetDefaultLabelKey(config.KindTaskRun)
 }
 
 // GetTTLSecondsAfterFinished retrieves the TTL (time-to-live) in seconds after a TaskRun finishes.
@@ -8,15 +8,19 @@ import (
 	"github.com/stretchr/testify/assert"
 	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	fakepipelineclientset "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	pipelinev1listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1"
+	"github.com/tektoncd/pruner/pkg/config"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
 	"knative.dev/pkg/logging"
 	logtesting "knative.dev/pkg/logging/testing"
 )
 
 func TestNewTrFuncs(t *testing.T) {
 	client := fakepipelineclientset.NewSimpleClientset()
-	trFuncs := NewTrFuncs(client)
+	trFuncs := NewTrFuncs(client, nil, "")
 
 	assert.NotNil(t, trFuncs)
 	assert.NotNil(t, trFuncs.client)
@@ -131,7 +135,7 @@ func TestTaskRun_ListByLabels(t *testing.T) {
 				runtimeObjs = append(runtimeObjs, tr)
 			}
 			client := fakepipelineclientset.NewSimpleClientset(runtimeObjs...)
-			trFuncs := NewTrFuncs(client)
+			trFuncs := NewTrFuncs(client, nil, "")
 
 			// Call ListByLabels
 			result, err := trFuncs.ListByLabels(ctx, tt.namespace, tt.labels)
@@ -231,7 +235,7 @@ func TestTaskRun_ListByAnnotations(t *testing.T) {
 				runtimeObjs = append(runtimeObjs, tr)
 			}
 			client := fakepipelineclientset.NewSimpleClientset(runtimeObjs...)
-			trFuncs := NewTrFuncs(client)
+			trFuncs := NewTrFuncs(client, nil, "")
 
 			// Call ListByAnnotations
 			result, err := trFuncs.ListByAnnotations(ctx, tt.namespace, tt.annotations)
@@ -313,7 +317,7 @@ func TestTaskRun_ListByNamespaces(t *testing.T) {
 				runtimeObjs = append(runtimeObjs, tr)
 			}
 			client := fakepipelineclientset.NewSimpleClientset(runtimeObjs...)
-			trFuncs := NewTrFuncs(client)
+			trFuncs := NewTrFuncs(client, nil, "")
 
 			// Call ListByNamespaces
 			result, err := trFuncs.ListByNamespaces(ctx, tt.namespaces)
@@ -330,6 +334,26 @@ func TestTaskRun_ListByNamespaces(t *testing.T) {
 	}
 }
 
+// TestTaskRun_ListByNamespacesLister verifies that the TaskRuns are read from the lister, without listing them from the API server
+func TestTaskRun_ListByNamespacesLister(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, tr := range []*pipelinev1.TaskRun{
+		{ObjectMeta: metav1.ObjectMeta{Name: "tr-1", Namespace: "ns1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "tr-2", Namespace: "ns2"}},
+	} {
+		assert.NoError(t, indexer.Add(tr))
+	}
+	client := fakepipelineclientset.NewSimpleClientset()
+	trFuncs := &TrFuncs{client: client, lister: pipelinev1listers.NewTaskRunLister(indexer)}
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+
+	result, err := trFuncs.ListByNamespaces(ctx, []string{metav1.NamespaceAll, "ns2"})
+	assert.NoError(t, err)
+	assert.Len(t, result[metav1.NamespaceAll], 2)
+	assert.Len(t, result["ns2"], 1)
+	assert.Empty(t, client.Actions())
+}
+
 func TestTaskRun_Update(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -379,7 +403,7 @@ func TestTaskRun_Update(t *testing.T) {
 
 			// Create fake client with TaskRun
 			client := fakepipelineclientset.NewSimpleClientset(tt.tr)
-			trFuncs := NewTrFuncs(client)
+			trFuncs := NewTrFuncs(client, nil, "")
 
 			// Apply update function
 			if tt.updateFunc != nil {
@@ -446,7 +470,7 @@ func TestTaskRun_GetCompletionTime(t *testing.T) {
 			ctx = logging.WithLogger(ctx, logger)
 
 			client := fakepipelineclientset.NewSimpleClientset(tt.tr)
-			trFuncs := NewTrFuncs(client)
+			trFuncs := NewTrFuncs(client, nil, "")
 
 			// Call GetCompletionTime
 			completionTime, err := trFuncs.GetCompletionTime(tt.tr)
@@ -513,6 +537,39 @@ func TestIsStandaloneTaskRun(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "Matrix child TaskRun of a running PipelineRun",
+			tr: &pipelinev1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pr-1-build-2",
+					Namespace: "default",
+					Labels: map[string]string{
+						"tekton.dev/pipelineRun":  "pr-1",
+						"tekton.dev/pipelineTask": "build",
+						"tekton.dev/memberOf":     "tasks",
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: "tekton.dev/v1",
+							Kind:       "PipelineRun",
+							Name:       "pr-1",
+						},
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "Matrix child TaskRun with only the pipeline task label",
+			tr: &pipelinev1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pr-1-build-3",
+					Namespace: "default",
+					Labels:    map[string]string{"tekton.dev/pipelineTask": "build"},
+				},
+			},
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -523,6 +580,35 @@ func TestIsStandaloneTaskRun(t *testing.T) {
 	}
 }
 
+// TestIsStandaloneTaskRunParentKinds verifies that a TaskRun owned by one of the configured taskRunParentKinds
+// is not standalone, while PipelineRun stays a parent kind
+func TestIsStandaloneTaskRunParentKinds(t *testing.T) {
+	ctx := context.Background()
+	cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: `
+taskRunParentKinds:
+- StepActionRun
+- CustomRun`}}
+	assert.NoError(t, config.PrunerConfigStore.LoadGlobalConfig(ctx, cm))
+	defer func() {
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	ownedBy := func(kind string) *pipelinev1.TaskRun {
+		return &pipelinev1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "tr",
+				Namespace:       "default",
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "tekton.dev/v1", Kind: kind, Name: "owner"}},
+			},
+		}
+	}
+
+	assert.False(t, isStandaloneTaskRun(ownedBy("PipelineRun")))
+	assert.False(t, isStandaloneTaskRun(ownedBy("StepActionRun")))
+	assert.False(t, isStandaloneTaskRun(ownedBy("CustomRun")))
+	assert.True(t, isStandaloneTaskRun(ownedBy("Pod")))
+}
+
 func TestTaskRun_Ignore(t *testing.T) {
 	now := metav1.Now()
 	earlier := metav1.NewTime(now.Add(-1 * time.Hour))
@@ -598,7 +684,7 @@ func TestTaskRun_Ignore(t *testing.T) {
 			ctx = logging.WithLogger(ctx, logger)
 
 			client := fakepipelineclientset.NewSimpleClientset(tt.tr)
-			trFuncs := NewTrFuncs(client)
+			trFuncs := NewTrFuncs(client, nil, "")
 
 			// Call Ignore (cast to metav1.Object)
 			result := trFuncs.Ignore(metav1.Object(tt.tr))
@@ -15,6 +15,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	clocktest "k8s.io/utils/clock/testing"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
@@ -93,6 +94,135 @@ func TestTrFuncs_IsCompleted(t *testing.T) {
 	}
 }
 
+func TestTrFuncs_IsSuccessful(t *testing.T) {
+	newRun := func(reason string) *pipelinev1.TaskRun {
+		return &pipelinev1.TaskRun{
+			Status: pipelinev1.TaskRunStatus{
+				Status: duckv1.Status{
+					Conditions: []apis.Condition{{
+						Type:   apis.ConditionSucceeded,
+						Status: corev1.ConditionTrue,
+						Reason: reason,
+					}},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name              string
+		successfulReasons string
+		reason            string
+		wantSuccessful    bool
+	}{
+		{
+			name:           "default reasons - succeeded",
+			reason:         "Succeeded",
+			wantSuccessful: true,
+		},
+		{
+			name:           "default reasons - failed",
+			reason:         "Failed",
+			wantSuccessful: false,
+		},
+		{
+			name:              "configured reasons - listed reason",
+			successfulReasons: "successfulReasons: {taskRuns: [Failed]}",
+			reason:            "Failed",
+			wantSuccessful:    true,
+		},
+		{
+			name:              "configured reasons - unlisted reason",
+			successfulReasons: "successfulReasons: {taskRuns: [Failed]}",
+			reason:            "Succeeded",
+			wantSuccessful:    false,
+		},
+		{
+			name:              "reasons configured for the other resource type",
+			successfulReasons: "successfulReasons: {pipelineRuns: [Failed]}",
+			reason:            "Failed",
+			wantSuccessful:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar())
+			cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: tt.successfulReasons}}
+			if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			funcs := &TrFuncs{client: fakepipelineclientset.NewSimpleClientset()}
+			taskRun := newRun(tt.reason)
+			if got := funcs.IsSuccessful(taskRun); got != tt.wantSuccessful {
+				t.Errorf("TrFuncs.IsSuccessful() = %v, want %v", got, tt.wantSuccessful)
+			}
+			if got := funcs.IsFailed(taskRun); got == tt.wantSuccessful {
+				t.Errorf("TrFuncs.IsFailed() = %v, want %v", got, !tt.wantSuccessful)
+			}
+		})
+	}
+}
+
+// TestTrFuncs_ConditionType verifies that the completion of a run is read from the configured condition type
+func TestTrFuncs_ConditionType(t *testing.T) {
+	transitionTime := apis.VolatileTime{Inner: metav1.Time{Time: time.Now().Add(-time.Hour).Truncate(time.Second)}}
+	run := &pipelinev1.TaskRun{
+		Status: pipelinev1.TaskRunStatus{
+			Status: duckv1.Status{
+				Conditions: []apis.Condition{
+					{Type: apis.ConditionSucceeded, Status: corev1.ConditionUnknown, Reason: "Running"},
+					{Type: "Done", Status: corev1.ConditionTrue, Reason: "Succeeded", LastTransitionTime: transitionTime},
+				},
+			},
+			TaskRunStatusFields: pipelinev1.TaskRunStatusFields{
+				StartTime: &metav1.Time{Time: time.Now().Add(-2 * time.Hour)},
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		conditionType  apis.ConditionType
+		wantCompleted  bool
+		wantSuccessful bool
+	}{
+		{
+			name: "default condition type",
+		},
+		{
+			name:           "custom condition type",
+			conditionType:  "Done",
+			wantCompleted:  true,
+			wantSuccessful: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			funcs := NewTrFuncs(fakepipelineclientset.NewSimpleClientset(), nil, tt.conditionType)
+			if got := funcs.IsCompleted(run); got != tt.wantCompleted {
+				t.Errorf("TrFuncs.IsCompleted() = %v, want %v", got, tt.wantCompleted)
+			}
+			if got := funcs.IsSuccessful(run); got != tt.wantSuccessful {
+				t.Errorf("TrFuncs.IsSuccessful() = %v, want %v", got, tt.wantSuccessful)
+			}
+			completionTime, err := funcs.GetCompletionTime(run)
+			if tt.wantCompleted {
+				if err != nil || !completionTime.Equal(&transitionTime.Inner) {
+					t.Errorf("TrFuncs.GetCompletionTime() = %v, %v, want %v", completionTime, err, transitionTime.Inner)
+				}
+			} else if err == nil {
+				t.Errorf("TrFuncs.GetCompletionTime() expected an error for a run which is not completed")
+			}
+		})
+	}
+}
+
 func TestTrFuncs_Ignore(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -228,11 +358,11 @@ func TestReconciler_ProcessTaskRun(t *testing.T) {
 			failureLimit: 2,
 			wantDelete:   true,
 			setupAdditional: func(client *fakepipelineclientset.Clientset) {
-				// Add older successful TaskRuns with proper completion times
+				// Add successful TaskRuns which completed more recently, so tr-3 is the one beyond the limit
 				for i := 0; i < 2; i++ {
 					_, _ = client.TektonV1().TaskRuns("default").Create(context.Background(), &pipelinev1.TaskRun{
 						ObjectMeta: metav1.ObjectMeta{
-							Name:      fmt.Sprintf("old-success-%d", i),
+							Name:      fmt.Sprintf("recent-success-%d", i),
 							Namespace: "default",
 							Annotations: map[string]string{
 								config.AnnotationTTLSecondsAfterFinished: "3600",
@@ -241,7 +371,7 @@ func TestReconciler_ProcessTaskRun(t *testing.T) {
 						Status: pipelinev1.TaskRunStatus{
 							TaskRunStatusFields: pipelinev1.TaskRunStatusFields{
 								StartTime:      &metav1.Time{Time: fakeClock.Now().Add(-2 * time.Hour)},
-								CompletionTime: &metav1.Time{Time: fakeClock.Now().Add(-100 * time.Minute)},
+								CompletionTime: &metav1.Time{Time: fakeClock.Now().Add(-5 * time.Minute)},
 							},
 							Status: duckv1.Status{
 								Conditions: []apis.Condition{{
@@ -283,11 +413,11 @@ func TestReconciler_ProcessTaskRun(t *testing.T) {
 			failureLimit: 1,
 			wantDelete:   true,
 			setupAdditional: func(client *fakepipelineclientset.Clientset) {
-				// Add older failed TaskRuns with proper completion times
+				// Add failed TaskRuns which completed more recently, so tr-4 is the one beyond the limit
 				for i := 0; i < 2; i++ {
 					_, _ = client.TektonV1().TaskRuns("default").Create(context.Background(), &pipelinev1.TaskRun{
 						ObjectMeta: metav1.ObjectMeta{
-							Name:      fmt.Sprintf("old-failure-%d", i),
+							Name:      fmt.Sprintf("recent-failure-%d", i),
 							Namespace: "default",
 							Annotations: map[string]string{
 								config.AnnotationTTLSecondsAfterFinished: "3600",
@@ -296,7 +426,7 @@ func TestReconciler_ProcessTaskRun(t *testing.T) {
 						Status: pipelinev1.TaskRunStatus{
 							TaskRunStatusFields: pipelinev1.TaskRunStatusFields{
 								StartTime:      &metav1.Time{Time: fakeClock.Now().Add(-2 * time.Hour)},
-								CompletionTime: &metav1.Time{Time: fakeClock.Now().Add(-100 * time.Minute)},
+								CompletionTime: &metav1.Time{Time: fakeClock.Now().Add(-5 * time.Minute)},
 							},
 							Status: duckv1.Status{
 								Conditions: []apis.Condition{{
@@ -444,3 +574,137 @@ failedHistoryLimit: %d`, tt.ttl, tt.successLimit, tt.failureLimit),
 		})
 	}
 }
+
+// TestTrFuncs_FieldManager verifies that the field manager is set on the patches and updates of TaskRuns
+func TestTrFuncs_FieldManager(t *testing.T) {
+	t.Setenv(config.EnvFieldManager, "policy-compliant-pruner")
+
+	tr := &pipelinev1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "test-tr", Namespace: "default"}}
+	client := fakepipelineclientset.NewSimpleClientset(tr)
+	funcs := &TrFuncs{client: client}
+	ctx := context.Background()
+
+	if err := funcs.Patch(ctx, "default", "test-tr", []byte(`[{"op":"add","path":"/metadata/annotations","value":{"a":"b"}}]`)); err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if err := funcs.Update(ctx, tr); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	for _, action := range client.Actions() {
+		var fieldManager string
+		switch a := action.(type) {
+		case k8stesting.PatchActionImpl:
+			fieldManager = a.PatchOptions.FieldManager
+		case k8stesting.UpdateActionImpl:
+			fieldManager = a.UpdateOptions.FieldManager
+		default:
+			continue
+		}
+		if fieldManager != "policy-compliant-pruner" {
+			t.Errorf("%s field manager = %q, want %q", action.GetVerb(), fieldManager, "policy-compliant-pruner")
+		}
+	}
+}
+
+func TestTrFuncs_IsCancelled(t *testing.T) {
+	tests := []struct {
+		name          string
+		reason        string
+		wantCancelled bool
+	}{
+		{name: "cancelled", reason: pipelinev1.TaskRunReasonCancelled.String(), wantCancelled: true},
+		{name: "failed", reason: pipelinev1.TaskRunReasonFailed.String(), wantCancelled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := &pipelinev1.TaskRun{
+				Status: pipelinev1.TaskRunStatus{
+					Status: duckv1.Status{
+						Conditions: []apis.Condition{{
+							Type:   apis.ConditionSucceeded,
+							Status: corev1.ConditionFalse,
+							Reason: tt.reason,
+						}},
+					},
+				},
+			}
+			funcs := &TrFuncs{}
+			if got := funcs.IsCancelled(run); got != tt.wantCancelled {
+				t.Errorf("TrFuncs.IsCancelled() = %v, want %v", got, tt.wantCancelled)
+			}
+		})
+	}
+}
+
+// TestTrFuncs_HasResults verifies that a TaskRun has results when it emitted results or output artifacts
+func TestTrFuncs_HasResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource metav1.Object
+		want     bool
+	}{
+		{
+			name: "results",
+			resource: &pipelinev1.TaskRun{Status: pipelinev1.TaskRunStatus{TaskRunStatusFields: pipelinev1.TaskRunStatusFields{
+				Results: []pipelinev1.TaskRunResult{{Name: "digest", Value: *pipelinev1.NewStructuredValues("sha256:abc")}},
+			}}},
+			want: true,
+		},
+		{
+			name: "output artifacts",
+			resource: &pipelinev1.TaskRun{Status: pipelinev1.TaskRunStatus{TaskRunStatusFields: pipelinev1.TaskRunStatusFields{
+				Artifacts: &pipelinev1.Artifacts{Outputs: []pipelinev1.Artifact{{Name: "image"}}},
+			}}},
+			want: true,
+		},
+		{
+			name: "input artifacts only",
+			resource: &pipelinev1.TaskRun{Status: pipelinev1.TaskRunStatus{TaskRunStatusFields: pipelinev1.TaskRunStatusFields{
+				Artifacts: &pipelinev1.Artifacts{Inputs: []pipelinev1.Artifact{{Name: "source"}}},
+			}}},
+			want: false,
+		},
+		{name: "no results", resource: &pipelinev1.TaskRun{}, want: false},
+		{name: "not a TaskRun", resource: &pipelinev1.PipelineRun{}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			funcs := &TrFuncs{}
+			if got := funcs.HasResults(tt.resource); got != tt.want {
+				t.Errorf("TrFuncs.HasResults() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTrFuncs_GetDefaultLabelKey verifies that the first of the taskRunLabelKeys of the global config overrides the default label key
+func TestTrFuncs_GetDefaultLabelKey(t *testing.T) {
+	tests := []struct {
+		name         string
+		globalConfig string
+		want         string
+	}{
+		{name: "tekton default", want: config.LabelTaskName},
+		{name: "overridden label key", globalConfig: "taskRunLabelKeys: [example.com/name, tekton.dev/task]", want: "example.com/name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("LoadGlobalConfig() error = %v", err)
+			}
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			if got := (&TrFuncs{}).GetDefaultLabelKey(); got != tt.want {
+				t.Errorf("GetDefaultLabelKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,21 +1,28 @@
 package tektonpruner
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
-	"fmt"
+	goerrors "errors"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
+	"knative.dev/pkg/reconciler"
 	"knative.dev/pkg/system"
 
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
 	"github.com/tektoncd/pruner/pkg/config"
+	"github.com/tektoncd/pruner/pkg/metrics"
 	"github.com/tektoncd/pruner/pkg/reconciler/pipelinerun"
 	"github.com/tektoncd/pruner/pkg/reconciler/taskrun"
 	"github.com/tektoncd/pruner/pkg/version"
@@ -25,9 +32,13 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"knative.dev/pkg/apis"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	namespaceinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/namespace"
 )
 
 // NewController creates a Reconciler and returns the result of NewImpl.
@@ -49,6 +60,29 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 		kubeclient: kubeclient.Get(ctx),
 	}
 
+	// Missing permissions would otherwise only show up as errors scattered over the GC cycles
+	if mode := getRBACSelfCheck(ctx); mode != RBACSelfCheckOff {
+		if err := checkRBACPermissions(ctx, r.kubeclient, getNamespaceScope(ctx)); err != nil {
+			if mode == RBACSelfCheckBlock {
+				logger.Fatalw("RBAC self-check failed, fix the RBAC of the controller or run it with --rbac-self-check=warn", zap.Error(err))
+			}
+			logger.Errorw("RBAC self-check failed, pruning fails wherever the permissions are missing", zap.Error(err))
+		} else {
+			logger.Info("RBAC self-check passed")
+		}
+	}
+
+	// GC runs read the namespaces from the informer cache rather than listing them from the API server
+	ctx = withNamespaceLister(ctx, namespaceinformer.Get(ctx).Lister())
+	// The ConfigMap update that triggered GC may have been skipped while this replica was not the leader,
+	// so a newly elected leader runs GC once to catch up
+	r.PromoteFunc = func(bkt reconciler.Bucket, _ func(reconciler.Bucket, types.NamespacedName)) error {
+		if bkt.Has(gcLeaderKey()) {
+			go r.safeRunGarbageCollector(ctx, logger)
+		}
+		return nil
+	}
+
 	impl := controller.NewContext(ctx, r, controller.ControllerOptions{
 		Logger:        logger,
 		WorkQueueName: "pruner",
@@ -56,9 +90,17 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 
 	// ConfigMap watcher triggers GC
 	cmw.Watch(config.PrunerConfigMapName, func(cm *corev1.ConfigMap) {
-		go safeRunGarbageCollector(ctx, logger)
+		go r.safeRunGarbageCollector(ctx, logger)
 	})
 
+	// GC also runs periodically, when gcIntervalSeconds is configured
+	go r.runPeriodicGC(ctx, clockUtil.RealClock{}, logger)
+
+	// GC can also be triggered on demand, when the endpoint is enabled
+	if trigger, ok := getTriggerGC(ctx); ok {
+		go r.serveTriggerGC(ctx, trigger)
+	}
+
 	return impl
 }
 
@@ -68,19 +110,62 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 // serialized nothing and let cluster-wide sweeps run concurrently.
 var gcMutex sync.Mutex
 
+// gcLeaderKey returns the key whose bucket owner runs garbage collection.
+// With high availability disabled, the only replica owns every key.
+func gcLeaderKey() types.NamespacedName {
+	return types.NamespacedName{Namespace: system.Namespace(), Name: config.PrunerConfigMapName}
+}
+
 // safeRunGarbageCollector is a thread-safe wrapper around the garbage collection process.
-func safeRunGarbageCollector(ctx context.Context, logger *zap.SugaredLogger) {
+// It is a no-op unless this replica is the leader for garbage collection, it reports whether garbage collection ran.
+func (r *Reconciler) safeRunGarbageCollector(ctx context.Context, logger *zap.SugaredLogger) bool {
+	if !r.IsLeaderFor(gcLeaderKey()) {
+		logger.Debug("Skipping cleanup, not the leader")
+		return false
+	}
+
 	logger.Debug("Waiting to acquire cleanup thread lock")
 	gcMutex.Lock()
 	defer gcMutex.Unlock()
 
+	// Leadership may have been lost while waiting for the lock
+	if !r.IsLeaderFor(gcLeaderKey()) {
+		logger.Debug("Skipping cleanup, no longer the leader")
+		return false
+	}
+
 	logger.Info("Running Cleanup")
 	runGarbageCollector(ctx)
 	logger.Info("Cleanup thread completed")
+	dumpMetrics(ctx, logger)
+	return true
+}
+
+// dumpMetrics writes the current metrics snapshot to the dump file, if configured
+func dumpMetrics(ctx context.Context, logger *zap.SugaredLogger) {
+	path := getMetricsDumpFile(ctx)
+	if path == "" {
+		return
+	}
+	if err := metrics.GetRecorder().WriteSnapshot(path); err != nil {
+		logger.Errorw("Failed to dump the metrics snapshot", "path", path, zap.Error(err))
+	}
 }
 
 func runGarbageCollector(ctx context.Context) {
 	logger := logging.FromContext(ctx)
+	start := time.Now()
+	// the cycle is summarized in a single log line, on top of the summary returned by the trigger endpoint
+	summary := getGCSummary(ctx)
+	if summary == nil {
+		summary = &gcSummary{}
+		ctx = withGCSummary(ctx, summary)
+	}
+	// protecting resources are looked up at most once per cycle
+	ctx = config.WithProtectionCache(ctx)
+	// and the successful runs of the cluster are counted at most once for minSuccessfulToKeep
+	ctx = config.WithSuccessfulCounts(ctx)
+	ctx = withPrunedPipelineRuns(ctx)
 	kubeClient := kubeclient.Get(ctx)
 
 	namespace := system.Namespace()
@@ -89,20 +174,46 @@ func runGarbageCollector(ctx context.Context) {
 	configMap, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, config.PrunerConfigMapName, metav1.GetOptions{})
 	if err != nil {
 		logger.Error("Failed to load ConfigMap for GC", zap.Error(err))
+		summary.addError()
 		return
 	}
 
+	// the configs accepted by safe mode and promoted from the canary namespace survive restarts and leader changes in the state ConfigMap
+	state, stateErr := loadPrunerState(ctx, kubeClient)
+	if stateErr != nil {
+		logger.Errorw("Failed to load the pruner state", zap.Error(stateErr))
+	}
+
 	if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, configMap); err != nil {
 		logger.Error("Error loading pruner global config", zap.Error(err))
+		summary.addError()
 		return
 	}
 
-	configMapUpdateTime := time.Now().Format(time.RFC3339)
+	// a state which failed to load is not overwritten, so that it can be fixed
+	if stateErr == nil {
+		if err := recordPrunerState(ctx, kubeClient, state); err != nil {
+			logger.Errorw("Failed to record the pruner state", zap.Error(err))
+		}
+	}
+
+	// the runs are evaluated again by the history limiter when the config changed, not on every cycle
+	configMapUpdateTime := config.PrunerConfigStore.GetConfigUpdateTime().Format(time.RFC3339)
+
+	if until, frozen := config.PrunerConfigStore.GetFreezeUntil(time.Now()); frozen {
+		logger.Infow("Pruning is frozen, garbage collection deletes nothing until the freeze ends", "freezeUntil", until)
+	}
+
+	// Delete calls are capped cluster-wide, independently of the number of workers
+	if maxDeletions := config.PrunerConfigStore.GetMaxConcurrentDeletions(); maxDeletions != nil {
+		ctx = config.WithDeletionLimit(ctx, int(*maxDeletions))
+	}
 
 	// Get filtered namespaces
 	namespaces, err := getFilteredNamespaces(ctx, kubeClient)
 	if err != nil {
 		logger.Error("Failed to filter namespaces for GC", zap.Error(err))
+		summary.addError()
 		return
 	}
 
@@ -115,6 +226,44 @@ func runGarbageCollector(ctx context.Context) {
 		workerCount = config.DefaultWorkerCountForNamespaceCleanup
 	}
 
+	collectNamespaces(ctx, namespaces, gcWorkerCount(ctx, workerCount, len(namespaces)), configMapUpdateTime)
+
+	// Remote clusters are collected one after the other, with the global config of the local cluster alone
+	for _, cluster := range getRemoteClusters(ctx) {
+		clusterCtx := withCluster(ctx, cluster)
+		clusterLogger := logging.FromContext(clusterCtx)
+		namespaces, err := getFilteredNamespaces(clusterCtx, cluster.KubeClient)
+		if err != nil {
+			clusterLogger.Error("Failed to filter namespaces for GC", zap.Error(err))
+			summary.addError()
+			continue
+		}
+		clusterLogger.Infow("Namespaces selected for garbage collection", "namespaces", namespaces)
+		collectNamespaces(clusterCtx, namespaces, gcWorkerCount(clusterCtx, workerCount, len(namespaces)), configMapUpdateTime)
+	}
+
+	logger.Infow("Garbage collection completed", append(summary.logFields(),
+		"duration", time.Since(start).String(),
+		"configMapUpdateTime", configMapUpdateTime)...)
+}
+
+// gcWorkerCount returns the number of workers collecting namespaceCount namespaces. With autoWorkerCount, it is one worker
+// per NamespacesPerAutoWorker namespaces, at least one and at most maxWorkerCount. Otherwise it is the static worker count
+func gcWorkerCount(ctx context.Context, staticCount, namespaceCount int) int {
+	auto, maxWorkers := config.PrunerConfigStore.GetAutoWorkerCount()
+	if !auto {
+		return staticCount
+	}
+	workerCount := min(max((namespaceCount+config.NamespacesPerAutoWorker-1)/config.NamespacesPerAutoWorker, 1), maxWorkers)
+	logging.FromContext(ctx).Debugw("Sized the worker pool after the number of namespaces", "namespaces", namespaceCount, "workers", workerCount)
+	return workerCount
+}
+
+// collectNamespaces garbage collects the given namespaces, spread over workerCount workers
+func collectNamespaces(ctx context.Context, namespaces []string, workerCount int, configMapUpdateTime string) {
+	logger := logging.FromContext(ctx)
+	delay := config.PrunerConfigStore.GetInterNamespaceDelay()
+
 	// Setup channels
 	nsChan := make(chan string)
 	var wg sync.WaitGroup
@@ -127,47 +276,1049 @@ func runGarbageCollector(ctx context.Context) {
 			for ns := range nsChan {
 				logger.Infow("Worker processing namespace", "worker", workerID, "namespace", ns)
 
-				if err := cleanupPRs(ctx, ns, configMapUpdateTime); err != nil {
-					logger.Errorw("Error collecting PipelineRuns", zap.String("namespace", ns), zap.Error(err))
-					continue
-				}
-				if err := cleanupTRs(ctx, ns, configMapUpdateTime); err != nil {
-					logger.Errorw("Error collecting TaskRuns", zap.String("namespace", ns), zap.Error(err))
-					continue
-				}
+				collectNamespace(ctx, ns, configMapUpdateTime)
+				waitInterNamespaceDelay(ctx, delay)
 			}
 		}(i)
 	}
 
-	// Send namespaces to workers
+	// Send namespaces to workers, until the controller shuts down
+send:
 	for _, ns := range namespaces {
-		nsChan <- ns
+		select {
+		case <-ctx.Done():
+			break send
+		case nsChan <- ns:
+		}
 	}
 	close(nsChan)
 
 	wg.Wait()
-	logger.Info("Garbage collection completed")
+}
+
+// waitInterNamespaceDelay waits for the delay configured between the namespaces collected by a worker,
+// or until the controller shuts down
+func waitInterNamespaceDelay(ctx context.Context, delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// collectNamespace runs the garbage collection steps of a namespace, traced in a span of its own.
+// A failing step is logged and stops the collection of the namespace.
+func collectNamespace(ctx context.Context, ns, configMapUpdateTime string) {
+	ctx, span := metrics.StartSpan(ctx, "GarbageCollector.Namespace", attribute.String(metrics.LabelNamespace, ns))
+	ctx = config.WithHistoryDeletionBudget(ctx)
+	var err error
+	defer func() {
+		if err != nil {
+			getGCSummary(ctx).addError()
+		}
+		metrics.EndSpan(span, err)
+	}()
+	logger := logging.FromContext(ctx)
+	getGCSummary(ctx).addNamespace()
+
+	// the runs of a namespace being deleted are all pruned, the other steps are pointless there
+	if config.PrunerConfigStore.GetAggressivePruneTerminatingNamespaces(ns) {
+		var terminating bool
+		if terminating, err = pruneTerminatingNamespace(ctx, ns); err != nil {
+			logger.Errorw("Error pruning the runs of a terminating namespace", zap.String("namespace", ns), zap.Error(err))
+			return
+		}
+		if terminating {
+			return
+		}
+	}
+
+	ctx = withQuotaPressure(ctx, ns)
+
+	// an ephemeral namespace is marked before its runs are pruned, as only a namespace which held runs can be deleted once empty
+	if err := markEphemeralNamespace(ctx, ns); err != nil {
+		logger.Errorw("Error marking the ephemeral namespace as holding runs", zap.String("namespace", ns), zap.Error(err))
+	}
+
+	if err := reportUnusedSelectors(ctx, ns); err != nil {
+		logger.Errorw("Error checking for unused selectors", zap.String("namespace", ns), zap.Error(err))
+	}
+	if err = cleanupPRs(ctx, ns, configMapUpdateTime); err != nil {
+		logger.Errorw("Error collecting PipelineRuns", zap.String("namespace", ns), zap.Error(err))
+		return
+	}
+	if err = cleanupTRs(ctx, ns, configMapUpdateTime); err != nil {
+		logger.Errorw("Error collecting TaskRuns", zap.String("namespace", ns), zap.Error(err))
+		return
+	}
+	// a namespace whose timestamp goes stale is failing to be collected, e.g. on missing permissions
+	metrics.GetRecorder().RecordNamespacePruned(ctx, ns, time.Now())
+	if err = pruneStuckRuns(ctx, ns); err != nil {
+		logger.Errorw("Error pruning stuck runs", zap.String("namespace", ns), zap.Error(err))
+		return
+	}
+	if err = pruneLargeStatusRuns(ctx, ns); err != nil {
+		logger.Errorw("Error pruning runs with a large status", zap.String("namespace", ns), zap.Error(err))
+		return
+	}
+	if err = enforceNamespaceRunCap(ctx, ns); err != nil {
+		logger.Errorw("Error enforcing completed runs cap", zap.String("namespace", ns), zap.Error(err))
+		return
+	}
+	if err = applyEphemeralNamespacePolicy(ctx, ns); err != nil {
+		logger.Errorw("Error applying ephemeral namespace policy", zap.String("namespace", ns), zap.Error(err))
+		return
+	}
+	if err = enforceNamespaceObjectBudget(ctx, ns); err != nil {
+		logger.Errorw("Error enforcing namespace object budget", zap.String("namespace", ns), zap.Error(err))
+		return
+	}
+	if metrics.GetRecorder().HighCardinality() {
+		if err := recordSurvivingRunAges(ctx, ns); err != nil {
+			logger.Warnw("Error sampling the age of the surviving runs", zap.String("namespace", ns), zap.Error(err))
+		}
+	}
+}
+
+// recordSurvivingRunAges records the age distribution of the completed runs left in a namespace once it is collected,
+// to help right-size the TTL and history limits. The runs are listed once more, only with the high cardinality metrics
+func recordSurvivingRunAges(ctx context.Context, namespace string) error {
+	runs, err := listCompletedRuns(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	ages := map[string][]time.Duration{}
+	for _, run := range runs {
+		ages[run.resourceType] = append(ages[run.resourceType], now.Sub(run.creationTime))
+	}
+	for resourceType, runAges := range ages {
+		metrics.GetRecorder().RecordSurvivingResourceAges(ctx, resourceType, namespace, runAges)
+	}
+	return nil
+}
+
+// namespaceScopeKey is used as the key for associating the controller namespace scope with the context.
+type namespaceScopeKey struct{}
+
+// WithNamespaceScope restricts garbage collection to the given namespaces.
+// An empty scope means all namespaces.
+func WithNamespaceScope(ctx context.Context, namespaces []string) context.Context {
+	return context.WithValue(ctx, namespaceScopeKey{}, namespaces)
+}
+
+// getNamespaceScope returns the namespaces garbage collection is restricted to, if any
+func getNamespaceScope(ctx context.Context) []string {
+	namespaces, _ := ctx.Value(namespaceScopeKey{}).([]string)
+	return namespaces
+}
+
+// metricsDumpFileKey is used as the key for associating the metrics dump file with the context.
+type metricsDumpFileKey struct{}
+
+// WithMetricsDumpFile dumps the current metrics snapshot as JSON to the given file after each GC cycle,
+// for clusters which cannot scrape the metrics endpoint
+func WithMetricsDumpFile(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, metricsDumpFileKey{}, path)
+}
+
+// getMetricsDumpFile returns the file the metrics snapshot is dumped to, empty if disabled
+func getMetricsDumpFile(ctx context.Context) string {
+	path, _ := ctx.Value(metricsDumpFileKey{}).(string)
+	return path
+}
+
+// prunedPipelineRunsKey is used as the key for associating the PipelineRuns pruned in a GC cycle with the context.
+type prunedPipelineRunsKey struct{}
+
+// prunedPipelineRuns holds the UIDs of the PipelineRuns deleted during a GC cycle
+type prunedPipelineRuns struct {
+	mutex sync.Mutex
+	uids  map[types.UID]bool
+}
+
+// withPrunedPipelineRuns attaches an empty set of pruned PipelineRuns to the context
+func withPrunedPipelineRuns(ctx context.Context) context.Context {
+	return context.WithValue(ctx, prunedPipelineRunsKey{}, &prunedPipelineRuns{uids: make(map[types.UID]bool)})
+}
+
+// getPrunedPipelineRuns returns the set of pruned PipelineRuns of the context, nil if there is none
+func getPrunedPipelineRuns(ctx context.Context) *prunedPipelineRuns {
+	pruned, _ := ctx.Value(prunedPipelineRunsKey{}).(*prunedPipelineRuns)
+	return pruned
+}
+
+func (p *prunedPipelineRuns) add(uid types.UID) {
+	if p == nil || uid == "" {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.uids[uid] = true
+}
+
+func (p *prunedPipelineRuns) has(uid types.UID) bool {
+	if p == nil {
+		return false
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.uids[uid]
+}
+
+// namespaceListerKey is used as the key for associating the namespace lister with the context.
+type namespaceListerKey struct{}
+
+// withNamespaceLister attaches the lister of the namespace informer to the context.
+// A nil lister, e.g. for a remote cluster, makes the namespaces be read from the API server
+func withNamespaceLister(ctx context.Context, lister corev1listers.NamespaceLister) context.Context {
+	return context.WithValue(ctx, namespaceListerKey{}, lister)
+}
+
+// getNamespaceLister returns the namespace lister of the context, nil if there is none
+func getNamespaceLister(ctx context.Context) corev1listers.NamespaceLister {
+	lister, _ := ctx.Value(namespaceListerKey{}).(corev1listers.NamespaceLister)
+	return lister
+}
+
+// recordingPrFuncs records the PipelineRuns deleted by the TTL handler and the history limiter,
+// so that cleanupTRs can tell apart the TaskRuns left behind by those PipelineRuns
+type recordingPrFuncs struct {
+	*pipelinerun.PrFuncs
+	uids   map[string]types.UID
+	pruned *prunedPipelineRuns
+	// ignoreNamespaceConfigs takes the TTL and history limits from the global config alone, e.g. for a remote cluster
+	ignoreNamespaceConfigs bool
+}
+
+// GetTTLSecondsAfterFinished retrieves the TTL of a PipelineRun, from the global config alone when the namespace ConfigMaps are ignored
+func (f *recordingPrFuncs) GetTTLSecondsAfterFinished(namespace, name string, selectors config.SelectorSpec) (*int32, string) {
+	if f.ignoreNamespaceConfigs {
+		return config.PrunerConfigStore.GetGlobalConfigFieldData(namespace, name, selectors, config.PrunerResourceTypePipelineRun, config.PrunerFieldTypeTTLSecondsAfterFinished)
+	}
+	return f.PrFuncs.GetTTLSecondsAfterFinished(namespace, name, selectors)
+}
+
+// GetSuccessHistoryLimitCount retrieves the success history limit of a PipelineRun, from the global config alone when the namespace ConfigMaps are ignored
+func (f *recordingPrFuncs) GetSuccessHistoryLimitCount(namespace, name string, selectors config.SelectorSpec) (*int32, string) {
+	if f.ignoreNamespaceConfigs {
+		return config.PrunerConfigStore.GetGlobalConfigFieldData(namespace, name, selectors, config.PrunerResourceTypePipelineRun, config.PrunerFieldTypeSuccessfulHistoryLimit)
+	}
+	return f.PrFuncs.GetSuccessHistoryLimitCount(namespace, name, selectors)
+}
+
+// GetFailedHistoryLimitCount retrieves the failed history limit of a PipelineRun, from the global config alone when the namespace ConfigMaps are ignored
+func (f *recordingPrFuncs) GetFailedHistoryLimitCount(namespace, name string, selectors config.SelectorSpec) (*int32, string) {
+	if f.ignoreNamespaceConfigs {
+		return config.PrunerConfigStore.GetGlobalConfigFieldData(namespace, name, selectors, config.PrunerResourceTypePipelineRun, config.PrunerFieldTypeFailedHistoryLimit)
+	}
+	return f.PrFuncs.GetFailedHistoryLimitCount(namespace, name, selectors)
+}
+
+// GetMatchingSelector returns the selector of the namespace ConfigMap matching a PipelineRun, none when the namespace ConfigMaps are ignored
+func (f *recordingPrFuncs) GetMatchingSelector(namespace, name string, selectors config.SelectorSpec) *config.SelectorSpec {
+	if f.ignoreNamespaceConfigs {
+		return nil
+	}
+	return f.PrFuncs.GetMatchingSelector(namespace, name, selectors)
+}
+
+// Delete removes a PipelineRun and records its UID as pruned
+func (f *recordingPrFuncs) Delete(ctx context.Context, namespace, name string) error {
+	if err := f.PrFuncs.Delete(ctx, namespace, name); err != nil {
+		return err
+	}
+	f.pruned.add(f.uids[name])
+	getGCSummary(ctx).addDeleted(getGCOperation(ctx))
+	return nil
+}
+
+// countingTrFuncs counts the TaskRuns deleted during a GC cycle in its summary
+type countingTrFuncs struct {
+	*taskrun.TrFuncs
+	// ignoreNamespaceConfigs takes the TTL and history limits from the global config alone, e.g. for a remote cluster
+	ignoreNamespaceConfigs bool
+}
+
+// GetTTLSecondsAfterFinished retrieves the TTL of a TaskRun, from the global config alone when the namespace ConfigMaps are ignored
+func (f *countingTrFuncs) GetTTLSecondsAfterFinished(namespace, name string, selectors config.SelectorSpec) (*int32, string) {
+	if f.ignoreNamespaceConfigs {
+		return config.PrunerConfigStore.GetGlobalConfigFieldData(namespace, name, selectors, config.PrunerResourceTypeTaskRun, config.PrunerFieldTypeTTLSecondsAfterFinished)
+	}
+	return f.TrFuncs.GetTTLSecondsAfterFinished(namespace, name, selectors)
+}
+
+// GetSuccessHistoryLimitCount retrieves the success history limit of a TaskRun, from the global config alone when the namespace ConfigMaps are ignored
+func (f *countingTrFuncs) GetSuccessHistoryLimitCount(namespace, name string, selectors config.SelectorSpec) (*int32, string) {
+	if f.ignoreNamespaceConfigs {
+		return config.PrunerConfigStore.GetGlobalConfigFieldData(namespace, name, selectors, config.PrunerResourceTypeTaskRun, config.PrunerFieldTypeSuccessfulHistoryLimit)
+	}
+	return f.TrFuncs.GetSuccessHistoryLimitCount(namespace, name, selectors)
+}
+
+// GetFailedHistoryLimitCount retrieves the failed history limit of a TaskRun, from the global config alone when the namespace ConfigMaps are ignored
+func (f *countingTrFuncs) GetFailedHistoryLimitCount(namespace, name string, selectors config.SelectorSpec) (*int32, string) {
+	if f.ignoreNamespaceConfigs {
+		return config.PrunerConfigStore.GetGlobalConfigFieldData(namespace, name, selectors, config.PrunerResourceTypeTaskRun, config.PrunerFieldTypeFailedHistoryLimit)
+	}
+	return f.TrFuncs.GetFailedHistoryLimitCount(namespace, name, selectors)
+}
+
+// GetMatchingSelector returns the selector of the namespace ConfigMap matching a TaskRun, none when the namespace ConfigMaps are ignored
+func (f *countingTrFuncs) GetMatchingSelector(namespace, name string, selectors config.SelectorSpec) *config.SelectorSpec {
+	if f.ignoreNamespaceConfigs {
+		return nil
+	}
+	return f.TrFuncs.GetMatchingSelector(namespace, name, selectors)
+}
+
+// Delete removes a TaskRun and counts it as deleted
+func (f *countingTrFuncs) Delete(ctx context.Context, namespace, name string) error {
+	if err := f.TrFuncs.Delete(ctx, namespace, name); err != nil {
+		return err
+	}
+	getGCSummary(ctx).addDeleted(getGCOperation(ctx))
+	return nil
 }
 
 // getFilteredNamespaces returns namespaces excluding system namespaces
 // Excluded: kube-*, openshift-*, tekton-pipelines, tekton-operator
+// When the controller is scoped to namespaces, those are returned as is, without listing the cluster namespaces.
+// Namespaces being deleted are kept, so that aggressivePruneTerminatingNamespaces can speed up their teardown.
 func getFilteredNamespaces(ctx context.Context, client kubernetes.Interface) ([]string, error) {
-	nsList, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return nil, err
+	if namespaces := getNamespaceScope(ctx); len(namespaces) > 0 {
+		return namespaces, nil
+	}
+
+	var names []string
+	if lister := getNamespaceLister(ctx); lister != nil {
+		namespaces, err := lister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range namespaces {
+			names = append(names, ns.Name)
+		}
+	} else {
+		nsList, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nsList.Items {
+			names = append(names, ns.Name)
+		}
 	}
 
 	var filtered []string
-	for _, ns := range nsList.Items {
-		name := ns.Name
-		if !strings.HasPrefix(name, "kube-") && !strings.HasPrefix(name, "openshift-") &&
-			name != "tekton-pipelines" && name != "tekton-operator" {
+	for _, name := range names {
+		if !isSystemNamespace(name) && !config.PrunerConfigStore.IsNamespaceExcluded(name) {
 			filtered = append(filtered, name)
 		}
 	}
+	orderNamespaces(filtered, config.PrunerConfigStore.GetNamespacePriority())
 	return filtered, nil
 }
 
+// getNamespace returns a namespace from the namespace lister, or from the API server when the context has no lister.
+// The namespace returned by the lister is shared with the informer cache, it must not be modified
+func getNamespace(ctx context.Context, name string) (*corev1.Namespace, error) {
+	if lister := getNamespaceLister(ctx); lister != nil {
+		return lister.Get(name)
+	}
+	return kubeclient.Get(ctx).CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+}
+
+// orderNamespaces sorts the namespaces in the order garbage collection processes them, so that cycles are reproducible:
+// the namespaces of the priority list first, in its order, then the others in alphabetical order
+func orderNamespaces(namespaces, priority []string) {
+	rank := func(namespace string) int {
+		if i := slices.Index(priority, namespace); i >= 0 {
+			return i
+		}
+		return len(priority)
+	}
+	slices.SortFunc(namespaces, func(a, b string) int {
+		if c := cmp.Compare(rank(a), rank(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+}
+
+// isSystemNamespace checks whether a namespace belongs to the platform, or is the one the pruner is installed in,
+// those are never garbage collected
+func isSystemNamespace(name string) bool {
+	return strings.HasPrefix(name, "kube-") || strings.HasPrefix(name, "openshift-") ||
+		name == "tekton-pipelines" || name == "tekton-operator" || name == system.Namespace()
+}
+
+// reportUnusedSelectors warns about and records the selectors of a namespace ConfigMap which match
+// no PipelineRun or TaskRun of the namespace, as a misconfigured selector silently prunes nothing.
+// It runs before the cleanup, so the runs pruned during the cycle still count as matched.
+func reportUnusedSelectors(ctx context.Context, namespace string) error {
+	if config.AreNamespaceConfigsIgnored(ctx) {
+		return nil
+	}
+	prSelectors := config.PrunerConfigStore.GetNamespaceSelectors(namespace, config.PrunerResourceTypePipelineRun)
+	trSelectors := config.PrunerConfigStore.GetNamespaceSelectors(namespace, config.PrunerResourceTypeTaskRun)
+	if len(prSelectors) == 0 && len(trSelectors) == 0 {
+		return nil
+	}
+
+	pipelineClient := pipelineclient.Get(ctx)
+	var prs, trs []metav1.Object
+	if len(prSelectors) > 0 {
+		prsList, err := pipelineClient.TektonV1().PipelineRuns(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for i := range prsList.Items {
+			prs = append(prs, &prsList.Items[i])
+		}
+	}
+	if len(trSelectors) > 0 {
+		trsList, err := pipelineClient.TektonV1().TaskRuns(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for i := range trsList.Items {
+			trs = append(trs, &trsList.Items[i])
+		}
+	}
+
+	logger := logging.FromContext(ctx)
+	metricsRecorder := metrics.GetRecorder()
+	for _, unused := range []struct {
+		resourceType string
+		selectors    []config.SelectorSpec
+	}{
+		{metrics.ResourceTypePipelineRun, unusedSelectors(prSelectors, prs)},
+		{metrics.ResourceTypeTaskRun, unusedSelectors(trSelectors, trs)},
+	} {
+		if len(unused.selectors) == 0 {
+			continue
+		}
+		logger.Warnw("configured selectors matched no resource, check them for typos",
+			"namespace", namespace, "resource", unused.resourceType, "selectors", unused.selectors)
+		for range unused.selectors {
+			metricsRecorder.RecordUnusedSelector(ctx, unused.resourceType, namespace)
+		}
+	}
+	return nil
+}
+
+// unusedSelectors returns the selectors which match none of the given resources
+func unusedSelectors(selectors []config.SelectorSpec, resources []metav1.Object) []config.SelectorSpec {
+	var unused []config.SelectorSpec
+	for _, selector := range selectors {
+		matched := false
+		for _, resource := range resources {
+			if selector.Matches(config.SelectorSpec{MatchLabels: resource.GetLabels(), MatchAnnotations: resource.GetAnnotations()}) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			unused = append(unused, selector)
+		}
+	}
+	return unused
+}
+
+// completedRun is a completed PipelineRun or standalone TaskRun considered by the namespace-wide rules,
+// or a stuck one, which has no completion time
+type completedRun struct {
+	resourceType   string
+	name           string
+	creationTime   time.Time
+	completionTime time.Time
+	statusBytes    int
+	object         metav1.Object
+}
+
+// kind returns the kind of the run, as named by the config
+func (r completedRun) kind() string {
+	if r.resourceType == metrics.ResourceTypePipelineRun {
+		return config.KindPipelineRun
+	}
+	return config.KindTaskRun
+}
+
+// listCompletedRuns returns the completed PipelineRuns and standalone TaskRuns of a namespace.
+// TaskRuns owned by a PipelineRun are left out, they are removed along with their PipelineRun,
+// and so are the runs already being deleted or marked as prunable.
+func listCompletedRuns(ctx context.Context, namespace string) ([]completedRun, error) {
+	prsList, trsList, err := listRuns(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return completedRuns(prsList, trsList), nil
+}
+
+// listRuns returns all the PipelineRuns and TaskRuns of a namespace
+func listRuns(ctx context.Context, namespace string) (*pipelinev1.PipelineRunList, *pipelinev1.TaskRunList, error) {
+	pipelineClient := pipelineclient.Get(ctx)
+
+	prsList, err := pipelineClient.TektonV1().PipelineRuns(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	trsList, err := pipelineClient.TektonV1().TaskRuns(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return prsList, trsList, nil
+}
+
+// completedRuns returns the completed PipelineRuns and standalone TaskRuns of the given lists,
+// as selected by listCompletedRuns
+func completedRuns(prsList *pipelinev1.PipelineRunList, trsList *pipelinev1.TaskRunList) []completedRun {
+	var runs []completedRun
+	for _, pr := range prsList.Items {
+		if pr.Status.CompletionTime == nil || pr.DeletionTimestamp != nil || config.IsMarkedPrunable(&pr) {
+			continue
+		}
+		status, _ := json.Marshal(pr.Status)
+		runs = append(runs, completedRun{
+			resourceType:   metrics.ResourceTypePipelineRun,
+			name:           pr.Name,
+			creationTime:   pr.CreationTimestamp.Time,
+			completionTime: pr.Status.CompletionTime.Time,
+			statusBytes:    len(status),
+			object:         &pr,
+		})
+	}
+	for _, tr := range trsList.Items {
+		if tr.Status.CompletionTime == nil || tr.DeletionTimestamp != nil || config.HasTaskRunParent(&tr) || config.IsMarkedPrunable(&tr) {
+			continue
+		}
+		status, _ := json.Marshal(tr.Status)
+		runs = append(runs, completedRun{
+			resourceType:   metrics.ResourceTypeTaskRun,
+			name:           tr.Name,
+			creationTime:   tr.CreationTimestamp.Time,
+			completionTime: tr.Status.CompletionTime.Time,
+			statusBytes:    len(status),
+			object:         &tr,
+		})
+	}
+	return runs
+}
+
+// pruneRuns deletes the given runs, or marks them as prunable when the deletion mode is annotate.
+// A run that fails to be pruned is logged and skipped. reason is reported on the runs marked as prunable,
+// the deletion metrics and the pruned events. Nothing is pruned while the global config freezes pruning.
+func pruneRuns(ctx context.Context, namespace string, runs []completedRun, reason config.PruneReason, operation string) error {
+	logger := logging.FromContext(ctx)
+	pipelineClient := pipelineclient.Get(ctx)
+
+	if until, frozen := config.PrunerConfigStore.GetFreezeUntil(time.Now()); frozen {
+		logger.Debugw("pruning is frozen, keeping the runs", "namespace", namespace, "operation", operation, "runs", len(runs), "freezeUntil", until)
+		return nil
+	}
+
+	// In annotate mode, runs are marked as prunable instead of deleted
+	annotate := config.PrunerConfigStore.GetDeletionMode(namespace) == config.DeletionModeAnnotate
+
+	metricsRecorder := metrics.GetRecorder()
+	for _, run := range runs {
+		// The runs of the Pipelines and Tasks listed in neverPrune are kept whatever the namespace-wide rules
+		if config.IsNeverPruned(run.kind(), run.object) {
+			continue
+		}
+
+		// A run finalized by another controller is kept, its deletion would hang until the finalizer is removed
+		if config.HasSkippedFinalizer(run.object) {
+			continue
+		}
+
+		// A run referenced by an existing protecting resource is kept until that resource is gone
+		protected, err := config.IsProtected(ctx, run.object)
+		if err != nil {
+			logger.Errorw("error checking run protection, skipping it", "resource", run.resourceType, "namespace", namespace, "name", run.name, zap.Error(err))
+			continue
+		}
+		if protected {
+			continue
+		}
+
+		var prunablePatch []byte
+		if annotate {
+			prunablePatch, err = config.PrunablePatch(run.object, reason)
+			if goerrors.Is(err, config.ErrAnnotationNotAllowed) {
+				logger.Warnw("skipping marking the run as prunable", "resource", run.resourceType, "namespace", namespace, "name", run.name, zap.Error(err))
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case annotate && run.resourceType == metrics.ResourceTypePipelineRun:
+			err = config.CallWithTimeout(ctx, run.resourceType, namespace, func(ctx context.Context) error {
+				_, err := pipelineClient.TektonV1().PipelineRuns(namespace).Patch(ctx, run.name, types.JSONPatchType, prunablePatch, metav1.PatchOptions{FieldManager: config.GetFieldManager()})
+				return err
+			})
+		case annotate:
+			err = config.CallWithTimeout(ctx, run.resourceType, namespace, func(ctx context.Context) error {
+				_, err := pipelineClient.TektonV1().TaskRuns(namespace).Patch(ctx, run.name, types.JSONPatchType, prunablePatch, metav1.PatchOptions{FieldManager: config.GetFieldManager()})
+				return err
+			})
+		case run.resourceType == metrics.ResourceTypePipelineRun:
+			err = config.LimitDeletion(ctx, func() error {
+				return config.CallWithTimeout(ctx, run.resourceType, namespace, func(ctx context.Context) error {
+					return pipelineClient.TektonV1().PipelineRuns(namespace).Delete(ctx, run.name, metav1.DeleteOptions{})
+				})
+			})
+		default:
+			err = config.LimitDeletion(ctx, func() error {
+				return config.CallWithTimeout(ctx, run.resourceType, namespace, func(ctx context.Context) error {
+					return pipelineClient.TektonV1().TaskRuns(namespace).Delete(ctx, run.name, metav1.DeleteOptions{})
+				})
+			})
+		}
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			metricsRecorder.RecordResourceError(ctx, run.resourceType, namespace, metrics.ClassifyError(err), operation+"_deletion_failed")
+			logger.Errorw("error pruning run", "resource", run.resourceType, "namespace", namespace, "name", run.name, "reason", reason, zap.Error(err))
+			continue // Continue to next run instead of returning error
+		}
+		if annotate {
+			continue
+		}
+		metricsRecorder.RecordResourceDeleted(ctx, run.resourceType, namespace, operation, reason.String(), time.Since(run.creationTime))
+		getGCSummary(ctx).addDeleted(operation)
+
+		runKind, runLabelKey := config.KindTaskRun, config.LabelTaskRunName
+		if run.resourceType == metrics.ResourceTypePipelineRun {
+			runKind, runLabelKey = config.KindPipelineRun, config.LabelPipelineRunName
+		}
+		config.NotifyPruned(ctx, config.NewPrunedEvent(runKind, run.object, reason))
+		if err := config.DeleteLeftoverPods(ctx, leftoverPodsClient(ctx, namespace), run.resourceType, namespace, runLabelKey, run.name); err != nil {
+			logger.Warnw("error deleting the leftover pods of a run", "resource", run.resourceType, "namespace", namespace, "name", run.name, zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// leftoverPodsClient returns the kube client deleting the leftover pods of the deleted runs of a namespace,
+// or nil when deleteLeftoverPods is disabled there
+func leftoverPodsClient(ctx context.Context, namespace string) kubernetes.Interface {
+	if !config.PrunerConfigStore.GetDeleteLeftoverPods(namespace) {
+		return nil
+	}
+	return kubeclient.Get(ctx)
+}
+
+// pruneLargeStatusRuns removes the completed runs of a namespace whose serialized status exceeds
+// pruneLargeStatusBytes, largest first, as they are the worst offenders on etcd usage.
+// It runs after the per-resource TTL and history limits were applied.
+func pruneLargeStatusRuns(ctx context.Context, namespace string) error {
+	maxStatusBytes := config.PrunerConfigStore.GetPruneLargeStatusBytes(namespace)
+	if maxStatusBytes == nil {
+		return nil
+	}
+
+	runs, err := listCompletedRuns(ctx, namespace)
+	if err != nil {
+		return err
+	}
+
+	var largeRuns []completedRun
+	for _, run := range runs {
+		if int64(run.statusBytes) > *maxStatusBytes {
+			largeRuns = append(largeRuns, run)
+		}
+	}
+	if len(largeRuns) == 0 {
+		return nil
+	}
+
+	// Sort runs by status size (largest first)
+	slices.SortStableFunc(largeRuns, func(a, b completedRun) int {
+		return b.statusBytes - a.statusBytes
+	})
+
+	logging.FromContext(ctx).Infow("pruning runs with a large status",
+		"namespace", namespace, "pruneLargeStatusBytes", *maxStatusBytes, "pruning", len(largeRuns))
+	return pruneRuns(ctx, namespace, largeRuns, config.PruneReasonLargeStatus, metrics.OperationLargeStatus)
+}
+
+// pruneStuckRuns removes the PipelineRuns and standalone TaskRuns of a namespace which are still not completed
+// pruneStuckAfterSeconds after they started, e.g. runs orphaned by a crashed controller, which no other rule removes.
+// A run is only considered stuck once its own timeout elapsed as well, so a run allowed to last longer is not cut short.
+func pruneStuckRuns(ctx context.Context, namespace string) error {
+	stuckAfter := config.PrunerConfigStore.GetPruneStuckAfterSeconds(namespace)
+	if stuckAfter == nil {
+		return nil
+	}
+	threshold := time.Duration(*stuckAfter) * time.Second
+
+	prsList, trsList, err := listRuns(ctx, namespace)
+	if err != nil {
+		return err
+	}
+
+	var stuckRuns []completedRun
+	for _, pr := range prsList.Items {
+		if pr.IsDone() || pr.IsPending() || pr.DeletionTimestamp != nil || config.IsMarkedPrunable(&pr) ||
+			!isStuck(pr.Status.StartTime, pr.PipelineTimeout(ctx), threshold) {
+			continue
+		}
+		stuckRuns = append(stuckRuns, completedRun{
+			resourceType: metrics.ResourceTypePipelineRun,
+			name:         pr.Name,
+			creationTime: pr.CreationTimestamp.Time,
+			object:       &pr,
+		})
+	}
+	for _, tr := range trsList.Items {
+		if tr.IsDone() || tr.IsPending() || tr.DeletionTimestamp != nil || config.HasTaskRunParent(&tr) || config.IsMarkedPrunable(&tr) ||
+			!isStuck(tr.Status.StartTime, tr.GetTimeout(ctx), threshold) {
+			continue
+		}
+		stuckRuns = append(stuckRuns, completedRun{
+			resourceType: metrics.ResourceTypeTaskRun,
+			name:         tr.Name,
+			creationTime: tr.CreationTimestamp.Time,
+			object:       &tr,
+		})
+	}
+	if len(stuckRuns) == 0 {
+		return nil
+	}
+
+	logging.FromContext(ctx).Infow("pruning stuck runs",
+		"namespace", namespace, "pruneStuckAfterSeconds", *stuckAfter, "pruning", len(stuckRuns))
+	return pruneRuns(ctx, namespace, stuckRuns, config.PruneReasonStuck, metrics.OperationStuck)
+}
+
+// isStuck checks whether a run which is not completed started longer ago than both the threshold and its own timeout.
+// A run which has not started yet is never stuck.
+func isStuck(startTime *metav1.Time, timeout, threshold time.Duration) bool {
+	if startTime == nil {
+		return false
+	}
+	return time.Since(startTime.Time) > max(threshold, timeout)
+}
+
+// enforceNamespaceRunCap removes the oldest completed PipelineRuns and standalone TaskRuns of a namespace
+// beyond maxCompletedRunsPerNamespace. It runs after the per-resource TTL and history limits were applied,
+// so only the runs those left behind are counted.
+func enforceNamespaceRunCap(ctx context.Context, namespace string) error {
+	maxRuns := config.PrunerConfigStore.GetMaxCompletedRunsPerNamespace(namespace)
+	if maxRuns == nil {
+		return nil
+	}
+
+	runs, err := listCompletedRuns(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	if len(runs) <= int(*maxRuns) {
+		return nil
+	}
+
+	// Sort runs by completion time (oldest first)
+	slices.SortStableFunc(runs, func(a, b completedRun) int {
+		return a.completionTime.Compare(b.completionTime)
+	})
+
+	// the runs which cannot be pruned are passed over, so that the cap is reached with the next oldest runs
+	logger := logging.FromContext(ctx)
+	var excess []completedRun
+	for _, run := range runs {
+		if len(excess) == len(runs)-int(*maxRuns) {
+			break
+		}
+		if canPrune(ctx, namespace, run) {
+			excess = append(excess, run)
+		}
+	}
+	logger.Infow("namespace exceeds completed runs cap, pruning the oldest runs",
+		"namespace", namespace, "completedRuns", len(runs), "maxCompletedRunsPerNamespace", *maxRuns, "pruning", len(excess))
+	if len(excess) < len(runs)-int(*maxRuns) {
+		logger.Warnw("namespace remains over its completed runs cap, not enough completed runs can be pruned",
+			"namespace", namespace, "maxCompletedRunsPerNamespace", *maxRuns, "remaining", len(runs)-len(excess))
+	}
+	return pruneRuns(ctx, namespace, excess, config.PruneReasonMaxPerNamespace, metrics.OperationNamespaceCap)
+}
+
+// canPrune reports whether a namespace-wide rule may select a run. The runs listed in neverPrune and the runs
+// referenced by a protecting resource are kept by pruneRuns, a rule selecting them would stay over its limit
+func canPrune(ctx context.Context, namespace string, run completedRun) bool {
+	if config.IsNeverPruned(run.kind(), run.object) || config.HasSkippedFinalizer(run.object) {
+		return false
+	}
+	protected, err := config.IsProtected(ctx, run.object)
+	if err != nil {
+		logging.FromContext(ctx).Errorw("error checking run protection, skipping it", "resource", run.resourceType, "namespace", namespace, "name", run.name, zap.Error(err))
+		return false
+	}
+	return !protected
+}
+
+// enforceNamespaceObjectBudget removes the oldest completed runs of a namespace until the PipelineRuns and TaskRuns
+// it holds, of any kind and status, fit in namespaceObjectBudget. It runs once all the other rules were applied,
+// as a hard ceiling over them. Deleting a PipelineRun frees its TaskRuns too, and protected runs are never selected.
+func enforceNamespaceObjectBudget(ctx context.Context, namespace string) error {
+	budget := config.PrunerConfigStore.GetNamespaceObjectBudget(namespace)
+	if budget == nil {
+		return nil
+	}
+
+	prsList, trsList, err := listRuns(ctx, namespace)
+	if err != nil {
+		return err
+	}
+
+	// Runs already being deleted or handed over as prunable are on their way out, they are not counted
+	objects := 0
+	childTaskRuns := map[types.UID]int{}
+	for _, pr := range prsList.Items {
+		if pr.DeletionTimestamp == nil && !config.IsMarkedPrunable(&pr) {
+			objects++
+		}
+	}
+	for _, tr := range trsList.Items {
+		if tr.DeletionTimestamp != nil || config.IsMarkedPrunable(&tr) {
+			continue
+		}
+		objects++
+		for _, ref := range tr.OwnerReferences {
+			if ref.Kind == pipeline.PipelineRunControllerName {
+				childTaskRuns[ref.UID]++
+			}
+		}
+	}
+	if objects <= int(*budget) {
+		return nil
+	}
+
+	runs := completedRuns(prsList, trsList)
+	// Sort runs by completion time (oldest first)
+	slices.SortStableFunc(runs, func(a, b completedRun) int {
+		return a.completionTime.Compare(b.completionTime)
+	})
+
+	logger := logging.FromContext(ctx)
+	var excess []completedRun
+	remaining := objects
+	for _, run := range runs {
+		if remaining <= int(*budget) {
+			break
+		}
+		if !canPrune(ctx, namespace, run) {
+			continue
+		}
+		excess = append(excess, run)
+		remaining -= 1 + childTaskRuns[run.object.GetUID()]
+	}
+
+	metrics.GetRecorder().RecordNamespaceBudgetEnforced(ctx, namespace)
+	logger.Infow("namespace exceeds its object budget, pruning the oldest completed runs",
+		"namespace", namespace, "objects", objects, "namespaceObjectBudget", *budget, "pruning", len(excess))
+	if remaining > int(*budget) {
+		logger.Warnw("namespace remains over its object budget, not enough completed runs can be pruned",
+			"namespace", namespace, "namespaceObjectBudget", *budget, "remaining", remaining)
+	}
+	return pruneRuns(ctx, namespace, excess, config.PruneReasonNamespaceBudget, metrics.OperationNamespaceBudget)
+}
+
+// withQuotaPressure tightens the history limits applied by the garbage collection of a namespace,
+// when quotaPressure is configured and a ResourceQuota on the number of runs of the namespace is nearly used up
+func withQuotaPressure(ctx context.Context, namespace string) context.Context {
+	if config.PrunerConfigStore.GetQuotaPressure() == nil {
+		return ctx
+	}
+
+	logger := logging.FromContext(ctx)
+	quotas, err := kubeclient.Get(ctx).CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logger.Warnw("error listing the resource quotas, the history limits are not tightened", "namespace", namespace, zap.Error(err))
+		return ctx
+	}
+
+	divisor := config.QuotaPressureDivisor(quotas.Items)
+	if divisor > 1 {
+		logger.Infow("namespace under quota pressure, tightening its history limits for this cycle", "namespace", namespace, "historyLimitDivisor", divisor)
+	}
+	return config.WithHistoryLimitDivisor(ctx, divisor)
+}
+
+// pruneTerminatingNamespace prunes all the completed runs of a namespace being deleted, whatever their TTL
+// and history limits, as the runs left there only slow down its teardown. It reports whether the namespace is terminating.
+func pruneTerminatingNamespace(ctx context.Context, namespace string) (bool, error) {
+	ns, err := getNamespace(ctx, namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// the namespace is already gone, along with its runs
+			return true, nil
+		}
+		return false, err
+	}
+	if ns.DeletionTimestamp == nil {
+		return false, nil
+	}
+
+	runs, err := listCompletedRuns(ctx, namespace)
+	if err != nil {
+		return true, err
+	}
+	if len(runs) > 0 {
+		logging.FromContext(ctx).Infow("pruning the completed runs of a terminating namespace", "namespace", namespace, "pruning", len(runs))
+	}
+	return true, pruneRuns(ctx, namespace, runs, config.PruneReasonTerminatingNamespace, metrics.OperationTerminatingNamespace)
+}
+
+// getEphemeralNamespace returns the namespace if it is an ephemeral namespace of the policy, not being deleted, nil otherwise
+func getEphemeralNamespace(ctx context.Context, policy *config.EphemeralNamespacePolicy, namespace string) (*corev1.Namespace, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&policy.NamespaceSelector)
+	if err != nil {
+		return nil, err
+	}
+	ns, err := getNamespace(ctx, namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if ns.DeletionTimestamp != nil || !selector.Matches(labels.Set(ns.Labels)) {
+		return nil, nil
+	}
+	return ns, nil
+}
+
+// hasRuns checks whether a namespace holds any PipelineRun or TaskRun, running or completed
+func hasRuns(ctx context.Context, namespace string) (bool, error) {
+	pipelineClient := pipelineclient.Get(ctx)
+	prs, err := pipelineClient.TektonV1().PipelineRuns(namespace).List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return false, err
+	}
+	if len(prs.Items) > 0 {
+		return true, nil
+	}
+	trs, err := pipelineClient.TektonV1().TaskRuns(namespace).List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return false, err
+	}
+	return len(trs.Items) > 0, nil
+}
+
+// markEphemeralNamespace annotates an ephemeral namespace holding runs with AnnotationHeldRuns, when the policy deletes the empty namespaces.
+// A namespace which never held runs, e.g. one just created, is then not deleted before its first runs are created
+func markEphemeralNamespace(ctx context.Context, namespace string) error {
+	policy := config.PrunerConfigStore.GetEphemeralNamespacePolicy(namespace)
+	if policy == nil || !policy.DeleteEmptyNamespace {
+		return nil
+	}
+	ns, err := getEphemeralNamespace(ctx, policy, namespace)
+	if err != nil || ns == nil {
+		return err
+	}
+	if _, marked := ns.Annotations[config.AnnotationHeldRuns]; marked {
+		return nil
+	}
+	found, err := hasRuns(ctx, namespace)
+	if err != nil || !found {
+		return err
+	}
+
+	patch, err := config.AnnotationPatch(ns, map[string]string{config.AnnotationHeldRuns: time.Now().UTC().Format(time.RFC3339)})
+	if goerrors.Is(err, config.ErrAnnotationNotAllowed) {
+		logging.FromContext(ctx).Warnw("skipping marking the ephemeral namespace, it is not deleted once empty", "namespace", namespace, zap.Error(err))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = kubeclient.Get(ctx).CoreV1().Namespaces().Patch(ctx, namespace, types.JSONPatchType, patch, metav1.PatchOptions{FieldManager: config.GetFieldManager()})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// applyEphemeralNamespacePolicy prunes the completed runs of an ephemeral namespace past the policy TTL.
+// If the policy allows it, it then deletes the namespace once it holds no PipelineRun nor TaskRun,
+// provided it held runs before and is older than the policy TTL.
+func applyEphemeralNamespacePolicy(ctx context.Context, namespace string) error {
+	policy := config.PrunerConfigStore.GetEphemeralNamespacePolicy(namespace)
+	if policy == nil {
+		return nil
+	}
+	ns, err := getEphemeralNamespace(ctx, policy, namespace)
+	if err != nil || ns == nil {
+		return err
+	}
+
+	logger := logging.FromContext(ctx)
+
+	if policy.TTLSecondsAfterFinished != nil {
+		runs, err := listCompletedRuns(ctx, namespace)
+		if err != nil {
+			return err
+		}
+		ttl := time.Duration(*policy.TTLSecondsAfterFinished) * time.Second
+		var expired []completedRun
+		for _, run := range runs {
+			if time.Since(run.completionTime) >= ttl {
+				expired = append(expired, run)
+			}
+		}
+		if len(expired) > 0 {
+			logger.Infow("pruning expired runs of an ephemeral namespace", "namespace", namespace, "pruning", len(expired))
+			if err := pruneRuns(ctx, namespace, expired, config.PruneReasonTTL, metrics.OperationTTL); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Namespace deletion is opt-in, and never done in annotate mode, where the pruner deletes nothing itself, or during a freeze
+	if !policy.DeleteEmptyNamespace || config.PrunerConfigStore.GetDeletionMode(namespace) == config.DeletionModeAnnotate {
+		return nil
+	}
+	if _, frozen := config.PrunerConfigStore.GetFreezeUntil(time.Now()); frozen {
+		return nil
+	}
+	if isSystemNamespace(namespace) || namespace == metav1.NamespaceDefault {
+		return nil
+	}
+
+	// A namespace which never held runs, or younger than the policy TTL, may just be waiting for its first runs
+	if _, marked := ns.Annotations[config.AnnotationHeldRuns]; !marked {
+		return nil
+	}
+	if ttl := policy.TTLSecondsAfterFinished; ttl != nil && time.Since(ns.CreationTimestamp.Time) < time.Duration(*ttl)*time.Second {
+		return nil
+	}
+
+	// The namespace must hold no run at all, running or completed
+	found, err := hasRuns(ctx, namespace)
+	if err != nil || found {
+		return err
+	}
+
+	logger.Infow("deleting empty ephemeral namespace", "namespace", namespace)
+	// The UID precondition makes sure a namespace recreated with the same name in the meantime is not deleted
+	err = kubeclient.Get(ctx).CoreV1().Namespaces().Delete(ctx, namespace, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &ns.UID},
+	})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
 // CleanupPRs is responsible for cleaning up completed PipelineRuns based on their TTL and history limit.
 func cleanupPRs(ctx context.Context, namespace string, configMapUpdateTime string) error {
 
@@ -175,7 +1326,8 @@ func cleanupPRs(ctx context.Context, namespace string, configMapUpdateTime strin
 	logger.Debugw("Start Cleanup PipelineRuns", "namespace", namespace)
 
 	pipelineClient := pipelineclient.Get(ctx)
-	prFuncs := pipelinerun.NewPrFuncs(pipelineClient)
+	prFuncs := &recordingPrFuncs{PrFuncs: pipelinerun.NewPrFuncs(pipelineClient, leftoverPodsClient(ctx, namespace), apis.ConditionSucceeded), uids: map[string]types.UID{}, pruned: getPrunedPipelineRuns(ctx),
+		ignoreNamespaceConfigs: config.AreNamespaceConfigsIgnored(ctx)}
 
 	prTTLHandler, err := config.NewTTLHandler(clockUtil.RealClock{}, prFuncs)
 	if err != nil {
@@ -186,26 +1338,42 @@ func cleanupPRs(ctx context.Context, namespace string, configMapUpdateTime strin
 	if err != nil {
 		logger.Fatal("error on getting history limiter", zap.Error(err))
 	}
+	prTTLHandler.SetHistoryLimiter(prHistoryLimiter)
 
-	prsList, err := pipelineClient.TektonV1().PipelineRuns(namespace).List(ctx, metav1.ListOptions{})
+	prsList, err := config.ListWithRetry(ctx, func() (*pipelinev1.PipelineRunList, error) {
+		return pipelineClient.TektonV1().PipelineRuns(namespace).List(ctx, metav1.ListOptions{})
+	})
 	if err != nil {
 		return err
 	}
+	metrics.GetRecorder().RecordNamespaceRunsEvaluated(ctx, metrics.ResourceTypePipelineRun, len(prsList.Items))
+	processedAnnotationKey := config.PrunerConfigStore.GetProcessedAnnotationKey()
 	logger.Debugw("Progressing cleanup PipelineRuns list", "list", prsList.Items, "namespace", namespace)
+	for _, pr := range prsList.Items {
+		prFuncs.uids[pr.Name] = pr.UID
+	}
 
 	if len(prsList.Items) > 0 {
 
 		for _, prInstance := range prsList.Items {
+			// Stop promptly when the controller shuts down, the rest of the PipelineRuns are left to the next run
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
 			logger.Debugw("Processing PipelineRun", "name", prInstance.Name, "namespace", prInstance.Namespace)
 			// Check if the PipelineRun is completed
 			if prInstance.Status.CompletionTime != nil {
 				pr := &prInstance
+				getGCSummary(ctx).addEvaluated(metrics.ResourceTypePipelineRun)
 
-				// Check if the history limit processed time which is stored as a string in annotation of PR config.AnnotationHistoryLimitCheckProcessed is not nil
+				// Check if the history limit processed time which is stored as a string in the processed annotation of PR is not nil
 				// and earlier than the configmap update time
-				if prInstance.Annotations[config.AnnotationHistoryLimitCheckProcessed] != "" {
+				if prInstance.Annotations[processedAnnotationKey] != "" {
 					// Parse the annotation value to a time.Time object
-					annotationTime, err := time.Parse(time.RFC3339, prInstance.Annotations[config.AnnotationHistoryLimitCheckProcessed])
+					annotationTime, err := time.Parse(time.RFC3339, prInstance.Annotations[processedAnnotationKey])
 					if err != nil {
 						logger.Errorw("Error parsing history limit check processed time", "namespace", pr.Namespace, "name", pr.Name, zap.Error(err))
 						continue // Continue to next PR instead of returning error
@@ -222,24 +1390,38 @@ func cleanupPRs(ctx context.Context, namespace string, configMapUpdateTime strin
 
 					if updateTime.After(annotationTime) {
 						// Use JSON Patch to remove only the specific annotation without affecting others
-						jsonPatch := fmt.Sprintf(`[{"op": "remove", "path": "/metadata/annotations/%s"}]`,
-							strings.ReplaceAll(config.AnnotationHistoryLimitCheckProcessed, "/", "~1"))
+						jsonPatch, err := config.AnnotationPatch(pr, nil, processedAnnotationKey)
+						if goerrors.Is(err, config.ErrAnnotationNotAllowed) {
+							logger.Warnw("skipping the removal of the history limit check processed annotation", "namespace", pr.Namespace, "name", pr.Name, zap.Error(err))
+							continue
+						}
+						if err != nil {
+							logger.Errorw("error creating the patch removing the history limit check processed annotation", "namespace", pr.Namespace, "name", pr.Name, zap.Error(err))
+							continue // Continue to next PR instead of returning error
+						}
 
 						// Patch the PipelineRun to remove the annotation
-						_, err = pipelineClient.TektonV1().PipelineRuns(pr.Namespace).Patch(ctx, pr.Name, types.JSONPatchType, []byte(jsonPatch), metav1.PatchOptions{})
+						err = config.PatchWithRetry(ctx, func() error {
+							return config.CallWithTimeout(ctx, metrics.ResourceTypePipelineRun, pr.Namespace, func(ctx context.Context) error {
+								_, err := pipelineClient.TektonV1().PipelineRuns(pr.Namespace).Patch(ctx, pr.Name, types.JSONPatchType, jsonPatch, metav1.PatchOptions{FieldManager: config.GetFieldManager()})
+								return err
+							})
+						})
 						if err != nil {
 							// If the PipelineRun is not found, it may have been deleted already, so we can continue
 							if errors.IsNotFound(err) {
 								logger.Debugw("PipelineRun not found during annotation patch - may have been deleted already", "namespace", pr.Namespace, "name", pr.Name)
 								continue
 							}
+							// The PipelineRun keeps its processed annotation, and is evaluated again by every cycle until the patch succeeds
 							logger.Errorw("error patching PipelineRun to remove history limit check processed annotation", "namespace", pr.Namespace, "name", pr.Name, zap.Error(err))
+							metrics.GetRecorder().RecordAnnotationPatchFailure(ctx, metrics.ResourceTypePipelineRun, pr.Namespace)
 							continue // Continue to next PR instead of returning error
 						}
 					}
 				}
 
-				err := prHistoryLimiter.ProcessEvent(ctx, pr)
+				err := prHistoryLimiter.ProcessEvent(withGCOperation(ctx, metrics.OperationHistory), pr)
 				if err != nil {
 					// If the PipelineRun is not found, it may have been processed/deleted by another worker, continue to next PR
 					if errors.IsNotFound(err) {
@@ -247,10 +1429,11 @@ func cleanupPRs(ctx context.Context, namespace string, configMapUpdateTime strin
 						continue
 					}
 					logger.Errorw("error processing history limiting for a PipelineRun", "namespace", pr.Namespace, "name", pr.Name, zap.Error(err))
+					getGCSummary(ctx).addError()
 					continue // Continue to next PR instead of returning error
 				}
 				// execute ttl handler
-				err = prTTLHandler.ProcessEvent(ctx, pr)
+				err = prTTLHandler.ProcessEvent(withGCOperation(ctx, metrics.OperationTTL), pr)
 				if err != nil {
 					// If the PipelineRun is not found, it may have been processed/deleted by another worker, continue to next PR
 					if errors.IsNotFound(err) {
@@ -262,6 +1445,7 @@ func cleanupPRs(ctx context.Context, namespace string, configMapUpdateTime strin
 					if !isRequeueKey {
 						data, _ := json.Marshal(pr)
 						logger.Errorw("error processing ttl for a PipelineRun", "namespace", pr.Namespace, "name", pr.Name, "resource", string(data), zap.Error(err))
+						getGCSummary(ctx).addError()
 					}
 					continue // Continue to next PR instead of returning error
 				}
@@ -273,14 +1457,15 @@ func cleanupPRs(ctx context.Context, namespace string, configMapUpdateTime strin
 }
 
 // CleanupTRs is responsible for cleaning up completed TaskRuns based on their TTL and history limit.
-// It checks if the TaskRun has a completion time and is not owned by a PipelineRun before processing.
+// It checks if the TaskRun has a completion time and is not owned by a PipelineRun, or another parent kind, before processing.
 func cleanupTRs(ctx context.Context, namespace string, configMapUpdateTime string) error {
 
 	logger := logging.FromContext(ctx)
 	logger.Debugw("Start Cleanup TaskRuns", "namespace", namespace)
 
 	pipelineClient := pipelineclient.Get(ctx)
-	trFuncs := taskrun.NewTrFuncs(pipelineClient)
+	trFuncs := &countingTrFuncs{TrFuncs: taskrun.NewTrFuncs(pipelineClient, leftoverPodsClient(ctx, namespace), apis.ConditionSucceeded),
+		ignoreNamespaceConfigs: config.AreNamespaceConfigsIgnored(ctx)}
 
 	trTTLHandler, err := config.NewTTLHandler(clockUtil.RealClock{}, trFuncs)
 	if err != nil {
@@ -291,23 +1476,49 @@ func cleanupTRs(ctx context.Context, namespace string, configMapUpdateTime strin
 	if err != nil {
 		logger.Fatal("error on getting history limiter", zap.Error(err))
 	}
+	trTTLHandler.SetHistoryLimiter(trHistoryLimiter)
 
-	trsList, err := pipelineClient.TektonV1().TaskRuns(namespace).List(ctx, metav1.ListOptions{})
+	trsList, err := config.ListWithRetry(ctx, func() (*pipelinev1.TaskRunList, error) {
+		return pipelineClient.TektonV1().TaskRuns(namespace).List(ctx, metav1.ListOptions{})
+	})
 	if err != nil {
 		return err
 	}
+	metrics.GetRecorder().RecordNamespaceRunsEvaluated(ctx, metrics.ResourceTypeTaskRun, len(trsList.Items))
+	processedAnnotationKey := config.PrunerConfigStore.GetProcessedAnnotationKey()
 
 	if len(trsList.Items) > 0 {
 
+		prunedPRs := getPrunedPipelineRuns(ctx)
+		var orphans []completedRun
 		for _, trInstance := range trsList.Items {
-			if trInstance.Status.CompletionTime != nil && !trInstance.HasPipelineRunOwnerReference() {
+			// Stop promptly when the controller shuts down, the rest of the TaskRuns are left to the next run
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			// A TaskRun whose PipelineRun was pruned earlier in the cycle, but which lost its owner reference,
+			// follows its PipelineRun instead of being evaluated as a standalone TaskRun
+			if prUID := types.UID(trInstance.Labels[pipeline.PipelineRunUIDLabelKey]); prUID != "" && prunedPRs.has(prUID) {
+				// like its PipelineRun, the TaskRun is pruned by TTL or history limits, which leave skipRunsWithFinalizers alone
+				if trInstance.DeletionTimestamp == nil && !config.HasSkippedFinalizer(&trInstance) {
+					logger.Debugw("Pruning TaskRun of a PipelineRun pruned in this cycle", "namespace", trInstance.Namespace, "name", trInstance.Name, "pipelineRunUID", prUID)
+					orphans = append(orphans, orphanedTaskRun(&trInstance))
+				}
+				continue
+			}
+
+			if trInstance.Status.CompletionTime != nil && !config.HasTaskRunParent(&trInstance) {
 				tr := &trInstance
+				getGCSummary(ctx).addEvaluated(metrics.ResourceTypeTaskRun)
 
-				// Check if the history limit processed time which is stored as a string in annotation of PR config.AnnotationHistoryLimitCheckProcessed is not nil
+				// Check if the history limit processed time which is stored as a string in the processed annotation of TR is not nil
 				// and earlier than the configmap update time
-				if trInstance.Annotations[config.AnnotationHistoryLimitCheckProcessed] != "" {
+				if trInstance.Annotations[processedAnnotationKey] != "" {
 					// Parse the annotation value to a time.Time object
-					annotationTime, err := time.Parse(time.RFC3339, trInstance.Annotations[config.AnnotationHistoryLimitCheckProcessed])
+					annotationTime, err := time.Parse(time.RFC3339, trInstance.Annotations[processedAnnotationKey])
 					if err != nil {
 						logger.Errorw("error parsing history limit check processed time", "namespace", tr.Namespace, "name", tr.Name, zap.Error(err))
 						continue // Continue to next TR instead of returning error
@@ -325,24 +1536,38 @@ func cleanupTRs(ctx context.Context, namespace string, configMapUpdateTime strin
 
 					if updateTime.After(annotationTime) {
 						// Use JSON Patch to remove only the specific annotation without affecting others
-						jsonPatch := fmt.Sprintf(`[{"op": "remove", "path": "/metadata/annotations/%s"}]`,
-							strings.ReplaceAll(config.AnnotationHistoryLimitCheckProcessed, "/", "~1"))
+						jsonPatch, err := config.AnnotationPatch(tr, nil, processedAnnotationKey)
+						if goerrors.Is(err, config.ErrAnnotationNotAllowed) {
+							logger.Warnw("skipping the removal of the history limit check processed annotation", "namespace", tr.Namespace, "name", tr.Name, zap.Error(err))
+							continue
+						}
+						if err != nil {
+							logger.Errorw("error creating the patch removing the history limit check processed annotation", "namespace", tr.Namespace, "name", tr.Name, zap.Error(err))
+							continue // Continue to next TR instead of returning error
+						}
 
 						// Patch the TaskRun to remove the annotation
-						_, err = pipelineClient.TektonV1().TaskRuns(tr.Namespace).Patch(ctx, tr.Name, types.JSONPatchType, []byte(jsonPatch), metav1.PatchOptions{})
+						err = config.PatchWithRetry(ctx, func() error {
+							return config.CallWithTimeout(ctx, metrics.ResourceTypeTaskRun, tr.Namespace, func(ctx context.Context) error {
+								_, err := pipelineClient.TektonV1().TaskRuns(tr.Namespace).Patch(ctx, tr.Name, types.JSONPatchType, jsonPatch, metav1.PatchOptions{FieldManager: config.GetFieldManager()})
+								return err
+							})
+						})
 						if err != nil {
 							// If the TaskRun is not found, it may have been deleted already, so we can continue
 							if errors.IsNotFound(err) {
 								logger.Debugw("TaskRun not found during annotation patch - may have been deleted already", "namespace", tr.Namespace, "name", tr.Name)
 								continue
 							}
+							// The TaskRun keeps its processed annotation, and is evaluated again by every cycle until the patch succeeds
 							logger.Errorw("error patching TaskRun to remove history limit check processed annotation", "namespace", tr.Namespace, "name", tr.Name, zap.Error(err))
+							metrics.GetRecorder().RecordAnnotationPatchFailure(ctx, metrics.ResourceTypeTaskRun, tr.Namespace)
 							continue // Continue to next TR instead of returning error
 						}
 					}
 				}
 
-				err := trHistoryLimiter.ProcessEvent(ctx, tr)
+				err := trHistoryLimiter.ProcessEvent(withGCOperation(ctx, metrics.OperationHistory), tr)
 				if err != nil {
 					// If the TaskRun is not found, it may have been processed/deleted by another worker, continue to next TR
 					if errors.IsNotFound(err) {
@@ -350,10 +1575,11 @@ func cleanupTRs(ctx context.Context, namespace string, configMapUpdateTime strin
 						continue
 					}
 					logger.Errorw("error processing history limiting for a TaskRun", "namespace", tr.Namespace, "name", tr.Name, zap.Error(err))
+					getGCSummary(ctx).addError()
 					continue // Continue to next TR instead of returning error
 				}
 				// execute ttl handler
-				err = trTTLHandler.ProcessEvent(ctx, tr)
+				err = trTTLHandler.ProcessEvent(withGCOperation(ctx, metrics.OperationTTL), tr)
 				if err != nil {
 					// If the TaskRun is not found, it may have been processed/deleted by another worker, continue to next TR
 					if errors.IsNotFound(err) {
@@ -365,12 +1591,33 @@ func cleanupTRs(ctx context.Context, namespace string, configMapUpdateTime strin
 					if !isRequeueKey {
 						data, _ := json.Marshal(tr)
 						logger.Errorw("error processing ttl for a TaskRun", "namespace", tr.Namespace, "name", tr.Name, "resource", string(data), zap.Error(err))
+						getGCSummary(ctx).addError()
 					}
 					continue // Continue to next TR instead of returning error
 				}
 			}
 
 		}
+
+		// the orphans go through the same checks as the other pruned runs, e.g. neverPrune, protection rules and annotate mode
+		if err := pruneRuns(ctx, namespace, orphans, config.PruneReasonOwner, metrics.OperationOwner); err != nil {
+			return err
+		}
 	}
 	return nil
 }
+
+// orphanedTaskRun returns a TaskRun left behind by its pruned PipelineRun as a run to prune,
+// whether it completed or not, as it is pruned along with its PipelineRun
+func orphanedTaskRun(tr *pipelinev1.TaskRun) completedRun {
+	run := completedRun{
+		resourceType: metrics.ResourceTypeTaskRun,
+		name:         tr.Name,
+		creationTime: tr.CreationTimestamp.Time,
+		object:       tr,
+	}
+	if tr.Status.CompletionTime != nil {
+		run.completionTime = tr.Status.CompletionTime.Time
+	}
+	return run
+}
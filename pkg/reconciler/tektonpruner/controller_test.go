@@ -2,26 +2,42 @@ package tektonpruner
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/logging"
 	logtesting "knative.dev/pkg/logging/testing"
+	"knative.dev/pkg/reconciler"
 	"knative.dev/pkg/system"
 	_ "knative.dev/pkg/system/testing" // Required for setting system namespace in tests
 
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
 	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	pipelinefake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
 	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
 	"github.com/tektoncd/pruner/pkg/config"
+	"github.com/tektoncd/pruner/pkg/metrics"
 )
 
 func TestGarbageCollection(t *testing.T) {
@@ -206,6 +222,11 @@ ttlSecondsAfterFinished: 60`,
 	ctx = context.WithValue(ctx, kubeclient.Key{}, kubeClient)
 	ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
 
+	r := &Reconciler{kubeclient: kubeClient}
+	if err := r.Promote(reconciler.UniversalBucket(), nil); err != nil {
+		t.Fatalf("Failed to promote reconciler: %v", err)
+	}
+
 	const triggers = 5
 
 	var wg sync.WaitGroup
@@ -213,7 +234,7 @@ ttlSecondsAfterFinished: 60`,
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			safeRunGarbageCollector(ctx, logger)
+			r.safeRunGarbageCollector(ctx, logger)
 		}()
 	}
 	wg.Wait()
@@ -228,9 +249,106 @@ ttlSecondsAfterFinished: 60`,
 	}
 }
 
+// TestSafeRunGarbageCollectorLeaderElection verifies that only the leader replica runs garbage collection.
+func TestSafeRunGarbageCollectorLeaderElection(t *testing.T) {
+	tests := []struct {
+		name      string
+		bucket    reconciler.Bucket
+		wantSweep bool
+	}{
+		{
+			name:      "leader runs garbage collection",
+			bucket:    reconciler.UniversalBucket(),
+			wantSweep: true,
+		},
+		{
+			name: "non-leader skips garbage collection",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := logtesting.TestLogger(t)
+			ctx := logging.WithLogger(context.Background(), logger)
+
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      config.PrunerConfigMapName,
+					Namespace: system.Namespace(),
+				},
+				Data: map[string]string{
+					"global-config": `ttlSecondsAfterFinished: 60`,
+				},
+			}
+			kubeClient := fake.NewSimpleClientset(cm)
+			ctx = context.WithValue(ctx, kubeclient.Key{}, kubeClient)
+			ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelinefake.NewSimpleClientset())
+
+			r := &Reconciler{kubeclient: kubeClient}
+			if tt.bucket != nil {
+				if err := r.Promote(tt.bucket, nil); err != nil {
+					t.Fatalf("Failed to promote reconciler: %v", err)
+				}
+			}
+
+			r.safeRunGarbageCollector(ctx, logger)
+
+			swept := false
+			for _, action := range kubeClient.Actions() {
+				if action.Matches("list", "namespaces") {
+					swept = true
+				}
+			}
+			if swept != tt.wantSweep {
+				t.Errorf("garbage collection ran = %v, want %v", swept, tt.wantSweep)
+			}
+		})
+	}
+}
+
+// TestSafeRunGarbageCollectorMetricsDump verifies that the metrics snapshot is dumped after each GC cycle, if enabled
+func TestSafeRunGarbageCollectorMetricsDump(t *testing.T) {
+	logger := logtesting.TestLogger(t)
+	ctx := logging.WithLogger(context.Background(), logger)
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	ctx = WithMetricsDumpFile(ctx, path)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.PrunerConfigMapName,
+			Namespace: system.Namespace(),
+		},
+		Data: map[string]string{
+			"global-config": `ttlSecondsAfterFinished: 60`,
+		},
+	}
+	kubeClient := fake.NewSimpleClientset(cm)
+	ctx = context.WithValue(ctx, kubeclient.Key{}, kubeClient)
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelinefake.NewSimpleClientset())
+
+	r := &Reconciler{kubeclient: kubeClient}
+	if err := r.Promote(reconciler.UniversalBucket(), nil); err != nil {
+		t.Fatalf("Failed to promote reconciler: %v", err)
+	}
+	r.safeRunGarbageCollector(ctx, logger)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the metrics snapshot: %v", err)
+	}
+	var snapshot metrics.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("Failed to decode the metrics snapshot: %v", err)
+	}
+	if snapshot.Timestamp.IsZero() {
+		t.Error("expected the metrics snapshot to carry its timestamp")
+	}
+}
+
 func TestGetFilteredNamespaces(t *testing.T) {
 	tests := []struct {
 		name         string
+		globalConfig string
 		namespaces   []string
 		wantFiltered []string
 	}{
@@ -258,11 +376,54 @@ func TestGetFilteredNamespaces(t *testing.T) {
 				"test2",
 			},
 		},
+		{
+			name:         "Filter namespaces matching exclude regexes",
+			globalConfig: `namespaceExcludeRegexes: [".*-system$", "^monitoring-.*"]`,
+			namespaces: []string{
+				"cert-manager-system",
+				"kube-system",
+				"monitoring-prometheus",
+				"system-tests",
+				"team-monitoring-a",
+			},
+			wantFiltered: []string{
+				"system-tests",
+				"team-monitoring-a",
+			},
+		},
+		{
+			name:       "Namespaces sorted alphabetically",
+			namespaces: []string{"team-c", "team-a", "default", "team-b"},
+			wantFiltered: []string{
+				"default",
+				"team-a",
+				"team-b",
+				"team-c",
+			},
+		},
+		{
+			name:         "Priority namespaces first",
+			globalConfig: `namespacePriority: [team-c, prod, team-b]`,
+			namespaces:   []string{"team-a", "team-b", "team-c", "team-d", "kube-system"},
+			wantFiltered: []string{
+				"team-c",
+				"team-b",
+				"team-a",
+				"team-d",
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
+			cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("LoadGlobalConfig() error = %v", err)
+			}
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
 
 			// Create fake namespaces
 			var namespaceObjects []runtime.Object
@@ -295,3 +456,1305 @@ func TestGetFilteredNamespaces(t *testing.T) {
 		})
 	}
 }
+
+// TestGetFilteredNamespacesLister verifies the namespaces are read from the namespace lister, without listing them from the API server.
+func TestGetFilteredNamespacesLister(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, name := range []string{"team-b", "team-a", "kube-system"} {
+		if err := indexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}); err != nil {
+			t.Fatalf("failed to add namespace %s: %v", name, err)
+		}
+	}
+	ctx := withNamespaceLister(context.Background(), corev1listers.NewNamespaceLister(indexer))
+	client := fake.NewSimpleClientset()
+
+	filtered, err := getFilteredNamespaces(ctx, client)
+	if err != nil {
+		t.Fatalf("getFilteredNamespaces() error = %v", err)
+	}
+	if want := []string{"team-a", "team-b"}; !reflect.DeepEqual(filtered, want) {
+		t.Errorf("namespaces = %v, want %v", filtered, want)
+	}
+	if actions := client.Actions(); len(actions) > 0 {
+		t.Errorf("unexpected API server calls %v", actions)
+	}
+}
+
+// TestRunGarbageCollectorNamespaceScope verifies that a controller scoped to namespaces
+// never lists the cluster namespaces nor the runs outside its scope.
+func TestRunGarbageCollectorNamespaceScope(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+	ctx = WithNamespaceScope(ctx, []string{"team-a", "team-b"})
+	defer func() {
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: config.PrunerConfigMapName, Namespace: system.Namespace()},
+			Data:       map[string]string{config.PrunerGlobalConfigKey: `ttlSecondsAfterFinished: 60`},
+		},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-c"}},
+	)
+	pipelineClient := pipelinefake.NewSimpleClientset()
+	ctx = context.WithValue(ctx, kubeclient.Key{}, kubeClient)
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+
+	runGarbageCollector(ctx)
+
+	for _, action := range kubeClient.Actions() {
+		if action.Matches("list", "namespaces") {
+			t.Errorf("unexpected list of the cluster namespaces")
+		}
+	}
+	listed := map[string]bool{}
+	for _, action := range pipelineClient.Actions() {
+		if action.GetVerb() == "list" {
+			listed[action.GetNamespace()] = true
+		}
+	}
+	if !reflect.DeepEqual(listed, map[string]bool{"team-a": true, "team-b": true}) {
+		t.Errorf("listed runs in namespaces %v, want only team-a and team-b", listed)
+	}
+}
+
+// TestRunGarbageCollectorCustomSystemNamespace verifies that with the pruner installed in a custom namespace,
+// the global config is loaded from that namespace, and the runs of that namespace are not garbage collected
+func TestRunGarbageCollectorCustomSystemNamespace(t *testing.T) {
+	t.Setenv(system.NamespaceEnvKey, "pruner-system")
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+	defer func() {
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: config.PrunerConfigMapName, Namespace: "pruner-system"},
+			Data:       map[string]string{config.PrunerGlobalConfigKey: `ttlSecondsAfterFinished: 60`},
+		},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "pruner-system"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+	)
+	pipelineClient := pipelinefake.NewSimpleClientset()
+	ctx = context.WithValue(ctx, kubeclient.Key{}, kubeClient)
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+
+	runGarbageCollector(ctx)
+
+	if ttl, _ := config.PrunerConfigStore.GetPipelineTTLSecondsAfterFinished("team-a", "", config.SelectorSpec{}); ttl == nil || *ttl != 60 {
+		t.Errorf("TTL = %v, want the 60 seconds of the global config in pruner-system", ttl)
+	}
+	listed := map[string]bool{}
+	for _, action := range pipelineClient.Actions() {
+		if action.GetVerb() == "list" {
+			listed[action.GetNamespace()] = true
+		}
+	}
+	if !reflect.DeepEqual(listed, map[string]bool{"team-a": true}) {
+		t.Errorf("listed runs in namespaces %v, want only team-a", listed)
+	}
+}
+
+// TestGCWorkerCount verifies that autoWorkerCount sizes the worker pool after the number of namespaces
+func TestGCWorkerCount(t *testing.T) {
+	tests := []struct {
+		name           string
+		globalConfig   string
+		namespaceCount int
+		want           int
+	}{
+		{name: "static worker count", globalConfig: `enforcedConfigLevel: global`, namespaceCount: 500, want: 5},
+		{name: "no namespace", globalConfig: `autoWorkerCount: true`, namespaceCount: 0, want: 1},
+		{name: "few namespaces", globalConfig: `autoWorkerCount: true`, namespaceCount: 3, want: 1},
+		{name: "rounded up", globalConfig: `autoWorkerCount: true`, namespaceCount: 41, want: 5},
+		{name: "default maximum", globalConfig: `autoWorkerCount: true`, namespaceCount: 5000, want: config.DefaultMaxWorkerCount},
+		{name: "configured maximum", globalConfig: "autoWorkerCount: true\nmaxWorkerCount: 8", namespaceCount: 200, want: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+			cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			if got := gcWorkerCount(ctx, config.DefaultWorkerCountForNamespaceCleanup, tt.namespaceCount); got != tt.want {
+				t.Errorf("gcWorkerCount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWaitInterNamespaceDelay verifies that the delay between namespaces is cut short when the controller shuts down
+func TestWaitInterNamespaceDelay(t *testing.T) {
+	start := time.Now()
+	waitInterNamespaceDelay(context.Background(), 20*time.Millisecond)
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("waited %v, want at least 20ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start = time.Now()
+	waitInterNamespaceDelay(ctx, time.Hour)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("waited %v after the shutdown, want no wait", elapsed)
+	}
+}
+
+// TestCleanupPRsShortenedTTL verifies that shortening the TTL in the config prunes the runs
+// which already expired under the new TTL on the next cycle, even though their TTL annotation
+// still holds the previous, longer TTL.
+func TestCleanupPRsShortenedTTL(t *testing.T) {
+	const namespace = "test-namespace"
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+
+	cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: `ttlSecondsAfterFinished: 600`}}
+	if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	defer func() {
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	now := time.Now()
+	newPR := func(name string, completedAgo time.Duration) *pipelinev1.PipelineRun {
+		return &pipelinev1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Labels:      map[string]string{config.LabelPipelineName: "build"},
+				Annotations: map[string]string{config.AnnotationTTLSecondsAfterFinished: "3600"},
+			},
+			Status: pipelinev1.PipelineRunStatus{
+				PipelineRunStatusFields: pipelinev1.PipelineRunStatusFields{
+					StartTime:      &metav1.Time{Time: now.Add(-completedAgo - time.Minute)},
+					CompletionTime: &metav1.Time{Time: now.Add(-completedAgo)},
+				},
+			},
+		}
+	}
+
+	pipelineClient := pipelinefake.NewSimpleClientset(
+		newPR("expired-under-new-ttl", 30*time.Minute),
+		newPR("within-new-ttl", time.Minute),
+	)
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+
+	if err := cleanupPRs(ctx, namespace, now.Format(time.RFC3339)); err != nil {
+		t.Fatalf("cleanupPRs() error = %v", err)
+	}
+
+	prs, err := pipelineClient.TektonV1().PipelineRuns(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list PipelineRuns: %v", err)
+	}
+	if len(prs.Items) != 1 || prs.Items[0].Name != "within-new-ttl" {
+		var names []string
+		for _, pr := range prs.Items {
+			names = append(names, pr.Name)
+		}
+		t.Fatalf("remaining PipelineRuns = %v, want [within-new-ttl]", names)
+	}
+	if got := prs.Items[0].Annotations[config.AnnotationTTLSecondsAfterFinished]; got != "600" {
+		t.Errorf("TTL annotation = %q, want %q", got, "600")
+	}
+}
+
+// TestCleanupRunsCancelled verifies that the cleanup of the runs of a namespace stops as soon as the context is cancelled
+func TestCleanupRunsCancelled(t *testing.T) {
+	const namespace = "test-namespace"
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+
+	cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: `ttlSecondsAfterFinished: 0`}}
+	if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	defer func() {
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	completionTime := &metav1.Time{Time: time.Now().Add(-time.Hour)}
+	pr := &pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: "pr-expired", Namespace: namespace}}
+	pr.Status.CompletionTime = completionTime
+	tr := &pipelinev1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "tr-expired", Namespace: namespace}}
+	tr.Status.CompletionTime = completionTime
+	pipelineClient := pipelinefake.NewSimpleClientset(pr, tr)
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+	ctx = withPrunedPipelineRuns(ctx)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if err := cleanupPRs(ctx, namespace, time.Now().Format(time.RFC3339)); err != context.Canceled {
+		t.Errorf("cleanupPRs() error = %v, want %v", err, context.Canceled)
+	}
+	if err := cleanupTRs(ctx, namespace, time.Now().Format(time.RFC3339)); err != context.Canceled {
+		t.Errorf("cleanupTRs() error = %v, want %v", err, context.Canceled)
+	}
+	for _, action := range pipelineClient.Actions() {
+		if action.GetVerb() == "delete" {
+			t.Errorf("unexpected delete of %s after cancellation", action.GetResource().Resource)
+		}
+	}
+}
+
+// TestCleanupPRsListThrottled verifies that listing the PipelineRuns of a namespace is retried
+// when the API server throttles it with 429 Too Many Requests
+func TestCleanupPRsListThrottled(t *testing.T) {
+	const namespace = "test-namespace"
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+
+	cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: `ttlSecondsAfterFinished: 600
+listRetryBackoffMilliseconds: 1`}}
+	if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	defer func() {
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	now := time.Now()
+	pipelineClient := pipelinefake.NewSimpleClientset(&pipelinev1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "expired",
+			Namespace: namespace,
+			Labels:    map[string]string{config.LabelPipelineName: "build"},
+		},
+		Status: pipelinev1.PipelineRunStatus{
+			PipelineRunStatusFields: pipelinev1.PipelineRunStatusFields{
+				StartTime:      &metav1.Time{Time: now.Add(-2 * time.Hour)},
+				CompletionTime: &metav1.Time{Time: now.Add(-time.Hour)},
+			},
+		},
+	})
+	throttled := true
+	pipelineClient.PrependReactor("list", "pipelineruns", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if throttled {
+			throttled = false
+			return true, nil, apierrors.NewTooManyRequests("throttled", 0)
+		}
+		return false, nil, nil // fall through to the tracker
+	})
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+
+	if err := cleanupPRs(ctx, namespace, now.Format(time.RFC3339)); err != nil {
+		t.Fatalf("cleanupPRs() error = %v", err)
+	}
+
+	if _, err := pipelineClient.TektonV1().PipelineRuns(namespace).Get(ctx, "expired", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the expired PipelineRun to be deleted after the throttled List, got %v", err)
+	}
+}
+
+// TestCleanupTRsOrphanedByPrunedPipelineRun verifies that a TaskRun which lost the owner reference
+// to its PipelineRun follows the PipelineRun pruned earlier in the cycle, instead of being kept
+// by the standalone TaskRun policy, while neverPrune still keeps it.
+func TestCleanupTRsOrphanedByPrunedPipelineRun(t *testing.T) {
+	const namespace = "test-namespace"
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+	ctx = withPrunedPipelineRuns(ctx)
+
+	cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: `ttlSecondsAfterFinished: 3600
+neverPrune:
+  taskRuns: [audit]`}}
+	if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	defer func() {
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	var pruned []config.PrunedEvent
+	ctx = config.WithPrunedFunc(ctx, func(_ context.Context, event config.PrunedEvent) {
+		pruned = append(pruned, event)
+	})
+
+	now := time.Now()
+	status := func(completedAgo time.Duration) pipelinev1.PipelineRunStatusFields {
+		return pipelinev1.PipelineRunStatusFields{
+			StartTime:      &metav1.Time{Time: now.Add(-completedAgo - time.Minute)},
+			CompletionTime: &metav1.Time{Time: now.Add(-completedAgo)},
+		}
+	}
+	newPR := func(name string, uid types.UID, completedAgo time.Duration) *pipelinev1.PipelineRun {
+		return &pipelinev1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: uid},
+			Status:     pipelinev1.PipelineRunStatus{PipelineRunStatusFields: status(completedAgo)},
+		}
+	}
+	// the TaskRuns completed recently enough to be kept as standalone TaskRuns
+	newOrphanedTR := func(name string, prUID types.UID) *pipelinev1.TaskRun {
+		tr := &pipelinev1.TaskRun{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{pipeline.PipelineRunUIDLabelKey: string(prUID)},
+		}}
+		tr.Status.StartTime = &metav1.Time{Time: now.Add(-2 * time.Minute)}
+		tr.Status.CompletionTime = &metav1.Time{Time: now.Add(-time.Minute)}
+		return tr
+	}
+
+	pipelineClient := pipelinefake.NewSimpleClientset(
+		newPR("pr-expired", "uid-expired", 2*time.Hour),
+		newPR("pr-recent", "uid-recent", time.Minute),
+		newOrphanedTR("pr-expired-task", "uid-expired"),
+		newOrphanedTR("pr-recent-task", "uid-recent"),
+	)
+	neverPruned := newOrphanedTR("pr-expired-audit", "uid-expired")
+	neverPruned.Labels[config.LabelTaskName] = "audit"
+	if err := pipelineClient.Tracker().Add(neverPruned); err != nil {
+		t.Fatalf("failed to add the TaskRun: %v", err)
+	}
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+
+	if err := cleanupPRs(ctx, namespace, now.Format(time.RFC3339)); err != nil {
+		t.Fatalf("cleanupPRs() error = %v", err)
+	}
+	if err := cleanupTRs(ctx, namespace, now.Format(time.RFC3339)); err != nil {
+		t.Fatalf("cleanupTRs() error = %v", err)
+	}
+
+	var deleted []string
+	for _, action := range pipelineClient.Actions() {
+		if deleteAction, ok := action.(k8stesting.DeleteAction); ok {
+			deleted = append(deleted, deleteAction.GetName())
+		}
+	}
+	if want := []string{"pr-expired", "pr-expired-task"}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("deleted runs = %v, want %v", deleted, want)
+	}
+	var ownerPruned []string
+	for _, event := range pruned {
+		if event.Reason == config.PruneReasonOwner {
+			ownerPruned = append(ownerPruned, event.Kind+"/"+event.Name)
+		}
+	}
+	if want := []string{config.KindTaskRun + "/pr-expired-task"}; !reflect.DeepEqual(ownerPruned, want) {
+		t.Errorf("runs pruned with their owner = %v, want %v", ownerPruned, want)
+	}
+}
+
+// TestEnforceNamespaceRunCap verifies that the oldest completed runs beyond maxCompletedRunsPerNamespace
+// are deleted, while running and PipelineRun-owned runs are neither counted nor deleted, and the runs which cannot be pruned
+// (protected, never pruned or finalized by another controller) are passed over in favor of the next oldest ones.
+func TestEnforceNamespaceRunCap(t *testing.T) {
+	const namespace = "test-namespace"
+	now := time.Now()
+	completedAt := func(minutesAgo int) *metav1.Time {
+		return &metav1.Time{Time: now.Add(-time.Duration(minutesAgo) * time.Minute)}
+	}
+	newPR := func(name string, completionTime *metav1.Time) *pipelinev1.PipelineRun {
+		pr := &pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		pr.Status.CompletionTime = completionTime
+		return pr
+	}
+	newTR := func(name string, completionTime *metav1.Time, owned bool) *pipelinev1.TaskRun {
+		tr := &pipelinev1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		if owned {
+			tr.OwnerReferences = []metav1.OwnerReference{{Kind: pipeline.PipelineRunControllerName, Name: "pr-owner"}}
+		}
+		tr.Status.CompletionTime = completionTime
+		return tr
+	}
+
+	tests := []struct {
+		name         string
+		globalConfig string
+		wantDeleted  []string
+	}{
+		{
+			name:         "no cap configured",
+			globalConfig: `enforcedConfigLevel: global`,
+		},
+		{
+			name:         "cap not exceeded",
+			globalConfig: `maxCompletedRunsPerNamespace: 4`,
+		},
+		{
+			name:         "oldest runs beyond the cap are deleted",
+			globalConfig: `maxCompletedRunsPerNamespace: 2`,
+			wantDeleted:  []string{"pr-oldest", "tr-old"},
+		},
+		{
+			name:         "zero cap deletes all completed runs",
+			globalConfig: `maxCompletedRunsPerNamespace: 0`,
+			wantDeleted:  []string{"pr-oldest", "tr-old", "pr-new", "tr-newest"},
+		},
+		{
+			name: "protected oldest run is passed over",
+			globalConfig: `
+maxCompletedRunsPerNamespace: 2
+protectIfReferencedBy:
+  - apiVersion: example.com/v1
+    kind: Record
+    resource: records
+    labelKey: example.com/record`,
+			wantDeleted: []string{"tr-old", "pr-new"},
+		},
+		{
+			name: "never pruned oldest run is passed over",
+			globalConfig: `
+maxCompletedRunsPerNamespace: 2
+neverPrune:
+  pipelineRuns: [release]`,
+			wantDeleted: []string{"tr-old", "pr-new"},
+		},
+		{
+			name: "finalized oldest run is passed over",
+			globalConfig: `
+maxCompletedRunsPerNamespace: 2
+skipRunsWithFinalizers: [chains.tekton.dev]`,
+			wantDeleted: []string{"tr-old", "pr-new"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+			cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+			ctx = config.WithResourceExistsFunc(ctx, func(_ context.Context, _ schema.GroupVersionResource, _, name string) (bool, error) {
+				return name == "keep", nil
+			})
+
+			prOldest := newPR("pr-oldest", completedAt(40))
+			prOldest.Labels = map[string]string{"example.com/record": "keep", config.LabelPipelineName: "release"}
+			prOldest.Finalizers = []string{"chains.tekton.dev"}
+			pipelineClient := pipelinefake.NewSimpleClientset(
+				prOldest,
+				newPR("pr-new", completedAt(20)),
+				newPR("pr-running", nil),
+				newTR("tr-old", completedAt(30), false),
+				newTR("tr-newest", completedAt(10), false),
+				newTR("tr-owned", completedAt(50), true),
+			)
+			ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+
+			if err := enforceNamespaceRunCap(ctx, namespace); err != nil {
+				t.Fatalf("enforceNamespaceRunCap() error = %v", err)
+			}
+
+			var deleted []string
+			for _, action := range pipelineClient.Actions() {
+				if deleteAction, ok := action.(k8stesting.DeleteAction); ok {
+					deleted = append(deleted, deleteAction.GetName())
+				}
+			}
+			if len(deleted) != len(tt.wantDeleted) {
+				t.Fatalf("deleted runs = %v, want %v", deleted, tt.wantDeleted)
+			}
+			for i, name := range deleted {
+				if name != tt.wantDeleted[i] {
+					t.Errorf("deleted[%d] = %s, want %s", i, name, tt.wantDeleted[i])
+				}
+			}
+		})
+	}
+}
+
+// TestEnforceNamespaceObjectBudget verifies that the oldest completed runs are deleted until all the runs of the namespace
+// fit in the budget, counting the TaskRuns of a PipelineRun, and that protected runs are skipped.
+func TestEnforceNamespaceObjectBudget(t *testing.T) {
+	const namespace = "test-namespace"
+	now := time.Now()
+	completedAt := func(minutesAgo int) *metav1.Time {
+		return &metav1.Time{Time: now.Add(-time.Duration(minutesAgo) * time.Minute)}
+	}
+	newPR := func(name string, completionTime *metav1.Time) *pipelinev1.PipelineRun {
+		pr := &pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID(name)}}
+		pr.Status.CompletionTime = completionTime
+		return pr
+	}
+	newTR := func(name string, completionTime *metav1.Time, owner string) *pipelinev1.TaskRun {
+		tr := &pipelinev1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		if owner != "" {
+			tr.OwnerReferences = []metav1.OwnerReference{{Kind: pipeline.PipelineRunControllerName, Name: owner, UID: types.UID(owner)}}
+		}
+		tr.Status.CompletionTime = completionTime
+		return tr
+	}
+	prOldest := newPR("pr-oldest", completedAt(40))
+	prOldest.Labels = map[string]string{"example.com/record": "keep"}
+
+	tests := []struct {
+		name         string
+		globalConfig string
+		wantDeleted  []string
+	}{
+		{
+			name:         "no budget configured",
+			globalConfig: `enforcedConfigLevel: global`,
+		},
+		{
+			name:         "budget not exceeded",
+			globalConfig: `namespaceObjectBudget: 7`,
+		},
+		{
+			name:         "deleting a PipelineRun frees its TaskRuns",
+			globalConfig: `namespaceObjectBudget: 4`,
+			wantDeleted:  []string{"pr-oldest"},
+		},
+		{
+			name:         "oldest runs are deleted until within budget",
+			globalConfig: `namespaceObjectBudget: 3`,
+			wantDeleted:  []string{"pr-oldest", "tr-old"},
+		},
+		{
+			name:         "running runs are never deleted",
+			globalConfig: `namespaceObjectBudget: 0`,
+			wantDeleted:  []string{"pr-oldest", "tr-old", "pr-new", "tr-newest"},
+		},
+		{
+			name: "protected runs are skipped",
+			globalConfig: `
+namespaceObjectBudget: 4
+protectIfReferencedBy:
+  - apiVersion: example.com/v1
+    kind: Record
+    resource: records
+    labelKey: example.com/record`,
+			wantDeleted: []string{"tr-old", "pr-new", "tr-newest"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+			cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+			ctx = config.WithResourceExistsFunc(ctx, func(_ context.Context, _ schema.GroupVersionResource, _, name string) (bool, error) {
+				return name == "keep", nil
+			})
+
+			pipelineClient := pipelinefake.NewSimpleClientset(
+				prOldest,
+				newPR("pr-new", completedAt(20)),
+				newPR("pr-running", nil),
+				newTR("tr-child-1", completedAt(45), "pr-oldest"),
+				newTR("tr-child-2", completedAt(42), "pr-oldest"),
+				newTR("tr-old", completedAt(30), ""),
+				newTR("tr-newest", completedAt(10), ""),
+			)
+			ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+
+			if err := enforceNamespaceObjectBudget(ctx, namespace); err != nil {
+				t.Fatalf("enforceNamespaceObjectBudget() error = %v", err)
+			}
+
+			var deleted []string
+			for _, action := range pipelineClient.Actions() {
+				if deleteAction, ok := action.(k8stesting.DeleteAction); ok {
+					deleted = append(deleted, deleteAction.GetName())
+				}
+			}
+			if !reflect.DeepEqual(deleted, tt.wantDeleted) {
+				t.Errorf("deleted runs = %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+// TestPruneLargeStatusRuns verifies that completed runs whose status exceeds pruneLargeStatusBytes are pruned, largest first.
+func TestPruneLargeStatusRuns(t *testing.T) {
+	const namespace = "test-namespace"
+	completedAt := &metav1.Time{Time: time.Now().Add(-time.Hour)}
+	newPR := func(name string, completionTime *metav1.Time, resultSize int) *pipelinev1.PipelineRun {
+		pr := &pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		pr.Status.CompletionTime = completionTime
+		if resultSize > 0 {
+			pr.Status.Results = []pipelinev1.PipelineRunResult{{Name: "out", Value: *pipelinev1.NewStructuredValues(strings.Repeat("x", resultSize))}}
+		}
+		return pr
+	}
+	newTR := func(name string, resultSize int) *pipelinev1.TaskRun {
+		tr := &pipelinev1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		tr.Status.CompletionTime = completedAt
+		tr.Status.Results = []pipelinev1.TaskRunResult{{Name: "out", Value: *pipelinev1.NewStructuredValues(strings.Repeat("x", resultSize))}}
+		return tr
+	}
+
+	tests := []struct {
+		name         string
+		globalConfig string
+		wantDeleted  []string
+		wantPatched  []string
+	}{
+		{
+			name:         "no threshold configured",
+			globalConfig: `enforcedConfigLevel: global`,
+		},
+		{
+			name:         "runs above the threshold are deleted largest first",
+			globalConfig: `pruneLargeStatusBytes: 1000`,
+			wantDeleted:  []string{"tr-huge", "pr-large"},
+		},
+		{
+			name: "runs above the threshold are annotated",
+			globalConfig: `
+pruneLargeStatusBytes: 1000
+deletionMode: annotate`,
+			wantPatched: []string{"tr-huge", "pr-large"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+			cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			pipelineClient := pipelinefake.NewSimpleClientset(
+				newPR("pr-small", completedAt, 10),
+				newPR("pr-large", completedAt, 2000),
+				newPR("pr-running", nil, 5000),
+				newTR("tr-huge", 4000),
+			)
+			ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+
+			if err := pruneLargeStatusRuns(ctx, namespace); err != nil {
+				t.Fatalf("pruneLargeStatusRuns() error = %v", err)
+			}
+
+			var deleted, patched []string
+			for _, action := range pipelineClient.Actions() {
+				switch a := action.(type) {
+				case k8stesting.DeleteAction:
+					deleted = append(deleted, a.GetName())
+				case k8stesting.PatchAction:
+					patched = append(patched, a.GetName())
+				}
+			}
+			if !reflect.DeepEqual(deleted, tt.wantDeleted) {
+				t.Errorf("deleted runs = %v, want %v", deleted, tt.wantDeleted)
+			}
+			if !reflect.DeepEqual(patched, tt.wantPatched) {
+				t.Errorf("patched runs = %v, want %v", patched, tt.wantPatched)
+			}
+		})
+	}
+}
+
+// TestPruneStuckRuns verifies that only the runs not completed past both pruneStuckAfterSeconds and their own timeout are pruned.
+func TestPruneStuckRuns(t *testing.T) {
+	const namespace = "test-namespace"
+	now := time.Now()
+	newPR := func(name string, startedAgo time.Duration) *pipelinev1.PipelineRun {
+		pr := &pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		pr.Status.StartTime = &metav1.Time{Time: now.Add(-startedAgo)}
+		return pr
+	}
+	newTR := func(name string, startedAgo time.Duration) *pipelinev1.TaskRun {
+		tr := &pipelinev1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		tr.Status.StartTime = &metav1.Time{Time: now.Add(-startedAgo)}
+		return tr
+	}
+
+	prLongTimeout := newPR("pr-long-timeout", 3*time.Hour)
+	prLongTimeout.Spec.Timeouts = &pipelinev1.TimeoutFields{Pipeline: &metav1.Duration{Duration: 4 * time.Hour}}
+	prDone := newPR("pr-done", 3*time.Hour)
+	prDone.Status.SetCondition(&apis.Condition{Type: apis.ConditionSucceeded, Status: corev1.ConditionTrue})
+	prPending := &pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: "pr-pending", Namespace: namespace}}
+	prPending.Spec.Status = pipelinev1.PipelineRunSpecStatusPending
+	trOwned := newTR("tr-owned", 3*time.Hour)
+	trOwned.OwnerReferences = []metav1.OwnerReference{{APIVersion: "tekton.dev/v1", Kind: pipeline.PipelineRunControllerName, Name: "pr-stuck"}}
+
+	tests := []struct {
+		name         string
+		globalConfig string
+		wantDeleted  []string
+		wantPatched  []string
+	}{
+		{
+			name:         "no threshold configured",
+			globalConfig: `enforcedConfigLevel: global`,
+		},
+		{
+			name:         "runs stuck past the threshold and their timeout are deleted",
+			globalConfig: `pruneStuckAfterSeconds: 7200`,
+			wantDeleted:  []string{"pr-stuck", "tr-stuck"},
+		},
+		{
+			name: "stuck runs are annotated",
+			globalConfig: `
+pruneStuckAfterSeconds: 7200
+deletionMode: annotate`,
+			wantPatched: []string{"pr-stuck", "tr-stuck"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+			cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			pipelineClient := pipelinefake.NewSimpleClientset(
+				newPR("pr-stuck", 3*time.Hour),
+				newPR("pr-recent", 30*time.Minute),
+				prLongTimeout,
+				prDone,
+				prPending,
+				newTR("tr-stuck", 3*time.Hour),
+				trOwned,
+			)
+			ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+
+			if err := pruneStuckRuns(ctx, namespace); err != nil {
+				t.Fatalf("pruneStuckRuns() error = %v", err)
+			}
+
+			var deleted, patched []string
+			for _, action := range pipelineClient.Actions() {
+				switch a := action.(type) {
+				case k8stesting.DeleteAction:
+					deleted = append(deleted, a.GetName())
+				case k8stesting.PatchAction:
+					patched = append(patched, a.GetName())
+				}
+			}
+			if !reflect.DeepEqual(deleted, tt.wantDeleted) {
+				t.Errorf("deleted runs = %v, want %v", deleted, tt.wantDeleted)
+			}
+			if !reflect.DeepEqual(patched, tt.wantPatched) {
+				t.Errorf("patched runs = %v, want %v", patched, tt.wantPatched)
+			}
+		})
+	}
+}
+
+// TestApplyEphemeralNamespacePolicy verifies that the expired runs of the namespaces matching the policy are pruned,
+// and that such a namespace is deleted only once empty and when the policy allows it.
+func TestApplyEphemeralNamespacePolicy(t *testing.T) {
+	const namespace = "preview-42"
+	now := time.Now()
+	newPR := func(name string, completedAgo time.Duration) *pipelinev1.PipelineRun {
+		pr := &pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		pr.Status.CompletionTime = &metav1.Time{Time: now.Add(-completedAgo)}
+		return pr
+	}
+	const policy = `
+ephemeralNamespacePolicy:
+  namespaceSelector:
+    matchLabels:
+      preview: "true"
+  ttlSecondsAfterFinished: 300
+  deleteEmptyNamespace: true`
+
+	heldRuns := map[string]string{config.AnnotationHeldRuns: now.Add(-time.Hour).Format(time.RFC3339)}
+
+	tests := []struct {
+		name                 string
+		globalConfig         string
+		namespaceLabels      map[string]string
+		namespaceAnnotations map[string]string
+		namespaceAge         time.Duration
+		runs                 []runtime.Object
+		wantDeletedRuns      []string
+		wantNamespaceDeleted bool
+	}{
+		{
+			name:            "no policy configured",
+			globalConfig:    `enforcedConfigLevel: global`,
+			namespaceLabels: map[string]string{"preview": "true"},
+			runs:            []runtime.Object{newPR("pr-expired", time.Hour)},
+		},
+		{
+			name:            "namespace not matching the selector",
+			globalConfig:    policy,
+			namespaceLabels: map[string]string{"preview": "false"},
+			runs:            []runtime.Object{newPR("pr-expired", time.Hour)},
+		},
+		{
+			name:            "expired runs are pruned, the namespace still holding runs is kept",
+			globalConfig:    policy,
+			namespaceLabels: map[string]string{"preview": "true"},
+			runs:            []runtime.Object{newPR("pr-expired", time.Hour), newPR("pr-recent", time.Minute)},
+			wantDeletedRuns: []string{"pr-expired"},
+		},
+		{
+			name:                 "empty namespace is deleted",
+			globalConfig:         policy,
+			namespaceLabels:      map[string]string{"preview": "true"},
+			namespaceAnnotations: heldRuns,
+			namespaceAge:         time.Hour,
+			wantNamespaceDeleted: true,
+		},
+		{
+			name:                 "empty namespace is kept in annotate mode",
+			globalConfig:         policy + "\ndeletionMode: annotate",
+			namespaceLabels:      map[string]string{"preview": "true"},
+			namespaceAnnotations: heldRuns,
+			namespaceAge:         time.Hour,
+		},
+		{
+			name:            "new empty namespace is kept",
+			globalConfig:    policy,
+			namespaceLabels: map[string]string{"preview": "true"},
+		},
+		{
+			name:            "empty namespace which never held runs is kept",
+			globalConfig:    policy,
+			namespaceLabels: map[string]string{"preview": "true"},
+			namespaceAge:    time.Hour,
+		},
+		{
+			name:                 "empty namespace younger than the policy TTL is kept",
+			globalConfig:         policy,
+			namespaceLabels:      map[string]string{"preview": "true"},
+			namespaceAnnotations: heldRuns,
+			namespaceAge:         time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+			cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			kubeClient := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:              namespace,
+				Labels:            tt.namespaceLabels,
+				Annotations:       tt.namespaceAnnotations,
+				CreationTimestamp: metav1.Time{Time: now.Add(-tt.namespaceAge)},
+			}})
+			pipelineClient := pipelinefake.NewSimpleClientset(tt.runs...)
+			ctx = context.WithValue(ctx, kubeclient.Key{}, kubeClient)
+			ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+
+			if err := applyEphemeralNamespacePolicy(ctx, namespace); err != nil {
+				t.Fatalf("applyEphemeralNamespacePolicy() error = %v", err)
+			}
+
+			var deletedRuns []string
+			for _, action := range pipelineClient.Actions() {
+				if deleteAction, ok := action.(k8stesting.DeleteAction); ok {
+					deletedRuns = append(deletedRuns, deleteAction.GetName())
+				}
+			}
+			if !reflect.DeepEqual(deletedRuns, tt.wantDeletedRuns) {
+				t.Errorf("deleted runs = %v, want %v", deletedRuns, tt.wantDeletedRuns)
+			}
+			namespaceDeleted := false
+			for _, action := range kubeClient.Actions() {
+				if action.Matches("delete", "namespaces") {
+					namespaceDeleted = true
+				}
+			}
+			if namespaceDeleted != tt.wantNamespaceDeleted {
+				t.Errorf("namespace deleted = %v, want %v", namespaceDeleted, tt.wantNamespaceDeleted)
+			}
+		})
+	}
+}
+
+// TestMarkEphemeralNamespace verifies that an ephemeral namespace is marked as holding runs once runs are found in it,
+// when the policy deletes the empty namespaces.
+func TestMarkEphemeralNamespace(t *testing.T) {
+	const namespace = "preview-42"
+	const policy = `
+ephemeralNamespacePolicy:
+  namespaceSelector:
+    matchLabels:
+      preview: "true"
+  deleteEmptyNamespace: true`
+	const keepEmptyPolicy = `
+ephemeralNamespacePolicy:
+  namespaceSelector:
+    matchLabels:
+      preview: "true"
+  ttlSecondsAfterFinished: 300`
+	run := &pipelinev1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "tr-running", Namespace: namespace}}
+
+	tests := []struct {
+		name         string
+		globalConfig string
+		runs         []runtime.Object
+		wantMarked   bool
+	}{
+		{
+			name:         "namespace holding runs is marked",
+			globalConfig: policy,
+			runs:         []runtime.Object{run},
+			wantMarked:   true,
+		},
+		{
+			name:         "empty namespace is not marked",
+			globalConfig: policy,
+		},
+		{
+			name:         "namespace is not marked when empty namespaces are kept",
+			globalConfig: keepEmptyPolicy,
+			runs:         []runtime.Object{run},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+			cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			kubeClient := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: map[string]string{"preview": "true"}}})
+			ctx = context.WithValue(ctx, kubeclient.Key{}, kubeClient)
+			ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelinefake.NewSimpleClientset(tt.runs...))
+
+			if err := markEphemeralNamespace(ctx, namespace); err != nil {
+				t.Fatalf("markEphemeralNamespace() error = %v", err)
+			}
+			ns, err := kubeClient.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get the namespace: %v", err)
+			}
+			if _, marked := ns.Annotations[config.AnnotationHeldRuns]; marked != tt.wantMarked {
+				t.Errorf("namespace marked = %v, want %v", marked, tt.wantMarked)
+			}
+		})
+	}
+}
+
+// TestPruneTerminatingNamespace verifies that, with aggressivePruneTerminatingNamespaces, all the completed runs of
+// a terminating namespace are pruned whatever their TTL, and that the other namespaces are left to the usual steps.
+func TestPruneTerminatingNamespace(t *testing.T) {
+	const namespace = "feature-branch"
+	now := time.Now()
+	newPR := func(name string, completedAgo *time.Duration) *pipelinev1.PipelineRun {
+		pr := &pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		if completedAgo != nil {
+			pr.Status.CompletionTime = &metav1.Time{Time: now.Add(-*completedAgo)}
+		}
+		return pr
+	}
+	minute := time.Minute
+	runs := []runtime.Object{newPR("pr-completed", &minute), newPR("pr-running", nil)}
+
+	tests := []struct {
+		name            string
+		globalConfig    string
+		terminating     bool
+		wantTerminating bool
+		wantDeletedRuns []string
+	}{
+		{
+			name:         "namespace not terminating",
+			globalConfig: "aggressivePruneTerminatingNamespaces: true\nttlSecondsAfterFinished: 3600",
+		},
+		{
+			name:            "completed runs of a terminating namespace are pruned whatever their TTL",
+			globalConfig:    "aggressivePruneTerminatingNamespaces: true\nttlSecondsAfterFinished: 3600",
+			terminating:     true,
+			wantTerminating: true,
+			wantDeletedRuns: []string{"pr-completed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+			cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: tt.globalConfig}}
+			if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+			if tt.terminating {
+				ns.DeletionTimestamp = &metav1.Time{Time: now}
+				ns.Finalizers = []string{"kubernetes"}
+			}
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(ns); err != nil {
+				t.Fatalf("failed to add the namespace: %v", err)
+			}
+			// the namespace is read from the lister only
+			kubeClient := fake.NewSimpleClientset()
+			pipelineClient := pipelinefake.NewSimpleClientset(runs...)
+			ctx = context.WithValue(ctx, kubeclient.Key{}, kubeClient)
+			ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+			ctx = withNamespaceLister(ctx, corev1listers.NewNamespaceLister(indexer))
+
+			// terminating namespaces are not left out of garbage collection
+			filtered, err := getFilteredNamespaces(ctx, kubeClient)
+			if err != nil {
+				t.Fatalf("getFilteredNamespaces() error = %v", err)
+			}
+			if !reflect.DeepEqual(filtered, []string{namespace}) {
+				t.Errorf("getFilteredNamespaces() = %v, want [%s]", filtered, namespace)
+			}
+
+			terminating, err := pruneTerminatingNamespace(ctx, namespace)
+			if err != nil {
+				t.Fatalf("pruneTerminatingNamespace() error = %v", err)
+			}
+			if terminating != tt.wantTerminating {
+				t.Errorf("pruneTerminatingNamespace() = %v, want %v", terminating, tt.wantTerminating)
+			}
+			if actions := kubeClient.Actions(); len(actions) > 0 {
+				t.Errorf("unexpected API server calls %v", actions)
+			}
+
+			var deletedRuns []string
+			for _, action := range pipelineClient.Actions() {
+				if deleteAction, ok := action.(k8stesting.DeleteAction); ok {
+					deletedRuns = append(deletedRuns, deleteAction.GetName())
+				}
+			}
+			if !reflect.DeepEqual(deletedRuns, tt.wantDeletedRuns) {
+				t.Errorf("deleted runs = %v, want %v", deletedRuns, tt.wantDeletedRuns)
+			}
+		})
+	}
+}
+
+// TestUnusedSelectors verifies that only the selectors matching none of the resources are reported.
+func TestUnusedSelectors(t *testing.T) {
+	resources := []metav1.Object{
+		&pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{
+			Name:   "build-run",
+			Labels: map[string]string{"app": "build"},
+		}},
+		&pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{
+			Name:        "deploy-run",
+			Labels:      map[string]string{"app": "deploy"},
+			Annotations: map[string]string{"env": "prod"},
+		}},
+	}
+
+	used := config.SelectorSpec{MatchLabels: map[string]string{"app": "build"}}
+	usedWithAnnotations := config.SelectorSpec{
+		MatchLabels:      map[string]string{"app": "deploy"},
+		MatchAnnotations: map[string]string{"env": "prod"},
+	}
+	typo := config.SelectorSpec{MatchLabels: map[string]string{"app": "biuld"}}
+
+	unused := unusedSelectors([]config.SelectorSpec{used, typo, usedWithAnnotations}, resources)
+	if len(unused) != 1 || unused[0].MatchLabels["app"] != "biuld" {
+		t.Errorf("unusedSelectors() = %v, want [%v]", unused, typo)
+	}
+
+	if unused := unusedSelectors([]config.SelectorSpec{used}, nil); len(unused) != 1 {
+		t.Errorf("unusedSelectors() without resources = %v, want [%v]", unused, used)
+	}
+}
+
+// TestCleanupPRsCustomProcessedAnnotationKey verifies that the GC loop removes the configured
+// processed annotation key, not the default one, when the config changed after processing.
+func TestCleanupPRsCustomProcessedAnnotationKey(t *testing.T) {
+	const (
+		namespace    = "test-namespace"
+		processedKey = "example.com/history-processed"
+	)
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+
+	cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: "processedAnnotationKey: " + processedKey}}
+	if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	defer func() {
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	now := time.Now()
+	pr := &pipelinev1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "processed-run",
+			Namespace:   namespace,
+			Labels:      map[string]string{config.LabelPipelineName: "build"},
+			Annotations: map[string]string{processedKey: now.Add(-time.Hour).Format(time.RFC3339)},
+		},
+		Status: pipelinev1.PipelineRunStatus{
+			PipelineRunStatusFields: pipelinev1.PipelineRunStatusFields{
+				StartTime:      &metav1.Time{Time: now.Add(-2 * time.Hour)},
+				CompletionTime: &metav1.Time{Time: now.Add(-time.Hour)},
+			},
+		},
+	}
+	pipelineClient := pipelinefake.NewSimpleClientset(pr)
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+
+	if err := cleanupPRs(ctx, namespace, now.Format(time.RFC3339)); err != nil {
+		t.Fatalf("cleanupPRs() error = %v", err)
+	}
+
+	wantPatch := `[{"op":"remove","path":"/metadata/annotations/example.com~1history-processed"}]`
+	found := false
+	for _, action := range pipelineClient.Actions() {
+		if patchAction, ok := action.(k8stesting.PatchAction); ok && string(patchAction.GetPatch()) == wantPatch {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("no JSON patch removing the configured processed annotation, want %s", wantPatch)
+	}
+}
+
+// TestCleanupPRsRetriesProcessedAnnotationPatch verifies that the GC loop retries a failed patch
+// removing the processed annotation, instead of leaving the run to be evaluated again.
+func TestCleanupPRsRetriesProcessedAnnotationPatch(t *testing.T) {
+	const namespace = "test-namespace"
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+
+	cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: `
+annotationPatchRetryAttempts: 2
+listRetryBackoffMilliseconds: 1`}}
+	if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	defer func() {
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	now := time.Now()
+	pr := &pipelinev1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "processed-run",
+			Namespace:   namespace,
+			Labels:      map[string]string{config.LabelPipelineName: "build"},
+			Annotations: map[string]string{config.AnnotationHistoryLimitCheckProcessed: now.Add(-time.Hour).Format(time.RFC3339)},
+		},
+		Status: pipelinev1.PipelineRunStatus{
+			PipelineRunStatusFields: pipelinev1.PipelineRunStatusFields{
+				StartTime:      &metav1.Time{Time: now.Add(-2 * time.Hour)},
+				CompletionTime: &metav1.Time{Time: now.Add(-time.Hour)},
+			},
+		},
+	}
+	pipelineClient := pipelinefake.NewSimpleClientset(pr)
+	wantPatch := `[{"op":"remove","path":"/metadata/annotations/` + strings.ReplaceAll(config.AnnotationHistoryLimitCheckProcessed, "/", "~1") + `"}]`
+	attempts := 0
+	pipelineClient.PrependReactor("patch", "pipelineruns", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if string(action.(k8stesting.PatchAction).GetPatch()) != wantPatch {
+			return false, nil, nil
+		}
+		attempts++
+		if attempts == 1 {
+			return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "pipelineruns"}, pr.Name, nil)
+		}
+		return false, nil, nil
+	})
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+
+	if err := cleanupPRs(ctx, namespace, now.Format(time.RFC3339)); err != nil {
+		t.Fatalf("cleanupPRs() error = %v", err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("patch attempts = %d, want 2", attempts)
+	}
+	updated, err := pipelineClient.TektonV1().PipelineRuns(namespace).Get(ctx, pr.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get the PipelineRun: %v", err)
+	}
+	if _, found := updated.Annotations[config.AnnotationHistoryLimitCheckProcessed]; found {
+		t.Errorf("processed annotation still set after the retried patch")
+	}
+}
+
+// TestCleanupPRsQuotaPressure verifies that the history limits of a namespace whose ResourceQuota on the number
+// of PipelineRuns is nearly used up are tightened, including for the runs already processed
+func TestCleanupPRsQuotaPressure(t *testing.T) {
+	const namespace = "test-namespace"
+
+	tests := []struct {
+		name     string
+		used     string
+		wantKept int
+	}{
+		{name: "below the threshold", used: "7", wantKept: 4},
+		{name: "above the threshold", used: "9", wantKept: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+			cm := &corev1.ConfigMap{Data: map[string]string{config.PrunerGlobalConfigKey: `
+successfulHistoryLimit: 4
+quotaPressure:
+  thresholdPercent: 80
+  historyLimitDivisor: 2`}}
+			if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, cm); err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			quota := &corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "runs", Namespace: namespace},
+				Status: corev1.ResourceQuotaStatus{
+					Hard: corev1.ResourceList{"count/pipelineruns.tekton.dev": resource.MustParse("10")},
+					Used: corev1.ResourceList{"count/pipelineruns.tekton.dev": resource.MustParse(tt.used)},
+				},
+			}
+			ctx = context.WithValue(ctx, kubeclient.Key{}, fake.NewSimpleClientset(quota))
+
+			now := time.Now()
+			var runs []runtime.Object
+			for i := range 4 {
+				pr := &pipelinev1.PipelineRun{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        fmt.Sprintf("build-%d", i),
+						Namespace:   namespace,
+						Labels:      map[string]string{config.LabelPipelineName: "build"},
+						Annotations: map[string]string{config.AnnotationHistoryLimitCheckProcessed: now.Format(time.RFC3339)},
+					},
+				}
+				pr.Status.StartTime = &metav1.Time{Time: now.Add(-time.Duration(10-i) * time.Minute)}
+				pr.Status.CompletionTime = &metav1.Time{Time: now.Add(-time.Duration(9-i) * time.Minute)}
+				pr.Status.SetCondition(&apis.Condition{Type: apis.ConditionSucceeded, Status: corev1.ConditionTrue, Reason: "Succeeded"})
+				runs = append(runs, pr)
+			}
+			pipelineClient := pipelinefake.NewSimpleClientset(runs...)
+			ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+
+			collectNamespace(ctx, namespace, now.Add(-time.Hour).Format(time.RFC3339))
+
+			prs, err := pipelineClient.TektonV1().PipelineRuns(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list the PipelineRuns: %v", err)
+			}
+			if len(prs.Items) != tt.wantKept {
+				t.Errorf("kept %d PipelineRuns, want %d", len(prs.Items), tt.wantKept)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tektonpruner
+
+import (
+	"context"
+	"fmt"
+
+	"knative.dev/pkg/logging"
+)
+
+// RunOnce runs a single garbage collection cycle over the filtered namespaces, without leader election,
+// for the pruner running as a CronJob. The metrics snapshot is dumped once the cycle completes, when a dump
+// file is configured. It returns the summary of the cycle, with an error if the cycle met any failure
+func RunOnce(ctx context.Context) (GCSummary, error) {
+	logger := logging.FromContext(ctx)
+	summary := &gcSummary{}
+
+	gcMutex.Lock()
+	runGarbageCollector(withGCSummary(ctx, summary))
+	gcMutex.Unlock()
+	dumpMetrics(ctx, logger)
+
+	result := summary.snapshot()
+	if result.Errors > 0 {
+		return result, fmt.Errorf("garbage collection met %d failures", result.Errors)
+	}
+	return result, nil
+}
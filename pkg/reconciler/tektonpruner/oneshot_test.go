@@ -0,0 +1,107 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tektonpruner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	pipelinefake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	"github.com/tektoncd/pruner/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"knative.dev/pkg/apis"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/logging"
+	logtesting "knative.dev/pkg/logging/testing"
+	"knative.dev/pkg/system"
+)
+
+// TestRunOnce verifies that a one-shot cycle prunes the runs, dumps the metrics and reports its failures
+func TestRunOnce(t *testing.T) {
+	const namespace = "test-namespace"
+	expired := &pipelinev1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "expired",
+			Namespace:         namespace,
+			Labels:            map[string]string{config.LabelPipelineName: "build"},
+			CreationTimestamp: metav1.Time{Time: time.Now().Add(-2 * time.Hour)},
+		},
+		Status: pipelinev1.PipelineRunStatus{
+			PipelineRunStatusFields: pipelinev1.PipelineRunStatusFields{
+				StartTime:      &metav1.Time{Time: time.Now().Add(-2 * time.Hour)},
+				CompletionTime: &metav1.Time{Time: time.Now().Add(-time.Hour)},
+			},
+		},
+	}
+	expired.Status.SetCondition(&apis.Condition{Type: apis.ConditionSucceeded, Status: corev1.ConditionTrue, Reason: "Succeeded"})
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.PrunerConfigMapName, Namespace: system.Namespace()},
+		Data:       map[string]string{config.PrunerGlobalConfigKey: "ttlSecondsAfterFinished: 600"},
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+
+	tests := []struct {
+		name        string
+		kubeObjects []runtime.Object
+		wantSummary GCSummary
+		wantErr     bool
+	}{
+		{
+			name:        "cycle completes",
+			kubeObjects: []runtime.Object{cm, ns},
+			wantSummary: GCSummary{Namespaces: 1, Evaluated: 1, Deleted: 1},
+		},
+		{
+			name:        "global config is missing",
+			kubeObjects: []runtime.Object{ns},
+			wantSummary: GCSummary{Errors: 1},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+			path := filepath.Join(t.TempDir(), "metrics.json")
+			ctx = WithMetricsDumpFile(ctx, path)
+			ctx = context.WithValue(ctx, kubeclient.Key{}, fake.NewSimpleClientset(tt.kubeObjects...))
+			ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelinefake.NewSimpleClientset(expired.DeepCopy()))
+
+			summary, err := RunOnce(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RunOnce() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if summary != tt.wantSummary {
+				t.Errorf("RunOnce() summary = %+v, want %+v", summary, tt.wantSummary)
+			}
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("the metrics snapshot was not dumped: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tektonpruner
+
+import (
+	"context"
+	"time"
+
+	"github.com/tektoncd/pruner/pkg/config"
+	"go.uber.org/zap"
+	clockUtil "k8s.io/utils/clock"
+)
+
+// periodicGCRecheckInterval is how often the periodic trigger checks the configured interval,
+// so that an update of gcIntervalSeconds applies without waiting for the previous interval to expire
+const periodicGCRecheckInterval = time.Minute
+
+// runPeriodicGC runs garbage collection every gcIntervalSeconds of the global config, until the context is cancelled
+func (r *Reconciler) runPeriodicGC(ctx context.Context, clock clockUtil.Clock, logger *zap.SugaredLogger) {
+	runPeriodically(ctx, clock, config.PrunerConfigStore.GetGCInterval, func() {
+		logger.Debug("Periodic garbage collection triggered")
+		r.safeRunGarbageCollector(ctx, logger)
+	})
+}
+
+// runPeriodically calls run once the interval returned by getInterval has elapsed since the previous call.
+// A zero interval disables the calls, the interval is counted from the time it is configured again
+func runPeriodically(ctx context.Context, clock clockUtil.Clock, getInterval func() time.Duration, run func()) {
+	last := clock.Now()
+	for {
+		wait := periodicGCRecheckInterval
+		if interval := getInterval(); interval > 0 {
+			remaining := interval - clock.Since(last)
+			if remaining <= 0 {
+				run()
+				last = clock.Now()
+				continue
+			}
+			wait = min(wait, remaining)
+		} else {
+			last = clock.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(wait):
+		}
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tektonpruner
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	pipelinefake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	"github.com/tektoncd/pruner/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/logging"
+	logtesting "knative.dev/pkg/logging/testing"
+	"knative.dev/pkg/system"
+)
+
+func TestRunPeriodically(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := clocktesting.NewFakeClock(time.Now())
+	var interval atomic.Int64
+	runs := make(chan struct{}, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runPeriodically(ctx, clock, func() time.Duration { return time.Duration(interval.Load()) }, func() { runs <- struct{}{} })
+	}()
+
+	// step waits for the loop to wait on the clock before advancing it
+	step := func(d time.Duration) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !clock.HasWaiters() {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for the periodic loop")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		clock.Step(d)
+	}
+	expectRuns := func(want int) {
+		t.Helper()
+		// let the loop observe the step before counting the runs
+		step(0)
+		if got := len(runs); got != want {
+			t.Fatalf("got %d runs, want %d", got, want)
+		}
+	}
+
+	// disabled, nothing runs however long it waits
+	step(time.Hour)
+	expectRuns(0)
+
+	interval.Store(int64(90 * time.Second))
+	step(periodicGCRecheckInterval)
+	expectRuns(0)
+	step(90 * time.Second)
+	expectRuns(1)
+	step(90 * time.Second)
+	expectRuns(2)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("periodic loop did not stop on cancellation")
+	}
+}
+
+// TestRunGarbageCollectorUnchangedConfig verifies that a periodic cycle with an unchanged config leaves the runs
+// processed since the config last changed alone, instead of resetting their processed annotation
+func TestRunGarbageCollectorUnchangedConfig(t *testing.T) {
+	const namespace = "test-namespace"
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+	defer func() {
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.PrunerConfigMapName, Namespace: system.Namespace()},
+		Data:       map[string]string{config.PrunerGlobalConfigKey: "gcIntervalSeconds: 30\nsuccessfulHistoryLimit: 5"},
+	}
+	kubeClient := fake.NewSimpleClientset(cm, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+	ctx = context.WithValue(ctx, kubeclient.Key{}, kubeClient)
+	pipelineClient := pipelinefake.NewSimpleClientset()
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+
+	runGarbageCollector(ctx)
+	updateTime := config.PrunerConfigStore.GetConfigUpdateTime()
+
+	// a run processed by the history limiter once the config was loaded
+	completedAt := &metav1.Time{Time: time.Now()}
+	pr := &pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{
+		Name:        "build-1",
+		Namespace:   namespace,
+		Labels:      map[string]string{config.LabelPipelineName: "build"},
+		Annotations: map[string]string{config.AnnotationHistoryLimitCheckProcessed: updateTime.Format(time.RFC3339)},
+	}}
+	pr.Status.StartTime = completedAt
+	pr.Status.CompletionTime = completedAt
+	if _, err := pipelineClient.TektonV1().PipelineRuns(namespace).Create(ctx, pr, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create the PipelineRun: %v", err)
+	}
+
+	// the next cycle runs a second later at least, a config reloaded at that time would reset the annotation
+	for time.Now().Truncate(time.Second).Equal(updateTime.Truncate(time.Second)) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	pipelineClient.ClearActions()
+	runGarbageCollector(ctx)
+
+	if got := config.PrunerConfigStore.GetConfigUpdateTime(); !got.Equal(updateTime) {
+		t.Errorf("config update time = %v, want %v", got, updateTime)
+	}
+	for _, action := range pipelineClient.Actions() {
+		if patch, ok := action.(k8stesting.PatchAction); ok {
+			t.Errorf("unexpected patch of %s %s: %s", patch.GetResource().Resource, patch.GetName(), patch.GetPatch())
+		}
+	}
+}
@@ -5,10 +5,14 @@ import (
 
 	"k8s.io/client-go/kubernetes"
 	"knative.dev/pkg/logging"
+	"knative.dev/pkg/reconciler"
 )
 
 // Reconciler includes the kubernetes client to interact with the cluster
+// LeaderAwareFuncs tracks the buckets this replica leads, so that only the leader runs garbage collection
 type Reconciler struct {
+	reconciler.LeaderAwareFuncs
+
 	kubeclient kubernetes.Interface
 }
 
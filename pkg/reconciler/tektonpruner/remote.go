@@ -0,0 +1,134 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tektonpruner
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	pipelineversioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	"github.com/tektoncd/pruner/pkg/config"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/logging"
+)
+
+// RemoteCluster is a cluster, other than the one the controller runs in, whose runs are garbage collected
+// along with the local ones. Only garbage collection reaches remote clusters, the reconcilers stay local.
+type RemoteCluster struct {
+	// Name identifies the cluster in the logs
+	Name           string
+	KubeClient     kubernetes.Interface
+	PipelineClient pipelineversioned.Interface
+	// ResourceExists looks up the resources protecting the runs of the cluster, it can be nil
+	ResourceExists config.ResourceExistsFunc
+	// ResourceGet reads the status of the resources protecting the runs of the cluster, it can be nil
+	ResourceGet config.ResourceGetFunc
+}
+
+// remoteClustersKey is used as the key for associating the remote clusters with the context.
+type remoteClustersKey struct{}
+
+// WithRemoteClusters adds the given clusters to the clusters garbage collected by the controller
+func WithRemoteClusters(ctx context.Context, clusters []RemoteCluster) context.Context {
+	return context.WithValue(ctx, remoteClustersKey{}, clusters)
+}
+
+// getRemoteClusters returns the remote clusters garbage collected by the controller, if any
+func getRemoteClusters(ctx context.Context) []RemoteCluster {
+	clusters, _ := ctx.Value(remoteClustersKey{}).([]RemoteCluster)
+	return clusters
+}
+
+// withCluster returns a context whose injected clients reach the given remote cluster, so that the garbage collection
+// steps run against it unchanged. The caches of the local cluster are not shared with it. Neither are the namespace ConfigMaps
+// nor the namespace scope of the local cluster, its namespaces only share their names with the remote ones:
+// the runs of a remote cluster are pruned by the global config alone, in all of its namespaces.
+func withCluster(ctx context.Context, cluster RemoteCluster) context.Context {
+	ctx = context.WithValue(ctx, kubeclient.Key{}, cluster.KubeClient)
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, cluster.PipelineClient)
+	ctx = config.WithResourceExistsFunc(ctx, cluster.ResourceExists)
+	ctx = config.WithResourceGetFunc(ctx, cluster.ResourceGet)
+	ctx = config.WithProtectionCache(ctx)
+	ctx = config.WithSuccessfulCounts(ctx)
+	ctx = withPrunedPipelineRuns(ctx)
+	ctx = withNamespaceLister(ctx, nil)
+	ctx = config.WithNamespaceConfigsIgnored(ctx)
+	ctx = WithNamespaceScope(ctx, nil)
+	return logging.WithLogger(ctx, logging.FromContext(ctx).With("cluster", cluster.Name))
+}
+
+// LoadRemoteClusters creates the remote clusters of a comma separated list of kubeconfig files.
+// Each entry is the path of a kubeconfig file, optionally followed by @ and the name of the context to use,
+// e.g. /etc/pruner/east.kubeconfig@east-admin. The cluster is named after the context, or the file otherwise.
+func LoadRemoteClusters(spec string) ([]RemoteCluster, error) {
+	var clusters []RemoteCluster
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		path, contextName, _ := strings.Cut(entry, "@")
+		cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: path},
+			&clientcmd.ConfigOverrides{CurrentContext: contextName},
+		).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("remote cluster %q: %w", entry, err)
+		}
+
+		name := contextName
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		cluster, err := NewRemoteCluster(name, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("remote cluster %q: %w", entry, err)
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters, nil
+}
+
+// NewRemoteCluster creates the clients of a remote cluster from its REST config
+func NewRemoteCluster(name string, cfg *rest.Config) (RemoteCluster, error) {
+	kubeClient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return RemoteCluster{}, err
+	}
+	pipelineClient, err := pipelineversioned.NewForConfig(cfg)
+	if err != nil {
+		return RemoteCluster{}, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return RemoteCluster{}, err
+	}
+	return RemoteCluster{
+		Name:           name,
+		KubeClient:     kubeClient,
+		PipelineClient: pipelineClient,
+		ResourceExists: config.NewDynamicResourceExistsFunc(dynamicClient),
+		ResourceGet:    config.NewDynamicResourceGetFunc(dynamicClient),
+	}, nil
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tektonpruner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	pipelinefake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	"github.com/tektoncd/pruner/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/logging"
+	logtesting "knative.dev/pkg/logging/testing"
+	"knative.dev/pkg/system"
+)
+
+// TestRunGarbageCollectorRemoteClusters verifies that the runs of the remote clusters are collected through their own clients,
+// with the global config of the local cluster.
+func TestRunGarbageCollectorRemoteClusters(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+	defer func() {
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	completedAt := &metav1.Time{Time: time.Now().Add(-time.Hour)}
+	newPR := func(namespace, name string) *pipelinev1.PipelineRun {
+		pr := &pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		pr.Status.StartTime = completedAt
+		pr.Status.CompletionTime = completedAt
+		return pr
+	}
+
+	localKubeClient := fake.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: config.PrunerConfigMapName, Namespace: system.Namespace()},
+			Data:       map[string]string{config.PrunerGlobalConfigKey: `ttlSecondsAfterFinished: 60`},
+		},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "local-ns"}},
+	)
+	localPipelineClient := pipelinefake.NewSimpleClientset(newPR("local-ns", "local-pr"))
+	remoteKubeClient := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "remote-ns"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+	)
+	remotePipelineClient := pipelinefake.NewSimpleClientset(newPR("remote-ns", "remote-pr"))
+
+	ctx = context.WithValue(ctx, kubeclient.Key{}, localKubeClient)
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, localPipelineClient)
+	ctx = WithRemoteClusters(ctx, []RemoteCluster{{Name: "remote", KubeClient: remoteKubeClient, PipelineClient: remotePipelineClient}})
+
+	runGarbageCollector(ctx)
+
+	for name, client := range map[string]*pipelinefake.Clientset{"local": localPipelineClient, "remote": remotePipelineClient} {
+		prs, err := client.TektonV1().PipelineRuns("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("Failed to list %s PipelineRuns: %v", name, err)
+		}
+		if len(prs.Items) != 0 {
+			t.Errorf("%s PipelineRuns left = %d, want 0", name, len(prs.Items))
+		}
+		for _, action := range client.Actions() {
+			if ns := action.GetNamespace(); ns != "" && ns != name+"-ns" {
+				t.Errorf("%s pipeline client reached namespace %s", name, ns)
+			}
+		}
+	}
+}
+
+// TestRunGarbageCollectorRemoteNamespaceConfigs verifies that the runs of the remote clusters are pruned by the global config alone,
+// neither the namespace ConfigMaps nor the namespace scope of the local cluster applying to the remote namespaces of the same name
+func TestRunGarbageCollectorRemoteNamespaceConfigs(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+	defer func() {
+		config.PrunerConfigStore.DeleteNamespaceConfig(ctx, "shared")
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	completedAt := &metav1.Time{Time: time.Now().Add(-time.Hour)}
+	newPR := func(namespace, name string) *pipelinev1.PipelineRun {
+		pr := &pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		pr.Status.StartTime = completedAt
+		pr.Status.CompletionTime = completedAt
+		return pr
+	}
+
+	// the local namespace ConfigMap keeps the runs of the shared namespace for a day
+	if err := config.PrunerConfigStore.LoadNamespaceConfig(ctx, "shared", &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.PrunerNamespaceConfigMapName, Namespace: "shared"},
+		Data:       map[string]string{config.PrunerNamespaceConfigKey: `ttlSecondsAfterFinished: 86400`},
+	}); err != nil {
+		t.Fatalf("Failed to load the namespace config: %v", err)
+	}
+	localKubeClient := fake.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: config.PrunerConfigMapName, Namespace: system.Namespace()},
+			Data:       map[string]string{config.PrunerGlobalConfigKey: "enforcedConfigLevel: namespace\nttlSecondsAfterFinished: 60"},
+		},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shared"}},
+	)
+	localPipelineClient := pipelinefake.NewSimpleClientset(newPR("shared", "local-pr"))
+	remoteKubeClient := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shared"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "remote-only"}},
+	)
+	remotePipelineClient := pipelinefake.NewSimpleClientset(newPR("shared", "remote-pr"), newPR("remote-only", "remote-only-pr"))
+
+	ctx = context.WithValue(ctx, kubeclient.Key{}, localKubeClient)
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, localPipelineClient)
+	ctx = WithNamespaceScope(ctx, []string{"shared"})
+	ctx = WithRemoteClusters(ctx, []RemoteCluster{{Name: "remote", KubeClient: remoteKubeClient, PipelineClient: remotePipelineClient}})
+
+	runGarbageCollector(ctx)
+
+	for name, tt := range map[string]struct {
+		client *pipelinefake.Clientset
+		want   int
+	}{
+		"local":  {client: localPipelineClient, want: 1},
+		"remote": {client: remotePipelineClient, want: 0},
+	} {
+		prs, err := tt.client.TektonV1().PipelineRuns("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("Failed to list %s PipelineRuns: %v", name, err)
+		}
+		if len(prs.Items) != tt.want {
+			t.Errorf("%s PipelineRuns left = %d, want %d", name, len(prs.Items), tt.want)
+		}
+	}
+}
+
+// TestLoadRemoteClusters verifies the parsing of the remote kubeconfig list.
+func TestLoadRemoteClusters(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "east.kubeconfig")
+	content := `apiVersion: v1
+kind: Config
+clusters:
+- name: east
+  cluster:
+    server: https://east.example.com
+- name: west
+  cluster:
+    server: https://west.example.com
+users:
+- name: pruner
+  user:
+    token: secret
+contexts:
+- name: east-admin
+  context: {cluster: east, user: pruner}
+- name: west-admin
+  context: {cluster: west, user: pruner}
+current-context: east-admin
+`
+	if err := os.WriteFile(kubeconfig, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write kubeconfig: %v", err)
+	}
+
+	clusters, err := LoadRemoteClusters(kubeconfig + ", " + kubeconfig + "@west-admin")
+	if err != nil {
+		t.Fatalf("LoadRemoteClusters() error = %v", err)
+	}
+	if len(clusters) != 2 || clusters[0].Name != "east" || clusters[1].Name != "west-admin" {
+		t.Errorf("clusters = %v, want east and west-admin", clusters)
+	}
+
+	if _, err := LoadRemoteClusters(kubeconfig + "@missing"); err == nil {
+		t.Errorf("LoadRemoteClusters() with a missing context succeeded, want an error")
+	}
+	if _, err := LoadRemoteClusters(filepath.Join(t.TempDir(), "missing.kubeconfig")); err == nil {
+		t.Errorf("LoadRemoteClusters() with a missing file succeeded, want an error")
+	}
+}
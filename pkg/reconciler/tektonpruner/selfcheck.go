@@ -0,0 +1,152 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tektonpruner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	"github.com/tektoncd/pruner/pkg/config"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/system"
+)
+
+// RBACSelfCheckMode tells what the controller does when the startup RBAC self-check finds missing permissions
+type RBACSelfCheckMode string
+
+const (
+	// RBACSelfCheckOff skips the RBAC self-check
+	RBACSelfCheckOff RBACSelfCheckMode = "off"
+	// RBACSelfCheckWarn logs the missing permissions and starts the controller anyway
+	RBACSelfCheckWarn RBACSelfCheckMode = "warn"
+	// RBACSelfCheckBlock logs the missing permissions and stops the controller
+	RBACSelfCheckBlock RBACSelfCheckMode = "block"
+)
+
+// ParseRBACSelfCheckMode parses the mode of the RBAC self-check
+func ParseRBACSelfCheckMode(value string) (RBACSelfCheckMode, error) {
+	switch mode := RBACSelfCheckMode(value); mode {
+	case RBACSelfCheckOff, RBACSelfCheckWarn, RBACSelfCheckBlock:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid RBAC self-check mode %q, must be one of %s, %s or %s", value, RBACSelfCheckOff, RBACSelfCheckWarn, RBACSelfCheckBlock)
+	}
+}
+
+// rbacSelfCheckKey is used as the key for associating the RBAC self-check mode with the context.
+type rbacSelfCheckKey struct{}
+
+// WithRBACSelfCheck verifies the RBAC permissions of the controller at startup, with the given mode
+func WithRBACSelfCheck(ctx context.Context, mode RBACSelfCheckMode) context.Context {
+	return context.WithValue(ctx, rbacSelfCheckKey{}, mode)
+}
+
+// getRBACSelfCheck returns the mode of the RBAC self-check, off if not set
+func getRBACSelfCheck(ctx context.Context) RBACSelfCheckMode {
+	mode, ok := ctx.Value(rbacSelfCheckKey{}).(RBACSelfCheckMode)
+	if !ok {
+		return RBACSelfCheckOff
+	}
+	return mode
+}
+
+// rbacPermission is a permission the controller needs to garbage collect runs
+type rbacPermission struct {
+	group    string
+	resource string
+	verb     string
+	// name restricts the permission to the resources of that name, if set
+	name string
+	// systemNamespace tells whether the permission is only needed in the namespace of the controller
+	systemNamespace bool
+}
+
+func (p rbacPermission) String() string {
+	resource := p.resource
+	if p.group != "" {
+		resource = p.group + "/" + resource
+	}
+	if p.name != "" {
+		resource += "/" + p.name
+	}
+	return p.verb + " " + resource
+}
+
+// requiredPermissions lists the permissions checked by the RBAC self-check
+var requiredPermissions = []rbacPermission{
+	{group: pipeline.GroupName, resource: "pipelineruns", verb: "list"},
+	{group: pipeline.GroupName, resource: "pipelineruns", verb: "delete"},
+	{group: pipeline.GroupName, resource: "pipelineruns", verb: "patch"},
+	{group: pipeline.GroupName, resource: "taskruns", verb: "list"},
+	{group: pipeline.GroupName, resource: "taskruns", verb: "delete"},
+	{group: pipeline.GroupName, resource: "taskruns", verb: "patch"},
+	{resource: "configmaps", verb: "list"},
+	{resource: "configmaps", verb: "patch", name: config.PrunerNamespaceConfigMapName},
+	{resource: "configmaps", verb: "get", name: config.PrunerConfigMapName, systemNamespace: true},
+	{resource: "configmaps", verb: "create", systemNamespace: true},
+	{resource: "configmaps", verb: "update", name: config.PrunerStateConfigMapName, systemNamespace: true},
+}
+
+// checkRBACPermissions reviews the required permissions of the controller in the given namespaces,
+// all namespaces if none. It returns an error listing the missing permissions, if any
+func checkRBACPermissions(ctx context.Context, kubeClient kubernetes.Interface, namespaces []string) error {
+	if len(namespaces) == 0 {
+		namespaces = []string{corev1.NamespaceAll}
+	}
+
+	var missing []string
+	for _, permission := range requiredPermissions {
+		permissionNamespaces := namespaces
+		if permission.systemNamespace {
+			permissionNamespaces = []string{system.Namespace()}
+		}
+		for _, namespace := range permissionNamespaces {
+			review, err := kubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace: namespace,
+						Verb:      permission.verb,
+						Group:     permission.group,
+						Resource:  permission.resource,
+						Name:      permission.name,
+					},
+				},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to review the %s permission: %w", permission, err)
+			}
+			if review.Status.Allowed {
+				continue
+			}
+			scope := "in all namespaces"
+			if namespace != corev1.NamespaceAll {
+				scope = "in namespace " + namespace
+			}
+			missing = append(missing, permission.String()+" "+scope)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing RBAC permissions: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tektonpruner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestCheckRBACPermissions verifies that the missing permissions are reported with their scope
+func TestCheckRBACPermissions(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespaces []string
+		denied     func(attrs *authorizationv1.ResourceAttributes) bool
+		reviewErr  error
+		wantErr    []string
+	}{
+		{
+			name:   "all permissions granted",
+			denied: func(*authorizationv1.ResourceAttributes) bool { return false },
+		},
+		{
+			name: "delete on TaskRuns missing",
+			denied: func(attrs *authorizationv1.ResourceAttributes) bool {
+				return attrs.Resource == "taskruns" && attrs.Verb == "delete"
+			},
+			wantErr: []string{"delete tekton.dev/taskruns in all namespaces"},
+		},
+		{
+			name:       "permissions missing in a scoped namespace",
+			namespaces: []string{"team-a", "team-b"},
+			denied: func(attrs *authorizationv1.ResourceAttributes) bool {
+				return attrs.Namespace == "team-b" && attrs.Resource == "configmaps"
+			},
+			wantErr: []string{
+				"list configmaps in namespace team-b",
+				"patch configmaps/tekton-pruner-namespace-spec in namespace team-b",
+			},
+		},
+		{
+			name:      "review failure",
+			reviewErr: errors.New("connection refused"),
+			wantErr:   []string{"failed to review the list tekton.dev/pipelineruns permission: connection refused"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if tt.reviewErr != nil {
+					return true, nil, tt.reviewErr
+				}
+				review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+				review.Status.Allowed = !tt.denied(review.Spec.ResourceAttributes)
+				return true, review, nil
+			})
+
+			err := checkRBACPermissions(context.Background(), kubeClient, tt.namespaces)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("checkRBACPermissions() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("checkRBACPermissions() error = nil, want one containing %v", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("checkRBACPermissions() error = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+// TestParseRBACSelfCheckMode verifies that only the known modes are accepted
+func TestParseRBACSelfCheckMode(t *testing.T) {
+	for _, mode := range []RBACSelfCheckMode{RBACSelfCheckOff, RBACSelfCheckWarn, RBACSelfCheckBlock} {
+		if got, err := ParseRBACSelfCheckMode(string(mode)); err != nil || got != mode {
+			t.Errorf("ParseRBACSelfCheckMode(%q) = %q, %v", mode, got, err)
+		}
+	}
+	if _, err := ParseRBACSelfCheckMode("strict"); err == nil {
+		t.Error("ParseRBACSelfCheckMode() expected an error for an unknown mode")
+	}
+}
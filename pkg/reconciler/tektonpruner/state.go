@@ -0,0 +1,63 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tektonpruner
+
+import (
+	"context"
+	"maps"
+
+	"github.com/tektoncd/pruner/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/system"
+)
+
+// loadPrunerState restores the state of the config store recorded in the state ConfigMap, before the global config is loaded.
+// It reads the ConfigMap on every cycle, as the leader recording it may have changed since this replica last led.
+// It returns the state ConfigMap, nil if it does not exist yet
+func loadPrunerState(ctx context.Context, kubeClient kubernetes.Interface) (*corev1.ConfigMap, error) {
+	state, err := kubeClient.CoreV1().ConfigMaps(system.Namespace()).Get(ctx, config.PrunerStateConfigMapName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		state = nil
+	} else if err != nil {
+		return nil, err
+	}
+	return state, config.PrunerConfigStore.LoadState(ctx, state)
+}
+
+// recordPrunerState records the state of the config store in the state ConfigMap once the global config is loaded,
+// creating the ConfigMap if it does not exist yet. The ConfigMap is left as is when the state did not change
+func recordPrunerState(ctx context.Context, kubeClient kubernetes.Interface, state *corev1.ConfigMap) error {
+	data := config.PrunerConfigStore.State()
+	configMaps := kubeClient.CoreV1().ConfigMaps(system.Namespace())
+	if state == nil {
+		_, err := configMaps.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: config.PrunerStateConfigMapName, Namespace: system.Namespace()},
+			Data:       data,
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if maps.Equal(state.Data, data) {
+		return nil
+	}
+	state = state.DeepCopy()
+	state.Data = data
+	_, err := configMaps.Update(ctx, state, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tektonpruner
+
+import (
+	"context"
+	"testing"
+
+	pipelinefake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	"github.com/tektoncd/pruner/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/logging"
+	logtesting "knative.dev/pkg/logging/testing"
+	"knative.dev/pkg/system"
+)
+
+// TestRunGarbageCollectorSafeModeState verifies that the configs accepted by safe mode and applied to every namespace
+// are recorded in the state ConfigMap, so that a config tightened while the controller restarts is still paused
+func TestRunGarbageCollectorSafeModeState(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+	defer func() {
+		_ = config.PrunerConfigStore.LoadState(ctx, nil)
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	const relaxed = "ttlSecondsAfterFinished: 2592000\nsafeMode: {}"
+	const tightened = "ttlSecondsAfterFinished: 60\nsafeMode: {}"
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.PrunerConfigMapName, Namespace: system.Namespace()},
+		Data:       map[string]string{config.PrunerGlobalConfigKey: relaxed},
+	}
+	cm.Annotations = map[string]string{config.AnnotationAcknowledgeConfig: config.ConfigDigest(cm)}
+	kubeClient := fake.NewSimpleClientset(cm)
+	ctx = context.WithValue(ctx, kubeclient.Key{}, kubeClient)
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelinefake.NewSimpleClientset())
+
+	getState := func() map[string]string {
+		t.Helper()
+		state, err := kubeClient.CoreV1().ConfigMaps(system.Namespace()).Get(ctx, config.PrunerStateConfigMapName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get the state ConfigMap: %v", err)
+		}
+		return state.Data
+	}
+
+	// the acknowledged config is accepted and recorded
+	runGarbageCollector(ctx)
+	if config.PrunerConfigStore.IsPruningPaused(config.PrunerFieldTypeTTLSecondsAfterFinished) {
+		t.Fatal("TTL pruning paused, want the acknowledged config applied")
+	}
+	if got := getState()[config.PrunerStateAcceptedConfigKey]; got != relaxed {
+		t.Fatalf("accepted config = %q, want %q", got, relaxed)
+	}
+	if got := getState()[config.PrunerStatePromotedConfigKey]; got != relaxed {
+		t.Fatalf("promoted config = %q, want %q", got, relaxed)
+	}
+
+	// a restarted controller forgets the accepted config, it restores it from the state ConfigMap
+	_ = config.PrunerConfigStore.LoadState(ctx, nil)
+	cm = cm.DeepCopy()
+	cm.Data[config.PrunerGlobalConfigKey] = tightened
+	cm.Annotations = nil
+	if _, err := kubeClient.CoreV1().ConfigMaps(system.Namespace()).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update the global ConfigMap: %v", err)
+	}
+	runGarbageCollector(ctx)
+	if !config.PrunerConfigStore.IsPruningPaused(config.PrunerFieldTypeTTLSecondsAfterFinished) {
+		t.Error("TTL pruning not paused, want the config tightened during the restart paused")
+	}
+	if got := getState()[config.PrunerStateAcceptedConfigKey]; got != relaxed {
+		t.Errorf("accepted config = %q, want %q", got, relaxed)
+	}
+}
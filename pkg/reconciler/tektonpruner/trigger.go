@@ -0,0 +1,372 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tektonpruner
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/tektoncd/pruner/pkg/config"
+	"github.com/tektoncd/pruner/pkg/metrics"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/logging"
+)
+
+// TriggerGCPath is the path of the endpoint triggering a garbage collection cycle on demand
+const TriggerGCPath = "/trigger-gc"
+
+// PruneOwnedPath is the path of the endpoint pruning the completed runs owned by a given resource on demand,
+// served along with the trigger endpoint
+const PruneOwnedPath = "/prune-owned"
+
+// TriggerGC configures the endpoint triggering a garbage collection cycle on demand
+type TriggerGC struct {
+	// Address the endpoint listens on, e.g. :8090
+	Address string
+	// Token is the bearer token the requests must carry
+	Token string
+}
+
+// triggerGCKey is used as the key for associating the trigger endpoint config with the context.
+type triggerGCKey struct{}
+
+// WithTriggerGC enables the endpoint triggering a garbage collection cycle on demand
+func WithTriggerGC(ctx context.Context, trigger TriggerGC) context.Context {
+	return context.WithValue(ctx, triggerGCKey{}, trigger)
+}
+
+// getTriggerGC returns the config of the endpoint triggering a garbage collection cycle, if enabled
+func getTriggerGC(ctx context.Context) (TriggerGC, bool) {
+	trigger, ok := ctx.Value(triggerGCKey{}).(TriggerGC)
+	return trigger, ok
+}
+
+// LoadTriggerGCToken reads the bearer token of the trigger endpoint from a file, e.g. mounted from a Secret
+func LoadTriggerGCToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the trigger-gc token: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("the trigger-gc token file %s is empty", path)
+	}
+	return token, nil
+}
+
+// gcSummaryKey is used as the key for associating the summary of a GC cycle with the context.
+type gcSummaryKey struct{}
+
+// gcSummary counts what a garbage collection cycle did, the counters are updated concurrently by the workers
+type gcSummary struct {
+	namespaces            atomic.Int64
+	evaluatedPipelineRuns atomic.Int64
+	evaluatedTaskRuns     atomic.Int64
+	deleted               atomic.Int64
+	deletedByTTL          atomic.Int64
+	deletedByHistory      atomic.Int64
+	errors                atomic.Int64
+}
+
+// GCSummary is the summary of a garbage collection cycle returned by the trigger endpoint
+type GCSummary struct {
+	// Namespaces is the number of namespaces collected
+	Namespaces int64 `json:"namespaces"`
+	// Evaluated is the number of completed PipelineRuns and standalone TaskRuns evaluated against TTL and history limits
+	Evaluated int64 `json:"evaluated"`
+	// Deleted is the number of PipelineRuns and TaskRuns deleted
+	Deleted int64 `json:"deleted"`
+	// Errors is the number of failures met by the cycle: the config or the namespaces failing to load, the runs failing to be pruned
+	Errors int64 `json:"errors"`
+}
+
+// withGCSummary attaches the given summary to the context, to be filled by a garbage collection cycle
+func withGCSummary(ctx context.Context, summary *gcSummary) context.Context {
+	return context.WithValue(ctx, gcSummaryKey{}, summary)
+}
+
+// getGCSummary returns the summary of the GC cycle attached to the context, nil if none
+func getGCSummary(ctx context.Context) *gcSummary {
+	summary, _ := ctx.Value(gcSummaryKey{}).(*gcSummary)
+	return summary
+}
+
+func (s *gcSummary) addNamespace() {
+	if s != nil {
+		s.namespaces.Add(1)
+	}
+}
+
+func (s *gcSummary) addEvaluated(resourceType string) {
+	if s == nil {
+		return
+	}
+	if resourceType == metrics.ResourceTypeTaskRun {
+		s.evaluatedTaskRuns.Add(1)
+	} else {
+		s.evaluatedPipelineRuns.Add(1)
+	}
+}
+
+// addDeleted counts a deleted run, by TTL and history limits apart from the other operations
+func (s *gcSummary) addDeleted(operation string) {
+	if s == nil {
+		return
+	}
+	s.deleted.Add(1)
+	switch operation {
+	case metrics.OperationTTL:
+		s.deletedByTTL.Add(1)
+	case metrics.OperationHistory:
+		s.deletedByHistory.Add(1)
+	}
+}
+
+// addError counts a run or a namespace whose collection failed
+func (s *gcSummary) addError() {
+	if s != nil {
+		s.errors.Add(1)
+	}
+}
+
+// snapshot returns the current counters of the summary
+func (s *gcSummary) snapshot() GCSummary {
+	return GCSummary{
+		Namespaces: s.namespaces.Load(),
+		Evaluated:  s.evaluatedPipelineRuns.Load() + s.evaluatedTaskRuns.Load(),
+		Deleted:    s.deleted.Load(),
+		Errors:     s.errors.Load(),
+	}
+}
+
+// logFields returns the counters of the summary as the key-value pairs of a structured log line
+func (s *gcSummary) logFields() []interface{} {
+	return []interface{}{
+		"namespaces", s.namespaces.Load(),
+		"pipelineRunsEvaluated", s.evaluatedPipelineRuns.Load(),
+		"taskRunsEvaluated", s.evaluatedTaskRuns.Load(),
+		"deleted", s.deleted.Load(),
+		"deletedByTTL", s.deletedByTTL.Load(),
+		"deletedByHistory", s.deletedByHistory.Load(),
+		"errors", s.errors.Load(),
+	}
+}
+
+// gcOperationKey is used as the key for associating the pruning operation in progress with the context.
+type gcOperationKey struct{}
+
+// withGCOperation tells the deletions made under the context apart in the summary of the GC cycle
+func withGCOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, gcOperationKey{}, operation)
+}
+
+// getGCOperation returns the pruning operation in progress, empty if none
+func getGCOperation(ctx context.Context) string {
+	operation, _ := ctx.Value(gcOperationKey{}).(string)
+	return operation
+}
+
+// triggerGCHandler returns the handler running a garbage collection cycle for each authenticated POST request.
+// The cycle runs with the controller context, so that it completes even if the client goes away.
+// Like a periodic cycle, it only processes again the runs processed before the global config last changed.
+func (r *Reconciler) triggerGCHandler(ctx context.Context, token string) http.Handler {
+	logger := logging.FromContext(ctx)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !authorizeTriggerRequest(w, req, token) {
+			return
+		}
+
+		logger.Infow("Garbage collection triggered on demand", "remoteAddr", req.RemoteAddr)
+		summary := &gcSummary{}
+		if !r.safeRunGarbageCollector(withGCSummary(ctx, summary), logger) {
+			http.Error(w, "this replica is not the leader for garbage collection", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(summary.snapshot()); err != nil {
+			logger.Errorw("Failed to write the garbage collection summary", zap.Error(err))
+		}
+	})
+}
+
+// pruneOwnedHandler returns the handler pruning, for each authenticated POST request, the completed PipelineRuns
+// and TaskRuns of a namespace owned by the resource selected by the ownerKind, ownerName and ownerUID query parameters.
+// The runs are pruned as by garbage collection, in the configured deletion mode and sparing the protected runs.
+// Like garbage collection, it runs on the leader only, and never in a namespace garbage collection leaves alone.
+func (r *Reconciler) pruneOwnedHandler(ctx context.Context, token string) http.Handler {
+	logger := logging.FromContext(ctx)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !authorizeTriggerRequest(w, req, token) {
+			return
+		}
+
+		query := req.URL.Query()
+		namespace := query.Get("namespace")
+		owner := config.OwnerSelector{
+			Kind: query.Get("ownerKind"),
+			Name: query.Get("ownerName"),
+			UID:  types.UID(query.Get("ownerUID")),
+		}
+		if namespace == "" || (owner.Name == "" && owner.UID == "") {
+			http.Error(w, "the namespace and either the ownerName or the ownerUID query parameters are required", http.StatusBadRequest)
+			return
+		}
+
+		if !isCollectedNamespace(ctx, namespace) {
+			http.Error(w, "the namespace is outside the scope of garbage collection", http.StatusForbidden)
+			return
+		}
+
+		logger.Infow("Pruning the runs of an owner on demand", "namespace", namespace,
+			"ownerKind", owner.Kind, "ownerName", owner.Name, "ownerUID", owner.UID, "remoteAddr", req.RemoteAddr)
+		summary := &gcSummary{}
+		ran, err := r.safePruneOwnedRuns(withGCSummary(ctx, summary), logger, namespace, owner)
+		if err != nil {
+			logger.Errorw("Failed to prune the runs of an owner", "namespace", namespace, zap.Error(err))
+			http.Error(w, "failed to list the runs of the namespace", http.StatusInternalServerError)
+			return
+		}
+		if !ran {
+			http.Error(w, "this replica is not the leader for garbage collection", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(summary.snapshot()); err != nil {
+			logger.Errorw("Failed to write the prune summary", zap.Error(err))
+		}
+	})
+}
+
+// isCollectedNamespace checks whether garbage collection collects a namespace: one of the namespaces the controller is scoped to,
+// or otherwise any namespace but the system and excluded ones
+func isCollectedNamespace(ctx context.Context, namespace string) bool {
+	if scope := getNamespaceScope(ctx); len(scope) > 0 {
+		return slices.Contains(scope, namespace)
+	}
+	return !isSystemNamespace(namespace) && !config.PrunerConfigStore.IsNamespaceExcluded(namespace)
+}
+
+// safePruneOwnedRuns prunes the runs of an owner under the garbage collection lock, so that it never runs along with a GC cycle.
+// It is a no-op unless this replica is the leader for garbage collection, it reports whether the runs were pruned.
+func (r *Reconciler) safePruneOwnedRuns(ctx context.Context, logger *zap.SugaredLogger, namespace string, owner config.OwnerSelector) (bool, error) {
+	if !r.IsLeaderFor(gcLeaderKey()) {
+		logger.Debug("Skipping pruning the runs of an owner, not the leader")
+		return false, nil
+	}
+
+	gcMutex.Lock()
+	defer gcMutex.Unlock()
+
+	// Leadership may have been lost while waiting for the lock
+	if !r.IsLeaderFor(gcLeaderKey()) {
+		logger.Debug("Skipping pruning the runs of an owner, no longer the leader")
+		return false, nil
+	}
+	return true, pruneOwnedRuns(ctx, namespace, owner)
+}
+
+// pruneOwnedRuns prunes the completed PipelineRuns and TaskRuns of a namespace owned by the selected resource.
+// Unlike garbage collection, the TaskRuns owned by a PipelineRun are pruned too, when the PipelineRun is the owner selected
+func pruneOwnedRuns(ctx context.Context, namespace string, owner config.OwnerSelector) error {
+	prsList, trsList, err := listRuns(ctx, namespace)
+	if err != nil {
+		return err
+	}
+
+	var runs []completedRun
+	for _, pr := range prsList.Items {
+		if pr.Status.CompletionTime == nil || pr.DeletionTimestamp != nil || config.IsMarkedPrunable(&pr) || !config.HasOwnerReference(&pr, owner) {
+			continue
+		}
+		runs = append(runs, completedRun{
+			resourceType:   metrics.ResourceTypePipelineRun,
+			name:           pr.Name,
+			creationTime:   pr.CreationTimestamp.Time,
+			completionTime: pr.Status.CompletionTime.Time,
+			object:         &pr,
+		})
+	}
+	for _, tr := range trsList.Items {
+		if tr.Status.CompletionTime == nil || tr.DeletionTimestamp != nil || config.IsMarkedPrunable(&tr) || !config.HasOwnerReference(&tr, owner) {
+			continue
+		}
+		runs = append(runs, completedRun{
+			resourceType:   metrics.ResourceTypeTaskRun,
+			name:           tr.Name,
+			creationTime:   tr.CreationTimestamp.Time,
+			completionTime: tr.Status.CompletionTime.Time,
+			object:         &tr,
+		})
+	}
+	summary := getGCSummary(ctx)
+	summary.addNamespace()
+	for _, run := range runs {
+		summary.addEvaluated(run.resourceType)
+	}
+	return pruneRuns(ctx, namespace, runs, config.PruneReasonOwner, metrics.OperationOwner)
+}
+
+// authorizeTriggerRequest checks that the request is a POST carrying the bearer token,
+// it responds with the error and returns false otherwise
+func authorizeTriggerRequest(w http.ResponseWriter, req *http.Request, token string) bool {
+	bearer, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !found || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	return true
+}
+
+// serveTriggerGC serves the endpoint triggering a garbage collection cycle until the context is done
+func (r *Reconciler) serveTriggerGC(ctx context.Context, trigger TriggerGC) {
+	logger := logging.FromContext(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle(TriggerGCPath, r.triggerGCHandler(ctx, trigger.Token))
+	mux.Handle(PruneOwnedPath, r.pruneOwnedHandler(ctx, trigger.Token))
+	server := &http.Server{
+		Addr:              trigger.Address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+
+	logger.Infow("Serving the garbage collection trigger endpoint", "address", trigger.Address, "paths", []string{TriggerGCPath, PruneOwnedPath})
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Errorw("Garbage collection trigger endpoint stopped", zap.Error(err))
+	}
+}
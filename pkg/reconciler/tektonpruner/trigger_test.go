@@ -0,0 +1,455 @@
+/*
+Copyright 2025 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tektonpruner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	pipelinefake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	"github.com/tektoncd/pruner/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"knative.dev/pkg/apis"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/logging"
+	logtesting "knative.dev/pkg/logging/testing"
+	"knative.dev/pkg/reconciler"
+	"knative.dev/pkg/system"
+)
+
+// TestTriggerGCHandler verifies that only authenticated POST requests run garbage collection on the leader,
+// and that the summary of the cycle is returned
+func TestTriggerGCHandler(t *testing.T) {
+	const token = "s3cret"
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		leader        bool
+		wantStatus    int
+		wantSummary   GCSummary
+	}{
+		{
+			name:          "leader runs garbage collection",
+			method:        http.MethodPost,
+			authorization: "Bearer " + token,
+			leader:        true,
+			wantStatus:    http.StatusOK,
+			wantSummary:   GCSummary{Namespaces: 1, Evaluated: 2, Deleted: 1},
+		},
+		{
+			name:          "non-leader does not run garbage collection",
+			method:        http.MethodPost,
+			authorization: "Bearer " + token,
+			wantStatus:    http.StatusServiceUnavailable,
+		},
+		{
+			name:       "missing token",
+			method:     http.MethodPost,
+			leader:     true,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "wrong token",
+			method:        http.MethodPost,
+			authorization: "Bearer other",
+			leader:        true,
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "GET is not allowed",
+			method:        http.MethodGet,
+			authorization: "Bearer " + token,
+			leader:        true,
+			wantStatus:    http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: config.PrunerConfigMapName, Namespace: system.Namespace()},
+				Data:       map[string]string{config.PrunerGlobalConfigKey: `ttlSecondsAfterFinished: 600`},
+			}
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-namespace"}}
+			newPR := func(name string, completedAgo time.Duration) *pipelinev1.PipelineRun {
+				return &pipelinev1.PipelineRun{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: ns.Name,
+						Labels:    map[string]string{config.LabelPipelineName: "build"},
+					},
+					Status: pipelinev1.PipelineRunStatus{
+						PipelineRunStatusFields: pipelinev1.PipelineRunStatusFields{
+							StartTime:      &metav1.Time{Time: time.Now().Add(-completedAgo - time.Minute)},
+							CompletionTime: &metav1.Time{Time: time.Now().Add(-completedAgo)},
+						},
+					},
+				}
+			}
+			kubeClient := fake.NewSimpleClientset(cm, ns)
+			ctx = context.WithValue(ctx, kubeclient.Key{}, kubeClient)
+			ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelinefake.NewSimpleClientset(
+				newPR("expired", time.Hour),
+				newPR("recent", time.Minute),
+			))
+
+			r := &Reconciler{kubeclient: kubeClient}
+			if tt.leader {
+				if err := r.Promote(reconciler.UniversalBucket(), nil); err != nil {
+					t.Fatalf("Failed to promote reconciler: %v", err)
+				}
+			}
+
+			req := httptest.NewRequest(tt.method, TriggerGCPath, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			r.triggerGCHandler(ctx, token).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var summary GCSummary
+			if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+				t.Fatalf("Failed to decode the summary: %v", err)
+			}
+			if summary != tt.wantSummary {
+				t.Errorf("summary = %+v, want %+v", summary, tt.wantSummary)
+			}
+		})
+	}
+}
+
+// TestTriggerGCHandlerUnchangedConfig verifies that a cycle triggered on demand with an unchanged config leaves
+// the runs processed since the config last changed alone, instead of resetting their processed annotation
+func TestTriggerGCHandlerUnchangedConfig(t *testing.T) {
+	const token = "s3cret"
+	const namespace = "test-namespace"
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+	defer func() {
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.PrunerConfigMapName, Namespace: system.Namespace()},
+		Data:       map[string]string{config.PrunerGlobalConfigKey: `successfulHistoryLimit: 5`},
+	}
+	kubeClient := fake.NewSimpleClientset(cm, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+	ctx = context.WithValue(ctx, kubeclient.Key{}, kubeClient)
+	pipelineClient := pipelinefake.NewSimpleClientset()
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+
+	r := &Reconciler{kubeclient: kubeClient}
+	if err := r.Promote(reconciler.UniversalBucket(), nil); err != nil {
+		t.Fatalf("Failed to promote reconciler: %v", err)
+	}
+	trigger := func() {
+		req := httptest.NewRequest(http.MethodPost, TriggerGCPath, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		r.triggerGCHandler(ctx, token).ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d, body: %s", rec.Code, http.StatusOK, rec.Body.String())
+		}
+	}
+
+	trigger()
+	updateTime := config.PrunerConfigStore.GetConfigUpdateTime()
+
+	// a run processed by the history limiter once the config was loaded
+	completedAt := &metav1.Time{Time: time.Now()}
+	pr := &pipelinev1.PipelineRun{ObjectMeta: metav1.ObjectMeta{
+		Name:        "build-1",
+		Namespace:   namespace,
+		Labels:      map[string]string{config.LabelPipelineName: "build"},
+		Annotations: map[string]string{config.AnnotationHistoryLimitCheckProcessed: updateTime.Format(time.RFC3339)},
+	}}
+	pr.Status.StartTime = completedAt
+	pr.Status.CompletionTime = completedAt
+	if _, err := pipelineClient.TektonV1().PipelineRuns(namespace).Create(ctx, pr, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create the PipelineRun: %v", err)
+	}
+
+	// the next request comes a second later at least, a config reloaded at that time would reset the annotation
+	for time.Now().Truncate(time.Second).Equal(updateTime.Truncate(time.Second)) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	pipelineClient.ClearActions()
+	trigger()
+
+	for _, action := range pipelineClient.Actions() {
+		if patch, ok := action.(k8stesting.PatchAction); ok {
+			t.Errorf("unexpected patch of %s %s: %s", patch.GetResource().Resource, patch.GetName(), patch.GetPatch())
+		}
+	}
+}
+
+// TestPruneOwnedHandler verifies that only the completed runs owned by the selected resource are pruned,
+// including the TaskRuns of a PipelineRun
+func TestPruneOwnedHandler(t *testing.T) {
+	const token = "s3cret"
+	const namespace = "test-namespace"
+
+	newTR := func(name, owner string, completed bool) *pipelinev1.TaskRun {
+		tr := &pipelinev1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				OwnerReferences: []metav1.OwnerReference{{
+					Kind: config.KindPipelineRun,
+					Name: owner,
+					UID:  types.UID(owner + "-uid"),
+				}},
+			},
+		}
+		if completed {
+			tr.Status.CompletionTime = &metav1.Time{Time: time.Now()}
+		}
+		return tr
+	}
+
+	tests := []struct {
+		name          string
+		query         string
+		scope         []string
+		globalConfig  string
+		notLeader     bool
+		wantStatus    int
+		wantRemaining []string
+	}{
+		{
+			name:          "owner name",
+			query:         "namespace=" + namespace + "&ownerKind=PipelineRun&ownerName=build",
+			wantStatus:    http.StatusOK,
+			wantRemaining: []string{"build-running", "deploy-task"},
+		},
+		{
+			name:          "owner uid",
+			query:         "namespace=" + namespace + "&ownerUID=deploy-uid",
+			wantStatus:    http.StatusOK,
+			wantRemaining: []string{"build-task-1", "build-task-2", "build-running"},
+		},
+		{
+			name:          "stale owner uid",
+			query:         "namespace=" + namespace + "&ownerName=build&ownerUID=other-uid",
+			wantStatus:    http.StatusOK,
+			wantRemaining: []string{"build-task-1", "build-task-2", "build-running", "deploy-task"},
+		},
+		{
+			name:          "missing owner",
+			query:         "namespace=" + namespace + "&ownerKind=PipelineRun",
+			wantStatus:    http.StatusBadRequest,
+			wantRemaining: []string{"build-task-1", "build-task-2", "build-running", "deploy-task"},
+		},
+		{
+			name:          "missing namespace",
+			query:         "ownerName=build",
+			wantStatus:    http.StatusBadRequest,
+			wantRemaining: []string{"build-task-1", "build-task-2", "build-running", "deploy-task"},
+		},
+		{
+			name:          "namespace in scope",
+			query:         "namespace=" + namespace + "&ownerName=build",
+			scope:         []string{namespace},
+			wantStatus:    http.StatusOK,
+			wantRemaining: []string{"build-running", "deploy-task"},
+		},
+		{
+			name:          "namespace outside the scope",
+			query:         "namespace=" + namespace + "&ownerName=build",
+			scope:         []string{"other-namespace"},
+			wantStatus:    http.StatusForbidden,
+			wantRemaining: []string{"build-task-1", "build-task-2", "build-running", "deploy-task"},
+		},
+		{
+			name:          "system namespace",
+			query:         "namespace=kube-system&ownerName=build",
+			wantStatus:    http.StatusForbidden,
+			wantRemaining: []string{"build-task-1", "build-task-2", "build-running", "deploy-task"},
+		},
+		{
+			name:          "excluded namespace",
+			query:         "namespace=" + namespace + "&ownerName=build",
+			globalConfig:  `namespaceExcludeRegexes: ["^test-"]`,
+			wantStatus:    http.StatusForbidden,
+			wantRemaining: []string{"build-task-1", "build-task-2", "build-running", "deploy-task"},
+		},
+		{
+			name:          "not the leader",
+			query:         "namespace=" + namespace + "&ownerName=build",
+			notLeader:     true,
+			wantStatus:    http.StatusServiceUnavailable,
+			wantRemaining: []string{"build-task-1", "build-task-2", "build-running", "deploy-task"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+			pipelineClient := pipelinefake.NewSimpleClientset(
+				newTR("build-task-1", "build", true),
+				newTR("build-task-2", "build", true),
+				newTR("build-running", "build", false),
+				newTR("deploy-task", "deploy", true),
+			)
+			ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelineClient)
+			if tt.scope != nil {
+				ctx = WithNamespaceScope(ctx, tt.scope)
+			}
+			if err := config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{
+				Data: map[string]string{config.PrunerGlobalConfigKey: tt.globalConfig},
+			}); err != nil {
+				t.Fatalf("Failed to load the global config: %v", err)
+			}
+			defer func() {
+				_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+			}()
+
+			r := &Reconciler{}
+			if !tt.notLeader {
+				if err := r.Promote(reconciler.UniversalBucket(), nil); err != nil {
+					t.Fatalf("Failed to promote the reconciler: %v", err)
+				}
+			}
+
+			req := httptest.NewRequest(http.MethodPost, PruneOwnedPath+"?"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			r.pruneOwnedHandler(ctx, token).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			trs, err := pipelineClient.TektonV1().TaskRuns(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("Failed to list TaskRuns: %v", err)
+			}
+			var remaining []string
+			for _, tr := range trs.Items {
+				remaining = append(remaining, tr.Name)
+			}
+			if !slices.Equal(slices.Sorted(slices.Values(remaining)), slices.Sorted(slices.Values(tt.wantRemaining))) {
+				t.Errorf("remaining TaskRuns = %v, want %v", remaining, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+// TestRunGarbageCollectorSummary verifies that the summary of a cycle tells the runs deleted by TTL and by history limits apart
+func TestRunGarbageCollectorSummary(t *testing.T) {
+	const namespace = "test-namespace"
+	ctx := logging.WithLogger(context.Background(), logtesting.TestLogger(t))
+	defer func() {
+		_ = config.PrunerConfigStore.LoadGlobalConfig(ctx, &corev1.ConfigMap{})
+	}()
+
+	newPR := func(name string, completedAgo time.Duration) *pipelinev1.PipelineRun {
+		return &pipelinev1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         namespace,
+				Labels:            map[string]string{config.LabelPipelineName: "build"},
+				CreationTimestamp: metav1.Time{Time: time.Now().Add(-completedAgo - time.Minute)},
+			},
+			Status: pipelinev1.PipelineRunStatus{
+				PipelineRunStatusFields: pipelinev1.PipelineRunStatusFields{
+					StartTime:      &metav1.Time{Time: time.Now().Add(-completedAgo - time.Minute)},
+					CompletionTime: &metav1.Time{Time: time.Now().Add(-completedAgo)},
+				},
+			},
+		}
+	}
+	succeeded := newPR("expired", time.Hour)
+	succeeded.Status.SetCondition(&apis.Condition{Type: apis.ConditionSucceeded, Status: corev1.ConditionTrue, Reason: "Succeeded"})
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.PrunerConfigMapName, Namespace: system.Namespace()},
+		Data:       map[string]string{config.PrunerGlobalConfigKey: "ttlSecondsAfterFinished: 600\nfailedHistoryLimit: 3"},
+	}
+	kubeClient := fake.NewSimpleClientset(cm, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+	ctx = context.WithValue(ctx, kubeclient.Key{}, kubeClient)
+	ctx = context.WithValue(ctx, pipelineclient.Key{}, pipelinefake.NewSimpleClientset(
+		succeeded,
+		newPR("failed-1", 4*time.Minute),
+		newPR("failed-2", 3*time.Minute),
+		newPR("failed-3", 2*time.Minute),
+		newPR("failed-4", time.Minute),
+	))
+
+	summary := &gcSummary{}
+	runGarbageCollector(withGCSummary(ctx, summary))
+
+	got := [...]int64{
+		summary.evaluatedPipelineRuns.Load(),
+		summary.evaluatedTaskRuns.Load(),
+		summary.deleted.Load(),
+		summary.deletedByTTL.Load(),
+		summary.deletedByHistory.Load(),
+		summary.errors.Load(),
+	}
+	// the oldest failed run is over the history limit, the successful run is past its TTL
+	if want := [...]int64{5, 0, 2, 1, 1, 0}; got != want {
+		t.Errorf("evaluated PipelineRuns, evaluated TaskRuns, deleted, by TTL, by history, errors = %v, want %v", got, want)
+	}
+}
+
+// TestLoadTriggerGCToken verifies that the token is read trimmed and that an empty token is rejected
+func TestLoadTriggerGCToken(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	emptyFile := filepath.Join(dir, "empty")
+	if err := os.WriteFile(emptyFile, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := LoadTriggerGCToken(tokenFile)
+	if err != nil || token != "s3cret" {
+		t.Errorf("LoadTriggerGCToken() = %q, %v, want %q", token, err, "s3cret")
+	}
+	if _, err := LoadTriggerGCToken(emptyFile); err == nil {
+		t.Error("LoadTriggerGCToken() expected an error for an empty token file")
+	}
+	if _, err := LoadTriggerGCToken(filepath.Join(dir, "missing")); err == nil {
+		t.Error("LoadTriggerGCToken() expected an error for a missing token file")
+	}
+}
@@ -19,10 +19,13 @@ package webhook
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/tektoncd/pruner/pkg/config"
+	"github.com/tektoncd/pruner/pkg/metrics"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -35,10 +38,26 @@ import (
 	certresources "knative.dev/pkg/webhook/certificates/resources"
 )
 
+const (
+	// DefaultGlobalConfigFetchAttempts is the default number of attempts to fetch the global config
+	// validating a namespace config
+	DefaultGlobalConfigFetchAttempts = 3
+
+	// DefaultGlobalConfigFetchBackoff is the default delay before the second attempt to fetch the global config,
+	// doubled before each further attempt
+	DefaultGlobalConfigFetchBackoff = 100 * time.Millisecond
+)
+
 type ValidateConfigMap struct {
 	Client      kubernetes.Interface
 	SecretName  string
 	WebhookName string
+	// GlobalConfigFetchAttempts bounds the attempts to fetch the global config validating a namespace config,
+	// before falling back to the basic validation. DefaultGlobalConfigFetchAttempts applies when not positive
+	GlobalConfigFetchAttempts int
+	// GlobalConfigFetchBackoff is the delay before the second attempt, doubled before each further attempt.
+	// DefaultGlobalConfigFetchBackoff applies when not positive
+	GlobalConfigFetchBackoff time.Duration
 }
 
 var _ webhook.AdmissionController = (*ValidateConfigMap)(nil)
@@ -74,7 +93,7 @@ func validateRequiredLabels(cm *corev1.ConfigMap) error {
 }
 
 // validateNamespaceForConfig checks if a namespace is allowed for namespace-level configs
-// Forbidden namespaces: kube-*, openshift-*, tekton-pipelines, tekton-operator
+// Forbidden namespaces: kube-*, openshift-*, tekton-pipelines, tekton-operator, and the one the pruner is installed in
 func validateNamespaceForConfig(namespace string) error {
 	if strings.HasPrefix(namespace, "kube-") {
 		return fmt.Errorf("namespace-level config cannot be created in kube-* namespaces, got: %s", namespace)
@@ -82,7 +101,7 @@ func validateNamespaceForConfig(namespace string) error {
 	if strings.HasPrefix(namespace, "openshift-") {
 		return fmt.Errorf("namespace-level config cannot be created in openshift-* namespaces, got: %s", namespace)
 	}
-	if namespace == "tekton-pipelines" || namespace == "tekton-operator" {
+	if namespace == "tekton-pipelines" || namespace == "tekton-operator" || namespace == system.Namespace() {
 		return fmt.Errorf("namespace-level config cannot be created in %s namespace", namespace)
 	}
 	return nil
@@ -148,6 +167,35 @@ func (v *ValidateConfigMap) Path() string {
 	return "/validate-configmap"
 }
 
+// fetchGlobalConfig fetches the global config, retrying the transient errors a bounded number of times so that
+// an apiserver blip does not let a namespace config through without the global limits. A missing global config is not retried
+func (v *ValidateConfigMap) fetchGlobalConfig(ctx context.Context) (*corev1.ConfigMap, error) {
+	logger := logging.FromContext(ctx)
+	attempts := v.GlobalConfigFetchAttempts
+	if attempts <= 0 {
+		attempts = DefaultGlobalConfigFetchAttempts
+	}
+	backoff := v.GlobalConfigFetchBackoff
+	if backoff <= 0 {
+		backoff = DefaultGlobalConfigFetchBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		globalConfig, err := v.Client.CoreV1().ConfigMaps(system.Namespace()).Get(ctx, config.PrunerConfigMapName, metav1.GetOptions{})
+		if err == nil || apierrors.IsNotFound(err) || attempt >= attempts {
+			return globalConfig, err
+		}
+		logger.Infow("Failed to fetch global config for namespace validation, retrying",
+			"attempt", attempt, "maxAttempts", attempts, "retryIn", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
 // Admit handles the admission request
 func (v *ValidateConfigMap) Admit(ctx context.Context, request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
 	logger := logging.FromContext(ctx)
@@ -171,10 +219,41 @@ func (v *ValidateConfigMap) Admit(ctx context.Context, request *admissionv1.Admi
 		}
 	}
 
+	response, reason := v.admitConfigMap(ctx, request, &cm)
+	metrics.GetRecorder().RecordAdmission(ctx, configTypeLabelValue(&cm), response.Allowed, reason)
+	return response
+}
+
+// configTypeLabelValue returns the config type of a ConfigMap recorded in the admission metrics
+func configTypeLabelValue(cm *corev1.ConfigMap) string {
+	switch configType := cm.Labels["pruner.tekton.dev/config-type"]; configType {
+	case metrics.ConfigTypeGlobal, metrics.ConfigTypeNamespace:
+		return configType
+	default:
+		return metrics.ConfigTypeUnknown
+	}
+}
+
+// validationRejectionReason returns the category of a config validation error recorded in the admission metrics
+func validationRejectionReason(err error) string {
+	switch {
+	case errors.Is(err, config.ErrNegativeValue):
+		return metrics.AdmissionReasonNegativeValue
+	case errors.Is(err, config.ErrExceedsLimit):
+		return metrics.AdmissionReasonExceedsLimit
+	default:
+		return metrics.AdmissionReasonInvalidConfig
+	}
+}
+
+// admitConfigMap decides on the admission of a pruner ConfigMap, it returns the reason of a rejection
+func (v *ValidateConfigMap) admitConfigMap(ctx context.Context, request *admissionv1.AdmissionRequest, cm *corev1.ConfigMap) (*admissionv1.AdmissionResponse, string) {
+	logger := logging.FromContext(ctx)
+
 	// Validate that ConfigMap has required labels
 	// The webhook objectSelector ensures only ConfigMaps with proper labels reach this point
 	// This is a defense-in-depth check
-	if err := validateRequiredLabels(&cm); err != nil {
+	if err := validateRequiredLabels(cm); err != nil {
 		logger.Warnw("ConfigMap missing required labels", "name", cm.Name, "namespace", cm.Namespace, "error", err)
 		return &admissionv1.AdmissionResponse{
 			Allowed: false,
@@ -184,7 +263,7 @@ func (v *ValidateConfigMap) Admit(ctx context.Context, request *admissionv1.Admi
 				Reason:  metav1.StatusReasonInvalid,
 				Code:    400,
 			},
-		}
+		}, metrics.AdmissionReasonBadLabel
 	}
 
 	// Determine config type from labels
@@ -193,28 +272,28 @@ func (v *ValidateConfigMap) Admit(ctx context.Context, request *admissionv1.Admi
 	isNamespaceConfig := configType == "namespace" && cm.Namespace != system.Namespace()
 
 	// Validate ConfigMap names match expected patterns
-	if isGlobalConfig && cm.Name != "tekton-pruner-default-spec" {
+	if isGlobalConfig && cm.Name != config.PrunerConfigMapName {
 		return &admissionv1.AdmissionResponse{
 			Allowed: false,
 			Result: &metav1.Status{
 				Status:  metav1.StatusFailure,
-				Message: fmt.Sprintf("Global config must be named 'tekton-pruner-default-spec', got: %s", cm.Name),
+				Message: fmt.Sprintf("Global config must be named '%s', got: %s", config.PrunerConfigMapName, cm.Name),
 				Reason:  metav1.StatusReasonInvalid,
 				Code:    400,
 			},
-		}
+		}, metrics.AdmissionReasonBadName
 	}
 
-	if isNamespaceConfig && cm.Name != "tekton-pruner-namespace-spec" {
+	if isNamespaceConfig && cm.Name != config.PrunerNamespaceConfigMapName {
 		return &admissionv1.AdmissionResponse{
 			Allowed: false,
 			Result: &metav1.Status{
 				Status:  metav1.StatusFailure,
-				Message: fmt.Sprintf("Namespace config must be named 'tekton-pruner-namespace-spec', got: %s", cm.Name),
+				Message: fmt.Sprintf("Namespace config must be named '%s', got: %s", config.PrunerNamespaceConfigMapName, cm.Name),
 				Reason:  metav1.StatusReasonInvalid,
 				Code:    400,
 			},
-		}
+		}, metrics.AdmissionReasonBadName
 	}
 
 	// Validate that namespace-level configs are not in forbidden namespaces
@@ -229,7 +308,7 @@ func (v *ValidateConfigMap) Admit(ctx context.Context, request *admissionv1.Admi
 					Reason:  metav1.StatusReasonInvalid,
 					Code:    400,
 				},
-			}
+			}, metrics.AdmissionReasonForbiddenNamespace
 		}
 	}
 
@@ -243,7 +322,7 @@ func (v *ValidateConfigMap) Admit(ctx context.Context, request *admissionv1.Admi
 				Reason:  metav1.StatusReasonInvalid,
 				Code:    400,
 			},
-		}
+		}, metrics.AdmissionReasonBadLabel
 	}
 
 	logger.Infow("Validating pruner ConfigMap",
@@ -264,7 +343,7 @@ func (v *ValidateConfigMap) Admit(ctx context.Context, request *admissionv1.Admi
 				if ref.Kind == "TektonInstallerSet" {
 					logger.Infow("Allowing deletion of operator-managed global config",
 						"name", cm.Name, "owner", ref.Name)
-					return &admissionv1.AdmissionResponse{Allowed: true}
+					return &admissionv1.AdmissionResponse{Allowed: true}, ""
 				}
 			}
 
@@ -275,7 +354,7 @@ func (v *ValidateConfigMap) Admit(ctx context.Context, request *admissionv1.Admi
 				logger.Errorw("Failed to check for existing namespace configs", "error", err)
 				// Allow deletion if we can't check (fail open for DELETE)
 				logger.Infow("Allowing deletion of global config (unable to verify dependents)", "name", cm.Name)
-				return &admissionv1.AdmissionResponse{Allowed: true}
+				return &admissionv1.AdmissionResponse{Allowed: true}, ""
 			}
 			if len(nsList.Items) > 0 {
 				namespaces := make([]string, len(nsList.Items))
@@ -294,13 +373,13 @@ func (v *ValidateConfigMap) Admit(ctx context.Context, request *admissionv1.Admi
 						Reason: metav1.StatusReasonInvalid,
 						Code:   422,
 					},
-				}
+				}, metrics.AdmissionReasonDependentsExist
 			}
 			logger.Infow("Allowing deletion of global config (no dependents)", "name", cm.Name)
 		} else if isNamespaceConfig {
 			logger.Infow("Allowing deletion of namespace config", "name", cm.Name, "namespace", cm.Namespace)
 		}
-		return &admissionv1.AdmissionResponse{Allowed: true}
+		return &admissionv1.AdmissionResponse{Allowed: true}, ""
 	}
 
 	// For CREATE/UPDATE operations, perform validation
@@ -308,16 +387,18 @@ func (v *ValidateConfigMap) Admit(ctx context.Context, request *admissionv1.Admi
 	var globalConfig *corev1.ConfigMap
 	if isNamespaceConfig {
 		var err error
-		globalConfig, err = v.Client.CoreV1().ConfigMaps(system.Namespace()).Get(ctx, "tekton-pruner-default-spec", metav1.GetOptions{})
+		globalConfig, err = v.fetchGlobalConfig(ctx)
 		if err != nil {
-			logger.Warnw("Failed to fetch global config for namespace validation", "error", err)
 			// Allow if global config is not available (e.g., during initial setup)
 			// Basic validation will still be performed
+			logger.Warnw("Failed to fetch global config for namespace validation, falling back to basic validation without the global limits",
+				"name", cm.Name, "namespace", cm.Namespace, "error", err)
+			globalConfig = nil
 		}
 	}
 
 	// Validate using the centralized validation function
-	if err := config.ValidateConfigMapWithGlobal(&cm, globalConfig); err != nil {
+	if err := config.ValidateConfigMapWithGlobal(cm, globalConfig); err != nil {
 		logger.Errorw("ConfigMap validation failed", "name", cm.Name, "namespace", cm.Namespace, "error", err)
 		return &admissionv1.AdmissionResponse{
 			Allowed: false,
@@ -327,9 +408,14 @@ func (v *ValidateConfigMap) Admit(ctx context.Context, request *admissionv1.Admi
 				Reason:  metav1.StatusReasonInvalid,
 				Code:    422,
 			},
-		}
+		}, validationRejectionReason(err)
+	}
+
+	warnings := config.ConfigMapWarnings(cm)
+	if len(warnings) > 0 {
+		logger.Warnw("ConfigMap validation succeeded with warnings", "name", cm.Name, "namespace", cm.Namespace, "warnings", warnings)
 	}
 
 	logger.Infow("ConfigMap validation successful", "name", cm.Name, "namespace", cm.Namespace)
-	return &admissionv1.AdmissionResponse{Allowed: true}
+	return &admissionv1.AdmissionResponse{Allowed: true, Warnings: warnings}, ""
 }
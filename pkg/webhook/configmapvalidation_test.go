@@ -19,14 +19,20 @@ package webhook
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/tektoncd/pruner/pkg/config"
+	"github.com/tektoncd/pruner/pkg/metrics"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"knative.dev/pkg/logging"
 	logtesting "knative.dev/pkg/logging/testing"
 	"knative.dev/pkg/system"
@@ -41,6 +47,7 @@ func TestValidateConfigMap_Admit_GlobalConfig(t *testing.T) {
 		configData  string
 		wantAllowed bool
 		wantMessage string
+		wantWarning string
 	}{
 		{
 			name: "valid global config",
@@ -67,6 +74,25 @@ failedHistoryLimit: 10`,
 			wantAllowed: false,
 			wantMessage: "invalid enforcedConfigLevel",
 		},
+		{
+			name:        "deprecated field is allowed with a warning",
+			configData:  `keep: 5`,
+			wantAllowed: true,
+			wantWarning: "global-config.keep is deprecated, use historyLimit instead",
+		},
+		{
+			name:        "zero history limit without TTL is allowed with a warning",
+			configData:  `failedHistoryLimit: 0`,
+			wantAllowed: true,
+			wantWarning: "global-config.failedHistoryLimit is 0 and ttlSecondsAfterFinished is not set: a limit of 0 deletes all failed runs as soon as they complete, " +
+				"while leaving the limit unset disables history-based pruning of them",
+		},
+		{
+			name:        "deprecated field is validated as its replacement",
+			configData:  `keep: -5`,
+			wantAllowed: false,
+			wantMessage: "historyLimit cannot be negative",
+		},
 	}
 
 	for _, tt := range tests {
@@ -106,6 +132,13 @@ failedHistoryLimit: 10`,
 					t.Errorf("Admit() message = %v, want to contain %v", resp.Result.Message, tt.wantMessage)
 				}
 			}
+
+			if tt.wantWarning == "" && len(resp.Warnings) > 0 {
+				t.Errorf("Admit() warnings = %v, want none", resp.Warnings)
+			}
+			if tt.wantWarning != "" && (len(resp.Warnings) != 1 || resp.Warnings[0] != tt.wantWarning) {
+				t.Errorf("Admit() warnings = %v, want [%v]", resp.Warnings, tt.wantWarning)
+			}
 		})
 	}
 }
@@ -118,6 +151,7 @@ func TestValidateConfigMap_Admit_NamespaceConfig(t *testing.T) {
 		globalConfig *corev1.ConfigMap
 		wantAllowed  bool
 		wantMessage  string
+		wantWarning  string
 	}{
 		{
 			name:      "valid namespace config without global",
@@ -133,6 +167,19 @@ successfulHistoryLimit: 5`,
 			wantAllowed: false,
 			wantMessage: "ttlSecondsAfterFinished cannot be negative",
 		},
+		{
+			name:      "overlapping selectors are allowed with a warning",
+			namespace: "my-app",
+			configData: `pipelineRuns:
+  - selector:
+      - matchLabels: {app: myapp}
+    ttlSecondsAfterFinished: 1800
+  - selector:
+      - matchLabels: {app: myapp}
+    ttlSecondsAfterFinished: 3600`,
+			wantAllowed: true,
+			wantWarning: "matchLabels are identical to pipelineRuns[0].selector[0]",
+		},
 		{
 			name:      "namespace config within global limits",
 			namespace: "my-app",
@@ -245,6 +292,13 @@ successfulHistoryLimit: 10`,
 					t.Errorf("Admit() message = %v, want to contain %v", resp.Result.Message, tt.wantMessage)
 				}
 			}
+
+			if tt.wantWarning == "" && len(resp.Warnings) > 0 {
+				t.Errorf("Admit() warnings = %v, want none", resp.Warnings)
+			}
+			if tt.wantWarning != "" && (len(resp.Warnings) != 1 || !contains(resp.Warnings[0], tt.wantWarning)) {
+				t.Errorf("Admit() warnings = %v, want one containing %v", resp.Warnings, tt.wantWarning)
+			}
 		})
 	}
 }
@@ -796,6 +850,12 @@ func TestValidateNamespaceForConfig_AdditionalCases(t *testing.T) {
 			wantErr:   true,
 			errMsg:    "openshift-* namespaces",
 		},
+		{
+			name:      "forbidden pruner namespace",
+			namespace: system.Namespace(),
+			wantErr:   true,
+			errMsg:    "namespace-level config cannot be created in " + system.Namespace() + " namespace",
+		},
 		{
 			name:      "forbidden tekton-pipelines",
 			namespace: "tekton-pipelines",
@@ -828,3 +888,155 @@ func TestValidateNamespaceForConfig_AdditionalCases(t *testing.T) {
 		})
 	}
 }
+
+// TestValidateConfigMap_Admit_RecordsDecision verifies that every decision on a pruner ConfigMap is counted
+func TestValidateConfigMap_Admit_RecordsDecision(t *testing.T) {
+	newConfigMap := func(configData string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "tekton-pruner-default-spec",
+				Namespace: system.Namespace(),
+				Labels: map[string]string{
+					"app.kubernetes.io/part-of":     "tekton-pruner",
+					"pruner.tekton.dev/config-type": "global",
+				},
+			},
+			Data: map[string]string{config.PrunerGlobalConfigKey: configData},
+		}
+	}
+	validator := &ValidateConfigMap{Client: fake.NewSimpleClientset()}
+	ctx := logtesting.TestContextWithLogger(t)
+	admissions := func() int64 {
+		return metrics.GetRecorder().Snapshot().Counters[metrics.MetricWebhookAdmissions]
+	}
+
+	before := admissions()
+	validator.Admit(ctx, makeAdmissionRequest(t, newConfigMap(`ttlSecondsAfterFinished: 3600`), admissionv1.Create))
+	validator.Admit(ctx, makeAdmissionRequest(t, newConfigMap(`ttlSecondsAfterFinished: -1`), admissionv1.Create))
+	if got := admissions() - before; got != 2 {
+		t.Errorf("recorded admission decisions = %d, want 2", got)
+	}
+}
+
+// TestValidationRejectionReason verifies the categories of the config validation errors
+func TestValidationRejectionReason(t *testing.T) {
+	validate := func(globalConfig string) error {
+		return config.ValidateConfigMap(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: config.PrunerConfigMapName, Namespace: "tekton-pipelines"},
+			Data:       map[string]string{config.PrunerGlobalConfigKey: globalConfig},
+		})
+	}
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{validate("ttlSecondsAfterFinished: -1"), metrics.AdmissionReasonNegativeValue},
+		{validate(fmt.Sprintf("retainDays: %d", config.MaxRetainDays+1)), metrics.AdmissionReasonExceedsLimit},
+		{validate("enforcedConfigLevel: invalid"), metrics.AdmissionReasonInvalidConfig},
+		// the categories follow the sentinel errors, not the text of the message
+		{errors.New("global-config: ttlSecondsAfterFinished cannot be negative, got -1"), metrics.AdmissionReasonInvalidConfig},
+	}
+	for _, tt := range tests {
+		if tt.err == nil {
+			t.Fatalf("expected a validation error for the %s category", tt.want)
+		}
+		if got := validationRejectionReason(tt.err); got != tt.want {
+			t.Errorf("validationRejectionReason(%q) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestConfigTypeLabelValue verifies that only the known config types are recorded as such
+func TestConfigTypeLabelValue(t *testing.T) {
+	for configType, want := range map[string]string{
+		"global":    metrics.ConfigTypeGlobal,
+		"namespace": metrics.ConfigTypeNamespace,
+		"other":     metrics.ConfigTypeUnknown,
+		"":          metrics.ConfigTypeUnknown,
+	} {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"pruner.tekton.dev/config-type": configType}}}
+		if got := configTypeLabelValue(cm); got != want {
+			t.Errorf("configTypeLabelValue(%q) = %q, want %q", configType, got, want)
+		}
+	}
+}
+
+// TestValidateConfigMap_Admit_GlobalConfigFetchRetry verifies that transient errors fetching the global config are retried
+// before falling back to the basic validation, so that the global limits still apply after an apiserver blip
+func TestValidateConfigMap_Admit_GlobalConfigFetchRetry(t *testing.T) {
+	globalConfig := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.PrunerConfigMapName,
+			Namespace: system.Namespace(),
+			Labels: map[string]string{
+				"app.kubernetes.io/part-of":     "tekton-pruner",
+				"pruner.tekton.dev/config-type": "global",
+			},
+		},
+		Data: map[string]string{config.PrunerGlobalConfigKey: `ttlSecondsAfterFinished: 3600`},
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tekton-pruner-namespace-spec",
+			Namespace: "my-app",
+			Labels: map[string]string{
+				"app.kubernetes.io/part-of":     "tekton-pruner",
+				"pruner.tekton.dev/config-type": "namespace",
+			},
+		},
+		Data: map[string]string{config.PrunerNamespaceConfigKey: `ttlSecondsAfterFinished: 7200`},
+	}
+
+	tests := []struct {
+		name        string
+		failures    int
+		fetchErr    error
+		wantGets    int
+		wantAllowed bool
+	}{
+		{
+			name:        "transient error then success enforces the global limits",
+			failures:    1,
+			fetchErr:    apierrors.NewServiceUnavailable("apiserver blip"),
+			wantGets:    2,
+			wantAllowed: false,
+		},
+		{
+			name:        "persistent error falls back to basic validation",
+			failures:    10,
+			fetchErr:    apierrors.NewServiceUnavailable("apiserver down"),
+			wantGets:    DefaultGlobalConfigFetchAttempts,
+			wantAllowed: true,
+		},
+		{
+			name:        "missing global config is not retried",
+			failures:    10,
+			fetchErr:    apierrors.NewNotFound(corev1.Resource("configmaps"), config.PrunerConfigMapName),
+			wantGets:    1,
+			wantAllowed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(globalConfig)
+			gets := 0
+			client.PrependReactor("get", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+				gets++
+				if gets <= tt.failures {
+					return true, nil, tt.fetchErr
+				}
+				return false, nil, nil
+			})
+			validator := &ValidateConfigMap{Client: client, GlobalConfigFetchBackoff: time.Millisecond}
+
+			resp := validator.Admit(logtesting.TestContextWithLogger(t), makeAdmissionRequest(t, cm, admissionv1.Create))
+			if resp.Allowed != tt.wantAllowed {
+				t.Errorf("Admit() allowed = %v, want %v", resp.Allowed, tt.wantAllowed)
+			}
+			if gets != tt.wantGets {
+				t.Errorf("global config fetched %d times, want %d", gets, tt.wantGets)
+			}
+		})
+	}
+}